@@ -82,7 +82,10 @@ func main() {
 	logger.Log.Info().Msg("Database migrations completed")
 
 	// Create and start server
-	srv := server.New(cfg, database)
+	srv, err := server.New(cfg, database)
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Failed to create server")
+	}
 
 	// Channel to listen for errors from the server
 	serverErrors := make(chan error, 1)
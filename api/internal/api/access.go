@@ -0,0 +1,483 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/middleware"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// ChannelAccessPolicy decides which channels a user may see and stream
+type ChannelAccessPolicy interface {
+	CanAccessChannel(ctx context.Context, userID, channelID uuid.UUID) (bool, error)
+	AccessibleChannels(ctx context.Context, userID uuid.UUID) (all bool, channels map[uuid.UUID]bool, err error)
+}
+
+// CreateGroupRequest represents a group creation request
+type CreateGroupRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+// AddGroupMemberRequest represents a request to add a user to a group
+type AddGroupMemberRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// GrantChannelAccessRequest represents a channel access grant; exactly one of UserID and GroupID is set
+type GrantChannelAccessRequest struct {
+	UserID  string `json:"user_id,omitempty"`
+	GroupID string `json:"group_id,omitempty"`
+}
+
+// GroupListResponse represents a list of groups
+type GroupListResponse struct {
+	Groups []*models.UserGroup `json:"groups"`
+}
+
+// ChannelAccessListResponse represents the access grants of a channel
+type ChannelAccessListResponse struct {
+	Access []*models.ChannelAccess `json:"access"`
+}
+
+// checkChannelAccess writes a 404 (or 500) response and returns false if the request's user may not access the channel.
+// Requests without a user (auth disabled) or without a policy are always allowed.
+// Hidden channels are reported as not found so their existence is not revealed.
+func checkChannelAccess(ctx context.Context, c *gin.Context, policy ChannelAccessPolicy, channelID uuid.UUID) bool {
+	user, ok := middleware.CurrentUser(c)
+	if policy == nil || !ok {
+		return true
+	}
+
+	allowed, err := policy.CanAccessChannel(ctx, user.ID, channelID)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelID.String()).
+			Str("user_id", user.ID.String()).
+			Msg("Failed to check channel access")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to check channel access",
+		})
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Channel not found",
+		})
+		return false
+	}
+	return true
+}
+
+// filterAccessibleChannels removes the channels the request's user may not see
+func filterAccessibleChannels(ctx context.Context, c *gin.Context, policy ChannelAccessPolicy, channels []*models.Channel) ([]*models.Channel, error) {
+	user, ok := middleware.CurrentUser(c)
+	if policy == nil || !ok {
+		return channels, nil
+	}
+
+	all, accessible, err := policy.AccessibleChannels(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return channels, nil
+	}
+
+	filtered := make([]*models.Channel, 0, len(accessible))
+	for _, ch := range channels {
+		if accessible[ch.ID] {
+			filtered = append(filtered, ch)
+		}
+	}
+	return filtered, nil
+}
+
+// AccessHandler handles user group and channel access API requests
+type AccessHandler struct {
+	repos *db.Repositories
+}
+
+// NewAccessHandler creates a new access handler instance
+func NewAccessHandler(repos *db.Repositories) *AccessHandler {
+	return &AccessHandler{
+		repos: repos,
+	}
+}
+
+// ListGroups handles GET /api/groups
+func (h *AccessHandler) ListGroups(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	groups, err := h.repos.Groups.List(ctx)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to list groups")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve groups",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, GroupListResponse{
+		Groups: groups,
+	})
+}
+
+// CreateGroup handles POST /api/groups
+func (h *AccessHandler) CreateGroup(c *gin.Context) {
+	var req CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Group name is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	group := models.NewUserGroup(name)
+	if err := h.repos.Groups.Create(ctx, group); err != nil {
+		if errors.Is(err, db.ErrDuplicate) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "duplicate_name",
+				Message: "A group with this name already exists",
+			})
+			return
+		}
+		logger.Log.Error().Err(err).Str("name", name).Msg("Failed to create group")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "create_failed",
+			Message: "Failed to create group",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// DeleteGroup handles DELETE /api/groups/:id
+func (h *AccessHandler) DeleteGroup(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id", "group")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repos.Groups.Delete(ctx, id); err != nil {
+		h.respondRepoError(c, err, "Group not found", "delete_failed", "Failed to delete group")
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message: "Group deleted successfully",
+	})
+}
+
+// ListGroupMembers handles GET /api/groups/:id/members
+func (h *AccessHandler) ListGroupMembers(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id", "group")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.repos.Groups.GetByID(ctx, id); err != nil {
+		h.respondRepoError(c, err, "Group not found", "query_failed", "Failed to retrieve group")
+		return
+	}
+
+	members, err := h.repos.Groups.ListMembers(ctx, id)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("group_id", id.String()).Msg("Failed to list group members")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve group members",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserListResponse{
+		Users: members,
+	})
+}
+
+// AddGroupMember handles POST /api/groups/:id/members
+func (h *AccessHandler) AddGroupMember(c *gin.Context) {
+	groupID, ok := parseUUIDParam(c, "id", "group")
+	if !ok {
+		return
+	}
+
+	var req AddGroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repos.Groups.AddMember(ctx, groupID, userID); err != nil {
+		switch {
+		case errors.Is(err, db.ErrForeignKey):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Group or user not found",
+			})
+		case errors.Is(err, db.ErrDuplicate):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "already_member",
+				Message: "User is already a member of this group",
+			})
+		default:
+			logger.Log.Error().Err(err).Str("group_id", groupID.String()).Msg("Failed to add group member")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "create_failed",
+				Message: "Failed to add group member",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.UserGroupMember{
+		GroupID: groupID,
+		UserID:  userID,
+	})
+}
+
+// RemoveGroupMember handles DELETE /api/groups/:id/members/:user_id
+func (h *AccessHandler) RemoveGroupMember(c *gin.Context) {
+	groupID, ok := parseUUIDParam(c, "id", "group")
+	if !ok {
+		return
+	}
+	userID, ok := parseUUIDParam(c, "user_id", "user")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repos.Groups.RemoveMember(ctx, groupID, userID); err != nil {
+		h.respondRepoError(c, err, "Group member not found", "delete_failed", "Failed to remove group member")
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message: "Group member removed",
+	})
+}
+
+// ListChannelAccess handles GET /api/channels/:id/access
+func (h *AccessHandler) ListChannelAccess(c *gin.Context) {
+	channelID, ok := parseUUIDParam(c, "id", "channel")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.repos.Channels.GetByID(ctx, channelID); err != nil {
+		h.respondRepoError(c, err, "Channel not found", "query_failed", "Failed to retrieve channel")
+		return
+	}
+
+	grants, err := h.repos.ChannelAccess.ListByChannel(ctx, channelID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("channel_id", channelID.String()).Msg("Failed to list channel access")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve channel access",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ChannelAccessListResponse{
+		Access: grants,
+	})
+}
+
+// GrantChannelAccess handles POST /api/channels/:id/access
+func (h *AccessHandler) GrantChannelAccess(c *gin.Context) {
+	channelID, ok := parseUUIDParam(c, "id", "channel")
+	if !ok {
+		return
+	}
+
+	var req GrantChannelAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if (req.UserID == "") == (req.GroupID == "") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Exactly one of user_id and group_id is required",
+		})
+		return
+	}
+
+	grant := &models.ChannelAccess{
+		ID:        uuid.New(),
+		ChannelID: channelID,
+		CreatedAt: time.Now().UTC(),
+	}
+	subject := req.UserID
+	if subject == "" {
+		subject = req.GroupID
+	}
+	subjectID, err := uuid.Parse(subject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user or group ID format",
+		})
+		return
+	}
+	if req.UserID != "" {
+		grant.UserID = &subjectID
+	} else {
+		grant.GroupID = &subjectID
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repos.ChannelAccess.Grant(ctx, grant); err != nil {
+		switch {
+		case errors.Is(err, db.ErrForeignKey):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Channel, user or group not found",
+			})
+		case errors.Is(err, db.ErrDuplicate):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "already_granted",
+				Message: "Access has already been granted",
+			})
+		default:
+			logger.Log.Error().Err(err).Str("channel_id", channelID.String()).Msg("Failed to grant channel access")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "create_failed",
+				Message: "Failed to grant channel access",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// RevokeChannelAccess handles DELETE /api/channels/:id/access/:access_id
+func (h *AccessHandler) RevokeChannelAccess(c *gin.Context) {
+	channelID, ok := parseUUIDParam(c, "id", "channel")
+	if !ok {
+		return
+	}
+	accessID, ok := parseUUIDParam(c, "access_id", "access")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repos.ChannelAccess.Revoke(ctx, channelID, accessID); err != nil {
+		h.respondRepoError(c, err, "Access grant not found", "delete_failed", "Failed to revoke channel access")
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message: "Channel access revoked",
+	})
+}
+
+// respondRepoError maps ErrNotFound to a 404 and anything else to a logged 500
+func (h *AccessHandler) respondRepoError(c *gin.Context, err error, notFoundMessage, errorCode, errorMessage string) {
+	if errors.Is(err, db.ErrNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: notFoundMessage,
+		})
+		return
+	}
+
+	logger.Log.Error().Err(err).Str("path", c.Request.URL.Path).Msg(errorMessage)
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   errorCode,
+		Message: errorMessage,
+	})
+}
+
+// parseUUIDParam parses a UUID path parameter, writing a 400 response if it is invalid
+func parseUUIDParam(c *gin.Context, param, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid " + name + " ID format",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// SetupAccessRoutes registers user group and channel access routes
+func SetupAccessRoutes(apiGroup *gin.RouterGroup, repos *db.Repositories) {
+	handler := NewAccessHandler(repos)
+
+	apiGroup.GET("/groups", handler.ListGroups)
+	apiGroup.POST("/groups", handler.CreateGroup)
+	apiGroup.DELETE("/groups/:id", handler.DeleteGroup)
+	apiGroup.GET("/groups/:id/members", handler.ListGroupMembers)
+	apiGroup.POST("/groups/:id/members", handler.AddGroupMember)
+	apiGroup.DELETE("/groups/:id/members/:user_id", handler.RemoveGroupMember)
+
+	apiGroup.GET("/channels/:id/access", handler.ListChannelAccess)
+	apiGroup.POST("/channels/:id/access", handler.GrantChannelAccess)
+	apiGroup.DELETE("/channels/:id/access/:access_id", handler.RevokeChannelAccess)
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/auth"
+	"github.com/stwalsh4118/hermes/internal/channel"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/middleware"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/timeline"
+)
+
+// accessTestEnv holds a router wired like the server with auth enabled, plus one token per role
+type accessTestEnv struct {
+	router   *gin.Engine
+	repos    *db.Repositories
+	streams  *mockStreamManager
+	admin    string
+	editor   string
+	viewer   string
+	viewerID uuid.UUID
+}
+
+// setupAccessTestEnv creates users for every role and registers channel, stream and access routes
+func setupAccessTestEnv(t *testing.T) (*accessTestEnv, func()) {
+	t.Helper()
+
+	database, repos, cleanup := setupTestDB(t)
+	service, err := auth.NewService(repos, &config.AuthConfig{Enabled: true, TokenSecret: "test-secret"})
+	require.NoError(t, err)
+
+	env := &accessTestEnv{repos: repos, streams: &mockStreamManager{}}
+	ctx := context.Background()
+	login := func(username, role string) (string, uuid.UUID) {
+		user, err := service.CreateUser(ctx, username, testAuthPassword, role)
+		require.NoError(t, err)
+		token, _, _, err := service.Login(ctx, username, testAuthPassword)
+		require.NoError(t, err)
+		return token, user.ID
+	}
+	env.admin, _ = login("admin", models.RoleAdmin)
+	env.editor, _ = login("editor", models.RoleEditor)
+	env.viewer, env.viewerID = login("kid", models.RoleViewer)
+
+	gin.SetMode(gin.TestMode)
+	env.router = gin.New()
+
+	authenticate := middleware.RequireAuth(service)
+	publicGroup := env.router.Group("/api")
+	SetupAuthRoutes(publicGroup, service, repos, true)
+	streamHandler := &StreamHandler{streamManager: env.streams, access: service, shares: service}
+	streamGroup := publicGroup.Group("/stream", middleware.RequireStreamAccess(service, service))
+	streamGroup.GET("/:channel_id/master.m3u8", streamHandler.GetMasterPlaylist)
+	streamGroup.DELETE("/:channel_id/client", middleware.RequireUser(), streamHandler.UnregisterClient)
+	streamGroup.POST("/:channel_id/position", middleware.RequireUser(), streamHandler.UpdatePosition)
+	streamGroup.GET("/:channel_id/debug", middleware.RequireUserRole(models.RoleAdmin), streamHandler.GetBatchDebug)
+	streamGroup.GET("/:channel_id/:quality/:segment", streamHandler.GetSegment)
+	streamGroup.GET("/:channel_id/:quality", streamHandler.GetMediaPlaylist)
+
+	apiGroup := env.router.Group("/api", authenticate, middleware.RequireRoleForWrites(models.RoleEditor))
+	SetupChannelRoutes(apiGroup, channel.NewChannelService(repos), channel.NewPlaylistService(database, repos), timeline.NewTimelineService(repos), service)
+
+	adminGroup := env.router.Group("/api", authenticate, middleware.RequireRole(models.RoleAdmin))
+	SetupAccessRoutes(adminGroup, repos)
+
+	return env, cleanup
+}
+
+// createChannel creates a channel directly in the database
+func (env *accessTestEnv) createChannel(t *testing.T, name string) *models.Channel {
+	t.Helper()
+	ch := models.NewChannel(name, time.Now().UTC(), true)
+	require.NoError(t, env.repos.Channels.Create(context.Background(), ch))
+	return ch
+}
+
+// listChannelNames lists the channels visible with the given token
+func (env *accessTestEnv) listChannelNames(t *testing.T, token string) []string {
+	t.Helper()
+	w := doJSON(t, env.router, http.MethodGet, "/api/channels", nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp ChannelListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	names := make([]string, len(resp.Channels))
+	for i, ch := range resp.Channels {
+		names[i] = ch.Name
+	}
+	return names
+}
+
+func TestRolePermissions(t *testing.T) {
+	env, cleanup := setupAccessTestEnv(t)
+	defer cleanup()
+
+	startTime := time.Now().UTC()
+	loop := true
+	body := CreateChannelRequest{Name: "New", StartTime: &startTime, Loop: &loop}
+
+	t.Run("viewer cannot create channels", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodPost, "/api/channels", body, env.viewer)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"forbidden"`)
+	})
+
+	t.Run("editor can create channels", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodPost, "/api/channels", body, env.editor)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("editor cannot manage access", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodGet, "/api/groups", nil, env.editor)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("only admins manage users", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodGet, "/api/auth/users", nil, env.editor)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doJSON(t, env.router, http.MethodPost, "/api/auth/users", CreateUserRequest{
+			Username: "another",
+			Password: testAuthPassword,
+			Role:     models.RoleViewer,
+		}, env.admin)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"role":"viewer"`)
+	})
+}
+
+func TestChannelVisibility(t *testing.T) {
+	env, cleanup := setupAccessTestEnv(t)
+	defer cleanup()
+
+	cartoons := env.createChannel(t, "Cartoons")
+	movies := env.createChannel(t, "Movies")
+
+	t.Run("viewer sees nothing without grants", func(t *testing.T) {
+		assert.Empty(t, env.listChannelNames(t, env.viewer))
+
+		w := doJSON(t, env.router, http.MethodGet, "/api/channels/"+cartoons.ID.String(), nil, env.viewer)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = doJSON(t, env.router, http.MethodGet, "/api/channels/"+cartoons.ID.String()+"/playlist", nil, env.viewer)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("editor and admin see every channel", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Cartoons", "Movies"}, env.listChannelNames(t, env.editor))
+		assert.ElementsMatch(t, []string{"Cartoons", "Movies"}, env.listChannelNames(t, env.admin))
+	})
+
+	t.Run("group grant makes channel visible", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodPost, "/api/groups", CreateGroupRequest{Name: "kids"}, env.admin)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var group models.UserGroup
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+
+		w = doJSON(t, env.router, http.MethodPost, "/api/groups/"+group.ID.String()+"/members", AddGroupMemberRequest{
+			UserID: env.viewerID.String(),
+		}, env.admin)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = doJSON(t, env.router, http.MethodPost, "/api/channels/"+cartoons.ID.String()+"/access", GrantChannelAccessRequest{
+			GroupID: group.ID.String(),
+		}, env.admin)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		assert.Equal(t, []string{"Cartoons"}, env.listChannelNames(t, env.viewer))
+		w = doJSON(t, env.router, http.MethodGet, "/api/channels/"+cartoons.ID.String(), nil, env.viewer)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("user grant can be revoked", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodPost, "/api/channels/"+movies.ID.String()+"/access", GrantChannelAccessRequest{
+			UserID: env.viewerID.String(),
+		}, env.admin)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var grant models.ChannelAccess
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &grant))
+		assert.ElementsMatch(t, []string{"Cartoons", "Movies"}, env.listChannelNames(t, env.viewer))
+
+		w = doJSON(t, env.router, http.MethodPost, "/api/channels/"+movies.ID.String()+"/access", GrantChannelAccessRequest{
+			UserID: env.viewerID.String(),
+		}, env.admin)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = doJSON(t, env.router, http.MethodDelete, "/api/channels/"+movies.ID.String()+"/access/"+grant.ID.String(), nil, env.admin)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"Cartoons"}, env.listChannelNames(t, env.viewer))
+	})
+
+	t.Run("grant requires exactly one subject", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodPost, "/api/channels/"+movies.ID.String()+"/access", GrantChannelAccessRequest{}, env.admin)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(t, env.router, http.MethodPost, "/api/channels/"+movies.ID.String()+"/access", GrantChannelAccessRequest{
+			UserID: uuid.New().String(),
+		}, env.admin)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestStreamAccessCheckedBeforeStart(t *testing.T) {
+	env, cleanup := setupAccessTestEnv(t)
+	defer cleanup()
+
+	hidden := env.createChannel(t, "Late Night")
+	started := false
+	env.streams.startStreamFunc = func(ctx context.Context, channelID uuid.UUID) (*models.StreamSession, error) {
+		started = true
+		return nil, assert.AnError
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stream/"+hidden.ID.String()+"/master.m3u8?session_id=test", nil)
+	req.Header.Set("Authorization", "Bearer "+env.viewer)
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.False(t, started, "stream must not start for a channel the viewer cannot access")
+
+	t.Run("stream token of a viewer without access", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodPost, "/api/auth/stream-token", CreateStreamTokenRequest{
+			ChannelID: hidden.ID.String(),
+		}, env.viewer)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestStreamClientRoutesCheckChannelAccess(t *testing.T) {
+	env, cleanup := setupAccessTestEnv(t)
+	defer cleanup()
+
+	hidden := env.createChannel(t, "Late Night")
+	session := models.NewStreamSession(hidden.ID)
+	session.RegisterSession("tv-1")
+	session.IncrementClients()
+	env.streams.getStreamFunc = func(id uuid.UUID) (*models.StreamSession, bool) {
+		return session, id == hidden.ID
+	}
+	env.streams.unregisterClientFunc = func(ctx context.Context, channelID uuid.UUID) error {
+		t.Error("client of a channel the viewer cannot access was unregistered")
+		return nil
+	}
+	base := "/api/stream/" + hidden.ID.String()
+
+	w := doJSON(t, env.router, http.MethodDelete, base+"/client?session_id=tv-1", nil, env.viewer)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(t, env.router, http.MethodPost, base+"/position", UpdatePositionRequest{
+		SessionID: "tv-1", SegmentNumber: 5, Quality: "720p",
+	}, env.viewer)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, session.GetClientPositions(), "position of a channel the viewer cannot access was updated")
+
+	t.Run("debug output is for admins", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodGet, base+"/debug", nil, env.editor)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doJSON(t, env.router, http.MethodGet, base+"/debug", nil, env.admin)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,696 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/auth"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/middleware"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// CredentialsRequest represents a username and password, used for setup and login
+type CredentialsRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// CreateUserRequest represents a user creation request
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=admin editor viewer"`
+}
+
+// UpdateRoleRequest represents a role change request
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor viewer"`
+}
+
+// ChangePasswordRequest represents a password change request
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// CreateAPIKeyRequest represents an API key creation request
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateStreamTokenRequest represents a stream token request; TTLSeconds of 0 uses the configured default
+type CreateStreamTokenRequest struct {
+	ChannelID  string `json:"channel_id" binding:"required"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty" binding:"min=0"`
+}
+
+// CreateShareLinkRequest represents a request for a share link to a channel
+type CreateShareLinkRequest struct {
+	ChannelID   string `json:"channel_id" binding:"required"`
+	TTLSeconds  int    `json:"ttl_seconds,omitempty" binding:"min=0"`
+	MaxSessions int    `json:"max_sessions,omitempty" binding:"min=0,max=100"`
+}
+
+// AuthStatusResponse reports whether auth is enforced and whether initial setup is pending
+type AuthStatusResponse struct {
+	Enabled       bool `json:"enabled"`
+	SetupRequired bool `json:"setup_required"`
+}
+
+// LoginResponse contains a new session token; the token is only returned once
+type LoginResponse struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	User      *models.User `json:"user"`
+}
+
+// CreateAPIKeyResponse contains a new API key; the plaintext key is only returned once
+type CreateAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// APIKeyListResponse represents a list of API keys
+type APIKeyListResponse struct {
+	APIKeys []*models.APIKey `json:"api_keys"`
+}
+
+// UserListResponse represents a list of users
+type UserListResponse struct {
+	Users []*models.User `json:"users"`
+}
+
+// StreamTokenResponse contains a signed stream token and a ready-to-play master playlist URL
+type StreamTokenResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ShareLinkResponse contains a share link; the URL plays the channel without an account
+type ShareLinkResponse struct {
+	Token       string    `json:"token"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	MaxSessions int       `json:"max_sessions"`
+}
+
+// AuthHandler handles authentication API requests
+type AuthHandler struct {
+	service *auth.Service
+	repos   *db.Repositories
+	enabled bool
+}
+
+// NewAuthHandler creates a new auth handler instance
+func NewAuthHandler(service *auth.Service, repos *db.Repositories, enabled bool) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+		repos:   repos,
+		enabled: enabled,
+	}
+}
+
+// GetStatus handles GET /api/auth/status
+func (h *AuthHandler) GetStatus(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	setupRequired, err := h.service.SetupRequired(ctx)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to check auth setup status")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve auth status",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthStatusResponse{
+		Enabled:       h.enabled,
+		SetupRequired: setupRequired,
+	})
+}
+
+// Setup handles POST /api/auth/setup
+// Creates the first user and logs them in; it is rejected once any user exists
+func (h *AuthHandler) Setup(c *gin.Context) {
+	var req CredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if _, err := h.service.Setup(ctx, req.Username, req.Password); err != nil {
+		if errors.Is(err, auth.ErrSetupComplete) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "setup_complete",
+				Message: "Initial setup has already been completed",
+			})
+			return
+		}
+		h.respondUserError(c, err)
+		return
+	}
+
+	h.login(ctx, c, req, http.StatusCreated)
+}
+
+// Login handles POST /api/auth/login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req CredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	h.login(ctx, c, req, http.StatusOK)
+}
+
+// Logout handles POST /api/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.service.Logout(ctx, middleware.Credential(c)); err != nil {
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "not_a_session",
+				Message: "Only session tokens can be logged out; revoke API keys instead",
+			})
+			return
+		}
+		logger.Log.Error().Err(err).Msg("Failed to log out")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "logout_failed",
+			Message: "Failed to log out",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message: "Logged out",
+	})
+}
+
+// GetCurrentUser handles GET /api/auth/me
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword handles PUT /api/auth/password
+// All of the user's sessions are ended, including the current one
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, _ := middleware.CurrentUser(c)
+	if err := h.service.ChangePassword(ctx, user.ID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "invalid_credentials",
+				Message: "Current password is incorrect",
+			})
+			return
+		}
+		h.respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message: "Password changed; all sessions have been logged out",
+	})
+}
+
+// ListAPIKeys handles GET /api/auth/keys
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, _ := middleware.CurrentUser(c)
+	keys, err := h.service.ListAPIKeys(ctx, user.ID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to list API keys")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve API keys",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIKeyListResponse{
+		APIKeys: keys,
+	})
+}
+
+// CreateAPIKey handles POST /api/auth/keys
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "expires_at must be in the future",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, _ := middleware.CurrentUser(c)
+	plaintext, key, err := h.service.CreateAPIKey(ctx, user.ID, req.Name, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_error",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to create API key")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "create_failed",
+			Message: "Failed to create API key",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{
+		Key:    plaintext,
+		APIKey: key,
+	})
+}
+
+// RevokeAPIKey handles DELETE /api/auth/keys/:id
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid API key ID format",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, _ := middleware.CurrentUser(c)
+	if err := h.service.RevokeAPIKey(ctx, user.ID, id); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "API key not found",
+			})
+			return
+		}
+		logger.Log.Error().Err(err).Str("api_key_id", id.String()).Msg("Failed to revoke API key")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "delete_failed",
+			Message: "Failed to revoke API key",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message: "API key revoked",
+	})
+}
+
+// ListUsers handles GET /api/auth/users
+func (h *AuthHandler) ListUsers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	users, err := h.service.ListUsers(ctx)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to list users")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve users",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserListResponse{
+		Users: users,
+	})
+}
+
+// CreateUser handles POST /api/auth/users
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.service.CreateUser(ctx, req.Username, req.Password, req.Role)
+	if err != nil {
+		h.respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUserRole handles PUT /api/auth/users/:id/role
+func (h *AuthHandler) UpdateUserRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.service.UpdateRole(ctx, id, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "User not found",
+			})
+		case errors.Is(err, auth.ErrLastAdmin):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "last_admin",
+				Message: "Cannot demote the last admin",
+			})
+		default:
+			h.respondUserError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser handles DELETE /api/auth/users/:id
+func (h *AuthHandler) DeleteUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteUser(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "User not found",
+			})
+		case errors.Is(err, auth.ErrLastUser):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "last_user",
+				Message: "Cannot delete the last user",
+			})
+		case errors.Is(err, auth.ErrLastAdmin):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "last_admin",
+				Message: "Cannot delete the last admin",
+			})
+		default:
+			logger.Log.Error().Err(err).Str("user_id", id.String()).Msg("Failed to delete user")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "delete_failed",
+				Message: "Failed to delete user",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message: "User deleted successfully",
+	})
+}
+
+// CreateStreamToken handles POST /api/auth/stream-token
+// The returned URL plays without headers, for IPTV clients that cannot authenticate otherwise
+func (h *AuthHandler) CreateStreamToken(c *gin.Context) {
+	var req CreateStreamTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	channelID, err := uuid.Parse(req.ChannelID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid channel ID format",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.checkChannelForToken(ctx, c, channelID) {
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	token, expiresAt := h.service.IssueStreamToken(user.ID, channelID, time.Duration(req.TTLSeconds)*time.Second)
+
+	// The master playlist needs a session_id; clients sharing this URL count as one viewer
+	query := url.Values{}
+	query.Set(middleware.StreamTokenQueryParam, token)
+	query.Set("session_id", uuid.New().String())
+
+	c.JSON(http.StatusCreated, StreamTokenResponse{
+		Token:     token,
+		URL:       fmt.Sprintf("/api/stream/%s/master.m3u8?%s", channelID, query.Encode()),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// CreateShareLink handles POST /api/auth/share-links
+func (h *AuthHandler) CreateShareLink(c *gin.Context) {
+	var req CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	channelID, err := uuid.Parse(req.ChannelID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid channel ID format",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.checkChannelForToken(ctx, c, channelID) {
+		return
+	}
+
+	token, claims, err := h.service.IssueShareToken(channelID, time.Duration(req.TTLSeconds)*time.Second, req.MaxSessions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// No session_id: each player opening the link gets its own session
+	query := url.Values{}
+	query.Set(middleware.ShareTokenQueryParam, token)
+
+	c.JSON(http.StatusCreated, ShareLinkResponse{
+		Token:       token,
+		URL:         fmt.Sprintf("/api/stream/%s/master.m3u8?%s", channelID, query.Encode()),
+		ExpiresAt:   claims.ExpiresAt,
+		MaxSessions: claims.MaxSessions,
+	})
+}
+
+// checkChannelForToken writes a 404 unless the channel exists and the current user can access it
+func (h *AuthHandler) checkChannelForToken(ctx context.Context, c *gin.Context, channelID uuid.UUID) bool {
+	if _, err := h.repos.Channels.GetByID(ctx, channelID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Channel not found",
+			})
+			return false
+		}
+		logger.Log.Error().Err(err).Str("channel_id", channelID.String()).Msg("Failed to get channel")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve channel",
+		})
+		return false
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	allowed, err := h.service.CanAccessChannel(ctx, user.ID, channelID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("channel_id", channelID.String()).Msg("Failed to check channel access")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to check channel access",
+		})
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Channel not found",
+		})
+		return false
+	}
+	return true
+}
+
+// login starts a session for valid credentials and writes the login response
+func (h *AuthHandler) login(ctx context.Context, c *gin.Context, req CredentialsRequest, status int) {
+	token, session, user, err := h.service.Login(ctx, req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "invalid_credentials",
+				Message: "Invalid username or password",
+			})
+			return
+		}
+		logger.Log.Error().Err(err).Msg("Failed to log in")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "login_failed",
+			Message: "Failed to log in",
+		})
+		return
+	}
+
+	c.JSON(status, LoginResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      user,
+	})
+}
+
+// respondUserError maps user validation errors to responses
+func (h *AuthHandler) respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidUsername), errors.Is(err, auth.ErrWeakPassword), errors.Is(err, auth.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, auth.ErrUsernameTaken):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "username_taken",
+			Message: "Username is already taken",
+		})
+	default:
+		logger.Log.Error().Err(err).Msg("Failed to save user")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "user_failed",
+			Message: "Failed to save user",
+		})
+	}
+}
+
+// SetupAuthRoutes registers authentication routes.
+// Status, setup and login are public; every other auth route requires a credential even when
+// auth is not enforced for the rest of the API, and user management requires the admin role.
+func SetupAuthRoutes(apiGroup *gin.RouterGroup, service *auth.Service, repos *db.Repositories, enabled bool) {
+	handler := NewAuthHandler(service, repos, enabled)
+
+	authGroup := apiGroup.Group("/auth")
+	authGroup.GET("/status", handler.GetStatus)
+	authGroup.POST("/setup", handler.Setup)
+	authGroup.POST("/login", handler.Login)
+
+	protected := authGroup.Group("", middleware.RequireAuth(service))
+	protected.POST("/logout", handler.Logout)
+	protected.GET("/me", handler.GetCurrentUser)
+	protected.PUT("/password", handler.ChangePassword)
+	protected.GET("/keys", handler.ListAPIKeys)
+	protected.POST("/keys", handler.CreateAPIKey)
+	protected.DELETE("/keys/:id", handler.RevokeAPIKey)
+	protected.POST("/stream-token", handler.CreateStreamToken)
+	protected.POST("/share-links", middleware.RequireRole(models.RoleEditor), handler.CreateShareLink)
+
+	users := protected.Group("/users", middleware.RequireRole(models.RoleAdmin))
+	users.GET("", handler.ListUsers)
+	users.POST("", handler.CreateUser)
+	users.PUT("/:id/role", handler.UpdateUserRole)
+	users.DELETE("/:id", handler.DeleteUser)
+}
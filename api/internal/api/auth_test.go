@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/auth"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/middleware"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+const testAuthPassword = "correct horse battery"
+
+// setupAuthTestRouter creates a router with auth routes, one protected management
+// route and one stream route guarded like the server does when auth is enabled
+func setupAuthTestRouter(t *testing.T) (*gin.Engine, *db.Repositories, func()) {
+	t.Helper()
+
+	_, repos, cleanup := setupTestDB(t)
+	service, err := auth.NewService(repos, &config.AuthConfig{Enabled: true, TokenSecret: "test-secret"})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	publicGroup := router.Group("/api")
+	SetupAuthRoutes(publicGroup, service, repos, true)
+	publicGroup.GET("/stream/:channel_id/master.m3u8", middleware.RequireStreamAccess(service, service), func(c *gin.Context) {
+		c.String(http.StatusOK, appendPlaylistQuery("#EXTM3U\n1080p.m3u8\n", url.Values{
+			middleware.StreamTokenQueryParam: []string{middleware.StreamToken(c)},
+		}))
+	})
+
+	apiGroup := router.Group("/api", middleware.RequireAuth(service))
+	SetupShowRoutes(apiGroup, repos)
+
+	return router, repos, cleanup
+}
+
+// doJSON performs a request with an optional JSON body and bearer token
+func doJSON(t *testing.T, router *gin.Engine, method, path string, body any, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// setupAndLogin completes initial setup and returns the session token
+func setupAndLogin(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+
+	w := doJSON(t, router, http.MethodPost, "/api/auth/setup", CredentialsRequest{
+		Username: "admin",
+		Password: testAuthPassword,
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Token)
+	return resp.Token
+}
+
+func TestAuthSetupAndLogin(t *testing.T) {
+	router, _, cleanup := setupAuthTestRouter(t)
+	defer cleanup()
+
+	w := doJSON(t, router, http.MethodGet, "/api/auth/status", nil, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"enabled":true,"setup_required":true}`, w.Body.String())
+
+	token := setupAndLogin(t, router)
+
+	t.Run("setup only once", func(t *testing.T) {
+		w := doJSON(t, router, http.MethodPost, "/api/auth/setup", CredentialsRequest{
+			Username: "intruder",
+			Password: testAuthPassword,
+		}, "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("login rejects wrong password", func(t *testing.T) {
+		w := doJSON(t, router, http.MethodPost, "/api/auth/login", CredentialsRequest{
+			Username: "admin",
+			Password: "wrong password",
+		}, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("me returns user without password hash", func(t *testing.T) {
+		w := doJSON(t, router, http.MethodGet, "/api/auth/me", nil, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"username":"admin"`)
+		assert.NotContains(t, w.Body.String(), "password")
+	})
+
+	t.Run("logout ends session", func(t *testing.T) {
+		w := doJSON(t, router, http.MethodPost, "/api/auth/login", CredentialsRequest{
+			Username: "admin",
+			Password: testAuthPassword,
+		}, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp LoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		w = doJSON(t, router, http.MethodPost, "/api/auth/logout", nil, resp.Token)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSON(t, router, http.MethodGet, "/api/auth/me", nil, resp.Token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestManagementRoutesRequireAuth(t *testing.T) {
+	router, _, cleanup := setupAuthTestRouter(t)
+	defer cleanup()
+
+	w := doJSON(t, router, http.MethodGet, "/api/shows", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"unauthorized"`)
+
+	w = doJSON(t, router, http.MethodGet, "/api/shows", nil, "hs_invalid")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	token := setupAndLogin(t, router)
+	w = doJSON(t, router, http.MethodGet, "/api/shows", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	router, _, cleanup := setupAuthTestRouter(t)
+	defer cleanup()
+
+	token := setupAndLogin(t, router)
+
+	w := doJSON(t, router, http.MethodPost, "/api/auth/keys", CreateAPIKeyRequest{Name: "prometheus"}, token)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created CreateAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotContains(t, w.Body.String(), "key_hash")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/shows", nil)
+	req.Header.Set("X-API-Key", created.Key)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	w = doJSON(t, router, http.MethodDelete, "/api/auth/keys/"+created.APIKey.ID.String(), nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(t, router, http.MethodGet, "/api/shows", nil, created.Key)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestStreamTokenAccess(t *testing.T) {
+	router, repos, cleanup := setupAuthTestRouter(t)
+	defer cleanup()
+
+	token := setupAndLogin(t, router)
+	channel := models.NewChannel("Test", time.Now().UTC(), true)
+	require.NoError(t, repos.Channels.Create(context.Background(), channel))
+
+	w := doJSON(t, router, http.MethodPost, "/api/auth/stream-token", CreateStreamTokenRequest{
+		ChannelID: channel.ID.String(),
+	}, token)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp StreamTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Contains(t, resp.URL, "session_id=")
+
+	t.Run("url plays without headers and carries token", func(t *testing.T) {
+		w := doJSON(t, router, http.MethodGet, resp.URL, nil, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "1080p.m3u8?token="+url.QueryEscape(resp.Token))
+	})
+
+	t.Run("token is bound to its channel", func(t *testing.T) {
+		other := models.NewChannel("Other", time.Now().UTC(), true)
+		path := "/api/stream/" + other.ID.String() + "/master.m3u8?token=" + url.QueryEscape(resp.Token)
+		w := doJSON(t, router, http.MethodGet, path, nil, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("token does not grant management access", func(t *testing.T) {
+		w := doJSON(t, router, http.MethodGet, "/api/shows?token="+url.QueryEscape(resp.Token), nil, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		w := doJSON(t, router, http.MethodPost, "/api/auth/stream-token", CreateStreamTokenRequest{
+			ChannelID: models.NewChannel("Missing", time.Now().UTC(), true).ID.String(),
+		}, token)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAppendPlaylistQuery(t *testing.T) {
+	content := "#EXTM3U\n#EXTINF:4.0,\n1080p/1080p_segment_000.ts\nlow.m3u8?session_id=abc\n"
+
+	result := appendPlaylistQuery(content, url.Values{"token": []string{"a+b"}})
+
+	lines := strings.Split(result, "\n")
+	assert.Equal(t, "#EXTINF:4.0,", lines[1])
+	assert.Equal(t, "1080p/1080p_segment_000.ts?token=a%2Bb", lines[2])
+	assert.Equal(t, "low.m3u8?session_id=abc&token=a%2Bb", lines[3])
+	assert.Equal(t, content, appendPlaylistQuery(content, nil))
+}
@@ -21,29 +21,44 @@ import (
 
 // CreateChannelRequest represents a request to create a new channel
 type CreateChannelRequest struct {
-	Name      string     `json:"name" binding:"required"`
-	Icon      *string    `json:"icon,omitempty"`
-	StartTime *time.Time `json:"start_time" binding:"required"`
-	Loop      *bool      `json:"loop,omitempty"`
+	Name          string     `json:"name" binding:"required"`
+	Icon          *string    `json:"icon,omitempty"`
+	StartTime     *time.Time `json:"start_time" binding:"required"`
+	Loop          *bool      `json:"loop,omitempty"`
+	SegmentFormat *string    `json:"segment_format,omitempty"`  // ts (default) or fmp4
+	Encryption    *string    `json:"encryption,omitempty"`      // none (default) or aes-128 (ts only)
+	AlwaysOn      *bool      `json:"always_on,omitempty"`       // Keep streaming without clients (default false)
+	AlwaysOnStart *string    `json:"always_on_start,omitempty"` // HH:MM server local time; set with always_on_end
+	AlwaysOnEnd   *string    `json:"always_on_end,omitempty"`   // HH:MM server local time; set with always_on_start
 }
 
 // UpdateChannelRequest represents a request to update channel metadata (partial update)
 type UpdateChannelRequest struct {
-	Name      *string    `json:"name,omitempty"`
-	Icon      *string    `json:"icon,omitempty"`
-	StartTime *time.Time `json:"start_time,omitempty"`
-	Loop      *bool      `json:"loop,omitempty"`
+	Name          *string    `json:"name,omitempty"`
+	Icon          *string    `json:"icon,omitempty"`
+	StartTime     *time.Time `json:"start_time,omitempty"`
+	Loop          *bool      `json:"loop,omitempty"`
+	SegmentFormat *string    `json:"segment_format,omitempty"` // ts or fmp4; applies from the next stream start
+	Encryption    *string    `json:"encryption,omitempty"`     // none or aes-128 (ts only); applies from the next stream start
+	AlwaysOn      *bool      `json:"always_on,omitempty"`
+	AlwaysOnStart *string    `json:"always_on_start,omitempty"` // HH:MM server local time; empty clears the window
+	AlwaysOnEnd   *string    `json:"always_on_end,omitempty"`   // HH:MM server local time; empty clears the window
 }
 
 // ChannelResponse represents a channel in API responses
 type ChannelResponse struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Icon      *string   `json:"icon,omitempty"`
-	StartTime time.Time `json:"start_time"`
-	Loop      bool      `json:"loop"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Icon          *string   `json:"icon,omitempty"`
+	StartTime     time.Time `json:"start_time"`
+	Loop          bool      `json:"loop"`
+	SegmentFormat string    `json:"segment_format"`
+	Encryption    string    `json:"encryption"`
+	AlwaysOn      bool      `json:"always_on"`
+	AlwaysOnStart *string   `json:"always_on_start,omitempty"`
+	AlwaysOnEnd   *string   `json:"always_on_end,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // ChannelListResponse represents a list of channels
@@ -101,30 +116,45 @@ type ChannelHandler struct {
 	channelService  *channel.ChannelService
 	playlistService *channel.PlaylistService
 	timelineService *timeline.TimelineService
+	access          ChannelAccessPolicy // Optional; nil allows every channel
 }
 
 // NewChannelHandler creates a new channel handler instance
-func NewChannelHandler(channelService *channel.ChannelService, playlistService *channel.PlaylistService, timelineService *timeline.TimelineService) *ChannelHandler {
+func NewChannelHandler(channelService *channel.ChannelService, playlistService *channel.PlaylistService, timelineService *timeline.TimelineService, access ChannelAccessPolicy) *ChannelHandler {
 	return &ChannelHandler{
 		channelService:  channelService,
 		playlistService: playlistService,
 		timelineService: timelineService,
+		access:          access,
 	}
 }
 
 // toChannelResponse converts a channel model to API response format
 func toChannelResponse(ch *models.Channel) *ChannelResponse {
 	return &ChannelResponse{
-		ID:        ch.ID.String(),
-		Name:      ch.Name,
-		Icon:      ch.Icon,
-		StartTime: ch.StartTime,
-		Loop:      ch.Loop,
-		CreatedAt: ch.CreatedAt,
-		UpdatedAt: ch.UpdatedAt,
+		ID:            ch.ID.String(),
+		Name:          ch.Name,
+		Icon:          ch.Icon,
+		StartTime:     ch.StartTime,
+		Loop:          ch.Loop,
+		SegmentFormat: ch.SegmentFormat,
+		Encryption:    ch.Encryption,
+		AlwaysOn:      ch.AlwaysOn,
+		AlwaysOnStart: ch.AlwaysOnStart,
+		AlwaysOnEnd:   ch.AlwaysOnEnd,
+		CreatedAt:     ch.CreatedAt,
+		UpdatedAt:     ch.UpdatedAt,
 	}
 }
 
+// alwaysOnBound returns the always-on window bound of a request; an empty bound clears it
+func alwaysOnBound(bound *string) *string {
+	if bound == nil || *bound == "" {
+		return nil
+	}
+	return bound
+}
+
 // toPlaylistItemResponse converts a playlist item model to API response format
 func toPlaylistItemResponse(item *models.PlaylistItem) *PlaylistItemResponse {
 	return &PlaylistItemResponse{
@@ -154,6 +184,41 @@ func (h *ChannelHandler) CreateChannel(c *gin.Context) {
 		loop = *req.Loop
 	}
 
+	if req.SegmentFormat != nil && !models.IsValidSegmentFormat(*req.SegmentFormat) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_segment_format",
+			Message: "Segment format must be ts or fmp4",
+		})
+		return
+	}
+
+	if req.Encryption != nil && !models.IsValidEncryption(*req.Encryption) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_encryption",
+			Message: "Encryption must be none or aes-128",
+		})
+		return
+	}
+
+	// Checked before the channel exists, so a rejected combination does not leave a channel behind
+	if req.Encryption != nil && *req.Encryption == models.EncryptionAES128 &&
+		req.SegmentFormat != nil && *req.SegmentFormat != models.SegmentFormatTS {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_encryption",
+			Message: "AES-128 encryption requires ts segments",
+		})
+		return
+	}
+
+	alwaysOnStart, alwaysOnEnd := alwaysOnBound(req.AlwaysOnStart), alwaysOnBound(req.AlwaysOnEnd)
+	if !models.IsValidAlwaysOnWindow(alwaysOnStart, alwaysOnEnd) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_always_on_window",
+			Message: "Always-on window needs both always_on_start and always_on_end as HH:MM",
+		})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
@@ -188,6 +253,36 @@ func (h *ChannelHandler) CreateChannel(c *gin.Context) {
 		return
 	}
 
+	// Channels are created with unencrypted MPEG-TS segments and only stream on demand; switch
+	// the format, encryption and always-on settings if others were requested
+	formatChanged := req.SegmentFormat != nil && *req.SegmentFormat != newChannel.SegmentFormat
+	encryptionChanged := req.Encryption != nil && *req.Encryption != newChannel.Encryption
+	alwaysOnChanged := (req.AlwaysOn != nil && *req.AlwaysOn) || alwaysOnStart != nil
+	if formatChanged || encryptionChanged || alwaysOnChanged {
+		if req.SegmentFormat != nil {
+			newChannel.SegmentFormat = *req.SegmentFormat
+		}
+		if req.Encryption != nil {
+			newChannel.Encryption = *req.Encryption
+		}
+		if req.AlwaysOn != nil {
+			newChannel.AlwaysOn = *req.AlwaysOn
+		}
+		newChannel.AlwaysOnStart, newChannel.AlwaysOnEnd = alwaysOnStart, alwaysOnEnd
+		if err := h.channelService.UpdateChannel(ctx, newChannel); err != nil {
+			logger.Log.Error().
+				Err(err).
+				Str("channel_id", newChannel.ID.String()).
+				Msg("Failed to set channel segment format, encryption and always-on settings")
+
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "create_failed",
+				Message: "Failed to create channel",
+			})
+			return
+		}
+	}
+
 	logger.Log.Info().
 		Str("channel_id", newChannel.ID.String()).
 		Str("name", newChannel.Name).
@@ -214,6 +309,19 @@ func (h *ChannelHandler) ListChannels(c *gin.Context) {
 		return
 	}
 
+	channels, err = filterAccessibleChannels(ctx, c, h.access, channels)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Msg("Failed to filter accessible channels")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve channel list",
+		})
+		return
+	}
+
 	// Convert to response format
 	responses := make([]*ChannelResponse, len(channels))
 	for i, ch := range channels {
@@ -242,6 +350,10 @@ func (h *ChannelHandler) GetChannel(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
+	if !checkChannelAccess(ctx, c, h.access, id) {
+		return
+	}
+
 	ch, err := h.channelService.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, channel.ErrChannelNotFound) {
@@ -329,6 +441,21 @@ func (h *ChannelHandler) UpdateChannel(c *gin.Context) {
 	if req.Loop != nil {
 		ch.Loop = *req.Loop
 	}
+	if req.SegmentFormat != nil {
+		ch.SegmentFormat = *req.SegmentFormat
+	}
+	if req.Encryption != nil {
+		ch.Encryption = *req.Encryption
+	}
+	if req.AlwaysOn != nil {
+		ch.AlwaysOn = *req.AlwaysOn
+	}
+	if req.AlwaysOnStart != nil {
+		ch.AlwaysOnStart = alwaysOnBound(req.AlwaysOnStart)
+	}
+	if req.AlwaysOnEnd != nil {
+		ch.AlwaysOnEnd = alwaysOnBound(req.AlwaysOnEnd)
+	}
 
 	// Save updates
 	if err := h.channelService.UpdateChannel(ctx, ch); err != nil {
@@ -353,6 +480,38 @@ func (h *ChannelHandler) UpdateChannel(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, channel.ErrInvalidSegmentFormat) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_segment_format",
+				Message: "Segment format must be ts or fmp4",
+			})
+			return
+		}
+
+		if errors.Is(err, channel.ErrInvalidEncryption) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_encryption",
+				Message: "Encryption must be none or aes-128",
+			})
+			return
+		}
+
+		if errors.Is(err, channel.ErrEncryptionRequiresTS) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_encryption",
+				Message: "AES-128 encryption requires ts segments",
+			})
+			return
+		}
+
+		if errors.Is(err, channel.ErrInvalidAlwaysOnWindow) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_always_on_window",
+				Message: "Always-on window needs both always_on_start and always_on_end as HH:MM",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "update_failed",
 			Message: "Failed to update channel",
@@ -432,6 +591,10 @@ func (h *ChannelHandler) GetCurrentProgram(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
+	if !checkChannelAccess(ctx, c, h.access, id) {
+		return
+	}
+
 	logger.Log.Debug().
 		Str("channel_id", id.String()).
 		Msg("Getting current timeline position")
@@ -524,6 +687,10 @@ func (h *ChannelHandler) GetPlaylist(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
+	if !checkChannelAccess(ctx, c, h.access, channelID) {
+		return
+	}
+
 	// Verify channel exists
 	_, err = h.channelService.GetByID(ctx, channelID)
 	if err != nil {
@@ -995,8 +1162,9 @@ func (h *ChannelHandler) ReorderPlaylist(c *gin.Context) {
 }
 
 // SetupChannelRoutes registers channel-related routes
-func SetupChannelRoutes(apiGroup *gin.RouterGroup, channelService *channel.ChannelService, playlistService *channel.PlaylistService, timelineService *timeline.TimelineService) {
-	handler := NewChannelHandler(channelService, playlistService, timelineService)
+// Viewers only see channels allowed by access; pass nil to allow every channel
+func SetupChannelRoutes(apiGroup *gin.RouterGroup, channelService *channel.ChannelService, playlistService *channel.PlaylistService, timelineService *timeline.TimelineService, access ChannelAccessPolicy) {
+	handler := NewChannelHandler(channelService, playlistService, timelineService, access)
 
 	// Channel CRUD endpoints
 	apiGroup.POST("/channels", handler.CreateChannel)
@@ -29,7 +29,7 @@ func setupChannelTestRouter(database *db.DB, repos *db.Repositories) *gin.Engine
 	channelService := channel.NewChannelService(repos)
 	playlistService := channel.NewPlaylistService(database, repos)
 	timelineService := timeline.NewTimelineService(repos)
-	SetupChannelRoutes(apiGroup, channelService, playlistService, timelineService)
+	SetupChannelRoutes(apiGroup, channelService, playlistService, timelineService, nil)
 
 	return router
 }
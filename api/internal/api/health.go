@@ -2,11 +2,24 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/media"
+	"github.com/stwalsh4118/hermes/internal/streaming"
+)
+
+// Component health states, in increasing severity
+const (
+	ComponentOK       = "ok"
+	ComponentDegraded = "degraded" // Working, but needs attention; does not fail readiness
+	ComponentFailed   = "failed"   // Fails readiness
 )
 
 // HealthResponse represents the response from the health check endpoint
@@ -17,14 +30,41 @@ type HealthResponse struct {
 	Details  map[string]interface{} `json:"details,omitempty"`
 }
 
+// ComponentHealth reports the health of one subsystem
+type ComponentHealth struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ComponentReport represents the response from the readiness and liveness endpoints
+type ComponentReport struct {
+	Status     string                      `json:"status"`
+	Time       string                      `json:"time"`
+	Components map[string]*ComponentHealth `json:"components"`
+}
+
+// DiskSpaceDetails describes free space on the segment volume
+type DiskSpaceDetails struct {
+	Path           string `json:"path"`
+	AvailableBytes uint64 `json:"available_bytes"`
+	RequiredBytes  uint64 `json:"required_bytes"`
+}
+
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	db *db.DB
+	db            *db.DB
+	streamManager *streaming.StreamManager // Optional; streaming checks are skipped when nil
+	scanner       *media.Scanner           // Optional; library check is skipped when nil
 }
 
 // NewHealthHandler creates a new health check handler
-func NewHealthHandler(database *db.DB) *HealthHandler {
-	return &HealthHandler{db: database}
+func NewHealthHandler(database *db.DB, streamManager *streaming.StreamManager, scanner *media.Scanner) *HealthHandler {
+	return &HealthHandler{
+		db:            database,
+		streamManager: streamManager,
+		scanner:       scanner,
+	}
 }
 
 // Check handles the health check endpoint
@@ -51,8 +91,154 @@ func (h *HealthHandler) Check(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// Live handles GET /api/health/live
+// Only checks that the process is not wedged, so a failing dependency never triggers a restart
+func (h *HealthHandler) Live(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	components := make(map[string]*ComponentHealth)
+	if h.streamManager != nil {
+		components["stream_manager"] = &ComponentHealth{Status: ComponentOK}
+		if !h.streamManager.Responsive(ctx) {
+			components["stream_manager"] = &ComponentHealth{
+				Status:  ComponentFailed,
+				Message: "stream manager lock could not be acquired",
+			}
+		}
+	}
+
+	h.writeReport(c, components)
+}
+
+// Ready handles GET /api/health/ready
+// Checks every dependency needed to serve streams and returns a per-component report
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	components := map[string]*ComponentHealth{
+		"database": h.checkDatabase(ctx),
+		"ffmpeg":   checkBinary(streaming.CheckFFmpegInstalled(), ComponentFailed),
+		// Without ffprobe scans fail, but existing media still streams
+		"ffprobe": checkBinary(media.CheckFFprobeInstalled(), ComponentDegraded),
+	}
+	if h.scanner != nil {
+		components["library"] = checkLibrary(h.scanner.LibraryPath())
+	}
+	if h.streamManager != nil {
+		components["disk_space"] = checkDiskSpace(h.streamManager.SegmentPath())
+		components["streams"] = checkStreams(h.streamManager.PlaylistHealth())
+	}
+
+	h.writeReport(c, components)
+}
+
+// writeReport responds with the overall status of the components (503 if any failed)
+func (h *HealthHandler) writeReport(c *gin.Context, components map[string]*ComponentHealth) {
+	report := ComponentReport{
+		Status:     ComponentOK,
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Components: components,
+	}
+
+	for _, component := range components {
+		switch component.Status {
+		case ComponentFailed:
+			report.Status = ComponentFailed
+		case ComponentDegraded:
+			if report.Status == ComponentOK {
+				report.Status = ComponentDegraded
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status == ComponentFailed {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// checkDatabase pings the database
+func (h *HealthHandler) checkDatabase(ctx context.Context) *ComponentHealth {
+	if err := h.db.Health(ctx); err != nil {
+		return &ComponentHealth{Status: ComponentFailed, Message: err.Error()}
+	}
+	return &ComponentHealth{Status: ComponentOK}
+}
+
+// checkBinary maps a binary availability check to a component with the given failure status
+func checkBinary(err error, failStatus string) *ComponentHealth {
+	if err != nil {
+		return &ComponentHealth{Status: failStatus, Message: err.Error()}
+	}
+	return &ComponentHealth{Status: ComponentOK}
+}
+
+// checkLibrary verifies the media library directory exists and can be read
+func checkLibrary(path string) *ComponentHealth {
+	if path == "" {
+		return &ComponentHealth{Status: ComponentDegraded, Message: "no media library path configured"}
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return &ComponentHealth{Status: ComponentFailed, Message: err.Error(), Details: gin.H{"path": path}}
+	}
+	defer func() { _ = dir.Close() }()
+
+	if _, err := dir.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		return &ComponentHealth{Status: ComponentFailed, Message: err.Error(), Details: gin.H{"path": path}}
+	}
+	return &ComponentHealth{Status: ComponentOK, Details: gin.H{"path": path}}
+}
+
+// checkDiskSpace compares free space on the segment volume with the streaming thresholds
+func checkDiskSpace(path string) *ComponentHealth {
+	available, err := streaming.AvailableDiskSpace(path)
+	if err != nil {
+		return &ComponentHealth{Status: ComponentFailed, Message: err.Error(), Details: gin.H{"path": path}}
+	}
+
+	details := DiskSpaceDetails{
+		Path:           path,
+		AvailableBytes: available,
+		RequiredBytes:  streaming.MinDiskSpaceBytes,
+	}
+	switch {
+	case available < streaming.MinDiskSpaceBytes:
+		return &ComponentHealth{Status: ComponentFailed, Message: "insufficient disk space for new streams", Details: details}
+	case available < streaming.WarnDiskSpaceBytes:
+		return &ComponentHealth{Status: ComponentDegraded, Message: "disk space is running low", Details: details}
+	default:
+		return &ComponentHealth{Status: ComponentOK, Details: details}
+	}
+}
+
+// checkStreams reports stalled stream playlists as degraded: one stuck channel must not take the
+// whole instance out of rotation
+func checkStreams(playlists []streaming.PlaylistHealth) *ComponentHealth {
+	stale := 0
+	for _, p := range playlists {
+		if !p.Healthy() {
+			stale++
+		}
+	}
+
+	component := &ComponentHealth{Status: ComponentOK, Details: playlists}
+	if stale > 0 {
+		component.Status = ComponentDegraded
+		component.Message = fmt.Sprintf("%d of %d stream playlists are stale", stale, len(playlists))
+	}
+	return component
+}
+
 // SetupHealthRoutes registers health check routes
-func SetupHealthRoutes(apiGroup *gin.RouterGroup, database *db.DB) {
-	handler := NewHealthHandler(database)
+// streamManager and scanner are optional; their checks are skipped when nil
+func SetupHealthRoutes(apiGroup *gin.RouterGroup, database *db.DB, streamManager *streaming.StreamManager, scanner *media.Scanner) {
+	handler := NewHealthHandler(database, streamManager, scanner)
 	apiGroup.GET("/health", handler.Check)
+	apiGroup.GET("/health/live", handler.Live)
+	apiGroup.GET("/health/ready", handler.Ready)
 }
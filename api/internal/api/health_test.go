@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/media"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/streaming"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	database, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	scanner := media.NewScanner(repos)
+	defer scanner.Stop()
+	scanner.ApplySettings(&models.Settings{MediaLibraryPath: t.TempDir()})
+
+	streamManager := streaming.NewStreamManager(repos, nil, &config.StreamingConfig{
+		SegmentPath:           t.TempDir(),
+		StreamSegmentDuration: 4,
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupHealthRoutes(router.Group("/api"), database, streamManager, scanner)
+
+	getReport := func(t *testing.T, path string) (int, ComponentReport) {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var report ComponentReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w.Code, report
+	}
+
+	t.Run("Live", func(t *testing.T) {
+		code, report := getReport(t, "/api/health/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, ComponentOK, report.Status)
+		require.Contains(t, report.Components, "stream_manager")
+		assert.Equal(t, ComponentOK, report.Components["stream_manager"].Status)
+	})
+
+	t.Run("Ready reports every component", func(t *testing.T) {
+		code, report := getReport(t, "/api/health/ready")
+		for _, name := range []string{"database", "ffmpeg", "ffprobe", "library", "disk_space", "streams"} {
+			assert.Contains(t, report.Components, name)
+		}
+		assert.Equal(t, ComponentOK, report.Components["database"].Status)
+		assert.Equal(t, ComponentOK, report.Components["library"].Status)
+		assert.Equal(t, ComponentOK, report.Components["streams"].Status)
+
+		// ffmpeg and disk space depend on the host, so only check consistency
+		if report.Status == ComponentFailed {
+			assert.Equal(t, http.StatusServiceUnavailable, code)
+		} else {
+			assert.Equal(t, http.StatusOK, code)
+		}
+	})
+
+	t.Run("Unreadable library fails readiness", func(t *testing.T) {
+		scanner.ApplySettings(&models.Settings{MediaLibraryPath: filepath.Join(t.TempDir(), "missing")})
+
+		code, report := getReport(t, "/api/health/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, ComponentFailed, report.Status)
+		assert.Equal(t, ComponentFailed, report.Components["library"].Status)
+	})
+}
+
+func TestCheckStreams_StaleIsDegraded(t *testing.T) {
+	playlists := []streaming.PlaylistHealth{
+		{ChannelID: "a", Quality: "720p", Status: streaming.PlaylistHealthOK},
+		{ChannelID: "b", Quality: "720p", Status: streaming.PlaylistHealthStale},
+	}
+
+	component := checkStreams(playlists)
+	assert.Equal(t, ComponentDegraded, component.Status, "a stuck channel must not fail readiness")
+	assert.Equal(t, "1 of 2 stream playlists are stale", component.Message)
+	assert.Equal(t, ComponentOK, checkStreams(playlists[:1]).Status)
+}
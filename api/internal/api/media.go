@@ -3,7 +3,9 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
@@ -19,7 +21,7 @@ import (
 
 // ScanRequest represents a request to trigger a media library scan
 type ScanRequest struct {
-	Path string `json:"path"` // Optional: defaults to config if not provided
+	Path string `json:"path"` // Optional: defaults to the media library path setting
 }
 
 // ScanResponse represents the response after triggering a scan
@@ -38,10 +40,11 @@ type MediaListResponse struct {
 
 // UpdateMediaRequest represents a request to update media metadata
 type UpdateMediaRequest struct {
-	Title    *string `json:"title,omitempty"`
-	ShowName *string `json:"show_name,omitempty"`
-	Season   *int    `json:"season,omitempty"`
-	Episode  *int    `json:"episode,omitempty"`
+	Title       *string `json:"title,omitempty"`
+	ShowName    *string `json:"show_name,omitempty"`
+	Season      *int    `json:"season,omitempty"`
+	Episode     *int    `json:"episode,omitempty"`
+	Description *string `json:"description,omitempty"`
 }
 
 // DeleteResponse represents a successful delete operation
@@ -57,15 +60,26 @@ type ErrorResponse struct {
 
 // MediaHandler handles media-related API requests
 type MediaHandler struct {
-	scanner *media.Scanner
-	repos   *db.Repositories
+	scanner    *media.Scanner
+	repos      *db.Repositories
+	metadata   media.MetadataProvider    // Optional; nil when no provider is configured
+	thumbnails *media.ThumbnailGenerator // Optional; nil disables on-demand generation
+	reconciler *media.Reconciler
 }
 
 // NewMediaHandler creates a new media handler instance
-func NewMediaHandler(scanner *media.Scanner, repos *db.Repositories) *MediaHandler {
+func NewMediaHandler(
+	scanner *media.Scanner,
+	repos *db.Repositories,
+	metadata media.MetadataProvider,
+	thumbnails *media.ThumbnailGenerator,
+) *MediaHandler {
 	return &MediaHandler{
-		scanner: scanner,
-		repos:   repos,
+		scanner:    scanner,
+		repos:      repos,
+		metadata:   metadata,
+		thumbnails: thumbnails,
+		reconciler: media.NewReconciler(repos),
 	}
 }
 
@@ -83,8 +97,10 @@ func (h *MediaHandler) TriggerScan(c *gin.Context) {
 		}
 	}
 
-	// If no path provided, this would use the default from config
-	// For now, we require a path to be provided
+	// Fall back to the configured media library path
+	if req.Path == "" {
+		req.Path = h.scanner.LibraryPath()
+	}
 	if req.Path == "" {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "missing_path",
@@ -166,6 +182,8 @@ func (h *MediaHandler) GetScanStatus(c *gin.Context) {
 }
 
 // ListMedia handles GET /api/media
+// Supports free-text search (q), filters (show, season, video_codec, audio_codec, resolution,
+// min_duration, max_duration, not_in_channel) and sorting (sort, order) with limit/offset pagination
 func (h *MediaHandler) ListMedia(c *gin.Context) {
 	// Parse pagination parameters
 	limit := 20 // default
@@ -193,77 +211,42 @@ func (h *MediaHandler) ListMedia(c *gin.Context) {
 		}
 	}
 
-	showName := c.Query("show")
+	filter, err := parseMediaFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_parameter",
+			Message: err.Error(),
+		})
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	var mediaItems []*models.Media
-	var totalCount int64
-	var err error
-
-	if showName != "" {
-		// Filter by show name
-		mediaItems, err = h.repos.Media.ListByShow(ctx, showName, limit, offset)
-		if err != nil {
-			logger.Log.Error().
-				Err(err).
-				Str("show", showName).
-				Int("limit", limit).
-				Int("offset", offset).
-				Msg("Failed to list media by show")
-
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "query_failed",
-				Message: "Failed to retrieve media list",
-			})
-			return
-		}
-
-		// Get total count for the show
-		totalCount, err = h.repos.Media.CountByShow(ctx, showName)
-		if err != nil {
-			logger.Log.Error().
-				Err(err).
-				Str("show", showName).
-				Msg("Failed to count media by show")
-
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "query_failed",
-				Message: "Failed to retrieve media count",
-			})
-			return
-		}
-	} else {
-		// List all media
-		mediaItems, err = h.repos.Media.List(ctx, limit, offset)
-		if err != nil {
-			logger.Log.Error().
-				Err(err).
-				Int("limit", limit).
-				Int("offset", offset).
-				Msg("Failed to list media")
-
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "query_failed",
-				Message: "Failed to retrieve media list",
-			})
-			return
-		}
-
-		// Get total count of all media
-		totalCount, err = h.repos.Media.Count(ctx)
-		if err != nil {
-			logger.Log.Error().
-				Err(err).
-				Msg("Failed to count media")
+	mediaItems, totalCount, err := h.repos.Media.Search(ctx, filter)
+	if errors.Is(err, db.ErrRelevanceUnavailable) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "sort_unavailable",
+			Message: "Relevance sorting is not available: the server was built without FTS5 search",
+		})
+		return
+	}
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("query", filter.Query).
+			Str("show", filter.ShowName).
+			Int("limit", limit).
+			Int("offset", offset).
+			Msg("Failed to list media")
 
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "query_failed",
-				Message: "Failed to retrieve media count",
-			})
-			return
-		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve media list",
+		})
+		return
 	}
 
 	// Calculate the limit to return in response
@@ -280,6 +263,80 @@ func (h *MediaHandler) ListMedia(c *gin.Context) {
 	})
 }
 
+// parseMediaFilter builds a media search filter from query parameters
+func parseMediaFilter(c *gin.Context) (db.MediaFilter, error) {
+	filter := db.MediaFilter{
+		Query:      c.Query("q"),
+		ShowName:   c.Query("show"),
+		VideoCodec: c.Query("video_codec"),
+		AudioCodec: c.Query("audio_codec"),
+		Resolution: c.Query("resolution"),
+	}
+
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		season, err := strconv.Atoi(seasonStr)
+		if err != nil || season < 0 {
+			return filter, fmt.Errorf("season must be a non-negative integer")
+		}
+		filter.Season = &season
+	}
+
+	var err error
+	if filter.MinDuration, err = parseDurationParam(c, "min_duration"); err != nil {
+		return filter, err
+	}
+	if filter.MaxDuration, err = parseDurationParam(c, "max_duration"); err != nil {
+		return filter, err
+	}
+	if filter.MaxDuration > 0 && filter.MinDuration > filter.MaxDuration {
+		return filter, fmt.Errorf("min_duration cannot exceed max_duration")
+	}
+
+	if notInChannel := c.Query("not_in_channel"); notInChannel != "" {
+		value, err := strconv.ParseBool(notInChannel)
+		if err != nil {
+			return filter, fmt.Errorf("not_in_channel must be true or false")
+		}
+		filter.NotInChannel = value
+	}
+
+	if status := c.Query("status"); status != "" {
+		if status != models.MediaStatusAvailable && status != models.MediaStatusMissing {
+			return filter, fmt.Errorf("status must be one of: available, missing")
+		}
+		filter.Status = status
+	}
+
+	if sortBy := c.Query("sort"); sortBy != "" {
+		filter.SortBy = db.MediaSortField(sortBy)
+		if !db.IsValidMediaSortField(filter.SortBy) {
+			return filter, fmt.Errorf("sort must be one of: created_at, title, duration, file_size, episode, relevance")
+		}
+	}
+
+	switch order := db.SortOrder(c.Query("order")); order {
+	case "", db.SortAsc, db.SortDesc:
+		filter.SortOrder = order
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	return filter, nil
+}
+
+// parseDurationParam parses an optional non-negative duration (in seconds) query parameter
+func parseDurationParam(c *gin.Context, name string) (int64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number of seconds", name)
+	}
+	return seconds, nil
+}
+
 // GetMedia handles GET /api/media/:id
 func (h *MediaHandler) GetMedia(c *gin.Context) {
 	idStr := c.Param("id")
@@ -384,6 +441,9 @@ func (h *MediaHandler) UpdateMedia(c *gin.Context) {
 	if req.Episode != nil {
 		mediaItem.Episode = req.Episode
 	}
+	if req.Description != nil {
+		mediaItem.Description = req.Description
+	}
 
 	// Save updates
 	if err := h.repos.Media.Update(ctx, mediaItem); err != nil {
@@ -460,6 +520,11 @@ func (h *MediaHandler) DeleteMedia(c *gin.Context) {
 		return
 	}
 
+	// Remove generated artwork along with the record
+	if h.thumbnails != nil {
+		h.thumbnails.Remove(id)
+	}
+
 	logger.Log.Info().
 		Str("id", id.String()).
 		Msg("Media deleted successfully")
@@ -469,17 +534,238 @@ func (h *MediaHandler) DeleteMedia(c *gin.Context) {
 	})
 }
 
+// GetMediaMetadata handles GET /api/media/:id/metadata
+// Looks up descriptive metadata for a media item from the configured provider
+func (h *MediaHandler) GetMediaMetadata(c *gin.Context) {
+	if h.metadata == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "metadata_unavailable",
+			Message: "No metadata provider is configured",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid media ID format",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	mediaItem, err := h.repos.Media.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Media not found",
+			})
+			return
+		}
+
+		logger.Log.Error().
+			Err(err).
+			Str("id", id.String()).
+			Msg("Failed to get media by ID")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve media",
+		})
+		return
+	}
+
+	// Episode lookup when fully identified, show lookup when only the show is known, movie otherwise
+	var result *media.MetadataResult
+	switch {
+	case mediaItem.ShowName != nil && mediaItem.Season != nil && mediaItem.Episode != nil:
+		result, err = h.metadata.LookupEpisode(ctx, *mediaItem.ShowName, *mediaItem.Season, *mediaItem.Episode)
+	case mediaItem.ShowName != nil:
+		result, err = h.metadata.LookupShow(ctx, *mediaItem.ShowName)
+	default:
+		result, err = h.metadata.LookupMovie(ctx, mediaItem.Title, 0)
+	}
+
+	if err != nil {
+		if errors.Is(err, media.ErrMetadataNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "metadata_not_found",
+				Message: "No metadata found for this media",
+			})
+			return
+		}
+
+		logger.Log.Error().
+			Err(err).
+			Str("id", id.String()).
+			Str("provider", h.metadata.Name()).
+			Msg("Metadata lookup failed")
+
+		c.JSON(http.StatusBadGateway, ErrorResponse{
+			Error:   "metadata_lookup_failed",
+			Message: "Failed to look up metadata",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// artworkKind identifies a generated image type served by the media API
+type artworkKind string
+
+// Generated artwork kinds
+const (
+	artworkThumbnail artworkKind = "thumbnail"
+	artworkSprite    artworkKind = "sprite"
+)
+
+// GetThumbnail handles GET /api/media/:id/thumbnail
+func (h *MediaHandler) GetThumbnail(c *gin.Context) {
+	h.serveArtwork(c, artworkThumbnail)
+}
+
+// GetSprite handles GET /api/media/:id/sprite
+func (h *MediaHandler) GetSprite(c *gin.Context) {
+	h.serveArtwork(c, artworkSprite)
+}
+
+// serveArtwork serves a generated image for a media item, generating it on demand if missing
+func (h *MediaHandler) serveArtwork(c *gin.Context, kind artworkKind) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid media ID format",
+		})
+		return
+	}
+
+	// Generous timeout since on-demand generation runs ffmpeg
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	mediaItem, err := h.repos.Media.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Media not found",
+			})
+			return
+		}
+
+		logger.Log.Error().
+			Err(err).
+			Str("id", id.String()).
+			Msg("Failed to get media by ID")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve media",
+		})
+		return
+	}
+
+	storedPath := mediaItem.ThumbnailPath
+	if kind == artworkSprite {
+		storedPath = mediaItem.SpritePath
+	}
+
+	if storedPath != nil {
+		if _, err := os.Stat(*storedPath); err == nil {
+			c.Header("Cache-Control", "public, max-age=86400")
+			c.File(*storedPath)
+			return
+		}
+	}
+
+	path, err := h.generateArtwork(ctx, mediaItem, kind)
+	if err != nil {
+		if !errors.Is(err, errArtworkUnavailable) && !errors.Is(err, media.ErrSpritesDisabled) {
+			logger.Log.Warn().
+				Err(err).
+				Str("id", id.String()).
+				Str("kind", string(kind)).
+				Msg("Failed to generate artwork on demand")
+		}
+
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   fmt.Sprintf("%s_not_found", kind),
+			Message: fmt.Sprintf("No %s available for this media", kind),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.File(path)
+}
+
+// errArtworkUnavailable indicates on-demand generation is not configured
+var errArtworkUnavailable = errors.New("artwork generation not configured")
+
+// generateArtwork creates the requested image and persists its path on the media record
+func (h *MediaHandler) generateArtwork(ctx context.Context, mediaItem *models.Media, kind artworkKind) (string, error) {
+	if h.thumbnails == nil {
+		return "", errArtworkUnavailable
+	}
+
+	var (
+		path string
+		err  error
+	)
+	thumbnailPath, spritePath := mediaItem.ThumbnailPath, mediaItem.SpritePath
+	if kind == artworkSprite {
+		path, err = h.thumbnails.GenerateSprite(ctx, mediaItem.ID, mediaItem.FilePath, mediaItem.Duration)
+		spritePath = &path
+	} else {
+		path, err = h.thumbnails.GenerateThumbnail(ctx, mediaItem.ID, mediaItem.FilePath, mediaItem.Duration)
+		thumbnailPath = &path
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := h.repos.Media.UpdateArtwork(ctx, mediaItem.ID, thumbnailPath, spritePath); err != nil {
+		// The image exists on disk, so still serve it; the next scan will fix the record
+		logger.Log.Warn().
+			Err(err).
+			Str("id", mediaItem.ID.String()).
+			Msg("Failed to store artwork path")
+	}
+
+	return path, nil
+}
+
 // SetupMediaRoutes registers media-related routes
-func SetupMediaRoutes(apiGroup *gin.RouterGroup, scanner *media.Scanner, repos *db.Repositories) {
-	handler := NewMediaHandler(scanner, repos)
+func SetupMediaRoutes(
+	apiGroup *gin.RouterGroup,
+	scanner *media.Scanner,
+	repos *db.Repositories,
+	metadata media.MetadataProvider,
+	thumbnails *media.ThumbnailGenerator,
+) {
+	handler := NewMediaHandler(scanner, repos, metadata, thumbnails)
 
 	// Scan endpoints
 	apiGroup.POST("/media/scan", handler.TriggerScan)
 	apiGroup.GET("/media/scan/:scanId/status", handler.GetScanStatus)
 
+	// Missing-file reconciliation endpoints
+	apiGroup.GET("/media/reconciliation", handler.GetReconciliation)
+	apiGroup.POST("/media/reconciliation/purge", handler.PurgeMissing)
+	apiGroup.POST("/media/:id/relink", handler.RelinkMedia)
+
 	// Media CRUD endpoints
 	apiGroup.GET("/media", handler.ListMedia)
 	apiGroup.GET("/media/:id", handler.GetMedia)
 	apiGroup.PUT("/media/:id", handler.UpdateMedia)
 	apiGroup.DELETE("/media/:id", handler.DeleteMedia)
+	apiGroup.GET("/media/:id/metadata", handler.GetMediaMetadata)
+	apiGroup.GET("/media/:id/thumbnail", handler.GetThumbnail)
+	apiGroup.GET("/media/:id/sprite", handler.GetSprite)
 }
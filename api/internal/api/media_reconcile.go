@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/media"
+)
+
+// PurgeMissingRequest represents a request to purge missing media
+type PurgeMissingRequest struct {
+	MediaIDs []uuid.UUID `json:"media_ids"` // Optional: purges every missing item when empty
+}
+
+// PurgeMissingResponse lists the media removed by a purge
+type PurgeMissingResponse struct {
+	Purged []uuid.UUID `json:"purged"`
+	Count  int         `json:"count"`
+}
+
+// RelinkMediaRequest represents a request to point a media item at a new file
+type RelinkMediaRequest struct {
+	FilePath string `json:"file_path" binding:"required"`
+}
+
+// GetReconciliation handles GET /api/media/reconciliation
+// With verify=true every media file is re-checked on disk before the report is built
+func (h *MediaHandler) GetReconciliation(c *gin.Context) {
+	verify := false
+	if verifyStr := c.Query("verify"); verifyStr != "" {
+		value, err := strconv.ParseBool(verifyStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_parameter",
+				Message: "verify must be true or false",
+			})
+			return
+		}
+		verify = value
+	}
+
+	// Verifying stats every file in the library, which can be slow on network storage
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	var verified *media.ReconcileResult
+	if verify {
+		result, err := h.reconciler.Verify(ctx, "")
+		if err != nil {
+			logger.Log.Error().
+				Err(err).
+				Msg("Failed to verify media files")
+
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "verify_failed",
+				Message: "Failed to verify media files",
+			})
+			return
+		}
+		verified = result
+	}
+
+	report, err := h.reconciler.Report(ctx)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Msg("Failed to build reconciliation report")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to build reconciliation report",
+		})
+		return
+	}
+	report.Verified = verified
+
+	c.JSON(http.StatusOK, report)
+}
+
+// PurgeMissing handles POST /api/media/reconciliation/purge
+func (h *MediaHandler) PurgeMissing(c *gin.Context) {
+	var req PurgeMissingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Empty body is acceptable - purge everything that is missing
+		if c.Request.ContentLength > 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "Invalid request body",
+			})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	purged, err := h.reconciler.Purge(ctx, req.MediaIDs)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Int("requested", len(req.MediaIDs)).
+			Msg("Failed to purge missing media")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "purge_failed",
+			Message: "Failed to purge missing media",
+		})
+		return
+	}
+
+	if h.thumbnails != nil {
+		for _, id := range purged {
+			h.thumbnails.Remove(id)
+		}
+	}
+
+	c.JSON(http.StatusOK, PurgeMissingResponse{
+		Purged: purged,
+		Count:  len(purged),
+	})
+}
+
+// RelinkMedia handles POST /api/media/:id/relink
+func (h *MediaHandler) RelinkMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid media ID format",
+		})
+		return
+	}
+
+	var req RelinkMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "file_path is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	mediaItem, err := h.reconciler.Relink(ctx, id, req.FilePath)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Media not found",
+			})
+		case errors.Is(err, media.ErrRelinkInvalidPath):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_path",
+				Message: err.Error(),
+			})
+		case errors.Is(err, media.ErrRelinkPathInUse):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "path_in_use",
+				Message: "Another media item already uses this file path",
+			})
+		default:
+			logger.Log.Error().
+				Err(err).
+				Str("id", id.String()).
+				Str("file_path", req.FilePath).
+				Msg("Failed to relink media")
+
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "relink_failed",
+				Message: "Failed to relink media",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, mediaItem)
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/media"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// seedReconcileLibrary creates one media item whose file exists and one whose file does not,
+// both on a channel playlist (present item first)
+func seedReconcileLibrary(t *testing.T, repos *db.Repositories) (present, missing *models.Media, channel *models.Channel) {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	presentPath := filepath.Join(dir, "present.mp4")
+	require.NoError(t, os.WriteFile(presentPath, []byte("video"), 0o644))
+
+	present = models.NewMedia(presentPath, "Present", 1800)
+	require.NoError(t, repos.Media.Create(ctx, present))
+	missing = models.NewMedia(filepath.Join(dir, "gone.mp4"), "Gone", 1800)
+	require.NoError(t, repos.Media.Create(ctx, missing))
+
+	channel = models.NewChannel("Reconcile Channel", time.Now().UTC(), true)
+	require.NoError(t, repos.Channels.Create(ctx, channel))
+	require.NoError(t, repos.PlaylistItems.Create(ctx, models.NewPlaylistItem(channel.ID, present.ID, 0)))
+	require.NoError(t, repos.PlaylistItems.Create(ctx, models.NewPlaylistItem(channel.ID, missing.ID, 1)))
+
+	return present, missing, channel
+}
+
+func TestMediaReconciliation(t *testing.T) {
+	_, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	scanner := media.NewScanner(repos)
+	defer scanner.Stop()
+
+	router := setupTestRouter(scanner, repos)
+	present, missing, channel := seedReconcileLibrary(t, repos)
+
+	getReport := func(t *testing.T, query string) media.ReconciliationReport {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/media/reconciliation"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var report media.ReconciliationReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return report
+	}
+
+	t.Run("New media is available and report is empty", func(t *testing.T) {
+		report := getReport(t, "")
+		assert.Equal(t, 0, report.Total)
+		assert.Nil(t, report.Verified)
+
+		item, err := repos.Media.GetByID(context.Background(), missing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.MediaStatusAvailable, item.Status)
+	})
+
+	t.Run("Invalid verify parameter", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/media/reconciliation?verify=maybe", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Verify marks missing files and lists referencing channels", func(t *testing.T) {
+		report := getReport(t, "?verify=true")
+		require.NotNil(t, report.Verified)
+		assert.Equal(t, 2, report.Verified.Checked)
+		assert.Equal(t, int64(1), report.Verified.Missing)
+
+		require.Equal(t, 1, report.Total)
+		assert.Equal(t, 1, report.AffectedChannels)
+		entry := report.Missing[0]
+		assert.Equal(t, missing.ID, entry.Media.ID)
+		assert.Equal(t, models.MediaStatusMissing, entry.Media.Status)
+		assert.NotNil(t, entry.Media.MissingSince)
+		require.Len(t, entry.Channels, 1)
+		assert.Equal(t, channel.ID, entry.Channels[0].ChannelID)
+		assert.Equal(t, "Reconcile Channel", entry.Channels[0].ChannelName)
+		assert.Equal(t, 1, entry.Channels[0].Position)
+	})
+
+	t.Run("Status filter on media list", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/media?status=missing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp MediaListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 1)
+		assert.Equal(t, missing.ID, resp.Items[0].ID)
+
+		req = httptest.NewRequest("GET", "/api/media?status=gone", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Relink rejects invalid and in-use paths", func(t *testing.T) {
+		cases := []struct {
+			path   string
+			status int
+		}{
+			{"relative/file.mp4", http.StatusBadRequest},
+			{filepath.Join(t.TempDir(), "nope.mp4"), http.StatusBadRequest},
+			{present.FilePath, http.StatusConflict},
+		}
+		for _, tc := range cases {
+			body := fmt.Sprintf(`{"file_path": %q}`, tc.path)
+			req := httptest.NewRequest("POST", "/api/media/"+missing.ID.String()+"/relink", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code, tc.path)
+		}
+	})
+
+	t.Run("Relink restores availability", func(t *testing.T) {
+		newPath := filepath.Join(filepath.Dir(present.FilePath), "moved.mkv")
+		require.NoError(t, os.WriteFile(newPath, []byte("video"), 0o644))
+
+		body := fmt.Sprintf(`{"file_path": %q}`, newPath)
+		req := httptest.NewRequest("POST", "/api/media/"+missing.ID.String()+"/relink", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var item models.Media
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		assert.Equal(t, newPath, item.FilePath)
+		assert.Equal(t, models.MediaStatusAvailable, item.Status)
+		assert.Nil(t, item.MissingSince)
+
+		assert.Equal(t, 0, getReport(t, "").Total)
+	})
+
+	t.Run("Purge deletes only missing media and closes playlist gaps", func(t *testing.T) {
+		require.NoError(t, os.Remove(present.FilePath))
+		report := getReport(t, "?verify=true")
+		require.Equal(t, 1, report.Total)
+		assert.Equal(t, present.ID, report.Missing[0].Media.ID)
+
+		body := fmt.Sprintf(`{"media_ids": [%q, %q]}`, present.ID, missing.ID)
+		req := httptest.NewRequest("POST", "/api/media/reconciliation/purge", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp PurgeMissingResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Count)
+		assert.Equal(t, present.ID, resp.Purged[0])
+
+		// The relinked (available) item survives and moves up from position 1 to 0
+		_, err := repos.Media.GetByID(context.Background(), present.ID)
+		assert.ErrorIs(t, err, db.ErrNotFound)
+
+		items, err := repos.PlaylistItems.GetByChannelID(context.Background(), channel.ID)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, missing.ID, items[0].MediaID)
+		assert.Equal(t, 0, items[0].Position)
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -47,7 +49,7 @@ func setupTestRouter(scanner *media.Scanner, repos *db.Repositories) *gin.Engine
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	SetupMediaRoutes(apiGroup, scanner, repos)
+	SetupMediaRoutes(apiGroup, scanner, repos, nil, nil)
 	return router
 }
 
@@ -652,3 +654,277 @@ func TestDeleteMedia(t *testing.T) {
 		assert.Equal(t, "invalid_id", resp.Error)
 	})
 }
+
+func TestGetMediaMetadata(t *testing.T) {
+	_, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	scanner := media.NewScanner(repos)
+	defer scanner.Stop()
+
+	mediaItem := createTestMedia(t, repos)
+
+	provider := media.NewLocalMetadataProviderFromDump(&media.LocalMetadataDump{
+		Shows: []media.LocalShowEntry{
+			{
+				Name:      "Test Show",
+				PosterURL: "https://img.example.com/poster.jpg",
+				Episodes: []media.LocalEpisodeEntry{
+					{Season: 1, Episode: 1, Title: "Pilot", Plot: "The first episode."},
+				},
+			},
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupMediaRoutes(router.Group("/api"), scanner, repos, provider, nil)
+
+	t.Run("Returns episode metadata", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/media/%s/metadata", mediaItem.ID.String()), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp media.MetadataResult
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		assert.Equal(t, "Pilot", resp.Title)
+		assert.Equal(t, "The first episode.", resp.Plot)
+		assert.Equal(t, "https://img.example.com/poster.jpg", resp.PosterURL)
+	})
+
+	t.Run("Unknown movie returns 404", func(t *testing.T) {
+		movie := models.NewMedia("/test/movie.mp4", "Unknown Movie", 5400)
+		require.NoError(t, repos.Media.Create(context.Background(), movie))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/media/%s/metadata", movie.ID.String()), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var resp ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		assert.Equal(t, "metadata_not_found", resp.Error)
+	})
+
+	t.Run("No provider returns 503", func(t *testing.T) {
+		noProviderRouter := setupTestRouter(scanner, repos)
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/media/%s/metadata", mediaItem.ID.String()), nil)
+		w := httptest.NewRecorder()
+
+		noProviderRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestGetThumbnail(t *testing.T) {
+	_, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	scanner := media.NewScanner(repos)
+	defer scanner.Stop()
+
+	router := setupTestRouter(scanner, repos)
+	mediaItem := createTestMedia(t, repos)
+
+	t.Run("Missing thumbnail without generator returns 404", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/media/%s/thumbnail", mediaItem.ID.String()), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var resp ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		assert.Equal(t, "thumbnail_not_found", resp.Error)
+	})
+
+	t.Run("Serves stored thumbnail", func(t *testing.T) {
+		thumbPath := filepath.Join(t.TempDir(), "thumb.jpg")
+		require.NoError(t, os.WriteFile(thumbPath, []byte("fake-jpeg"), 0o600))
+		require.NoError(t, repos.Media.UpdateArtwork(context.Background(), mediaItem.ID, &thumbPath, nil))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/media/%s/thumbnail", mediaItem.ID.String()), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "fake-jpeg", w.Body.String())
+	})
+
+	t.Run("Sprite without stored path returns 404", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/media/%s/sprite", mediaItem.ID.String()), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid UUID returns 400", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/media/invalid-uuid/thumbnail", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListMediaSearchAndFilters(t *testing.T) {
+	database, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	scanner := media.NewScanner(repos)
+	defer scanner.Stop()
+
+	router := setupTestRouter(scanner, repos)
+	ctx := context.Background()
+
+	newMedia := func(path, title, show string, season int, duration int64, codec, resolution string) *models.Media {
+		item := models.NewMedia(path, title, duration)
+		if show != "" {
+			item.ShowName = &show
+			item.Season = &season
+		}
+		item.VideoCodec = &codec
+		item.Resolution = &resolution
+		require.NoError(t, repos.Media.Create(ctx, item))
+		return item
+	}
+
+	pilot := newMedia("/tv/office/s01e01.mkv", "Pilot", "The Office", 1, 1320, "h264", "1920x1080")
+	newMedia("/tv/office/s02e01.mkv", "The Dundies", "The Office", 2, 1300, "hevc", "1280x720")
+	heat := newMedia("/movies/heat.mkv", "Heat", "", 0, 10200, "H264", "1920x1080")
+
+	description := "A crew of professional thieves"
+	heat.Description = &description
+	require.NoError(t, repos.Media.Update(ctx, heat))
+
+	// Put the pilot in a channel so not_in_channel can exclude it
+	channel := models.NewChannel("Sitcoms", time.Now().UTC(), true)
+	require.NoError(t, repos.Channels.Create(ctx, channel))
+	require.NoError(t, repos.PlaylistItems.Create(ctx, models.NewPlaylistItem(channel.ID, pilot.ID, 0)))
+
+	list := func(t *testing.T, query string) MediaListResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/media?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp MediaListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	titles := func(resp MediaListResponse) []string {
+		result := make([]string, 0, len(resp.Items))
+		for _, item := range resp.Items {
+			result = append(result, item.Title)
+		}
+		return result
+	}
+
+	t.Run("Full-text prefix search on title and show", func(t *testing.T) {
+		resp := list(t, "q=offi")
+		assert.Equal(t, 2, resp.Total)
+		assert.ElementsMatch(t, []string{"Pilot", "The Dundies"}, titles(resp))
+	})
+
+	t.Run("Search matches description", func(t *testing.T) {
+		resp := list(t, "q=thieves")
+		assert.Equal(t, []string{"Heat"}, titles(resp))
+	})
+
+	t.Run("Search ignores FTS syntax", func(t *testing.T) {
+		resp := list(t, "q=%22dundies%22+(*")
+		assert.Equal(t, []string{"The Dundies"}, titles(resp))
+	})
+
+	t.Run("Search reflects title updates", func(t *testing.T) {
+		renamed := "Diversity Day"
+		pilot.Title = renamed
+		require.NoError(t, repos.Media.Update(ctx, pilot))
+
+		assert.Equal(t, []string{"Diversity Day"}, titles(list(t, "q=diversity")))
+		assert.Empty(t, list(t, "q=pilot").Items)
+	})
+
+	t.Run("Codec filter is case-insensitive", func(t *testing.T) {
+		resp := list(t, "video_codec=h264&sort=title")
+		assert.Equal(t, []string{"Diversity Day", "Heat"}, titles(resp))
+	})
+
+	t.Run("Resolution by height", func(t *testing.T) {
+		assert.Equal(t, []string{"The Dundies"}, titles(list(t, "resolution=720p")))
+		assert.Equal(t, 2, list(t, "resolution=1920x1080").Total)
+	})
+
+	t.Run("Duration range", func(t *testing.T) {
+		resp := list(t, "min_duration=1310&max_duration=5000")
+		assert.Equal(t, []string{"Diversity Day"}, titles(resp))
+	})
+
+	t.Run("Season filter with show", func(t *testing.T) {
+		resp := list(t, "show=The+Office&season=2")
+		assert.Equal(t, []string{"The Dundies"}, titles(resp))
+	})
+
+	t.Run("Not in any channel", func(t *testing.T) {
+		resp := list(t, "not_in_channel=true&sort=title")
+		assert.Equal(t, []string{"Heat", "The Dundies"}, titles(resp))
+	})
+
+	t.Run("Sort by duration descending", func(t *testing.T) {
+		resp := list(t, "sort=duration&order=desc")
+		assert.Equal(t, []string{"Heat", "Diversity Day", "The Dundies"}, titles(resp))
+	})
+
+	t.Run("Total ignores pagination", func(t *testing.T) {
+		resp := list(t, "sort=title&limit=1&offset=1")
+		assert.Equal(t, 3, resp.Total)
+		assert.Equal(t, []string{"Heat"}, titles(resp))
+	})
+
+	t.Run("Relevance sorting needs FTS5", func(t *testing.T) {
+		sqlDB, err := database.GetSQLDB()
+		require.NoError(t, err)
+		engine, err := db.EnsureMediaSearchIndex(ctx, sqlDB)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/api/media?q=office&sort=relevance", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if engine == db.SearchEngineFTS5 {
+			assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			return
+		}
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "sort_unavailable")
+
+		// Without an explicit sort, searches fall back to title order
+		assert.Equal(t, []string{"Diversity Day", "The Dundies"}, titles(list(t, "q=office")))
+	})
+
+	t.Run("Invalid parameters return 400", func(t *testing.T) {
+		for _, query := range []string{"sort=bogus", "order=sideways", "season=x", "min_duration=-5", "min_duration=10&max_duration=5", "not_in_channel=maybe"} {
+			req := httptest.NewRequest("GET", "/api/media?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
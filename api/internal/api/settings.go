@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// UpdateSettingsRequest represents a partial settings update; omitted fields are unchanged
+type UpdateSettingsRequest struct {
+	MediaLibraryPath *string `json:"media_library_path,omitempty"`
+	TranscodeQuality *string `json:"transcode_quality,omitempty"`
+	HardwareAccel    *string `json:"hardware_accel,omitempty"`
+}
+
+// SettingsApplier is implemented by running components that consume settings live
+type SettingsApplier interface {
+	ApplySettings(settings *models.Settings)
+}
+
+// Valid values for settings fields
+var (
+	validTranscodeQualities = []string{models.QualityUltra, models.QualityHigh, models.QualityMedium, models.QualityLow}
+	validHardwareAccels     = []string{
+		models.HardwareAccelNone, models.HardwareAccelNVENC, models.HardwareAccelQSV,
+		models.HardwareAccelVAAPI, models.HardwareAccelVideoToolbox, models.HardwareAccelAuto,
+	}
+)
+
+// SettingsHandler handles settings API requests
+type SettingsHandler struct {
+	repos    *db.Repositories
+	appliers []SettingsApplier
+}
+
+// NewSettingsHandler creates a new settings handler instance
+// Every applier is notified after settings are successfully updated
+func NewSettingsHandler(repos *db.Repositories, appliers ...SettingsApplier) *SettingsHandler {
+	return &SettingsHandler{
+		repos:    repos,
+		appliers: appliers,
+	}
+}
+
+// GetSettings handles GET /api/settings
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	settings, err := h.repos.Settings.Get(ctx)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Msg("Failed to get settings")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /api/settings
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	if err := validateSettingsRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_settings",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	settings, err := h.repos.Settings.Get(ctx)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Msg("Failed to load settings for update")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve settings",
+		})
+		return
+	}
+
+	if req.MediaLibraryPath != nil {
+		settings.MediaLibraryPath = *req.MediaLibraryPath
+	}
+	if req.TranscodeQuality != nil {
+		settings.TranscodeQuality = *req.TranscodeQuality
+	}
+	if req.HardwareAccel != nil {
+		settings.HardwareAccel = *req.HardwareAccel
+	}
+
+	if err := h.repos.Settings.Update(ctx, settings); err != nil {
+		logger.Log.Error().
+			Err(err).
+			Msg("Failed to update settings")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "update_failed",
+			Message: "Failed to update settings",
+		})
+		return
+	}
+
+	for _, applier := range h.appliers {
+		applier.ApplySettings(settings)
+	}
+
+	logger.Log.Info().
+		Str("media_library_path", settings.MediaLibraryPath).
+		Str("transcode_quality", settings.TranscodeQuality).
+		Str("hardware_accel", settings.HardwareAccel).
+		Msg("Settings updated")
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// validateSettingsRequest checks every provided field of a settings update
+func validateSettingsRequest(req *UpdateSettingsRequest) error {
+	if req.MediaLibraryPath != nil {
+		path := strings.TrimSpace(*req.MediaLibraryPath)
+		if path == "" {
+			return fmt.Errorf("media_library_path cannot be empty")
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("media_library_path must be an existing directory")
+		}
+		req.MediaLibraryPath = &path
+	}
+	if req.TranscodeQuality != nil && !slices.Contains(validTranscodeQualities, *req.TranscodeQuality) {
+		return fmt.Errorf("transcode_quality must be one of: %s", strings.Join(validTranscodeQualities, ", "))
+	}
+	if req.HardwareAccel != nil && !slices.Contains(validHardwareAccels, *req.HardwareAccel) {
+		return fmt.Errorf("hardware_accel must be one of: %s", strings.Join(validHardwareAccels, ", "))
+	}
+	return nil
+}
+
+// SetupSettingsRoutes registers settings routes
+func SetupSettingsRoutes(apiGroup *gin.RouterGroup, repos *db.Repositories, appliers ...SettingsApplier) {
+	handler := NewSettingsHandler(repos, appliers...)
+
+	apiGroup.GET("/settings", handler.GetSettings)
+	apiGroup.PUT("/settings", handler.UpdateSettings)
+}
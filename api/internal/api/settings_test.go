@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// recordingApplier records every settings value it is given
+type recordingApplier struct {
+	applied []models.Settings
+}
+
+func (a *recordingApplier) ApplySettings(settings *models.Settings) {
+	a.applied = append(a.applied, *settings)
+}
+
+func TestSettingsAPI(t *testing.T) {
+	_, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	applier := &recordingApplier{}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupSettingsRoutes(router.Group("/api"), repos, applier)
+
+	putSettings := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/settings", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Get returns defaults", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/settings", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var settings models.Settings
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		assert.Equal(t, models.QualityMedium, settings.TranscodeQuality)
+		assert.Equal(t, models.HardwareAccelNone, settings.HardwareAccel)
+	})
+
+	t.Run("Partial update applies settings", func(t *testing.T) {
+		libraryDir := t.TempDir()
+		w := putSettings(fmt.Sprintf(`{"media_library_path": %q, "transcode_quality": "low"}`, libraryDir))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var settings models.Settings
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		assert.Equal(t, libraryDir, settings.MediaLibraryPath)
+		assert.Equal(t, models.QualityLow, settings.TranscodeQuality)
+		assert.Equal(t, models.HardwareAccelNone, settings.HardwareAccel)
+
+		require.Len(t, applier.applied, 1)
+		assert.Equal(t, models.QualityLow, applier.applied[0].TranscodeQuality)
+
+		w = putSettings(`{"hardware_accel": "auto"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		assert.Equal(t, models.HardwareAccelAuto, settings.HardwareAccel)
+		assert.Equal(t, models.QualityLow, settings.TranscodeQuality)
+		assert.Equal(t, libraryDir, settings.MediaLibraryPath)
+	})
+
+	t.Run("Invalid values are rejected", func(t *testing.T) {
+		before := len(applier.applied)
+		cases := []string{
+			`{"transcode_quality": "extreme"}`,
+			`{"hardware_accel": "cuda"}`,
+			`{"media_library_path": ""}`,
+			`{"media_library_path": "/does/not/exist"}`,
+			`not json`,
+		}
+		for _, body := range cases {
+			w := putSettings(body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+		assert.Len(t, applier.applied, before)
+	})
+}
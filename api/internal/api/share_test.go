@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+)
+
+// get performs an anonymous GET request
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// firstURI returns the first URI line of a playlist
+func firstURI(t *testing.T, playlist string) string {
+	t.Helper()
+	for _, line := range strings.Split(playlist, "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	t.Fatalf("playlist has no URI lines:\n%s", playlist)
+	return ""
+}
+
+func TestShareLinks(t *testing.T) {
+	env, cleanup := setupAccessTestEnv(t)
+	defer cleanup()
+
+	ch := env.createChannel(t, "Movie Night")
+	other := env.createChannel(t, "Other")
+
+	outputDir := t.TempDir()
+	createTestFiles(t, outputDir)
+	session := models.NewStreamSession(ch.ID)
+	session.SetOutputDir(outputDir)
+	session.SetQualities([]models.StreamQuality{{Level: "1080p"}})
+	env.streams.getStreamFunc = func(id uuid.UUID) (*models.StreamSession, bool) {
+		return session, id == ch.ID
+	}
+	env.streams.playlistManagerFunc = publishedSegments(t, playlist.SegmentMeta{URI: "1080p_segment_000.ts", Duration: 6.0})
+
+	t.Run("viewers cannot create share links", func(t *testing.T) {
+		w := doJSON(t, env.router, http.MethodPost, "/api/auth/share-links", CreateShareLinkRequest{
+			ChannelID: ch.ID.String(),
+		}, env.viewer)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	w := doJSON(t, env.router, http.MethodPost, "/api/auth/share-links", CreateShareLinkRequest{
+		ChannelID:   ch.ID.String(),
+		TTLSeconds:  3600,
+		MaxSessions: 1,
+	}, env.editor)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var link ShareLinkResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &link))
+	assert.Equal(t, 1, link.MaxSessions)
+	assert.NotContains(t, link.URL, "session_id")
+
+	streamBase := "/api/stream/" + ch.ID.String() + "/"
+
+	// Follow the link like a player: master -> media playlist -> segment
+	w = get(env.router, link.URL)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	variant := firstURI(t, w.Body.String())
+	variantURL, err := url.Parse(variant)
+	require.NoError(t, err)
+	assert.Equal(t, link.Token, variantURL.Query().Get("share"))
+	assert.NotEmpty(t, variantURL.Query().Get("session_id"))
+	assert.Equal(t, 1, session.GetClientCount())
+
+	w = get(env.router, streamBase+variant)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	segment := firstURI(t, w.Body.String())
+	assert.True(t, strings.HasPrefix(segment, "1080p/1080p_segment_000.ts?"), segment)
+	assert.Contains(t, segment, "share=")
+
+	w = get(env.router, streamBase+segment)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	t.Run("session limit", func(t *testing.T) {
+		w := get(env.router, link.URL)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "share_session_limit")
+	})
+
+	t.Run("link is bound to its channel", func(t *testing.T) {
+		w := get(env.router, "/api/stream/"+other.ID.String()+"/master.m3u8?share="+url.QueryEscape(link.Token))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid link", func(t *testing.T) {
+		w := get(env.router, streamBase+"master.m3u8?share=garbage")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("link only allows playback", func(t *testing.T) {
+		w := get(env.router, streamBase+"debug?share="+url.QueryEscape(link.Token))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
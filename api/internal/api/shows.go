@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// ShowListResponse represents the list of shows in the library
+type ShowListResponse struct {
+	Shows []*db.ShowSummary `json:"shows"`
+	Total int               `json:"total"`
+}
+
+// SeasonListResponse represents the seasons of a single show
+type SeasonListResponse struct {
+	ShowName      string              `json:"show_name"`
+	Seasons       []*db.SeasonSummary `json:"seasons"`
+	EpisodeCount  int64               `json:"episode_count"`
+	TotalDuration int64               `json:"total_duration_seconds"`
+}
+
+// EpisodeListResponse represents the episodes of a single season
+type EpisodeListResponse struct {
+	ShowName      string          `json:"show_name"`
+	Season        int             `json:"season"`
+	Episodes      []*models.Media `json:"episodes"`
+	TotalDuration int64           `json:"total_duration_seconds"`
+}
+
+// ShowHandler handles show/season browse API requests
+type ShowHandler struct {
+	repos *db.Repositories
+}
+
+// NewShowHandler creates a new show handler instance
+func NewShowHandler(repos *db.Repositories) *ShowHandler {
+	return &ShowHandler{
+		repos: repos,
+	}
+}
+
+// ListShows handles GET /api/shows
+func (h *ShowHandler) ListShows(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	shows, err := h.repos.Media.ListShows(ctx)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Msg("Failed to list shows")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve shows",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ShowListResponse{
+		Shows: shows,
+		Total: len(shows),
+	})
+}
+
+// ListSeasons handles GET /api/shows/:name/seasons
+func (h *ShowHandler) ListSeasons(c *gin.Context) {
+	showName := c.Param("name")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	seasons, err := h.repos.Media.ListSeasons(ctx, showName)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("show", showName).
+			Msg("Failed to list seasons")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve seasons",
+		})
+		return
+	}
+
+	if len(seasons) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Show not found",
+		})
+		return
+	}
+
+	response := SeasonListResponse{
+		ShowName: showName,
+		Seasons:  seasons,
+	}
+	for _, season := range seasons {
+		response.EpisodeCount += season.EpisodeCount
+		response.TotalDuration += season.TotalDuration
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListEpisodes handles GET /api/shows/:name/seasons/:season/episodes
+func (h *ShowHandler) ListEpisodes(c *gin.Context) {
+	showName := c.Param("name")
+
+	season, err := strconv.Atoi(c.Param("season"))
+	if err != nil || season < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_season",
+			Message: "Season must be a non-negative integer",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	episodes, err := h.repos.Media.ListEpisodes(ctx, showName, season)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("show", showName).
+			Int("season", season).
+			Msg("Failed to list episodes")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "query_failed",
+			Message: "Failed to retrieve episodes",
+		})
+		return
+	}
+
+	if len(episodes) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Season not found",
+		})
+		return
+	}
+
+	response := EpisodeListResponse{
+		ShowName: showName,
+		Season:   season,
+		Episodes: episodes,
+	}
+	for _, episode := range episodes {
+		response.TotalDuration += episode.Duration
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupShowRoutes registers show/season browse routes
+func SetupShowRoutes(apiGroup *gin.RouterGroup, repos *db.Repositories) {
+	handler := NewShowHandler(repos)
+
+	apiGroup.GET("/shows", handler.ListShows)
+	apiGroup.GET("/shows/:name/seasons", handler.ListSeasons)
+	apiGroup.GET("/shows/:name/seasons/:season/episodes", handler.ListEpisodes)
+}
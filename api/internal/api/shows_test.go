@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// seedShowMedia creates media for show browse tests
+func seedShowMedia(t *testing.T, repos *db.Repositories) {
+	t.Helper()
+
+	ctx := context.Background()
+	create := func(path, title string, show *string, season, episode *int, duration int64) {
+		item := models.NewMedia(path, title, duration)
+		item.ShowName = show
+		item.Season = season
+		item.Episode = episode
+		require.NoError(t, repos.Media.Create(ctx, item))
+	}
+
+	office := "The Office"
+	friends := "Friends"
+	one, two, three := 1, 2, 3
+
+	create("/tv/office/s01e02.mkv", "Diversity Day", &office, &one, &two, 1300)
+	create("/tv/office/s01e01.mkv", "Pilot", &office, &one, &one, 1400)
+	create("/tv/office/s02e01.mkv", "The Dundies", &office, &two, &one, 1250)
+	create("/tv/office/extras.mkv", "Bloopers", &office, nil, nil, 600)
+	create("/tv/friends/s03e01.mkv", "The One with the Princess Leia Fantasy", &friends, &three, &one, 1320)
+	create("/movies/heat.mkv", "Heat", nil, nil, nil, 10200)
+}
+
+func setupShowRouter(repos *db.Repositories) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupShowRoutes(router.Group("/api"), repos)
+	return router
+}
+
+func TestListShows(t *testing.T) {
+	_, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+	seedShowMedia(t, repos)
+
+	router := setupShowRouter(repos)
+
+	req := httptest.NewRequest("GET", "/api/shows", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp ShowListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 2, resp.Total)
+
+	assert.Equal(t, "Friends", resp.Shows[0].ShowName)
+	assert.Equal(t, "The Office", resp.Shows[1].ShowName)
+	assert.Equal(t, int64(2), resp.Shows[1].SeasonCount)
+	assert.Equal(t, int64(4), resp.Shows[1].EpisodeCount)
+	assert.Equal(t, int64(4550), resp.Shows[1].TotalDuration)
+}
+
+func TestListSeasons(t *testing.T) {
+	_, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+	seedShowMedia(t, repos)
+
+	router := setupShowRouter(repos)
+
+	t.Run("Seasons with unknown season last", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/shows/The%20Office/seasons", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp SeasonListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Seasons, 3)
+
+		assert.Equal(t, 1, *resp.Seasons[0].Season)
+		assert.Equal(t, int64(2), resp.Seasons[0].EpisodeCount)
+		assert.Equal(t, int64(2700), resp.Seasons[0].TotalDuration)
+		assert.Equal(t, 2, *resp.Seasons[1].Season)
+		assert.Nil(t, resp.Seasons[2].Season)
+		assert.Equal(t, int64(4), resp.EpisodeCount)
+		assert.Equal(t, int64(4550), resp.TotalDuration)
+	})
+
+	t.Run("Unknown show returns 404", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/shows/Nope/seasons", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestListEpisodes(t *testing.T) {
+	_, repos, cleanup := setupTestDB(t)
+	defer cleanup()
+	seedShowMedia(t, repos)
+
+	router := setupShowRouter(repos)
+
+	t.Run("Episodes ordered by episode number", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/shows/The%20Office/seasons/1/episodes", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp EpisodeListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Episodes, 2)
+		assert.Equal(t, "Pilot", resp.Episodes[0].Title)
+		assert.Equal(t, "Diversity Day", resp.Episodes[1].Title)
+		assert.Equal(t, int64(2700), resp.TotalDuration)
+	})
+
+	t.Run("Missing season returns 404", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/shows/The%20Office/seasons/9/episodes", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid season returns 400", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/shows/The%20Office/seasons/abc/episodes", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
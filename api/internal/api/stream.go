@@ -5,17 +5,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/auth"
 	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/middleware"
 	"github.com/stwalsh4118/hermes/internal/models"
 	"github.com/stwalsh4118/hermes/internal/streaming"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
 )
 
 // streamManager defines the interface required by StreamHandler for stream management
@@ -25,15 +33,35 @@ type streamManager interface {
 	UnregisterClient(ctx context.Context, channelID uuid.UUID) error
 	GetStream(channelID uuid.UUID) (*models.StreamSession, bool)
 	GetTriggerThreshold() int // Returns the configured trigger threshold
+	PlaylistManager(channelID uuid.UUID, quality string) (playlist.Manager, bool)
+	EncryptionKey(channelID uuid.UUID, index int) ([]byte, error)
+	CatchUpPlaylist(ctx context.Context, channelID uuid.UUID, quality string, at time.Time) (string, error)
 }
 
+// Low-Latency HLS blocking playlist reload query parameters
+const (
+	hlsMSNQueryParam  = "_HLS_msn"
+	hlsPartQueryParam = "_HLS_part"
+)
+
+// transcodeCapacityRetryAfter is the Retry-After (seconds) sent when the transcode budget is used up
+const transcodeCapacityRetryAfter = "10"
+
 // validQualities defines the allowed quality levels for streaming
 var validQualities = map[string]bool{
+	"2160p": true,
 	"1080p": true,
 	"720p":  true,
 	"480p":  true,
 }
 
+// segmentContentTypes maps the segment file extensions served by GetSegment to their content type
+var segmentContentTypes = map[string]string{
+	".ts":  "video/MP2T",        // MPEG-TS segment
+	".m4s": "video/iso.segment", // fMP4 (CMAF) fragment
+	".mp4": "video/mp4",         // fMP4 init segment
+}
+
 // UpdatePositionRequest represents a client position update request
 type UpdatePositionRequest struct {
 	SessionID     string `json:"session_id" binding:"required"`
@@ -43,20 +71,31 @@ type UpdatePositionRequest struct {
 }
 
 // rewriteSegmentPaths modifies playlist content to include quality directory in segment paths
-// Converts "1080p_segment_000.ts" to "1080p/1080p_segment_000.ts"
-func rewriteSegmentPaths(content, quality string) string {
+// Converts "1080p_segment_000.ts" to "1080p/1080p_segment_000.ts", and the URI attributes of the
+// fMP4 init segment (#EXT-X-MAP:URI="init-0.mp4" to URI="1080p/init-0.mp4") and of
+// Low-Latency HLS parts and preload hints the same way
+// Key URIs (#EXT-X-KEY) already resolve against the playlist's own URL and only get the query
+// A non-empty query (stream token or share link) is appended so players carry it to every segment
+func rewriteSegmentPaths(content, quality string, query url.Values) string {
 	lines := strings.Split(content, "\n")
 	var result strings.Builder
 
 	for _, line := range lines {
-		// Check if line is a segment reference (ends with .ts or .vtt and doesn't start with #)
+		// Check if line is a segment reference (ends with .ts, .m4s or .vtt and doesn't start with #)
 		trimmedLine := strings.TrimSpace(line)
-		if !strings.HasPrefix(trimmedLine, "#") &&
-			(strings.HasSuffix(trimmedLine, ".ts") || strings.HasSuffix(trimmedLine, ".vtt")) &&
-			len(trimmedLine) > 0 {
+		switch {
+		case strings.HasPrefix(trimmedLine, "#EXT-X-MAP:"),
+			strings.HasPrefix(trimmedLine, "#EXT-X-PART:"),
+			strings.HasPrefix(trimmedLine, "#EXT-X-PRELOAD-HINT:"):
+			result.WriteString(rewriteTagURI(trimmedLine, quality, query))
+		case strings.HasPrefix(trimmedLine, "#EXT-X-KEY:"):
+			result.WriteString(rewriteTagURI(trimmedLine, "", query))
+		case !strings.HasPrefix(trimmedLine, "#") &&
+			(strings.HasSuffix(trimmedLine, ".ts") || strings.HasSuffix(trimmedLine, ".m4s") || strings.HasSuffix(trimmedLine, ".vtt")) &&
+			len(trimmedLine) > 0:
 			// Prepend quality directory to segment filename
-			result.WriteString(quality + "/" + line)
-		} else {
+			result.WriteString(appendURIQuery(quality+"/"+trimmedLine, query))
+		default:
 			result.WriteString(line)
 		}
 		result.WriteString("\n")
@@ -65,16 +104,193 @@ func rewriteSegmentPaths(content, quality string) string {
 	return result.String()
 }
 
+// rewriteTagURI prepends the quality directory (if any) to the URI attribute of a playlist tag
+func rewriteTagURI(tag, quality string, query url.Values) string {
+	prefix, rest, ok := strings.Cut(tag, `URI="`)
+	if !ok {
+		return tag
+	}
+	uri, suffix, ok := strings.Cut(rest, `"`)
+	if !ok || uri == "" {
+		return tag
+	}
+	if quality != "" {
+		uri = quality + "/" + uri
+	}
+	return prefix + `URI="` + appendURIQuery(uri, query) + `"` + suffix
+}
+
+// rewriteCatchUpPaths rewrites a catch-up playlist served next to the quality's segments
+// (/stream/:channel_id/:quality/startover.m3u8): segment and init segment URIs only get the query,
+// while key URIs point one directory up to the channel's keys
+func rewriteCatchUpPaths(content string, query url.Values) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmedLine, "#EXT-X-MAP:"):
+			lines[i] = rewriteTagURI(trimmedLine, "", query)
+		case strings.HasPrefix(trimmedLine, "#EXT-X-KEY:"):
+			lines[i] = rewriteTagURI(trimmedLine, "..", query)
+		case trimmedLine != "" && !strings.HasPrefix(trimmedLine, "#"):
+			lines[i] = appendURIQuery(trimmedLine, query)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendPlaylistQuery adds query parameters to every URI line of a playlist
+// Players resolve relative URIs without the parent query string, so token-only clients
+// would otherwise be rejected when fetching variants and segments
+func appendPlaylistQuery(content string, query url.Values) string {
+	if len(query) == 0 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+		lines[i] = appendURIQuery(trimmedLine, query)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendURIQuery appends encoded query parameters to a single URI
+func appendURIQuery(uri string, query url.Values) string {
+	if len(query) == 0 {
+		return uri
+	}
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+	return uri + separator + query.Encode()
+}
+
+// manifestURIAttribute matches the segment URI attributes of a DASH SegmentTemplate
+var manifestURIAttribute = regexp.MustCompile(`\b(initialization|media)="([^"]*)"`)
+
+// appendManifestQuery adds query parameters to the segment URI templates of a DASH manifest
+func appendManifestQuery(content string, query url.Values) string {
+	if len(query) == 0 {
+		return content
+	}
+	// The encoded query only needs its separators escaped to be a valid attribute value
+	escaped := strings.ReplaceAll(query.Encode(), "&", "&amp;")
+	return manifestURIAttribute.ReplaceAllStringFunc(content, func(attr string) string {
+		match := manifestURIAttribute.FindStringSubmatch(attr)
+		return match[1] + `="` + match[2] + "?" + escaped + `"`
+	})
+}
+
+// rangeStart returns the first byte of a single-range "bytes=N-" or "bytes=N-M" Range header
+func rangeStart(header string) (int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, false
+	}
+	first, _, ok := strings.Cut(spec, "-")
+	start, err := strconv.ParseInt(first, 10, 64)
+	return start, ok && err == nil && start >= 0
+}
+
+// blockingTimeout is how long a blocking playlist reload or preload hint request is held:
+// three target durations, as Low-Latency HLS recommends
+func blockingTimeout(pm playlist.Manager) time.Duration {
+	return 3 * time.Duration(math.Ceil(pm.GetMaxDuration())) * time.Second
+}
+
+// ShareLinkAdmitter validates share links and tracks the player sessions using them
+type ShareLinkAdmitter interface {
+	AdmitShareSession(token string, channelID uuid.UUID, sessionID string) (*auth.ShareTokenClaims, error)
+}
+
 // StreamHandler handles streaming-related API requests
 type StreamHandler struct {
 	streamManager streamManager
+	access        ChannelAccessPolicy // Optional; nil allows every channel
+	shares        ShareLinkAdmitter   // Optional; nil ignores share links
 }
 
 // NewStreamHandler creates a new stream handler instance
-func NewStreamHandler(manager *streaming.StreamManager) *StreamHandler {
+func NewStreamHandler(manager *streaming.StreamManager, access ChannelAccessPolicy, shares ShareLinkAdmitter) *StreamHandler {
 	return &StreamHandler{
 		streamManager: manager,
+		access:        access,
+		shares:        shares,
+	}
+}
+
+// shareToken returns the share link token of a playback request, if share links are enabled
+func (h *StreamHandler) shareToken(c *gin.Context) string {
+	if h.shares == nil {
+		return ""
+	}
+	return c.Query(middleware.ShareTokenQueryParam)
+}
+
+// authorizePlayback checks that the request may play the channel and writes an error response if not.
+// Share link requests are checked against the link's channel, expiry and session limit;
+// all other requests go through the channel access policy.
+func (h *StreamHandler) authorizePlayback(ctx context.Context, c *gin.Context, channelID uuid.UUID, sessionID string) bool {
+	shareToken := h.shareToken(c)
+	if shareToken == "" {
+		return checkChannelAccess(ctx, c, h.access, channelID)
+	}
+
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "missing_session_id",
+			Message: "Session ID is required",
+		})
+		return false
+	}
+
+	_, err := h.shares.AdmitShareSession(shareToken, channelID, sessionID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, auth.ErrShareTokenExpired):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "share_link_expired",
+			Message: "Share link has expired",
+		})
+	case errors.Is(err, auth.ErrShareSessionLimit):
+		logger.Log.Info().
+			Str("channel_id", channelID.String()).
+			Str("session_id", sessionID).
+			Msg("Share link session limit reached")
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "share_session_limit",
+			Message: "Too many people are already watching with this link",
+		})
+	default:
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "invalid_share_link",
+			Message: "Invalid share link",
+		})
+	}
+	return false
+}
+
+// playbackQuery returns the query parameters that playlist URIs must carry: the request's
+// credential (the stream token or share link) and the player's session ID, with which media
+// playlist reloads register the player again after a resumed stream dropped its clients
+func (h *StreamHandler) playbackQuery(c *gin.Context, sessionID string) url.Values {
+	query := url.Values{}
+	if token := middleware.StreamToken(c); token != "" {
+		query.Set(middleware.StreamTokenQueryParam, token)
+	}
+	if shareToken := h.shareToken(c); shareToken != "" {
+		query.Set(middleware.ShareTokenQueryParam, shareToken)
 	}
+	if sessionID != "" {
+		query.Set("session_id", sessionID)
+	}
+	return query
 }
 
 // GetMasterPlaylist handles GET /stream/:channel_id/master.m3u8
@@ -93,6 +309,11 @@ func (h *StreamHandler) GetMasterPlaylist(c *gin.Context) {
 		return
 	}
 
+	// Each player opening a share link is its own session, so share link URLs carry no session ID
+	if sessionID == "" && h.shareToken(c) != "" {
+		sessionID = uuid.New().String()
+	}
+
 	// Validate session ID
 	if sessionID == "" {
 		logger.Log.Warn().
@@ -108,6 +329,11 @@ func (h *StreamHandler) GetMasterPlaylist(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	// Check access before anything can start a stream for the channel
+	if !h.authorizePlayback(ctx, c, channelID, sessionID) {
+		return
+	}
+
 	logger.Log.Info().
 		Str("channel_id", channelID.String()).
 		Str("session_id", sessionID).
@@ -115,40 +341,9 @@ func (h *StreamHandler) GetMasterPlaylist(c *gin.Context) {
 		Msg("Client requesting master playlist")
 
 	// Start stream if not already active (but don't register client yet)
-	session, found := h.streamManager.GetStream(channelID)
-	if !found {
-		// Stream doesn't exist, start it
-		var err error
-		session, err = h.streamManager.StartStream(ctx, channelID)
-		if err != nil {
-			logger.Log.Error().
-				Err(err).
-				Str("channel_id", channelID.String()).
-				Msg("Failed to start stream")
-
-			// Map errors to appropriate HTTP status codes
-			if errors.Is(err, streaming.ErrStreamNotFound) {
-				c.JSON(http.StatusNotFound, ErrorResponse{
-					Error:   "channel_not_found",
-					Message: "Channel not found",
-				})
-				return
-			}
-
-			if errors.Is(err, streaming.ErrManagerStopped) {
-				c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-					Error:   "service_unavailable",
-					Message: "Streaming service is unavailable",
-				})
-				return
-			}
-
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "stream_failed",
-				Message: "Failed to start stream",
-			})
-			return
-		}
+	session, ok := h.startOrGetStream(ctx, c, channelID)
+	if !ok {
+		return
 	}
 
 	// Get output directory from session
@@ -200,34 +395,197 @@ func (h *StreamHandler) GetMasterPlaylist(c *gin.Context) {
 
 	// NOW register the client (only on successful playlist delivery)
 	// This prevents counting retries and failed attempts
+	registerPlaybackSession(session, sessionID)
+
+	logger.Log.Debug().
+		Str("channel_id", channelID.String()).
+		Int("client_count", session.GetClientCount()).
+		Msg("Serving master playlist")
+
+	// Set appropriate headers
+	c.Header("Content-Type", "application/vnd.apple.mpegurl")
+	c.Header("Cache-Control", "no-cache") // Don't cache to ensure client registration on each page load
+
+	c.Data(http.StatusOK, "application/vnd.apple.mpegurl", []byte(appendPlaylistQuery(string(content), h.playbackQuery(c, sessionID))))
+}
+
+// GetDASHManifest handles GET /stream/:channel_id/manifest.mpd
+// This endpoint serves the live MPEG-DASH manifest of an fMP4 stream and registers the client.
+// DASH players pick representations from the manifest themselves, so every rendition is requested.
+func (h *StreamHandler) GetDASHManifest(c *gin.Context) {
+	sessionID := c.Query("session_id")
+
+	// Validate UUID
+	channelID, err := uuid.Parse(c.Param("channel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid channel ID format",
+		})
+		return
+	}
+
+	// Each player opening a share link is its own session, so share link URLs carry no session ID
+	if sessionID == "" && h.shareToken(c) != "" {
+		sessionID = uuid.New().String()
+	}
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "missing_session_id",
+			Message: "Session ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// Check access before anything can start a stream for the channel
+	if !h.authorizePlayback(ctx, c, channelID, sessionID) {
+		return
+	}
+
+	session, ok := h.startOrGetStream(ctx, c, channelID)
+	if !ok {
+		return
+	}
+
+	// DASH players do not accept MPEG-TS segments
+	if session.GetSegmentFormat() != models.SegmentFormatFMP4 {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "dash_unavailable",
+			Message: "DASH playback requires the channel's segment format to be fmp4",
+		})
+		return
+	}
+
+	// Nothing is encoded until a client is registered and renditions are requested,
+	// so both happen before the manifest exists
+	registerPlaybackSession(session, sessionID)
+	for _, quality := range session.GetQualities() {
+		session.RequestRendition(quality.Level)
+	}
+
+	outputDir := session.GetOutputDir()
+	if outputDir == "" {
+		logger.Log.Error().
+			Str("channel_id", channelID.String()).
+			Msg("Session output directory not set")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "stream_error",
+			Message: "Stream configuration error",
+		})
+		return
+	}
+
+	// Written once the first segments are encoded
+	manifestPath := filepath.Join(outputDir, streaming.DASHManifestFilename)
+	content, err := os.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "stream_starting",
+			Message: "Stream is starting, please retry in a moment",
+		})
+		return
+	}
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelID.String()).
+			Str("path", manifestPath).
+			Msg("Failed to read DASH manifest")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "read_failed",
+			Message: "Failed to read DASH manifest",
+		})
+		return
+	}
+
+	// Segment requests carry the session ID so they report the player's position
+	query := h.playbackQuery(c, sessionID)
+	query.Set("session_id", sessionID)
+
+	logger.Log.Debug().
+		Str("channel_id", channelID.String()).
+		Str("session_id", sessionID).
+		Msg("Serving DASH manifest")
+
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/dash+xml", []byte(appendManifestQuery(string(content), query)))
+}
+
+// startOrGetStream returns the channel's stream, starting it if it is not running.
+// Writes an error response and returns false if the stream cannot be started.
+func (h *StreamHandler) startOrGetStream(ctx context.Context, c *gin.Context, channelID uuid.UUID) (*models.StreamSession, bool) {
+	session, found := h.streamManager.GetStream(channelID)
+	if !found {
+		// Stream doesn't exist, start it
+		var err error
+		session, err = h.streamManager.StartStream(ctx, channelID)
+		if err != nil {
+			logger.Log.Error().
+				Err(err).
+				Str("channel_id", channelID.String()).
+				Msg("Failed to start stream")
+
+			// Map errors to appropriate HTTP status codes
+			if errors.Is(err, streaming.ErrStreamNotFound) {
+				c.JSON(http.StatusNotFound, ErrorResponse{
+					Error:   "channel_not_found",
+					Message: "Channel not found",
+				})
+				return nil, false
+			}
+
+			if errors.Is(err, streaming.ErrTranscodeCapacity) {
+				c.Header("Retry-After", transcodeCapacityRetryAfter)
+				c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+					Error:   "transcode_capacity",
+					Message: "The server is busy transcoding other channels, please retry shortly",
+				})
+				return nil, false
+			}
+
+			if errors.Is(err, streaming.ErrManagerStopped) {
+				c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+					Error:   "service_unavailable",
+					Message: "Streaming service is unavailable",
+				})
+				return nil, false
+			}
+
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "stream_failed",
+				Message: "Failed to start stream",
+			})
+			return nil, false
+		}
+	}
+	return session, true
+}
+
+// registerPlaybackSession counts a player session as a client of the stream.
+// Registration is idempotent per session ID, so playlist reloads and retries are not counted twice.
+func registerPlaybackSession(session *models.StreamSession, sessionID string) {
 	// Use session ID to ensure idempotent registration
 	wasNew := session.RegisterSession(sessionID)
 	if wasNew {
 		session.IncrementClients()
 		logger.Log.Debug().
-			Str("channel_id", channelID.String()).
+			Str("channel_id", session.ChannelID.String()).
 			Str("session_id", sessionID).
 			Int("client_count", session.GetClientCount()).
 			Msg("New client session registered")
 	} else {
 		logger.Log.Debug().
-			Str("channel_id", channelID.String()).
+			Str("channel_id", session.ChannelID.String()).
 			Str("session_id", sessionID).
 			Int("client_count", session.GetClientCount()).
 			Msg("Existing client session reconnected")
 	}
 	session.UpdateLastAccess()
-
-	logger.Log.Debug().
-		Str("channel_id", channelID.String()).
-		Int("client_count", session.GetClientCount()).
-		Msg("Serving master playlist")
-
-	// Set appropriate headers
-	c.Header("Content-Type", "application/vnd.apple.mpegurl")
-	c.Header("Cache-Control", "no-cache") // Don't cache to ensure client registration on each page load
-
-	c.Data(http.StatusOK, "application/vnd.apple.mpegurl", content)
 }
 
 // GetMediaPlaylist handles GET /stream/:channel_id/:quality
@@ -254,11 +612,16 @@ func (h *StreamHandler) GetMediaPlaylist(c *gin.Context) {
 	if !validQualities[quality] {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "invalid_quality",
-			Message: "Quality must be 1080p, 720p, or 480p",
+			Message: "Quality must be 2160p, 1080p, 720p, or 480p",
 		})
 		return
 	}
 
+	sessionID := c.Query("session_id")
+	if !h.authorizePlayback(c.Request.Context(), c, channelID, sessionID) {
+		return
+	}
+
 	// Get stream session
 	session, found := h.streamManager.GetStream(channelID)
 	if !found {
@@ -269,9 +632,21 @@ func (h *StreamHandler) GetMediaPlaylist(c *gin.Context) {
 		return
 	}
 
-	// Update last access time only if there are active clients
-	// This prevents lingering HLS requests from keeping idle streams alive
-	if session.GetClientCount() > 0 {
+	// Record the request; renditions are only generated while clients request them
+	if !session.RequestRendition(quality) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "quality_not_available",
+			Message: "Quality is not offered by this stream",
+		})
+		return
+	}
+
+	// Players carrying their session ID are registered (again, after a resumed stream dropped its
+	// clients). Otherwise update last access time only if there are active clients: this prevents
+	// lingering HLS requests from keeping idle streams alive.
+	if sessionID != "" {
+		registerPlaybackSession(session, sessionID)
+	} else if session.GetClientCount() > 0 {
 		session.UpdateLastAccess()
 	}
 
@@ -289,6 +664,11 @@ func (h *StreamHandler) GetMediaPlaylist(c *gin.Context) {
 		return
 	}
 
+	// Low-Latency HLS blocking playlist reload
+	if !h.waitForBlockingReload(c, channelID, quality) {
+		return
+	}
+
 	// Build path to quality-specific directory and playlist
 	qualityDir := filepath.Join(outputDir, quality)
 	playlistPath := filepath.Join(qualityDir, fmt.Sprintf("%s.m3u8", quality))
@@ -328,7 +708,7 @@ func (h *StreamHandler) GetMediaPlaylist(c *gin.Context) {
 	// Rewrite segment paths to include quality directory
 	// FFmpeg generates segments as "1080p_segment_000.ts" but we need "1080p/1080p_segment_000.ts"
 	// to match our route structure /:channel_id/:quality/:segment
-	modifiedContent := rewriteSegmentPaths(string(content), quality)
+	modifiedContent := rewriteSegmentPaths(string(content), quality, h.playbackQuery(c, sessionID))
 
 	logger.Log.Debug().
 		Str("channel_id", channelID.String()).
@@ -363,16 +743,17 @@ func (h *StreamHandler) GetSegment(c *gin.Context) {
 	if !validQualities[quality] {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "invalid_quality",
-			Message: "Quality must be 1080p, 720p, or 480p",
+			Message: "Quality must be 2160p, 1080p, 720p, or 480p",
 		})
 		return
 	}
 
-	// Validate segment filename (must be .ts file)
-	if !strings.HasSuffix(segment, ".ts") {
+	// Validate segment filename (MPEG-TS segment, fMP4 fragment or fMP4 init segment)
+	contentType, ok := segmentContentTypes[filepath.Ext(segment)]
+	if !ok {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "invalid_segment",
-			Message: "Segment must be a .ts file",
+			Message: "Segment must be a .ts, .m4s or .mp4 file",
 		})
 		return
 	}
@@ -391,6 +772,10 @@ func (h *StreamHandler) GetSegment(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizePlayback(c.Request.Context(), c, channelID, c.Query("session_id")) {
+		return
+	}
+
 	// Get stream session
 	session, found := h.streamManager.GetStream(channelID)
 	if !found {
@@ -401,12 +786,28 @@ func (h *StreamHandler) GetSegment(c *gin.Context) {
 		return
 	}
 
+	// Record the request; renditions are only generated while clients request them
+	if !session.RequestRendition(quality) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "quality_not_available",
+			Message: "Quality is not offered by this stream",
+		})
+		return
+	}
+
 	// Update last access time only if there are active clients
 	// This prevents lingering HLS requests from keeping idle streams alive
 	if session.GetClientCount() > 0 {
 		session.UpdateLastAccess()
 	}
 
+	// Players that never report positions themselves (DASH) identify their session in segment URLs
+	if sessionID := c.Query("session_id"); sessionID != "" {
+		if number, ok := streaming.SegmentNumber(segment); ok {
+			session.UpdateClientPosition(sessionID, number, quality)
+		}
+	}
+
 	// Get output directory from session
 	outputDir := session.GetOutputDir()
 	if outputDir == "" {
@@ -469,8 +870,17 @@ func (h *StreamHandler) GetSegment(c *gin.Context) {
 		return
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(segmentPath); os.IsNotExist(err) {
+	// Low-Latency HLS parts are byte ranges of a segment that is still being written
+	if pm, ok := h.inProgressSegment(channelID, quality, segment); ok {
+		h.servePart(c, pm, segmentPath, segment, contentType)
+		return
+	}
+
+	// Only serve files the playlist lists: the encoder writes segments in place, so anything else
+	// may be half-written or not yet encrypted, and would then be cached as immutable
+	pm, ok := h.streamManager.PlaylistManager(channelID, quality)
+	published := ok && pm.HasSegment(segment)
+	if _, err := os.Stat(segmentPath); !published || os.IsNotExist(err) {
 		logger.Log.Debug().
 			Str("channel_id", channelID.String()).
 			Str("quality", quality).
@@ -491,13 +901,316 @@ func (h *StreamHandler) GetSegment(c *gin.Context) {
 		Msg("Serving video segment")
 
 	// Set appropriate headers
-	c.Header("Content-Type", "video/MP2T")
+	c.Header("Content-Type", contentType)
 	c.Header("Cache-Control", "public, max-age=31536000, immutable") // Segments never change
 
 	// Serve the file
 	c.File(segmentPath)
 }
 
+// GetStartOverPlaylist handles GET /stream/:channel_id/:quality/startover.m3u8
+// This endpoint serves the current program from its start as an EVENT playlist and registers the client
+func (h *StreamHandler) GetStartOverPlaylist(c *gin.Context) {
+	h.serveCatchUpPlaylist(c, time.Now())
+}
+
+// GetCatchUpPlaylist handles GET /stream/:channel_id/:quality/catchup.m3u8?at=<RFC 3339 time>
+// This endpoint serves the program that was playing at the given time and registers the client
+func (h *StreamHandler) GetCatchUpPlaylist(c *gin.Context) {
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_time",
+			Message: "at must be an RFC 3339 time, e.g. 2025-01-01T20:00:00Z",
+		})
+		return
+	}
+	h.serveCatchUpPlaylist(c, at)
+}
+
+// serveCatchUpPlaylist serves the playlist of the program playing on the channel at the given time.
+// Catch-up players count as clients like live ones, which keeps the stream and its retained segments.
+func (h *StreamHandler) serveCatchUpPlaylist(c *gin.Context, at time.Time) {
+	quality := c.Param("quality")
+	sessionID := c.Query("session_id")
+
+	// Validate UUID
+	channelID, err := uuid.Parse(c.Param("channel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid channel ID format",
+		})
+		return
+	}
+
+	// Validate quality parameter
+	if !validQualities[quality] {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_quality",
+			Message: "Quality must be 2160p, 1080p, 720p, or 480p",
+		})
+		return
+	}
+
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "missing_session_id",
+			Message: "Session ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if !h.authorizePlayback(ctx, c, channelID, sessionID) {
+		return
+	}
+
+	// Get stream session; segments are only kept while the stream runs
+	session, found := h.streamManager.GetStream(channelID)
+	if !found {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "stream_not_found",
+			Message: "Stream not found or not active",
+		})
+		return
+	}
+
+	// Record the request; renditions are only generated while clients request them
+	if !session.RequestRendition(quality) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "quality_not_available",
+			Message: "Quality is not offered by this stream",
+		})
+		return
+	}
+
+	content, err := h.streamManager.CatchUpPlaylist(ctx, channelID, quality, at)
+	if err != nil {
+		switch {
+		case errors.Is(err, streaming.ErrStreamNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "stream_not_found",
+				Message: "Stream not found or not active",
+			})
+		case errors.Is(err, streaming.ErrCatchUpDisabled):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "catch_up_disabled",
+				Message: "Start-over and catch-up are disabled",
+			})
+		case errors.Is(err, streaming.ErrCatchUpNotAvailable):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "program_not_available",
+				Message: "Program is not available for catch-up",
+			})
+		default:
+			logger.Log.Error().
+				Err(err).
+				Str("channel_id", channelID.String()).
+				Str("quality", quality).
+				Time("at", at).
+				Msg("Failed to build catch-up playlist")
+
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "catch_up_failed",
+				Message: "Failed to build catch-up playlist",
+			})
+		}
+		return
+	}
+
+	// Register the client only on successful playlist delivery, like the master playlist
+	registerPlaybackSession(session, sessionID)
+
+	logger.Log.Debug().
+		Str("channel_id", channelID.String()).
+		Str("quality", quality).
+		Time("at", at).
+		Msg("Serving catch-up playlist")
+
+	// EVENT playlists grow, so they are not cached either
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/vnd.apple.mpegurl", []byte(rewriteCatchUpPaths(content, h.playbackQuery(c, sessionID))))
+}
+
+// GetEncryptionKey handles GET /stream/:channel_id/keys/:key
+// This endpoint delivers the AES-128 keys of an encrypted stream's segments ("<index>.key"),
+// to the same clients that may fetch its playlists and segments
+func (h *StreamHandler) GetEncryptionKey(c *gin.Context) {
+	// Validate UUID
+	channelID, err := uuid.Parse(c.Param("channel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid channel ID format",
+		})
+		return
+	}
+
+	indexStr, ok := strings.CutSuffix(c.Param("key"), ".key")
+	index, err := strconv.Atoi(indexStr)
+	if !ok || err != nil || index < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_key",
+			Message: "Key must be <index>.key",
+		})
+		return
+	}
+
+	if !h.authorizePlayback(c.Request.Context(), c, channelID, c.Query("session_id")) {
+		return
+	}
+
+	key, err := h.streamManager.EncryptionKey(channelID, index)
+	if err != nil {
+		switch {
+		case errors.Is(err, streaming.ErrStreamNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "stream_not_found",
+				Message: "Stream not found or not active",
+			})
+		case errors.Is(err, streaming.ErrEncryptionDisabled), errors.Is(err, streaming.ErrKeyNotAvailable):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "key_not_found",
+				Message: "Key not found",
+			})
+		default:
+			logger.Log.Error().
+				Err(err).
+				Str("channel_id", channelID.String()).
+				Int("key_index", index).
+				Msg("Failed to get encryption key")
+
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "key_failed",
+				Message: "Failed to get encryption key",
+			})
+		}
+		return
+	}
+
+	// Keys must never be stored by shared caches, unlike the segments they protect
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/octet-stream", key)
+}
+
+// waitForBlockingReload holds a blocking playlist reload (_HLS_msn, optionally with _HLS_part) until
+// the playlist contains the requested segment or part. Playlists without Low-Latency HLS parts ignore
+// the parameters. Returns false after writing an error response.
+func (h *StreamHandler) waitForBlockingReload(c *gin.Context, channelID uuid.UUID, quality string) bool {
+	msnParam, partParam := c.Query(hlsMSNQueryParam), c.Query(hlsPartQueryParam)
+	if msnParam == "" && partParam == "" {
+		return true
+	}
+	pm, ok := h.streamManager.PlaylistManager(channelID, quality)
+	if !ok || pm.GetPartTarget() <= 0 {
+		return true
+	}
+
+	// _HLS_part is only valid together with _HLS_msn
+	msn, err := strconv.ParseUint(msnParam, 10, 64)
+	part := -1
+	if err == nil && partParam != "" {
+		part, err = strconv.Atoi(partParam)
+		if err == nil && part < 0 {
+			err = fmt.Errorf("negative part index")
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_blocking_request",
+			Message: "_HLS_msn must be a media sequence number and _HLS_part a part index",
+		})
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), blockingTimeout(pm))
+	defer cancel()
+	if err := pm.WaitForPlaylist(ctx, msn, part); err != nil {
+		if errors.Is(err, playlist.ErrSequenceTooFar) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_blocking_request",
+				Message: "Requested segment is too far beyond the end of the playlist",
+			})
+			return false
+		}
+
+		logger.Log.Debug().
+			Err(err).
+			Str("channel_id", channelID.String()).
+			Str("quality", quality).
+			Uint64("msn", msn).
+			Int("part", part).
+			Msg("Blocking playlist reload not satisfied")
+
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "playlist_not_ready",
+			Message: "Requested segment is not available yet, please retry",
+		})
+		return false
+	}
+	return true
+}
+
+// inProgressSegment returns the playlist manager of a Low-Latency HLS rendition if segment is one
+// it has not completed yet, i.e. one only its parts can be requested of
+func (h *StreamHandler) inProgressSegment(channelID uuid.UUID, quality, segment string) (playlist.Manager, bool) {
+	number, ok := streaming.SegmentNumber(segment)
+	if !ok {
+		return nil, false
+	}
+	pm, ok := h.streamManager.PlaylistManager(channelID, quality)
+	if !ok || pm.GetPartTarget() <= 0 {
+		return nil, false
+	}
+	return pm, uint64(number) >= pm.GetMediaSequence()+uint64(pm.GetSegmentCount())
+}
+
+// servePart serves the part of an in-progress segment that starts at the request's range offset.
+// Preload hint requests arrive before the part is complete, so the request is held until it is.
+func (h *StreamHandler) servePart(c *gin.Context, pm playlist.Manager, segmentPath, segment, contentType string) {
+	start, ok := rangeStart(c.GetHeader("Range"))
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "segment_not_found",
+			Message: "Segment is still being written; request its parts by byte range",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), blockingTimeout(pm))
+	defer cancel()
+	part, err := pm.WaitForPart(ctx, segment, start)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "part_not_ready",
+			Message: "Part not yet available, please retry",
+		})
+		return
+	}
+
+	file, err := os.Open(segmentPath)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("segment", segment).
+			Msg("Failed to open segment for part")
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "read_failed",
+			Message: "Failed to read segment",
+		})
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/*", part.ByteStart, part.ByteStart+part.ByteLength-1))
+	c.Header("Cache-Control", "no-cache") // The whole segment is still being written
+	c.DataFromReader(http.StatusPartialContent, part.ByteLength, contentType, io.NewSectionReader(file, part.ByteStart, part.ByteLength), nil)
+}
+
 // UnregisterClient handles DELETE /stream/:channel_id/client
 // This endpoint allows clients to explicitly unregister from a stream
 func (h *StreamHandler) UnregisterClient(c *gin.Context) {
@@ -529,6 +1242,10 @@ func (h *StreamHandler) UnregisterClient(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
+	if !checkChannelAccess(ctx, c, h.access, channelID) {
+		return
+	}
+
 	logger.Log.Info().
 		Str("channel_id", channelID.String()).
 		Str("session_id", sessionID).
@@ -603,6 +1320,10 @@ func (h *StreamHandler) UpdatePosition(c *gin.Context) {
 		return
 	}
 
+	if !checkChannelAccess(c.Request.Context(), c, h.access, channelID) {
+		return
+	}
+
 	// Parse and validate request body
 	var req UpdatePositionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -617,7 +1338,7 @@ func (h *StreamHandler) UpdatePosition(c *gin.Context) {
 	if !validQualities[req.Quality] {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "invalid_quality",
-			Message: "Quality must be 1080p, 720p, or 480p",
+			Message: "Quality must be 2160p, 1080p, 720p, or 480p",
 		})
 		return
 	}
@@ -688,6 +1409,10 @@ func (h *StreamHandler) GetBatchDebug(c *gin.Context) {
 		return
 	}
 
+	if !checkChannelAccess(c.Request.Context(), c, h.access, channelID) {
+		return
+	}
+
 	// Get stream session
 	session, found := h.streamManager.GetStream(channelID)
 	if !found {
@@ -758,18 +1483,27 @@ func (h *StreamHandler) GetBatchDebug(c *gin.Context) {
 }
 
 // SetupStreamRoutes registers streaming-related routes
-func SetupStreamRoutes(apiGroup *gin.RouterGroup, manager *streaming.StreamManager) {
-	handler := NewStreamHandler(manager)
+// Viewers can only play channels allowed by access (nil allows every channel).
+// Share links validated by shares can play the master playlist, DASH manifest, media playlists (live,
+// start-over and catch-up), segments and encryption keys only. The debug endpoint is for admins.
+// Optional middleware (such as stream access checks) applies to every stream route.
+func SetupStreamRoutes(apiGroup *gin.RouterGroup, manager *streaming.StreamManager, access ChannelAccessPolicy, shares ShareLinkAdmitter, handlers ...gin.HandlerFunc) {
+	handler := NewStreamHandler(manager, access, shares)
 
 	// Create stream route group
-	streamGroup := apiGroup.Group("/stream")
+	streamGroup := apiGroup.Group("/stream", handlers...)
+	requireUser := middleware.RequireUser()
 
 	// HLS streaming endpoints - order matters for Gin routing
 	streamGroup.GET("/:channel_id/master.m3u8", handler.GetMasterPlaylist)
-	streamGroup.DELETE("/:channel_id/client", handler.UnregisterClient)
-	streamGroup.POST("/:channel_id/position", handler.UpdatePosition)
-	streamGroup.GET("/:channel_id/debug", handler.GetBatchDebug) // Debug endpoint
+	streamGroup.GET("/:channel_id/manifest.mpd", handler.GetDASHManifest)
+	streamGroup.DELETE("/:channel_id/client", requireUser, handler.UnregisterClient)
+	streamGroup.POST("/:channel_id/position", requireUser, handler.UpdatePosition)
+	streamGroup.GET("/:channel_id/debug", middleware.RequireUserRole(models.RoleAdmin), handler.GetBatchDebug) // Debug endpoint
 	// More specific route (3 segments) must come before less specific (2 segments)
+	streamGroup.GET("/:channel_id/keys/:key", handler.GetEncryptionKey)
+	streamGroup.GET("/:channel_id/:quality/startover.m3u8", handler.GetStartOverPlaylist)
+	streamGroup.GET("/:channel_id/:quality/catchup.m3u8", handler.GetCatchUpPlaylist)
 	streamGroup.GET("/:channel_id/:quality/:segment", handler.GetSegment)
 	streamGroup.GET("/:channel_id/:quality", handler.GetMediaPlaylist)
 }
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,6 +19,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stwalsh4118/hermes/internal/models"
 	"github.com/stwalsh4118/hermes/internal/streaming"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
 )
 
 // mockStreamManager is a test helper that implements streamManager interface
@@ -26,6 +29,9 @@ type mockStreamManager struct {
 	unregisterClientFunc    func(ctx context.Context, channelID uuid.UUID) error
 	getStreamFunc           func(channelID uuid.UUID) (*models.StreamSession, bool)
 	getTriggerThresholdFunc func() int
+	playlistManagerFunc     func(channelID uuid.UUID, quality string) (playlist.Manager, bool)
+	encryptionKeyFunc       func(channelID uuid.UUID, index int) ([]byte, error)
+	catchUpPlaylistFunc     func(ctx context.Context, channelID uuid.UUID, quality string, at time.Time) (string, error)
 }
 
 func (m *mockStreamManager) StartStream(ctx context.Context, channelID uuid.UUID) (*models.StreamSession, error) {
@@ -63,6 +69,27 @@ func (m *mockStreamManager) GetTriggerThreshold() int {
 	return 7 // Default value for tests
 }
 
+func (m *mockStreamManager) PlaylistManager(channelID uuid.UUID, quality string) (playlist.Manager, bool) {
+	if m.playlistManagerFunc != nil {
+		return m.playlistManagerFunc(channelID, quality)
+	}
+	return nil, false
+}
+
+func (m *mockStreamManager) EncryptionKey(channelID uuid.UUID, index int) ([]byte, error) {
+	if m.encryptionKeyFunc != nil {
+		return m.encryptionKeyFunc(channelID, index)
+	}
+	return nil, streaming.ErrStreamNotFound
+}
+
+func (m *mockStreamManager) CatchUpPlaylist(ctx context.Context, channelID uuid.UUID, quality string, at time.Time) (string, error) {
+	if m.catchUpPlaylistFunc != nil {
+		return m.catchUpPlaylistFunc(ctx, channelID, quality, at)
+	}
+	return "", streaming.ErrCatchUpDisabled
+}
+
 // setupStreamTestRouter creates a test Gin router with stream routes
 func setupStreamTestRouter(manager *mockStreamManager) *gin.Engine {
 	gin.SetMode(gin.TestMode)
@@ -77,8 +104,12 @@ func setupStreamTestRouter(manager *mockStreamManager) *gin.Engine {
 	streamGroup := apiGroup.Group("/stream")
 
 	streamGroup.GET("/:channel_id/master.m3u8", handler.GetMasterPlaylist)
+	streamGroup.GET("/:channel_id/manifest.mpd", handler.GetDASHManifest)
 	streamGroup.DELETE("/:channel_id/client", handler.UnregisterClient)
 	streamGroup.POST("/:channel_id/position", handler.UpdatePosition)
+	streamGroup.GET("/:channel_id/keys/:key", handler.GetEncryptionKey)
+	streamGroup.GET("/:channel_id/:quality/startover.m3u8", handler.GetStartOverPlaylist)
+	streamGroup.GET("/:channel_id/:quality/catchup.m3u8", handler.GetCatchUpPlaylist)
 	streamGroup.GET("/:channel_id/:quality/:segment", handler.GetSegment)
 	streamGroup.GET("/:channel_id/:quality", handler.GetMediaPlaylist)
 
@@ -214,6 +245,33 @@ func TestGetMasterPlaylist_ChannelNotFound(t *testing.T) {
 	assert.Equal(t, "channel_not_found", response.Error)
 }
 
+func TestGetMasterPlaylist_TranscodeCapacity(t *testing.T) {
+	channelID := uuid.New()
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(_ uuid.UUID) (*models.StreamSession, bool) {
+			return nil, false
+		},
+		startStreamFunc: func(_ context.Context, _ uuid.UUID) (*models.StreamSession, error) {
+			return nil, streaming.ErrTranscodeCapacity
+		},
+	}
+
+	router := setupStreamTestRouter(mockManager)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/master.m3u8?session_id=test-session", channelID.String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, transcodeCapacityRetryAfter, w.Header().Get("Retry-After"))
+
+	var response ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "transcode_capacity", response.Error)
+}
+
 func TestGetMasterPlaylist_StreamStarting(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "stream-test-*")
 	require.NoError(t, err)
@@ -247,6 +305,103 @@ func TestGetMasterPlaylist_StreamStarting(t *testing.T) {
 	assert.Equal(t, "stream_starting", response.Error)
 }
 
+func TestGetDASHManifest_Success(t *testing.T) {
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(t.TempDir())
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}, {Level: "480p"}})
+	session.SetSegmentFormat(models.SegmentFormatFMP4)
+
+	manifest := `<MPD><Period><AdaptationSet><Representation id="720p">` +
+		`<SegmentTemplate initialization="720p/init-0.mp4" media="720p/seg-$Number%06d$.m4s"></SegmentTemplate>` +
+		`</Representation></AdaptationSet></Period></MPD>`
+	require.NoError(t, os.WriteFile(filepath.Join(session.GetOutputDir(), streaming.DASHManifestFilename), []byte(manifest), 0644))
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(id uuid.UUID) (*models.StreamSession, bool) {
+			return session, id == channelID
+		},
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/manifest.mpd?session_id=tv-1", channelID.String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/dash+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `initialization="720p/init-0.mp4?session_id=tv-1"`)
+	assert.Contains(t, w.Body.String(), `media="720p/seg-$Number%06d$.m4s?session_id=tv-1"`)
+
+	// The player is a client and every rendition is requested
+	assert.Equal(t, 1, session.GetClientCount())
+	assert.Contains(t, session.GetRenditionRequests(), "720p")
+	assert.Contains(t, session.GetRenditionRequests(), "480p")
+}
+
+func TestGetDASHManifest_StreamStarting(t *testing.T) {
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(t.TempDir())
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}})
+	session.SetSegmentFormat(models.SegmentFormatFMP4)
+
+	mockManager := &mockStreamManager{
+		startStreamFunc: func(_ context.Context, _ uuid.UUID) (*models.StreamSession, error) {
+			return session, nil
+		},
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/manifest.mpd?session_id=tv-1", channelID.String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Registered anyway, so the stream starts encoding while the player retries
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "stream_starting")
+	assert.Equal(t, 1, session.GetClientCount())
+	assert.Contains(t, session.GetRenditionRequests(), "720p")
+}
+
+func TestGetDASHManifest_TSStream(t *testing.T) {
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(t.TempDir())
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(_ uuid.UUID) (*models.StreamSession, bool) {
+			return session, true
+		},
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/manifest.mpd?session_id=tv-1", channelID.String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "dash_unavailable")
+	assert.Equal(t, 0, session.GetClientCount())
+}
+
+func TestGetDASHManifest_MissingSessionID(t *testing.T) {
+	router := setupStreamTestRouter(&mockStreamManager{})
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/manifest.mpd", uuid.New().String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "missing_session_id")
+}
+
+func TestAppendManifestQuery_EscapesSeparators(t *testing.T) {
+	query := url.Values{"token": []string{"abc"}, "session_id": []string{"tv-1"}}
+	content := appendManifestQuery(`<SegmentTemplate media="720p/seg-$Number%06d$.m4s"/>`, query)
+	assert.Equal(t, `<SegmentTemplate media="720p/seg-$Number%06d$.m4s?session_id=tv-1&amp;token=abc"/>`, content)
+}
+
 func TestGetMediaPlaylist_Success(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "stream-test-*")
 	require.NoError(t, err)
@@ -255,6 +410,7 @@ func TestGetMediaPlaylist_Success(t *testing.T) {
 	channelID := uuid.New()
 	session := models.NewStreamSession(channelID)
 	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "1080p"}, {Level: "720p"}, {Level: "480p"}})
 
 	createTestFiles(t, tmpDir)
 
@@ -286,6 +442,356 @@ func TestGetMediaPlaylist_Success(t *testing.T) {
 	}
 }
 
+func TestRewriteSegmentPaths_FMP4(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-MAP:URI=\"init-0.mp4\"\n#EXTINF:4.000,\nseg-000000.m4s\n"
+	query := url.Values{"token": []string{"abc"}}
+
+	rewritten := rewriteSegmentPaths(content, "720p", query)
+
+	assert.Contains(t, rewritten, `#EXT-X-MAP:URI="720p/init-0.mp4?token=abc"`)
+	assert.Contains(t, rewritten, "\n720p/seg-000000.m4s?token=abc\n")
+}
+
+func TestRewriteSegmentPaths_LowLatency(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-PART-INF:PART-TARGET=1.000\n" +
+		"#EXT-X-PART:DURATION=1.000,URI=\"seg-000001.m4s\",BYTERANGE=\"100@0\",INDEPENDENT=YES\n" +
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"seg-000001.m4s\",BYTERANGE-START=100\n"
+
+	rewritten := rewriteSegmentPaths(content, "720p", url.Values{"token": []string{"abc"}})
+
+	assert.Contains(t, rewritten, "#EXT-X-PART-INF:PART-TARGET=1.000\n")
+	assert.Contains(t, rewritten, `#EXT-X-PART:DURATION=1.000,URI="720p/seg-000001.m4s?token=abc",BYTERANGE="100@0",INDEPENDENT=YES`)
+	assert.Contains(t, rewritten, `#EXT-X-PRELOAD-HINT:TYPE=PART,URI="720p/seg-000001.m4s?token=abc",BYTERANGE-START=100`)
+}
+
+func TestRewriteSegmentPaths_EncryptionKey(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"keys/3.key\"\n#EXTINF:4.000,\nseg-000045.ts\n#EXT-X-KEY:METHOD=NONE\n"
+
+	rewritten := rewriteSegmentPaths(content, "720p", url.Values{"token": []string{"abc"}})
+
+	// Keys are per channel: the URI keeps resolving against the playlist URL, not the quality directory
+	assert.Contains(t, rewritten, `#EXT-X-KEY:METHOD=AES-128,URI="keys/3.key?token=abc"`)
+	assert.Contains(t, rewritten, "\n720p/seg-000045.ts?token=abc\n")
+	assert.Contains(t, rewritten, "#EXT-X-KEY:METHOD=NONE\n")
+}
+
+func TestRewriteCatchUpPaths(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-KEY:METHOD=AES-128,URI=\"keys/3.key\"\n#EXT-X-MAP:URI=\"init-0.mp4\"\n#EXTINF:4.000,\nseg-000045.m4s\n"
+
+	rewritten := rewriteCatchUpPaths(content, url.Values{"token": []string{"abc"}})
+
+	// The playlist is served from the quality directory, next to its segments
+	assert.Contains(t, rewritten, `#EXT-X-KEY:METHOD=AES-128,URI="../keys/3.key?token=abc"`)
+	assert.Contains(t, rewritten, `#EXT-X-MAP:URI="init-0.mp4?token=abc"`)
+	assert.Contains(t, rewritten, "\nseg-000045.m4s?token=abc\n")
+}
+
+func TestGetCatchUpPlaylists(t *testing.T) {
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}})
+	programStart := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	var requestedAt time.Time
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(id uuid.UUID) (*models.StreamSession, bool) {
+			return session, id == channelID
+		},
+		catchUpPlaylistFunc: func(ctx context.Context, id uuid.UUID, quality string, at time.Time) (string, error) {
+			requestedAt = at
+			if at.Before(programStart) {
+				return "", streaming.ErrCatchUpNotAvailable
+			}
+			return "#EXTM3U\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:4.000,\nseg-000045.ts\n#EXT-X-ENDLIST\n", nil
+		},
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantError  string
+		wantAt     time.Time
+	}{
+		{name: "start over", path: fmt.Sprintf("/api/stream/%s/720p/startover.m3u8?session_id=player-1", channelID), wantStatus: http.StatusOK},
+		{name: "catch up", path: fmt.Sprintf("/api/stream/%s/720p/catchup.m3u8?session_id=player-1&at=2025-01-01T20:30:00Z", channelID), wantStatus: http.StatusOK, wantAt: programStart.Add(30 * time.Minute)},
+		{name: "program not kept", path: fmt.Sprintf("/api/stream/%s/720p/catchup.m3u8?session_id=player-1&at=2025-01-01T19:00:00Z", channelID), wantStatus: http.StatusNotFound, wantError: "program_not_available"},
+		{name: "invalid time", path: fmt.Sprintf("/api/stream/%s/720p/catchup.m3u8?session_id=player-1&at=yesterday", channelID), wantStatus: http.StatusBadRequest, wantError: "invalid_time"},
+		{name: "missing session", path: fmt.Sprintf("/api/stream/%s/720p/startover.m3u8", channelID), wantStatus: http.StatusBadRequest, wantError: "missing_session_id"},
+		{name: "inactive stream", path: fmt.Sprintf("/api/stream/%s/720p/startover.m3u8?session_id=player-1", uuid.New()), wantStatus: http.StatusNotFound, wantError: "stream_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantError != "" {
+				var response ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.wantError, response.Error)
+				return
+			}
+			assert.Contains(t, w.Body.String(), "\nseg-000045.ts?session_id=player-1\n")
+			assert.Equal(t, "application/vnd.apple.mpegurl", w.Header().Get("Content-Type"))
+			if !tt.wantAt.IsZero() {
+				assert.True(t, requestedAt.Equal(tt.wantAt), "at = %s, want %s", requestedAt, tt.wantAt)
+			}
+		})
+	}
+
+	// Catch-up players are clients of the stream
+	assert.Equal(t, 1, session.GetClientCount())
+}
+
+func TestGetEncryptionKey(t *testing.T) {
+	channelID := uuid.New()
+	key := []byte("0123456789abcdef")
+
+	mockManager := &mockStreamManager{
+		encryptionKeyFunc: func(id uuid.UUID, index int) ([]byte, error) {
+			switch {
+			case id != channelID:
+				return nil, streaming.ErrStreamNotFound
+			case index > 3:
+				return nil, streaming.ErrKeyNotAvailable
+			}
+			return key, nil
+		},
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "available key", path: fmt.Sprintf("/api/stream/%s/keys/3.key", channelID), wantStatus: http.StatusOK},
+		{name: "future key", path: fmt.Sprintf("/api/stream/%s/keys/4.key", channelID), wantStatus: http.StatusNotFound, wantError: "key_not_found"},
+		{name: "inactive stream", path: fmt.Sprintf("/api/stream/%s/keys/0.key", uuid.New()), wantStatus: http.StatusNotFound, wantError: "stream_not_found"},
+		{name: "invalid key name", path: fmt.Sprintf("/api/stream/%s/keys/abc", channelID), wantStatus: http.StatusBadRequest, wantError: "invalid_key"},
+		{name: "negative index", path: fmt.Sprintf("/api/stream/%s/keys/-1.key", channelID), wantStatus: http.StatusBadRequest, wantError: "invalid_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantError != "" {
+				var response ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.wantError, response.Error)
+				return
+			}
+			assert.Equal(t, key, w.Body.Bytes())
+			assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+			assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+// publishedSegments returns a playlist manager lookup whose playlist lists the given segments,
+// which GetSegment then serves
+func publishedSegments(t *testing.T, segments ...playlist.SegmentMeta) func(uuid.UUID, string) (playlist.Manager, bool) {
+	t.Helper()
+	pm, err := playlist.NewManager(0, filepath.Join(t.TempDir(), "playlist.m3u8"), 6.0)
+	require.NoError(t, err)
+	for _, seg := range segments {
+		_, err := pm.AddSegment(seg)
+		require.NoError(t, err)
+	}
+	return func(uuid.UUID, string) (playlist.Manager, bool) {
+		return pm, true
+	}
+}
+
+// setupLowLatencyStream creates a 720p fMP4 rendition with Low-Latency HLS parts whose playlist
+// holds segment 0, and a router whose stream manager serves it
+func setupLowLatencyStream(t *testing.T) (*gin.Engine, uuid.UUID, playlist.Manager, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}})
+
+	qualityDir := filepath.Join(tmpDir, "720p")
+	require.NoError(t, os.MkdirAll(qualityDir, 0755))
+	pm, err := playlist.NewManager(10, filepath.Join(qualityDir, "720p.m3u8"), 1.0)
+	require.NoError(t, err)
+	pm.SetPartTarget(0.5)
+	_, err = pm.AddSegment(playlist.SegmentMeta{URI: "seg-000000.m4s", Duration: 1.0, Map: "init-0.mp4"})
+	require.NoError(t, err)
+	require.NoError(t, pm.Write())
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(id uuid.UUID) (*models.StreamSession, bool) {
+			return session, id == channelID
+		},
+		playlistManagerFunc: func(id uuid.UUID, quality string) (playlist.Manager, bool) {
+			return pm, id == channelID && quality == "720p"
+		},
+	}
+	return setupStreamTestRouter(mockManager), channelID, pm, qualityDir
+}
+
+func TestGetMediaPlaylist_BlockingReload(t *testing.T) {
+	router, channelID, pm, _ := setupLowLatencyStream(t)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = pm.AddPart(playlist.PartMeta{URI: "seg-000001.m4s", Duration: 0.5, ByteLength: 100, Independent: true})
+		_ = pm.Write()
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/720p.m3u8?_HLS_msn=1&_HLS_part=0", channelID.String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES")
+	assert.Contains(t, w.Body.String(), `URI="720p/seg-000001.m4s",BYTERANGE="100@0"`)
+}
+
+func TestGetMediaPlaylist_BlockingReload_InvalidRequests(t *testing.T) {
+	router, channelID, _, _ := setupLowLatencyStream(t)
+
+	tests := map[string]string{
+		"part without msn":          "_HLS_part=0",
+		"non-numeric msn":           "_HLS_msn=abc",
+		"negative part":             "_HLS_msn=1&_HLS_part=-1",
+		"more than two segments on": "_HLS_msn=3",
+	}
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/720p.m3u8?%s", channelID.String(), query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "invalid_blocking_request")
+		})
+	}
+}
+
+func TestGetSegment_ServesPartOfInProgressSegment(t *testing.T) {
+	router, channelID, pm, qualityDir := setupLowLatencyStream(t)
+	require.NoError(t, os.WriteFile(filepath.Join(qualityDir, "seg-000001.m4s"), []byte("aaaabbbb"), 0644))
+	require.NoError(t, pm.AddPart(playlist.PartMeta{URI: "seg-000001.m4s", Duration: 0.5, ByteLength: 4, Independent: true}))
+
+	// The preload hint for the second part is held until the part is complete
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = pm.AddPart(playlist.PartMeta{URI: "seg-000001.m4s", Duration: 0.5, ByteStart: 4, ByteLength: 4})
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/720p/seg-000001.m4s", channelID.String()), nil)
+	req.Header.Set("Range", "bytes=4-")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bbbb", w.Body.String())
+	assert.Equal(t, "bytes 4-7/*", w.Header().Get("Content-Range"))
+	assert.Equal(t, "video/iso.segment", w.Header().Get("Content-Type"))
+}
+
+func TestGetSegment_InProgressSegmentRequiresRange(t *testing.T) {
+	router, channelID, _, qualityDir := setupLowLatencyStream(t)
+	require.NoError(t, os.WriteFile(filepath.Join(qualityDir, "seg-000001.m4s"), []byte("aaaa"), 0644))
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/720p/seg-000001.m4s", channelID.String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRangeStart(t *testing.T) {
+	tests := map[string]struct {
+		start int64
+		ok    bool
+	}{
+		"bytes=100-":    {100, true},
+		"bytes=0-99":    {0, true},
+		"bytes=-100":    {0, false},
+		"bytes=0-1,5-6": {0, false},
+		"":              {0, false},
+	}
+	for header, want := range tests {
+		start, ok := rangeStart(header)
+		assert.Equal(t, want.ok, ok, header)
+		if want.ok {
+			assert.Equal(t, want.start, start, header)
+		}
+	}
+}
+
+func TestGetMediaPlaylist_RecordsRenditionRequest(t *testing.T) {
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(t.TempDir())
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}, {Level: "480p"}})
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(id uuid.UUID) (*models.StreamSession, bool) {
+			return session, id == channelID
+		},
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	// Not generated yet: the request starts the rendition and the player retries
+	req := httptest.NewRequest(http.MethodGet, "/api/stream/"+channelID.String()+"/480p.m3u8", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, session.GetRenditionRequests(), "480p")
+
+	// Above the stream's top quality
+	req = httptest.NewRequest(http.MethodGet, "/api/stream/"+channelID.String()+"/1080p.m3u8", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "quality_not_available")
+	assert.NotContains(t, session.GetRenditionRequests(), "1080p")
+}
+
+func TestGetMediaPlaylist_RegistersSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID) // A resumed stream: no clients counted
+	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}})
+	createTestFiles(t, tmpDir)
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(id uuid.UUID) (*models.StreamSession, bool) {
+			return session, id == channelID
+		},
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	// Reloads carrying the player's session ID register it once
+	for range 2 {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stream/"+channelID.String()+"/720p.m3u8?session_id=tv-1", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "?session_id=tv-1")
+	}
+	assert.Equal(t, 1, session.GetClientCount())
+
+	// Reloads without one are not counted
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stream/"+channelID.String()+"/720p.m3u8", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, session.GetClientCount())
+}
+
 func TestGetMediaPlaylist_InvalidQuality(t *testing.T) {
 	channelID := uuid.New()
 	session := models.NewStreamSession(channelID)
@@ -341,6 +847,7 @@ func TestGetSegment_Success(t *testing.T) {
 	channelID := uuid.New()
 	session := models.NewStreamSession(channelID)
 	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "1080p"}})
 
 	createTestFiles(t, tmpDir)
 
@@ -351,6 +858,7 @@ func TestGetSegment_Success(t *testing.T) {
 			}
 			return nil, false
 		},
+		playlistManagerFunc: publishedSegments(t, playlist.SegmentMeta{URI: "1080p_segment_000.ts", Duration: 6.0}),
 	}
 
 	router := setupStreamTestRouter(mockManager)
@@ -378,7 +886,7 @@ func TestGetSegment_InvalidSegmentExtension(t *testing.T) {
 
 	router := setupStreamTestRouter(mockManager)
 
-	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/1080p/segment.mp4", channelID.String()), nil)
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/1080p/segment.mkv", channelID.String()), nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -390,6 +898,98 @@ func TestGetSegment_InvalidSegmentExtension(t *testing.T) {
 	assert.Equal(t, "invalid_segment", response.Error)
 }
 
+func TestGetSegment_FMP4(t *testing.T) {
+	tmpDir := t.TempDir()
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}})
+
+	qualityDir := filepath.Join(tmpDir, "720p")
+	require.NoError(t, os.MkdirAll(qualityDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(qualityDir, "init-0.mp4"), []byte("init"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(qualityDir, "seg-000000.m4s"), []byte("fragment"), 0644))
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(_ uuid.UUID) (*models.StreamSession, bool) {
+			return session, true
+		},
+		playlistManagerFunc: publishedSegments(t, playlist.SegmentMeta{URI: "seg-000000.m4s", Duration: 4.0, Map: "init-0.mp4"}),
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	for segment, contentType := range map[string]string{
+		"init-0.mp4":     "video/mp4",
+		"seg-000000.m4s": "video/iso.segment",
+	} {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/720p/%s", channelID.String(), segment), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, segment)
+		assert.Equal(t, contentType, w.Header().Get("Content-Type"), segment)
+	}
+}
+
+func TestGetSegment_UnpublishedNotServed(t *testing.T) {
+	tmpDir := t.TempDir()
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}})
+
+	// Segment 1 is on disk but still being written (or encrypted): the playlist only lists segment 0
+	qualityDir := filepath.Join(tmpDir, "720p")
+	require.NoError(t, os.MkdirAll(qualityDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(qualityDir, "seg-000000.ts"), []byte("segment"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(qualityDir, "seg-000001.ts"), []byte("partial"), 0644))
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(_ uuid.UUID) (*models.StreamSession, bool) {
+			return session, true
+		},
+		playlistManagerFunc: publishedSegments(t, playlist.SegmentMeta{URI: "seg-000000.ts", Duration: 4.0}),
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	for segment, code := range map[string]int{"seg-000000.ts": http.StatusOK, "seg-000001.ts": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/720p/%s", channelID.String(), segment), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, code, w.Code, segment)
+	}
+}
+
+func TestGetSegment_RecordsPositionForSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "720p"}})
+
+	qualityDir := filepath.Join(tmpDir, "720p")
+	require.NoError(t, os.MkdirAll(qualityDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(qualityDir, "seg-000042.m4s"), []byte("fragment"), 0644))
+
+	mockManager := &mockStreamManager{
+		getStreamFunc: func(_ uuid.UUID) (*models.StreamSession, bool) {
+			return session, true
+		},
+		playlistManagerFunc: publishedSegments(t, playlist.SegmentMeta{URI: "seg-000042.m4s", Duration: 4.0}),
+	}
+	router := setupStreamTestRouter(mockManager)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/stream/%s/720p/seg-000042.m4s?session_id=tv-1", channelID.String()), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 42, session.GetFurthestPosition())
+	require.Contains(t, session.GetClientPositions(), "tv-1")
+	assert.Equal(t, "720p", session.GetClientPositions()["tv-1"].Quality)
+}
+
 func TestGetSegment_DirectoryTraversalAttempt(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "stream-test-*")
 	require.NoError(t, err)
@@ -442,6 +1042,7 @@ func TestGetSegment_NotFound(t *testing.T) {
 	channelID := uuid.New()
 	session := models.NewStreamSession(channelID)
 	session.SetOutputDir(tmpDir)
+	session.SetQualities([]models.StreamQuality{{Level: "1080p"}})
 
 	// Create quality directory but no segment
 	qualityDir := filepath.Join(tmpDir, "1080p")
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stwalsh4118/hermes/internal/streaming"
+)
+
+// SystemHandler handles system capability requests
+type SystemHandler struct {
+	encoders *streaming.EncoderDetector
+}
+
+// NewSystemHandler creates a new system handler instance
+func NewSystemHandler(encoders *streaming.EncoderDetector) *SystemHandler {
+	return &SystemHandler{encoders: encoders}
+}
+
+// GetEncoders handles GET /api/system/encoders
+// Returns the cached result of hardware encoder detection performed at startup
+func (h *SystemHandler) GetEncoders(c *gin.Context) {
+	caps := h.encoders.Capabilities()
+	if caps == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "not_ready",
+			Message: "Encoder detection has not completed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, caps)
+}
+
+// SetupSystemRoutes registers system routes
+func SetupSystemRoutes(apiGroup *gin.RouterGroup, encoders *streaming.EncoderDetector) {
+	handler := NewSystemHandler(encoders)
+
+	system := apiGroup.Group("/system")
+	system.GET("/encoders", handler.GetEncoders)
+}
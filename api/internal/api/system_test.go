@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/streaming"
+)
+
+func TestGetEncoders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	detector := streaming.NewEncoderDetector()
+	SetupSystemRoutes(router.Group("/api"), detector)
+
+	t.Run("Not ready before detection", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/system/encoders", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("Returns cached capabilities", func(t *testing.T) {
+		detector.Detect(context.Background())
+
+		req := httptest.NewRequest("GET", "/api/system/encoders", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var caps streaming.EncoderCapabilities
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &caps))
+		assert.Contains(t, caps.Verified, streaming.HardwareAccelNone)
+		assert.NotEmpty(t, caps.Selected)
+		assert.False(t, caps.DetectedAt.IsZero())
+	})
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/db"
+)
+
+// CanAccessChannel reports whether a user may see and stream a channel.
+// Admins and editors see every channel; viewers need a grant for themselves or one of their groups.
+// Unknown users (e.g. deleted after a stream token was issued) have no access.
+func (s *Service) CanAccessChannel(ctx context.Context, userID, channelID uuid.UUID) (bool, error) {
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if user.SeesAllChannels() {
+		return true, nil
+	}
+	return s.repos.ChannelAccess.HasAccess(ctx, channelID, userID)
+}
+
+// AccessibleChannels returns the channels a user may see.
+// all is true when the user sees every channel, in which case channels is nil.
+func (s *Service) AccessibleChannels(ctx context.Context, userID uuid.UUID) (all bool, channels map[uuid.UUID]bool, err error) {
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return false, map[uuid.UUID]bool{}, nil
+		}
+		return false, nil, err
+	}
+	if user.SeesAllChannels() {
+		return true, nil, nil
+	}
+
+	channels, err = s.repos.ChannelAccess.ChannelIDsForUser(ctx, userID)
+	if err != nil {
+		return false, nil, err
+	}
+	return false, channels, nil
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestChannelAccess(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+	repos := service.repos
+
+	editor, err := service.CreateUser(ctx, "parent", testPassword, models.RoleEditor)
+	require.NoError(t, err)
+	kid, err := service.CreateUser(ctx, "kid", testPassword, models.RoleViewer)
+	require.NoError(t, err)
+	guest, err := service.CreateUser(ctx, "guest", testPassword, models.RoleViewer)
+	require.NoError(t, err)
+
+	cartoons := models.NewChannel("Cartoons", time.Now().UTC(), true)
+	require.NoError(t, repos.Channels.Create(ctx, cartoons))
+	movies := models.NewChannel("Movies", time.Now().UTC(), true)
+	require.NoError(t, repos.Channels.Create(ctx, movies))
+	news := models.NewChannel("News", time.Now().UTC(), true)
+	require.NoError(t, repos.Channels.Create(ctx, news))
+
+	// Kid is granted cartoons directly and movies through a group
+	require.NoError(t, repos.ChannelAccess.Grant(ctx, &models.ChannelAccess{ID: uuid.New(), ChannelID: cartoons.ID, UserID: &kid.ID}))
+	kids := models.NewUserGroup("kids")
+	require.NoError(t, repos.Groups.Create(ctx, kids))
+	require.NoError(t, repos.Groups.AddMember(ctx, kids.ID, kid.ID))
+	require.NoError(t, repos.ChannelAccess.Grant(ctx, &models.ChannelAccess{ID: uuid.New(), ChannelID: movies.ID, GroupID: &kids.ID}))
+
+	t.Run("viewer sees granted channels only", func(t *testing.T) {
+		for channelID, expected := range map[uuid.UUID]bool{cartoons.ID: true, movies.ID: true, news.ID: false} {
+			allowed, err := service.CanAccessChannel(ctx, kid.ID, channelID)
+			require.NoError(t, err)
+			assert.Equal(t, expected, allowed)
+		}
+
+		all, channels, err := service.AccessibleChannels(ctx, kid.ID)
+		require.NoError(t, err)
+		assert.False(t, all)
+		assert.Equal(t, map[uuid.UUID]bool{cartoons.ID: true, movies.ID: true}, channels)
+	})
+
+	t.Run("viewer without grants sees nothing", func(t *testing.T) {
+		allowed, err := service.CanAccessChannel(ctx, guest.ID, cartoons.ID)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		all, channels, err := service.AccessibleChannels(ctx, guest.ID)
+		require.NoError(t, err)
+		assert.False(t, all)
+		assert.Empty(t, channels)
+	})
+
+	t.Run("editor sees every channel", func(t *testing.T) {
+		allowed, err := service.CanAccessChannel(ctx, editor.ID, news.ID)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		all, _, err := service.AccessibleChannels(ctx, editor.ID)
+		require.NoError(t, err)
+		assert.True(t, all)
+	})
+
+	t.Run("leaving the group removes group grants", func(t *testing.T) {
+		require.NoError(t, repos.Groups.RemoveMember(ctx, kids.ID, kid.ID))
+		allowed, err := service.CanAccessChannel(ctx, kid.ID, movies.ID)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("unknown user has no access", func(t *testing.T) {
+		allowed, err := service.CanAccessChannel(ctx, uuid.New(), cartoons.ID)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
@@ -0,0 +1,480 @@
+// Package auth provides user accounts, API keys, login sessions, signed stream tokens and share links.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// Defaults used when the corresponding config values are zero
+const (
+	DefaultSessionTTL     = 7 * 24 * time.Hour
+	DefaultStreamTokenTTL = 12 * time.Hour
+
+	// MaxStreamTokenTTL caps the lifetime a caller can request for a stream token
+	MaxStreamTokenTTL = 30 * 24 * time.Hour
+
+	// apiKeyTouchInterval limits how often last_used_at is written for a busy key
+	apiKeyTouchInterval = time.Minute
+
+	maxUsernameLength = 64
+)
+
+// Common errors
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUnauthenticated    = errors.New("missing, invalid or expired credentials")
+	ErrSetupComplete      = errors.New("initial setup has already been completed")
+	ErrInvalidUsername    = errors.New("invalid username")
+	ErrWeakPassword       = errors.New("password does not meet requirements")
+	ErrUsernameTaken      = errors.New("username is already taken")
+	ErrLastUser           = errors.New("cannot delete the last user")
+	ErrLastAdmin          = errors.New("cannot remove the last admin")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrInvalidStreamToken = errors.New("invalid stream token")
+	ErrStreamTokenExpired = errors.New("stream token has expired")
+	ErrInvalidShareToken  = errors.New("invalid share link")
+	ErrShareTokenExpired  = errors.New("share link has expired")
+	ErrShareSessionLimit  = errors.New("share link session limit reached")
+	ErrInvalidMaxSessions = errors.New("invalid max sessions")
+)
+
+// Service handles authentication and credential management
+type Service struct {
+	repos          *db.Repositories
+	streamTokens   *StreamTokenSigner
+	shareSessions  *shareSessions
+	sessionTTL     time.Duration
+	streamTokenTTL time.Duration
+	now            func() time.Time
+}
+
+// NewService creates a new auth service.
+// Without a configured token secret a random one is generated, so stream tokens
+// stop working after a restart.
+func NewService(repos *db.Repositories, cfg *config.AuthConfig) (*Service, error) {
+	secret := []byte(cfg.TokenSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate token secret: %w", err)
+		}
+		if cfg.Enabled {
+			logger.Log.Warn().Msg("No auth token secret configured; stream tokens will be invalidated on restart")
+		}
+	}
+
+	sessionTTL := cfg.SessionTTL
+	if sessionTTL == 0 {
+		sessionTTL = DefaultSessionTTL
+	}
+	streamTokenTTL := cfg.StreamTokenTTL
+	if streamTokenTTL == 0 {
+		streamTokenTTL = DefaultStreamTokenTTL
+	}
+
+	return &Service{
+		repos:          repos,
+		streamTokens:   NewStreamTokenSigner(secret),
+		shareSessions:  newShareSessions(),
+		sessionTTL:     sessionTTL,
+		streamTokenTTL: streamTokenTTL,
+		now:            func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+// SetupRequired reports whether no user exists yet
+func (s *Service) SetupRequired(ctx context.Context) (bool, error) {
+	count, err := s.repos.Users.Count(ctx)
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// Setup creates the first user as an admin; it fails once any user exists.
+// The existence check is part of the insert, so concurrent setups create one admin.
+func (s *Service) Setup(ctx context.Context, username, password string) (*models.User, error) {
+	required, err := s.SetupRequired(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !required {
+		return nil, ErrSetupComplete
+	}
+
+	user, err := s.newUser(username, password, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repos.Users.CreateFirst(ctx, user); err != nil {
+		if errors.Is(err, db.ErrUsersExist) {
+			return nil, ErrSetupComplete
+		}
+		return nil, err
+	}
+
+	logger.Log.Info().
+		Str("user_id", user.ID.String()).
+		Str("username", user.Username).
+		Msg("Initial admin created")
+
+	return user, nil
+}
+
+// CreateUser validates and creates a new user with the given role
+func (s *Service) CreateUser(ctx context.Context, username, password, role string) (*models.User, error) {
+	user, err := s.newUser(username, password, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repos.Users.Create(ctx, user); err != nil {
+		if errors.Is(err, db.ErrDuplicate) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
+
+	logger.Log.Info().
+		Str("user_id", user.ID.String()).
+		Str("username", user.Username).
+		Str("role", user.Role).
+		Msg("User created")
+
+	return user, nil
+}
+
+// newUser validates the username and role and hashes the password of a user to create
+func (s *Service) newUser(username, password, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	username = strings.TrimSpace(username)
+	if username == "" || len(username) > maxUsernameLength {
+		return nil, fmt.Errorf("%w: must be 1-%d characters", ErrInvalidUsername, maxUsernameLength)
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewUser(username, hash, role), nil
+}
+
+// ListUsers returns all users
+func (s *Service) ListUsers(ctx context.Context) ([]*models.User, error) {
+	return s.repos.Users.List(ctx)
+}
+
+// UpdateRole changes a user's role; the last admin cannot be demoted
+func (s *Service) UpdateRole(ctx context.Context, id uuid.UUID, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+		if err := s.ensureOtherAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repos.Users.UpdateRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// DeleteUser deletes a user with their keys and sessions; the last user and the last admin cannot be deleted
+func (s *Service) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repos.Users.Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return ErrLastUser
+	}
+	if user.Role == models.RoleAdmin {
+		if err := s.ensureOtherAdmin(ctx); err != nil {
+			return err
+		}
+	}
+	return s.repos.Users.Delete(ctx, id)
+}
+
+// ensureOtherAdmin returns ErrLastAdmin unless more than one admin exists
+func (s *Service) ensureOtherAdmin(ctx context.Context) error {
+	admins, err := s.repos.Users.CountByRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// Login verifies a username and password and starts a session.
+// Returns the plaintext session token, which is only available here.
+func (s *Service) Login(ctx context.Context, username, password string) (string, *models.UserSession, *models.User, error) {
+	user, err := s.repos.Users.GetByUsername(ctx, strings.TrimSpace(username))
+	if err != nil {
+		if !errors.Is(err, db.ErrNotFound) {
+			return "", nil, nil, err
+		}
+		// Spend the same time as a real comparison so usernames cannot be probed
+		CheckPassword(string(dummyPasswordHash), password)
+		return "", nil, nil, ErrInvalidCredentials
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return "", nil, nil, ErrInvalidCredentials
+	}
+
+	token, hash, err := generateToken(sessionTokenPrefix)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	now := s.now()
+	session := &models.UserSession{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.sessionTTL),
+		CreatedAt: now,
+	}
+	if err := s.repos.UserSessions.Create(ctx, session); err != nil {
+		return "", nil, nil, err
+	}
+
+	// Opportunistic cleanup keeps the table small without a background job
+	if _, err := s.repos.UserSessions.DeleteExpired(ctx, now); err != nil {
+		logger.Log.Warn().Err(err).Msg("Failed to delete expired sessions")
+	}
+
+	return token, session, user, nil
+}
+
+// Logout ends the session identified by token
+func (s *Service) Logout(ctx context.Context, token string) error {
+	if !strings.HasPrefix(token, sessionTokenPrefix) {
+		return ErrUnauthenticated
+	}
+	if err := s.repos.UserSessions.DeleteByHash(ctx, HashToken(token)); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return ErrUnauthenticated
+		}
+		return err
+	}
+	return nil
+}
+
+// Authenticate resolves a session token or API key to its user
+func (s *Service) Authenticate(ctx context.Context, credential string) (*models.User, error) {
+	now := s.now()
+
+	var userID uuid.UUID
+	switch {
+	case strings.HasPrefix(credential, sessionTokenPrefix):
+		session, err := s.repos.UserSessions.GetByHash(ctx, HashToken(credential))
+		if err != nil {
+			return nil, mapLookupError(err)
+		}
+		if session.IsExpired(now) {
+			return nil, ErrUnauthenticated
+		}
+		userID = session.UserID
+
+	case strings.HasPrefix(credential, apiKeyPrefix):
+		key, err := s.repos.APIKeys.GetByHash(ctx, HashToken(credential))
+		if err != nil {
+			return nil, mapLookupError(err)
+		}
+		if key.IsExpired(now) {
+			return nil, ErrUnauthenticated
+		}
+		if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+			if err := s.repos.APIKeys.TouchLastUsed(ctx, key.ID, now); err != nil {
+				logger.Log.Warn().Err(err).Str("api_key_id", key.ID.String()).Msg("Failed to record API key usage")
+			}
+		}
+		userID = key.UserID
+
+	default:
+		return nil, ErrUnauthenticated
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, mapLookupError(err)
+	}
+	return user, nil
+}
+
+// ChangePassword verifies the current password, sets a new one and ends all of the user's sessions
+func (s *Service) ChangePassword(ctx context.Context, userID uuid.UUID, current, next string) error {
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !CheckPassword(user.PasswordHash, current) {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := HashPassword(next)
+	if err != nil {
+		return err
+	}
+	if err := s.repos.Users.UpdatePassword(ctx, userID, hash); err != nil {
+		return err
+	}
+	return s.repos.UserSessions.DeleteByUser(ctx, userID)
+}
+
+// CreateAPIKey creates a named API key for a user.
+// Returns the plaintext key, which is only available here.
+func (s *Service) CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, fmt.Errorf("%w: api key name is required", db.ErrInvalidInput)
+	}
+
+	plaintext, hash, err := generateToken(apiKeyPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plaintext[:apiKeyPrefixChars],
+		KeyHash:   hash,
+		ExpiresAt: expiresAt,
+		CreatedAt: s.now(),
+	}
+	if err := s.repos.APIKeys.Create(ctx, key); err != nil {
+		return "", nil, err
+	}
+
+	logger.Log.Info().
+		Str("user_id", userID.String()).
+		Str("api_key_id", key.ID.String()).
+		Str("name", key.Name).
+		Msg("API key created")
+
+	return plaintext, key, nil
+}
+
+// ListAPIKeys returns the API keys of a user
+func (s *Service) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error) {
+	return s.repos.APIKeys.ListByUser(ctx, userID)
+}
+
+// RevokeAPIKey deletes one of the user's API keys
+func (s *Service) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	return s.repos.APIKeys.Delete(ctx, keyID, userID)
+}
+
+// IssueStreamToken signs a stream token for a channel.
+// A zero ttl uses the configured default; longer requests are capped at MaxStreamTokenTTL.
+func (s *Service) IssueStreamToken(userID, channelID uuid.UUID, ttl time.Duration) (string, time.Time) {
+	if ttl <= 0 {
+		ttl = s.streamTokenTTL
+	}
+	if ttl > MaxStreamTokenTTL {
+		ttl = MaxStreamTokenTTL
+	}
+	expiresAt := s.now().Add(ttl).Truncate(time.Second)
+	return s.streamTokens.Sign(channelID, userID, expiresAt), expiresAt
+}
+
+// VerifyStreamToken checks that a stream token is valid for the channel
+func (s *Service) VerifyStreamToken(token string, channelID uuid.UUID) (*StreamTokenClaims, error) {
+	return s.streamTokens.Verify(token, channelID, s.now())
+}
+
+// IssueShareToken signs a share link for a channel that anyone can play without an account.
+// A zero ttl uses DefaultShareTTL; longer requests are capped at MaxShareTTL.
+// maxSessions limits concurrent players (0 means unlimited).
+func (s *Service) IssueShareToken(channelID uuid.UUID, ttl time.Duration, maxSessions int) (string, *ShareTokenClaims, error) {
+	if maxSessions < 0 || maxSessions > MaxShareSessions {
+		return "", nil, fmt.Errorf("%w: must be 0-%d", ErrInvalidMaxSessions, MaxShareSessions)
+	}
+	if ttl <= 0 {
+		ttl = DefaultShareTTL
+	}
+	if ttl > MaxShareTTL {
+		ttl = MaxShareTTL
+	}
+
+	claims := &ShareTokenClaims{
+		ShareID:     uuid.New(),
+		ChannelID:   channelID,
+		ExpiresAt:   s.now().Add(ttl).Truncate(time.Second),
+		MaxSessions: maxSessions,
+	}
+
+	logger.Log.Info().
+		Str("share_id", claims.ShareID.String()).
+		Str("channel_id", channelID.String()).
+		Time("expires_at", claims.ExpiresAt).
+		Int("max_sessions", maxSessions).
+		Msg("Share link issued")
+
+	return s.streamTokens.SignShare(claims), claims, nil
+}
+
+// VerifyShareToken checks that a share link token is valid for the channel
+func (s *Service) VerifyShareToken(token string, channelID uuid.UUID) (*ShareTokenClaims, error) {
+	return s.streamTokens.VerifyShare(token, channelID, s.now())
+}
+
+// AdmitShareSession verifies a share link and records a request from the player session,
+// rejecting new sessions once the link's session limit is reached
+func (s *Service) AdmitShareSession(token string, channelID uuid.UUID, sessionID string) (*ShareTokenClaims, error) {
+	now := s.now()
+	claims, err := s.streamTokens.VerifyShare(token, channelID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.shareSessions.admit(claims, sessionID, now); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// TakeIdleSessions returns the player sessions of share links that stopped fetching or whose link
+// expired, by channel ID. Each session is returned once, so the caller can unregister it from its stream.
+func (s *Service) TakeIdleSessions() map[uuid.UUID][]string {
+	return s.shareSessions.takeIdle(s.now())
+}
+
+// mapLookupError turns a missing credential row into ErrUnauthenticated
+func mapLookupError(err error) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return ErrUnauthenticated
+	}
+	return err
+}
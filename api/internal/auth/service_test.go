@@ -0,0 +1,418 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+const testPassword = "correct horse battery"
+
+// setupTestService creates an auth service with a test database
+func setupTestService(t *testing.T) (*Service, func()) {
+	t.Helper()
+
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+
+	sqlDB, err := database.GetSQLDB()
+	require.NoError(t, err)
+	require.NoError(t, db.RunMigrations(sqlDB, "file://../../migrations"))
+
+	service, err := NewService(db.NewRepositories(database), &config.AuthConfig{
+		Enabled:     true,
+		TokenSecret: "test-secret",
+	})
+	require.NoError(t, err)
+
+	cleanup := func() {
+		_ = database.Close()
+	}
+	return service, cleanup
+}
+
+func TestSetup_OnlyOnce(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	required, err := service.SetupRequired(ctx)
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	user, err := service.Setup(ctx, "admin", testPassword)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", user.Username)
+	assert.NotEqual(t, testPassword, user.PasswordHash)
+
+	_, err = service.Setup(ctx, "second", testPassword)
+	assert.ErrorIs(t, err, ErrSetupComplete)
+}
+
+func TestCreateUser_Validation(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := service.CreateUser(ctx, "  ", testPassword, models.RoleAdmin)
+	assert.ErrorIs(t, err, ErrInvalidUsername)
+
+	_, err = service.CreateUser(ctx, "admin", "short", models.RoleAdmin)
+	assert.ErrorIs(t, err, ErrWeakPassword)
+
+	_, err = service.CreateUser(ctx, "admin", strings.Repeat("a", MaxPasswordLength+1), models.RoleAdmin)
+	assert.ErrorIs(t, err, ErrWeakPassword)
+
+	_, err = service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+
+	_, err = service.CreateUser(ctx, "ADMIN", testPassword, models.RoleAdmin)
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+
+	_, err = service.CreateUser(ctx, "owner", testPassword, "owner")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+}
+
+func TestSetup_CreatesAdmin(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+
+	user, err := service.Setup(context.Background(), "admin", testPassword)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, user.Role)
+}
+
+func TestSetup_ConcurrentCreatesOneAdmin(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	const attempts = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := service.Setup(ctx, fmt.Sprintf("admin%d", i), testPassword)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrSetupComplete)
+	}
+	assert.Equal(t, 1, created)
+
+	users, err := service.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, models.RoleAdmin, users[0].Role)
+	assert.False(t, users[0].CreatedAt.IsZero())
+
+	_, _, _, err = service.Login(ctx, users[0].Username, testPassword)
+	assert.NoError(t, err)
+}
+
+func TestUpdateRole_KeepsLastAdmin(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	admin, err := service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+	editor, err := service.CreateUser(ctx, "editor", testPassword, models.RoleEditor)
+	require.NoError(t, err)
+
+	_, err = service.UpdateRole(ctx, admin.ID, models.RoleViewer)
+	assert.ErrorIs(t, err, ErrLastAdmin)
+	assert.ErrorIs(t, service.DeleteUser(ctx, admin.ID), ErrLastAdmin)
+
+	_, err = service.UpdateRole(ctx, editor.ID, "owner")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+
+	promoted, err := service.UpdateRole(ctx, editor.ID, models.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, promoted.Role)
+
+	demoted, err := service.UpdateRole(ctx, admin.ID, models.RoleViewer)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleViewer, demoted.Role)
+}
+
+func TestLogin_AndAuthenticate(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+
+	_, _, _, err = service.Login(ctx, "admin", "wrong password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, _, _, err = service.Login(ctx, "nobody", testPassword)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	token, session, user, err := service.Login(ctx, "Admin", testPassword)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, user.ID)
+	assert.True(t, strings.HasPrefix(token, sessionTokenPrefix))
+	assert.Equal(t, HashToken(token), session.TokenHash)
+
+	authenticated, err := service.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, authenticated.ID)
+
+	_, err = service.Authenticate(ctx, sessionTokenPrefix+"unknown")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = service.Authenticate(ctx, "garbage")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	require.NoError(t, service.Logout(ctx, token))
+	_, err = service.Authenticate(ctx, token)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestAuthenticate_ExpiredSession(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+
+	token, _, _, err := service.Login(ctx, "admin", testPassword)
+	require.NoError(t, err)
+
+	service.now = func() time.Time { return time.Now().UTC().Add(DefaultSessionTTL + time.Minute) }
+	_, err = service.Authenticate(ctx, token)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestChangePassword_EndsSessions(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	user, err := service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+	token, _, _, err := service.Login(ctx, "admin", testPassword)
+	require.NoError(t, err)
+
+	err = service.ChangePassword(ctx, user.ID, "wrong password", "new password 123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.NoError(t, service.ChangePassword(ctx, user.ID, testPassword, "new password 123"))
+
+	_, err = service.Authenticate(ctx, token)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, _, _, err = service.Login(ctx, "admin", "new password 123")
+	assert.NoError(t, err)
+}
+
+func TestAPIKeys(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	user, err := service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+
+	plaintext, key, err := service.CreateAPIKey(ctx, user.ID, "prometheus", nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, apiKeyPrefix))
+	assert.True(t, strings.HasPrefix(plaintext, key.Prefix))
+	assert.NotContains(t, key.KeyHash, plaintext)
+
+	authenticated, err := service.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, authenticated.ID)
+
+	keys, err := service.ListAPIKeys(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt, "authentication should record key usage")
+
+	require.NoError(t, service.RevokeAPIKey(ctx, user.ID, key.ID))
+	_, err = service.Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, _, err = service.CreateAPIKey(ctx, user.ID, " ", nil)
+	assert.ErrorIs(t, err, db.ErrInvalidInput)
+}
+
+func TestAPIKeys_Expired(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	user, err := service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	plaintext, _, err := service.CreateAPIKey(ctx, user.ID, "temporary", &expiresAt)
+	require.NoError(t, err)
+
+	service.now = func() time.Time { return expiresAt.Add(time.Second) }
+	_, err = service.Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestDeleteUser_KeepsLastUser(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	first, err := service.CreateUser(ctx, "admin", testPassword, models.RoleAdmin)
+	require.NoError(t, err)
+	second, err := service.CreateUser(ctx, "viewer", testPassword, models.RoleViewer)
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteUser(ctx, second.ID))
+	assert.ErrorIs(t, service.DeleteUser(ctx, first.ID), ErrLastUser)
+	assert.ErrorIs(t, service.DeleteUser(ctx, uuid.New()), db.ErrNotFound)
+}
+
+func TestStreamTokens(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+
+	userID := uuid.New()
+	channelID := uuid.New()
+
+	token, expiresAt := service.IssueStreamToken(userID, channelID, time.Hour)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, 2*time.Second)
+
+	claims, err := service.VerifyStreamToken(token, channelID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, channelID, claims.ChannelID)
+	assert.True(t, expiresAt.Equal(claims.ExpiresAt))
+
+	t.Run("wrong channel", func(t *testing.T) {
+		_, err := service.VerifyStreamToken(token, uuid.New())
+		assert.ErrorIs(t, err, ErrInvalidStreamToken)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := "A" + token[1:]
+		if tampered == token {
+			tampered = "B" + token[1:]
+		}
+		_, err := service.VerifyStreamToken(tampered, channelID)
+		assert.ErrorIs(t, err, ErrInvalidStreamToken)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := service.VerifyStreamToken("not-a-token", channelID)
+		assert.ErrorIs(t, err, ErrInvalidStreamToken)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		other := NewStreamTokenSigner([]byte("other-secret"))
+		_, err := other.Verify(token, channelID, time.Now())
+		assert.ErrorIs(t, err, ErrInvalidStreamToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		service.now = func() time.Time { return expiresAt.Add(time.Second) }
+		defer func() { service.now = func() time.Time { return time.Now().UTC() } }()
+		_, err := service.VerifyStreamToken(token, channelID)
+		assert.ErrorIs(t, err, ErrStreamTokenExpired)
+	})
+
+	t.Run("ttl capped", func(t *testing.T) {
+		_, capped := service.IssueStreamToken(userID, channelID, 365*24*time.Hour)
+		assert.WithinDuration(t, time.Now().Add(MaxStreamTokenTTL), capped, 2*time.Second)
+	})
+}
+
+func TestShareTokens(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+
+	channelID := uuid.New()
+	token, claims, err := service.IssueShareToken(channelID, time.Hour, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, claims.MaxSessions)
+
+	verified, err := service.VerifyShareToken(token, channelID)
+	require.NoError(t, err)
+	assert.Equal(t, claims.ShareID, verified.ShareID)
+	assert.True(t, claims.ExpiresAt.Equal(verified.ExpiresAt))
+
+	t.Run("session limit", func(t *testing.T) {
+		_, err := service.AdmitShareSession(token, channelID, "first")
+		require.NoError(t, err)
+		_, err = service.AdmitShareSession(token, channelID, "second")
+		require.NoError(t, err)
+
+		_, err = service.AdmitShareSession(token, channelID, "third")
+		assert.ErrorIs(t, err, ErrShareSessionLimit)
+
+		_, err = service.AdmitShareSession(token, channelID, "first")
+		assert.NoError(t, err, "known sessions keep playing")
+	})
+
+	t.Run("idle sessions free their slot", func(t *testing.T) {
+		service.now = func() time.Time { return time.Now().UTC().Add(ShareSessionIdleTimeout + time.Second) }
+		defer func() { service.now = func() time.Time { return time.Now().UTC() } }()
+
+		_, err := service.AdmitShareSession(token, channelID, "third")
+		assert.NoError(t, err)
+
+		// Idle sessions are handed out once so their stream clients can be unregistered
+		idle := service.TakeIdleSessions()
+		assert.ElementsMatch(t, []string{"first", "second"}, idle[channelID])
+		assert.Empty(t, service.TakeIdleSessions())
+	})
+
+	t.Run("wrong channel", func(t *testing.T) {
+		_, err := service.VerifyShareToken(token, uuid.New())
+		assert.ErrorIs(t, err, ErrInvalidShareToken)
+	})
+
+	t.Run("stream token is not a share link", func(t *testing.T) {
+		streamToken, _ := service.IssueStreamToken(uuid.New(), channelID, time.Hour)
+		_, err := service.VerifyShareToken(streamToken, channelID)
+		assert.ErrorIs(t, err, ErrInvalidShareToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		service.now = func() time.Time { return claims.ExpiresAt.Add(time.Second) }
+		defer func() { service.now = func() time.Time { return time.Now().UTC() } }()
+
+		_, err := service.AdmitShareSession(token, channelID, "first")
+		assert.ErrorIs(t, err, ErrShareTokenExpired)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		_, _, err := service.IssueShareToken(channelID, time.Hour, MaxShareSessions+1)
+		assert.ErrorIs(t, err, ErrInvalidMaxSessions)
+
+		_, capped, err := service.IssueShareToken(channelID, 365*24*time.Hour, 0)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(MaxShareTTL), capped.ExpiresAt, 2*time.Second)
+	})
+}
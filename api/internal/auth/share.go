@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// shareTokenContext domain-separates share link signatures from stream tokens
+const shareTokenContext = "hermes-share-token-v1"
+
+// shareTokenPayloadLen is share ID (16) + channel ID (16) + expiry unix seconds (8) + max sessions (2)
+const shareTokenPayloadLen = 16 + 16 + 8 + 2
+
+// Share link limits
+const (
+	DefaultShareTTL = 6 * time.Hour
+
+	// MaxShareTTL caps the lifetime a caller can request for a share link
+	MaxShareTTL = 7 * 24 * time.Hour
+
+	// MaxShareSessions caps the concurrent sessions a share link can allow
+	MaxShareSessions = 100
+
+	// ShareSessionIdleTimeout frees a share link session slot once the player stops fetching
+	ShareSessionIdleTimeout = 2 * time.Minute
+)
+
+// ShareTokenClaims holds the verified contents of a share link token
+type ShareTokenClaims struct {
+	ShareID     uuid.UUID
+	ChannelID   uuid.UUID
+	ExpiresAt   time.Time
+	MaxSessions int // 0 means unlimited
+}
+
+// SignShare returns a share link token for the claims.
+// Share tokens are not tied to a user, so they grant playback of one channel to anyone holding them.
+func (s *StreamTokenSigner) SignShare(claims *ShareTokenClaims) string {
+	payload := make([]byte, 0, shareTokenPayloadLen)
+	payload = append(payload, claims.ShareID[:]...)
+	payload = append(payload, claims.ChannelID[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(claims.ExpiresAt.Unix())) // nolint:gosec // expiry is always after 1970
+	payload = binary.BigEndian.AppendUint16(payload, uint16(claims.MaxSessions))      // nolint:gosec // capped at MaxShareSessions
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(shareTokenContext, payload))
+}
+
+// VerifyShare checks the share token signature, expiry and channel and returns its claims
+func (s *StreamTokenSigner) VerifyShare(token string, channelID uuid.UUID, now time.Time) (*ShareTokenClaims, error) {
+	payload, ok := s.open(shareTokenContext, token, shareTokenPayloadLen)
+	if !ok {
+		return nil, ErrInvalidShareToken
+	}
+
+	claims := &ShareTokenClaims{
+		ExpiresAt:   time.Unix(int64(binary.BigEndian.Uint64(payload[32:40])), 0).UTC(), // nolint:gosec // signed value we produced
+		MaxSessions: int(binary.BigEndian.Uint16(payload[40:])),
+	}
+	copy(claims.ShareID[:], payload[:16])
+	copy(claims.ChannelID[:], payload[16:32])
+
+	if claims.ChannelID != channelID {
+		return nil, ErrInvalidShareToken
+	}
+	if !now.Before(claims.ExpiresAt) {
+		return nil, ErrShareTokenExpired
+	}
+	return claims, nil
+}
+
+// shareSessions tracks the player sessions using each share link, to enforce session limits.
+// State is in memory only; after a restart every share link starts with no sessions.
+type shareSessions struct {
+	mu     sync.Mutex
+	shares map[uuid.UUID]*shareState
+}
+
+// shareState holds the sessions of one share link
+type shareState struct {
+	channelID uuid.UUID
+	expiresAt time.Time
+	lastSeen  map[string]time.Time // session ID -> last request
+}
+
+// newShareSessions creates an empty share session tracker
+func newShareSessions() *shareSessions {
+	return &shareSessions{shares: make(map[uuid.UUID]*shareState)}
+}
+
+// admit records a request from sessionID on a share link.
+// Known sessions are refreshed; new sessions are admitted only while the link has a free slot.
+// Idle sessions do not take a slot; they stay tracked until takeIdle hands them out.
+func (t *shareSessions) admit(claims *ShareTokenClaims, sessionID string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.shares[claims.ShareID]
+	if !ok {
+		state = &shareState{channelID: claims.ChannelID, expiresAt: claims.ExpiresAt, lastSeen: make(map[string]time.Time)}
+		t.shares[claims.ShareID] = state
+	}
+
+	if _, known := state.lastSeen[sessionID]; !known &&
+		claims.MaxSessions > 0 && state.activeSessions(now) >= claims.MaxSessions {
+		return ErrShareSessionLimit
+	}
+	state.lastSeen[sessionID] = now
+	return nil
+}
+
+// activeSessions counts the sessions that fetched within the idle timeout
+func (s *shareState) activeSessions(now time.Time) int {
+	active := 0
+	for _, seen := range s.lastSeen {
+		if now.Sub(seen) <= ShareSessionIdleTimeout {
+			active++
+		}
+	}
+	return active
+}
+
+// takeIdle stops tracking the sessions that went idle and the sessions of expired share links,
+// and returns their session IDs by channel ID
+func (t *shareSessions) takeIdle(now time.Time) map[uuid.UUID][]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	idle := make(map[uuid.UUID][]string)
+	for shareID, state := range t.shares {
+		expired := !now.Before(state.expiresAt)
+		for sessionID, seen := range state.lastSeen {
+			if expired || now.Sub(seen) > ShareSessionIdleTimeout {
+				idle[state.channelID] = append(idle[state.channelID], sessionID)
+				delete(state.lastSeen, sessionID)
+			}
+		}
+		if expired {
+			delete(t.shares, shareID)
+		}
+	}
+	return idle
+}
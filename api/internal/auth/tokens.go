@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Credential prefixes let Authenticate tell API keys from session tokens without a lookup
+const (
+	sessionTokenPrefix = "hs_"
+	apiKeyPrefix       = "hk_"
+
+	tokenBytes        = 32
+	apiKeyPrefixChars = len(apiKeyPrefix) + 8 // Characters of an API key kept for display
+)
+
+// generateToken returns a random URL-safe token with the given prefix and its storage hash
+func generateToken(prefix string) (token, hash string, err error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = prefix + base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex SHA-256 hash under which a token or API key is stored
+// Tokens are high-entropy random values, so a fast unsalted hash is sufficient
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
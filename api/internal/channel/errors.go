@@ -25,6 +25,18 @@ var (
 
 	// ErrEmptyPlaylist indicates the playlist has no items
 	ErrEmptyPlaylist = errors.New("playlist is empty")
+
+	// ErrInvalidSegmentFormat indicates the segment format is not ts or fmp4
+	ErrInvalidSegmentFormat = errors.New("segment format must be ts or fmp4")
+
+	// ErrInvalidEncryption indicates the encryption is not none or aes-128
+	ErrInvalidEncryption = errors.New("encryption must be none or aes-128")
+
+	// ErrEncryptionRequiresTS indicates AES-128 encryption was combined with fMP4 segments
+	ErrEncryptionRequiresTS = errors.New("aes-128 encryption requires ts segments")
+
+	// ErrInvalidAlwaysOnWindow indicates only one always-on window bound was set or a bound is not HH:MM
+	ErrInvalidAlwaysOnWindow = errors.New("always-on window needs both start and end as HH:MM")
 )
 
 // IsDuplicateName checks if the error is a duplicate channel name error
@@ -61,3 +73,18 @@ func IsInvalidPosition(err error) bool {
 func IsEmptyPlaylist(err error) bool {
 	return errors.Is(err, ErrEmptyPlaylist)
 }
+
+// IsInvalidSegmentFormat checks if the error is an invalid segment format error
+func IsInvalidSegmentFormat(err error) bool {
+	return errors.Is(err, ErrInvalidSegmentFormat)
+}
+
+// IsInvalidEncryption checks if the error is an invalid encryption error
+func IsInvalidEncryption(err error) bool {
+	return errors.Is(err, ErrInvalidEncryption) || errors.Is(err, ErrEncryptionRequiresTS)
+}
+
+// IsInvalidAlwaysOnWindow checks if the error is an invalid always-on window error
+func IsInvalidAlwaysOnWindow(err error) bool {
+	return errors.Is(err, ErrInvalidAlwaysOnWindow)
+}
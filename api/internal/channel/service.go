@@ -50,13 +50,15 @@ func (s *ChannelService) CreateChannel(ctx context.Context, name string, icon *s
 	// Create channel model
 	now := time.Now().UTC()
 	channel := &models.Channel{
-		ID:        uuid.New(),
-		Name:      name,
-		Icon:      icon,
-		StartTime: startTime.UTC(),
-		Loop:      loop,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:            uuid.New(),
+		Name:          name,
+		Icon:          icon,
+		StartTime:     startTime.UTC(),
+		Loop:          loop,
+		SegmentFormat: models.SegmentFormatTS,
+		Encryption:    models.EncryptionNone,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 
 	// Save to database
@@ -129,6 +131,29 @@ func (s *ChannelService) UpdateChannel(ctx context.Context, channel *models.Chan
 		}
 	}
 
+	// Callers that do not set a segment format keep the current one
+	if channel.SegmentFormat == "" {
+		channel.SegmentFormat = existing.SegmentFormat
+	}
+	if !models.IsValidSegmentFormat(channel.SegmentFormat) {
+		return fmt.Errorf("failed to update channel: %w", ErrInvalidSegmentFormat)
+	}
+
+	// Likewise for encryption; whole-segment AES-128 is only offered for MPEG-TS
+	if channel.Encryption == "" {
+		channel.Encryption = existing.Encryption
+	}
+	if !models.IsValidEncryption(channel.Encryption) {
+		return fmt.Errorf("failed to update channel: %w", ErrInvalidEncryption)
+	}
+	if channel.Encryption == models.EncryptionAES128 && channel.SegmentFormat != models.SegmentFormatTS {
+		return fmt.Errorf("failed to update channel: %w", ErrEncryptionRequiresTS)
+	}
+
+	if !models.IsValidAlwaysOnWindow(channel.AlwaysOnStart, channel.AlwaysOnEnd) {
+		return fmt.Errorf("failed to update channel: %w", ErrInvalidAlwaysOnWindow)
+	}
+
 	// Validate start time if changed
 	if !existing.StartTime.Equal(channel.StartTime) {
 		if err := s.validateStartTime(channel.StartTime); err != nil {
@@ -297,6 +297,118 @@ func TestUpdateChannel_InvalidStartTime(t *testing.T) {
 	assert.True(t, IsInvalidStartTime(err))
 }
 
+func TestUpdateChannel_SegmentFormat(t *testing.T) {
+	service, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// New channels default to MPEG-TS
+	channel, err := service.CreateChannel(ctx, "Test Channel", nil, time.Now().UTC(), true)
+	require.NoError(t, err)
+	assert.Equal(t, models.SegmentFormatTS, channel.SegmentFormat)
+
+	channel.SegmentFormat = models.SegmentFormatFMP4
+	require.NoError(t, service.UpdateChannel(ctx, channel))
+
+	updated, err := service.GetByID(ctx, channel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SegmentFormatFMP4, updated.SegmentFormat)
+
+	// Leaving the format empty keeps the current one
+	updated.SegmentFormat = ""
+	require.NoError(t, service.UpdateChannel(ctx, updated))
+	assert.Equal(t, models.SegmentFormatFMP4, updated.SegmentFormat)
+
+	updated.SegmentFormat = "webm"
+	err = service.UpdateChannel(ctx, updated)
+	require.Error(t, err)
+	assert.True(t, IsInvalidSegmentFormat(err))
+}
+
+func TestUpdateChannel_Encryption(t *testing.T) {
+	service, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// New channels are unencrypted
+	channel, err := service.CreateChannel(ctx, "Test Channel", nil, time.Now().UTC(), true)
+	require.NoError(t, err)
+	assert.Equal(t, models.EncryptionNone, channel.Encryption)
+
+	channel.Encryption = models.EncryptionAES128
+	require.NoError(t, service.UpdateChannel(ctx, channel))
+
+	updated, err := service.GetByID(ctx, channel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EncryptionAES128, updated.Encryption)
+
+	// Leaving encryption empty keeps the current one
+	updated.Encryption = ""
+	require.NoError(t, service.UpdateChannel(ctx, updated))
+	assert.Equal(t, models.EncryptionAES128, updated.Encryption)
+
+	updated.Encryption = "sample-aes"
+	err = service.UpdateChannel(ctx, updated)
+	require.Error(t, err)
+	assert.True(t, IsInvalidEncryption(err))
+
+	// Whole-segment encryption is only offered for MPEG-TS
+	updated.Encryption = models.EncryptionAES128
+	updated.SegmentFormat = models.SegmentFormatFMP4
+	err = service.UpdateChannel(ctx, updated)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrEncryptionRequiresTS)
+}
+
+func TestUpdateChannel_AlwaysOn(t *testing.T) {
+	service, _, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// New channels only stream on demand
+	channel, err := service.CreateChannel(ctx, "Living Room", nil, time.Now().UTC(), true)
+	require.NoError(t, err)
+	assert.False(t, channel.AlwaysOn)
+
+	start, end := "18:00", "23:30"
+	channel.AlwaysOn = true
+	channel.AlwaysOnStart, channel.AlwaysOnEnd = &start, &end
+	require.NoError(t, service.UpdateChannel(ctx, channel))
+
+	updated, err := service.GetByID(ctx, channel.ID)
+	require.NoError(t, err)
+	assert.True(t, updated.AlwaysOn)
+	require.NotNil(t, updated.AlwaysOnStart)
+	require.NotNil(t, updated.AlwaysOnEnd)
+	assert.Equal(t, start, *updated.AlwaysOnStart)
+	assert.Equal(t, end, *updated.AlwaysOnEnd)
+
+	// A window needs both bounds
+	updated.AlwaysOnEnd = nil
+	err = service.UpdateChannel(ctx, updated)
+	require.Error(t, err)
+	assert.True(t, IsInvalidAlwaysOnWindow(err))
+
+	invalid := "6pm"
+	updated.AlwaysOnEnd = &invalid
+	err = service.UpdateChannel(ctx, updated)
+	require.Error(t, err)
+	assert.True(t, IsInvalidAlwaysOnWindow(err))
+
+	// Clearing the window keeps the channel always on all day
+	updated.AlwaysOnStart, updated.AlwaysOnEnd = nil, nil
+	require.NoError(t, service.UpdateChannel(ctx, updated))
+
+	cleared, err := service.GetByID(ctx, channel.ID)
+	require.NoError(t, err)
+	assert.True(t, cleared.AlwaysOn)
+	assert.Nil(t, cleared.AlwaysOnStart)
+	assert.Nil(t, cleared.AlwaysOnEnd)
+}
+
 func TestUpdateChannel_NotFound(t *testing.T) {
 	service, _, cleanup := setupTestService(t)
 	defer cleanup()
@@ -5,6 +5,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 const (
 	defaultServerPort                   = 8080
 	defaultServerHost                   = "0.0.0.0"
+	defaultAllowedOrigin                = "http://localhost:3000" // Web UI dev server
 	defaultReadTimeout                  = 30 * time.Second
 	defaultWriteTimeout                 = 30 * time.Second
 	defaultDatabasePath                 = "./data/hermes.db"
@@ -34,6 +36,20 @@ const (
 	defaultStreamSegmentDuration        = 4
 	defaultStreamSegmentFilenamePattern = "seg-%Y%m%dT%H%M%S.ts"
 	defaultFPS                          = 30
+	defaultMaxConcurrentTranscodes      = 0 // Unlimited
+	defaultWeightedTranscodes           = false
+	defaultTranscodeQueueTimeout        = 0
+	defaultPartDuration                 = 1000 // Milliseconds
+	defaultKeyRotationSegments          = 15   // One minute of 4 second segments per key
+	defaultWarmChannels                 = 3
+	defaultWarmSeconds                  = 600 // Ten minutes
+	defaultCatchUpSeconds               = 0   // Off: catch-up keeps hours of segments on disk per rendition
+	defaultMetadataProvider             = "none"
+	defaultMediaThumbnailPath           = "./data/thumbnails"
+	defaultMediaGenerateSprites         = false
+	defaultAuthEnabled                  = false
+	defaultAuthSessionTTL               = 7 * 24 * time.Hour
+	defaultAuthStreamTokenTTL           = 12 * time.Hour
 	envPrefix                           = "HERMES"
 )
 
@@ -43,15 +59,18 @@ type Config struct {
 	Database  DatabaseConfig
 	Logging   LoggingConfig
 	Media     MediaConfig
+	Metadata  MetadataConfig
 	Streaming StreamingConfig
+	Auth      AuthConfig
 }
 
 // ServerConfig holds HTTP server configuration
 type ServerConfig struct {
-	Port         int
-	Host         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
+	Port           int
+	Host           string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	AllowedOrigins []string // CORS origins; empty is same-origin only, "*" allows all and is rejected when auth is enabled
 }
 
 // DatabaseConfig holds database connection configuration
@@ -71,22 +90,52 @@ type LoggingConfig struct {
 type MediaConfig struct {
 	LibraryPath      string
 	SupportedFormats []string
+	ThumbnailPath    string // Directory for generated thumbnails and sprite sheets
+	GenerateSprites  bool   // Generate scrubbing sprite sheets in addition to thumbnails
+}
+
+// MetadataConfig holds external metadata (EPG) provider configuration
+type MetadataConfig struct {
+	Provider     string // none, local, http
+	LocalPath    string // Path to JSON metadata dump (local provider)
+	BaseURL      string // API root for TMDB-style HTTP provider
+	APIKey       string // API key for HTTP provider
+	ImageBaseURL string // Prefix for relative artwork paths (HTTP provider)
+	Language     string // Optional language for HTTP provider, e.g. "en-US"
+}
+
+// AuthConfig holds API authentication configuration
+type AuthConfig struct {
+	Enabled        bool          // Require authentication for /api routes
+	TokenSecret    string        // HMAC secret for signed stream tokens; random per start when empty
+	SessionTTL     time.Duration // Lifetime of login sessions
+	StreamTokenTTL time.Duration // Default lifetime of signed stream tokens
 }
 
 // StreamingConfig holds video streaming configuration
 type StreamingConfig struct {
-	HardwareAccel                string // none, nvenc, qsv, vaapi, videotoolbox, auto
-	SegmentDuration              int    // HLS segment duration in seconds
-	PlaylistSize                 int    // Number of segments to keep in playlist
-	SegmentPath                  string // Directory for storing stream segments
-	GracePeriodSeconds           int    // Time to keep stream alive after last client disconnects
-	CleanupInterval              int    // How often to cleanup old segments in seconds
-	EncodingPreset               string // FFmpeg encoding preset (ultrafast, veryfast, medium, slow)
-	BatchSize                    int    // Number of segments per batch (default: 20)
-	TriggerThreshold             int    // Generate next batch when N segments remain (default: 5)
-	StreamSegmentDuration        int    // Stream segment duration in seconds (default: 4)
-	StreamSegmentFilenamePattern string // Filename pattern for stream segments with strftime (default: seg-%Y%m%dT%H%M%S.ts)
-	FPS                          int    // Frames per second for GOP calculations (default: 30)
+	HardwareAccel                string            // none, nvenc, qsv, vaapi, videotoolbox, auto
+	SegmentDuration              int               // HLS segment duration in seconds
+	PlaylistSize                 int               // Number of segments to keep in playlist
+	SegmentPath                  string            // Directory for storing stream segments
+	GracePeriodSeconds           int               // Time to keep stream alive after last client disconnects
+	CleanupInterval              int               // How often to cleanup old segments in seconds
+	EncodingPreset               string            // FFmpeg encoding preset (ultrafast, veryfast, medium, slow)
+	BatchSize                    int               // Number of segments per batch (default: 20)
+	TriggerThreshold             int               // Generate next batch when N segments remain (default: 5)
+	StreamSegmentDuration        int               // Stream segment duration in seconds (default: 4)
+	StreamSegmentFilenamePattern string            // Filename pattern for stream segments with strftime (default: seg-%Y%m%dT%H%M%S.ts)
+	FPS                          int               // Frames per second for GOP calculations (default: 30)
+	MaxConcurrentTranscodes      int               // Transcode budget: max concurrent FFmpeg processes (0 = unlimited)
+	WeightedTranscodes           bool              // Weight the budget by quality and hardware vs. software encode
+	TranscodeQueueTimeout        int               // Seconds a new stream waits for budget before being rejected (0 = reject immediately)
+	PartDuration                 int               // Low-Latency HLS part duration in milliseconds for fMP4 streams (0 = disabled, default: 1000)
+	Codecs                       map[string]string // Video codec per quality rung, e.g. {"2160p": "hevc"} (h264, hevc, av1; unlisted rungs use h264)
+	KeyRotationSegments          int               // Segments encrypted with each AES-128 key before it rotates (0 = default: 15)
+	KeySecret                    string            // HMAC secret segment encryption keys are derived from; random per start when empty
+	WarmChannels                 int               // Recently watched channels kept warm at the lowest rung after the grace period (0 = disabled, default: 3)
+	WarmSeconds                  int               // How long a channel stays warm after its last client left (default: 600)
+	CatchUpSeconds               int               // How long segments are kept for start-over and catch-up playlists (0 = disabled, default: 0)
 }
 
 // Load reads configuration from .env file, config files, environment variables, and defaults
@@ -142,6 +191,7 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.host", defaultServerHost)
 	v.SetDefault("server.readtimeout", defaultReadTimeout)
 	v.SetDefault("server.writetimeout", defaultWriteTimeout)
+	v.SetDefault("server.allowedorigins", []string{defaultAllowedOrigin})
 
 	// Database defaults
 	v.SetDefault("database.path", defaultDatabasePath)
@@ -154,6 +204,17 @@ func setDefaults(v *viper.Viper) {
 
 	// Media defaults
 	v.SetDefault("media.supportedformats", []string{"mp4", "mkv", "avi", "mov"})
+	v.SetDefault("media.thumbnailpath", defaultMediaThumbnailPath)
+	v.SetDefault("media.generatesprites", defaultMediaGenerateSprites)
+
+	// Metadata defaults
+	v.SetDefault("metadata.provider", defaultMetadataProvider)
+
+	// Auth defaults
+	v.SetDefault("auth.enabled", defaultAuthEnabled)
+	v.SetDefault("auth.tokensecret", "")
+	v.SetDefault("auth.sessionttl", defaultAuthSessionTTL)
+	v.SetDefault("auth.streamtokenttl", defaultAuthStreamTokenTTL)
 
 	// Streaming defaults
 	v.SetDefault("streaming.hardwareaccel", defaultStreamingHardwareAccel)
@@ -168,6 +229,15 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("streaming.streamsegmentduration", defaultStreamSegmentDuration)
 	v.SetDefault("streaming.streamsegmentfilenamepattern", defaultStreamSegmentFilenamePattern)
 	v.SetDefault("streaming.fps", defaultFPS)
+	v.SetDefault("streaming.maxconcurrenttranscodes", defaultMaxConcurrentTranscodes)
+	v.SetDefault("streaming.weightedtranscodes", defaultWeightedTranscodes)
+	v.SetDefault("streaming.transcodequeuetimeout", defaultTranscodeQueueTimeout)
+	v.SetDefault("streaming.partduration", defaultPartDuration)
+	v.SetDefault("streaming.keyrotationsegments", defaultKeyRotationSegments)
+	v.SetDefault("streaming.keysecret", "")
+	v.SetDefault("streaming.warmchannels", defaultWarmChannels)
+	v.SetDefault("streaming.warmseconds", defaultWarmSeconds)
+	v.SetDefault("streaming.catchupseconds", defaultCatchUpSeconds)
 }
 
 // Validate checks that configuration values are valid
@@ -252,12 +322,93 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid FPS: %d (must be > 0)", c.Streaming.FPS)
 	}
 
+	// Validate transcode budget (0 disables the limit / queueing)
+	if c.Streaming.MaxConcurrentTranscodes < 0 {
+		return fmt.Errorf("invalid max concurrent transcodes: %d (must be >= 0)", c.Streaming.MaxConcurrentTranscodes)
+	}
+
+	if c.Streaming.TranscodeQueueTimeout < 0 {
+		return fmt.Errorf("invalid transcode queue timeout: %d (must be >= 0)", c.Streaming.TranscodeQueueTimeout)
+	}
+
+	// Validate Low-Latency HLS parts (0 disables them); a part must be shorter than a segment
+	if c.Streaming.PartDuration < 0 || c.Streaming.PartDuration >= c.Streaming.StreamSegmentDuration*1000 {
+		return fmt.Errorf("invalid part duration: %dms (must be >= 0 and shorter than the %ds stream segment duration)", c.Streaming.PartDuration, c.Streaming.StreamSegmentDuration)
+	}
+
+	// Validate segment encryption key rotation (0 falls back to the default in the streaming package)
+	if c.Streaming.KeyRotationSegments < 0 {
+		return fmt.Errorf("invalid key rotation segments: %d (must be >= 0)", c.Streaming.KeyRotationSegments)
+	}
+
+	// Validate warm channel buffers
+	if c.Streaming.WarmChannels < 0 {
+		return fmt.Errorf("invalid warm channels: %d (must be >= 0)", c.Streaming.WarmChannels)
+	}
+	if c.Streaming.WarmSeconds < 0 {
+		return fmt.Errorf("invalid warm seconds: %d (must be >= 0)", c.Streaming.WarmSeconds)
+	}
+
+	// Validate catch-up retention
+	if c.Streaming.CatchUpSeconds < 0 {
+		return fmt.Errorf("invalid catch-up seconds: %d (must be >= 0)", c.Streaming.CatchUpSeconds)
+	}
+
+	// Validate per-rung video codecs
+	validQualities := []string{"2160p", "1080p", "720p", "480p"}
+	validCodecs := []string{"h264", "hevc", "av1"}
+	for quality, codec := range c.Streaming.Codecs {
+		if !contains(validQualities, quality) {
+			return fmt.Errorf("invalid codec quality: %s (must be one of: %s)", quality, strings.Join(validQualities, ", "))
+		}
+		if !contains(validCodecs, codec) {
+			return fmt.Errorf("invalid codec for %s: %s (must be one of: %s)", quality, codec, strings.Join(validCodecs, ", "))
+		}
+	}
+
+	// Validate metadata provider configuration (empty means none)
+	validProviders := []string{"none", "local", "http"}
+	if c.Metadata.Provider != "" && !contains(validProviders, c.Metadata.Provider) {
+		return fmt.Errorf("invalid metadata provider: %s (must be one of: %s)", c.Metadata.Provider, strings.Join(validProviders, ", "))
+	}
+
+	if c.Metadata.Provider == "local" && c.Metadata.LocalPath == "" {
+		return fmt.Errorf("metadata local path is required when provider is local")
+	}
+
+	if c.Metadata.Provider == "http" && c.Metadata.BaseURL == "" {
+		return fmt.Errorf("metadata base URL is required when provider is http")
+	}
+
+	// Validate auth configuration
+	// Zero TTLs fall back to the defaults in the auth package
+	if c.Auth.SessionTTL < 0 {
+		return fmt.Errorf("invalid session TTL: %v (must be >= 0)", c.Auth.SessionTTL)
+	}
+
+	if c.Auth.StreamTokenTTL < 0 {
+		return fmt.Errorf("invalid stream token TTL: %v (must be >= 0)", c.Auth.StreamTokenTTL)
+	}
+
+	if c.Auth.Enabled && contains(c.Server.AllowedOrigins, "*") {
+		return fmt.Errorf("server allowed origins must not include \"*\" when auth is enabled")
+	}
+
 	// Database path validation will be done when opening DB
 	// Media library path is optional at this stage (will be required when media features are implemented)
 
 	return nil
 }
 
+// IsLoopback reports whether the server only listens on a loopback address
+func (s ServerConfig) IsLoopback() bool {
+	if s.Host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(s.Host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // contains checks if a string slice contains a specific value
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestConfigDefaults(t *testing.T) {
@@ -28,6 +29,9 @@ func TestConfigDefaults(t *testing.T) {
 	if cfg.Server.Host != defaultServerHost {
 		t.Errorf("Server.Host = %s, want %s", cfg.Server.Host, defaultServerHost)
 	}
+	if len(cfg.Server.AllowedOrigins) != 1 || cfg.Server.AllowedOrigins[0] != defaultAllowedOrigin {
+		t.Errorf("Server.AllowedOrigins = %v, want [%s]", cfg.Server.AllowedOrigins, defaultAllowedOrigin)
+	}
 
 	// Test database defaults
 	if cfg.Database.Path != defaultDatabasePath {
@@ -79,6 +83,34 @@ func TestConfigDefaults(t *testing.T) {
 	if cfg.Streaming.FPS != defaultFPS {
 		t.Errorf("Streaming.FPS = %d, want %d", cfg.Streaming.FPS, defaultFPS)
 	}
+	if cfg.Streaming.PartDuration != defaultPartDuration {
+		t.Errorf("Streaming.PartDuration = %d, want %d", cfg.Streaming.PartDuration, defaultPartDuration)
+	}
+	if cfg.Streaming.KeyRotationSegments != defaultKeyRotationSegments {
+		t.Errorf("Streaming.KeyRotationSegments = %d, want %d", cfg.Streaming.KeyRotationSegments, defaultKeyRotationSegments)
+	}
+	if cfg.Streaming.WarmChannels != defaultWarmChannels {
+		t.Errorf("Streaming.WarmChannels = %d, want %d", cfg.Streaming.WarmChannels, defaultWarmChannels)
+	}
+	if cfg.Streaming.WarmSeconds != defaultWarmSeconds {
+		t.Errorf("Streaming.WarmSeconds = %d, want %d", cfg.Streaming.WarmSeconds, defaultWarmSeconds)
+	}
+	if cfg.Streaming.CatchUpSeconds != defaultCatchUpSeconds {
+		t.Errorf("Streaming.CatchUpSeconds = %d, want %d", cfg.Streaming.CatchUpSeconds, defaultCatchUpSeconds)
+	}
+
+	// Test media defaults
+	if cfg.Media.ThumbnailPath != defaultMediaThumbnailPath {
+		t.Errorf("Media.ThumbnailPath = %s, want %s", cfg.Media.ThumbnailPath, defaultMediaThumbnailPath)
+	}
+	if cfg.Media.GenerateSprites != defaultMediaGenerateSprites {
+		t.Errorf("Media.GenerateSprites = %v, want %v", cfg.Media.GenerateSprites, defaultMediaGenerateSprites)
+	}
+
+	// Test metadata defaults
+	if cfg.Metadata.Provider != defaultMetadataProvider {
+		t.Errorf("Metadata.Provider = %s, want %s", cfg.Metadata.Provider, defaultMetadataProvider)
+	}
 }
 
 func TestConfigValidation(t *testing.T) {
@@ -681,6 +713,237 @@ func TestStreamingConfigEnvVars(t *testing.T) {
 	}
 }
 
+// validTestConfig returns a minimal configuration that passes Validate
+func validTestConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:         8080,
+			Host:         "0.0.0.0",
+			ReadTimeout:  defaultReadTimeout,
+			WriteTimeout: defaultWriteTimeout,
+		},
+		Database: DatabaseConfig{
+			Path:              "./data/hermes.db",
+			ConnectionTimeout: defaultDatabaseConnectionTimeout,
+		},
+		Logging: LoggingConfig{
+			Level: "info",
+		},
+		Streaming: StreamingConfig{
+			HardwareAccel:                "auto",
+			SegmentDuration:              6,
+			PlaylistSize:                 10,
+			SegmentPath:                  "./data/streams",
+			GracePeriodSeconds:           30,
+			CleanupInterval:              60,
+			EncodingPreset:               "ultrafast",
+			BatchSize:                    20,
+			TriggerThreshold:             5,
+			StreamSegmentDuration:        4,
+			StreamSegmentFilenamePattern: "seg-%Y%m%dT%H%M%S.ts",
+			FPS:                          30,
+		},
+	}
+}
+
+func TestMetadataConfigValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata MetadataConfig
+		wantErr  bool
+	}{
+		{name: "empty provider", metadata: MetadataConfig{}, wantErr: false},
+		{name: "none provider", metadata: MetadataConfig{Provider: "none"}, wantErr: false},
+		{name: "local with path", metadata: MetadataConfig{Provider: "local", LocalPath: "./metadata.json"}, wantErr: false},
+		{name: "local without path", metadata: MetadataConfig{Provider: "local"}, wantErr: true},
+		{name: "http with base url", metadata: MetadataConfig{Provider: "http", BaseURL: "https://api.example.com/3"}, wantErr: false},
+		{name: "http without base url", metadata: MetadataConfig{Provider: "http"}, wantErr: true},
+		{name: "unknown provider", metadata: MetadataConfig{Provider: "imdb"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg.Metadata = tt.metadata
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPartDurationValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		partDuration int
+		wantErr      bool
+	}{
+		{name: "disabled", partDuration: 0, wantErr: false},
+		{name: "one second", partDuration: 1000, wantErr: false},
+		{name: "negative", partDuration: -1, wantErr: true},
+		{name: "as long as a segment", partDuration: 4000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg.Streaming.PartDuration = tt.partDuration
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRotationSegmentsValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments int
+		wantErr  bool
+	}{
+		{name: "default", segments: 0, wantErr: false},
+		{name: "every segment", segments: 1, wantErr: false},
+		{name: "negative", segments: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg.Streaming.KeyRotationSegments = tt.segments
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWarmChannelsValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		warmChannels int
+		warmSeconds  int
+		wantErr      bool
+	}{
+		{name: "disabled", warmChannels: 0, warmSeconds: 600, wantErr: false},
+		{name: "three channels", warmChannels: 3, warmSeconds: 600, wantErr: false},
+		{name: "negative channels", warmChannels: -1, warmSeconds: 600, wantErr: true},
+		{name: "negative seconds", warmChannels: 3, warmSeconds: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg.Streaming.WarmChannels = tt.warmChannels
+			cfg.Streaming.WarmSeconds = tt.warmSeconds
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCatchUpSecondsValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		catchUpSeconds int
+		wantErr        bool
+	}{
+		{name: "disabled", catchUpSeconds: 0, wantErr: false},
+		{name: "three hours", catchUpSeconds: 10800, wantErr: false},
+		{name: "negative", catchUpSeconds: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg.Streaming.CatchUpSeconds = tt.catchUpSeconds
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCodecsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		codecs  map[string]string
+		wantErr bool
+	}{
+		{name: "unset", codecs: nil, wantErr: false},
+		{name: "per rung", codecs: map[string]string{"2160p": "hevc", "1080p": "av1", "480p": "h264"}, wantErr: false},
+		{name: "unknown quality", codecs: map[string]string{"1440p": "hevc"}, wantErr: true},
+		{name: "unknown codec", codecs: map[string]string{"2160p": "vp9"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg.Streaming.Codecs = tt.codecs
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    AuthConfig
+		origins []string
+		wantErr bool
+	}{
+		{name: "disabled allows any origin", auth: AuthConfig{}, origins: []string{"*"}, wantErr: false},
+		{name: "enabled with explicit origins", auth: AuthConfig{Enabled: true}, origins: []string{"https://tv.example.com"}, wantErr: false},
+		{name: "enabled with wildcard origin", auth: AuthConfig{Enabled: true}, origins: []string{"*"}, wantErr: true},
+		{name: "enabled same-origin only", auth: AuthConfig{Enabled: true}, origins: nil, wantErr: false},
+		{name: "negative session ttl", auth: AuthConfig{SessionTTL: -time.Hour}, wantErr: true},
+		{name: "negative stream token ttl", auth: AuthConfig{StreamTokenTTL: -time.Hour}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg.Auth = tt.auth
+			cfg.Server.AllowedOrigins = tt.origins
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServerConfigIsLoopback(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "localhost", want: true},
+		{host: "127.0.0.1", want: true},
+		{host: "::1", want: true},
+		{host: "0.0.0.0", want: false},
+		{host: "", want: false},
+		{host: "192.168.1.10", want: false},
+		{host: "hermes.example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := (ServerConfig{Host: tt.host}).IsLoopback(); got != tt.want {
+				t.Errorf("IsLoopback() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestContains(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// APIKeyRepository handles database operations for API keys
+type APIKeyRepository struct {
+	db *DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create inserts a new API key into the database
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	result := r.db.WithContext(ctx).Create(key)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create api key: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// GetByHash retrieves an API key by the hash of its plaintext value
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	result := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key)
+	if result.Error != nil {
+		return nil, MapGormError(result.Error)
+	}
+	return &key, nil
+}
+
+// ListByUser retrieves all API keys of a user (newest first)
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	result := r.db.WithContext(ctx).
+		Where("user_id = ?", userID.String()).
+		Order("created_at DESC").
+		Find(&keys)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", MapGormError(result.Error))
+	}
+	return keys, nil
+}
+
+// TouchLastUsed records that the key was just used
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ?", id.String()).
+		Update("last_used_at", usedAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update api key usage: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// Delete deletes an API key owned by the given user
+func (r *APIKeyRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id.String(), userID.String()).
+		Delete(&models.APIKey{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete api key: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
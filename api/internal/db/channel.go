@@ -57,7 +57,8 @@ func (r *ChannelRepository) Update(ctx context.Context, channel *models.Channel)
 	// Use Select to explicitly update all fields including zero values
 	result := r.db.WithContext(ctx).
 		Where("id = ?", channel.ID.String()).
-		Select("name", "icon", "start_time", "loop", "updated_at").
+		Select("name", "icon", "start_time", "loop", "segment_format", "encryption",
+			"always_on", "always_on_start", "always_on_end", "updated_at").
 		Updates(channel)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update channel: %w", MapGormError(result.Error))
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// userGroupsSubquery selects the groups a user belongs to
+const userGroupsSubquery = "SELECT group_id FROM user_group_members WHERE user_id = ?"
+
+// ChannelAccessRepository handles database operations for channel access grants
+type ChannelAccessRepository struct {
+	db *DB
+}
+
+// NewChannelAccessRepository creates a new channel access repository
+func NewChannelAccessRepository(db *DB) *ChannelAccessRepository {
+	return &ChannelAccessRepository{db: db}
+}
+
+// Grant inserts a new channel access grant
+func (r *ChannelAccessRepository) Grant(ctx context.Context, access *models.ChannelAccess) error {
+	result := r.db.WithContext(ctx).Create(access)
+	if result.Error != nil {
+		return fmt.Errorf("failed to grant channel access: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// Revoke deletes a grant by its UUID, scoped to the channel
+func (r *ChannelAccessRepository) Revoke(ctx context.Context, channelID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND channel_id = ?", id.String(), channelID.String()).
+		Delete(&models.ChannelAccess{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke channel access: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListByChannel retrieves the grants of a channel
+func (r *ChannelAccessRepository) ListByChannel(ctx context.Context, channelID uuid.UUID) ([]*models.ChannelAccess, error) {
+	var grants []*models.ChannelAccess
+	result := r.db.WithContext(ctx).
+		Where("channel_id = ?", channelID.String()).
+		Order("created_at ASC").
+		Find(&grants)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list channel access: %w", MapGormError(result.Error))
+	}
+	return grants, nil
+}
+
+// HasAccess reports whether a user is granted a channel directly or through a group
+func (r *ChannelAccessRepository) HasAccess(ctx context.Context, channelID, userID uuid.UUID) (bool, error) {
+	var count int64
+	result := r.db.WithContext(ctx).
+		Model(&models.ChannelAccess{}).
+		Where("channel_id = ?", channelID.String()).
+		Where("user_id = ? OR group_id IN ("+userGroupsSubquery+")", userID.String(), userID.String()).
+		Count(&count)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to check channel access: %w", MapGormError(result.Error))
+	}
+	return count > 0, nil
+}
+
+// ChannelIDsForUser returns the channels a user is granted directly or through a group
+func (r *ChannelAccessRepository) ChannelIDsForUser(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]bool, error) {
+	var ids []string
+	result := r.db.WithContext(ctx).
+		Model(&models.ChannelAccess{}).
+		Distinct("channel_id").
+		Where("user_id = ? OR group_id IN ("+userGroupsSubquery+")", userID.String(), userID.String()).
+		Pluck("channel_id", &ids)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list accessible channels: %w", MapGormError(result.Error))
+	}
+
+	channels := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel id %q in channel_access: %w", id, err)
+		}
+		channels[parsed] = true
+	}
+	return channels, nil
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// GroupRepository handles database operations for user groups and their members
+type GroupRepository struct {
+	db *DB
+}
+
+// NewGroupRepository creates a new group repository
+func NewGroupRepository(db *DB) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+// Create inserts a new group into the database
+func (r *GroupRepository) Create(ctx context.Context, group *models.UserGroup) error {
+	result := r.db.WithContext(ctx).Create(group)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create group: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// GetByID retrieves a group by its UUID
+func (r *GroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.UserGroup, error) {
+	var group models.UserGroup
+	result := r.db.WithContext(ctx).Where("id = ?", id.String()).First(&group)
+	if result.Error != nil {
+		return nil, MapGormError(result.Error)
+	}
+	return &group, nil
+}
+
+// List retrieves all groups ordered by name
+func (r *GroupRepository) List(ctx context.Context) ([]*models.UserGroup, error) {
+	var groups []*models.UserGroup
+	result := r.db.WithContext(ctx).Order("name ASC").Find(&groups)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", MapGormError(result.Error))
+	}
+	return groups, nil
+}
+
+// Delete deletes a group by its UUID (cascade delete to memberships and channel grants)
+func (r *GroupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id.String()).Delete(&models.UserGroup{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete group: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddMember adds a user to a group
+func (r *GroupRepository) AddMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	member := &models.UserGroupMember{GroupID: groupID, UserID: userID}
+	result := r.db.WithContext(ctx).Create(member)
+	if result.Error != nil {
+		return fmt.Errorf("failed to add group member: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// RemoveMember removes a user from a group
+func (r *GroupRepository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("group_id = ? AND user_id = ?", groupID.String(), userID.String()).
+		Delete(&models.UserGroupMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove group member: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListMembers retrieves the users in a group ordered by username
+func (r *GroupRepository) ListMembers(ctx context.Context, groupID uuid.UUID) ([]*models.User, error) {
+	var users []*models.User
+	result := r.db.WithContext(ctx).
+		Joins("JOIN user_group_members ON user_group_members.user_id = users.id").
+		Where("user_group_members.group_id = ?", groupID.String()).
+		Order("users.username ASC").
+		Find(&users)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", MapGormError(result.Error))
+	}
+	return users, nil
+}
@@ -124,6 +124,13 @@ func (r *MediaRepository) Update(ctx context.Context, media *models.Media) error
 		"audio_codec": media.AudioCodec,
 		"resolution":  media.Resolution,
 		"file_size":   media.FileSize,
+		"hdr":         media.HDR,
+		"interlaced":  media.Interlaced,
+	}
+
+	// Description is user/provider supplied; scans never set it, so only overwrite when provided
+	if media.Description != nil {
+		updates["description"] = media.Description
 	}
 
 	result := r.db.WithContext(ctx).Model(&models.Media{}).Where("id = ?", media.ID.String()).Updates(updates)
@@ -136,6 +143,24 @@ func (r *MediaRepository) Update(ctx context.Context, media *models.Media) error
 	return nil
 }
 
+// UpdateArtwork sets the generated thumbnail and sprite sheet paths for a media item
+// Nil values clear the corresponding column
+func (r *MediaRepository) UpdateArtwork(ctx context.Context, id uuid.UUID, thumbnailPath, spritePath *string) error {
+	updates := map[string]interface{}{
+		"thumbnail_path": thumbnailPath,
+		"sprite_path":    spritePath,
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.Media{}).Where("id = ?", id.String()).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update media artwork: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // Delete deletes a media item by its UUID
 func (r *MediaRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	result := r.db.WithContext(ctx).Where("id = ?", id.String()).Delete(&models.Media{})
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stwalsh4118/hermes/internal/models"
+	"gorm.io/gorm"
+)
+
+// MediaSortField identifies a supported sort order for media searches
+type MediaSortField string
+
+// Supported media sort fields
+const (
+	MediaSortCreatedAt MediaSortField = "created_at"
+	MediaSortTitle     MediaSortField = "title"
+	MediaSortDuration  MediaSortField = "duration"
+	MediaSortFileSize  MediaSortField = "file_size"
+	MediaSortEpisode   MediaSortField = "episode"   // show, season, episode with unknowns last
+	MediaSortRelevance MediaSortField = "relevance" // best match first; requires a query and FTS5
+)
+
+// ErrRelevanceUnavailable is returned for relevance sorting without an FTS5 index to rank matches:
+// FTS5 is only compiled in with the sqlite_fts5 build tag
+var ErrRelevanceUnavailable = errors.New("relevance sorting requires the FTS5 search index (build with -tags sqlite_fts5)")
+
+// episodeOrder sorts by show/season/episode using COALESCE to put NULLs last
+const episodeOrder = "COALESCE(media.show_name, '~') %[1]s, COALESCE(media.season, 9999999) %[1]s, COALESCE(media.episode, 9999999) %[1]s"
+
+// mediaSortColumns maps simple sort fields to their SQL expressions
+var mediaSortColumns = map[MediaSortField]string{
+	MediaSortCreatedAt: "media.created_at",
+	MediaSortTitle:     "media.title COLLATE NOCASE",
+	MediaSortDuration:  "media.duration",
+	MediaSortFileSize:  "COALESCE(media.file_size, 0)",
+}
+
+// IsValidMediaSortField reports whether field is a supported sort field
+func IsValidMediaSortField(field MediaSortField) bool {
+	if _, ok := mediaSortColumns[field]; ok {
+		return true
+	}
+	return field == MediaSortEpisode || field == MediaSortRelevance
+}
+
+// SortOrder is an explicit sort direction
+type SortOrder string
+
+// Sort directions
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// MediaFilter describes a media library search. Zero values mean "no filter".
+type MediaFilter struct {
+	Query        string         // Free-text search over title, show name and description
+	ShowName     string         // Exact show name
+	Season       *int           // Season number
+	VideoCodec   string         // Case-insensitive codec name, e.g. "h264"
+	AudioCodec   string         // Case-insensitive codec name, e.g. "aac"
+	Resolution   string         // "1920x1080" (exact) or "1080p" (by height)
+	MinDuration  int64          // Minimum duration in seconds
+	MaxDuration  int64          // Maximum duration in seconds
+	NotInChannel bool           // Only media not referenced by any channel playlist
+	Status       string         // models.MediaStatusAvailable or models.MediaStatusMissing
+	SortBy       MediaSortField // Defaults to relevance (with query; title without FTS5), episode (with show) or created_at
+	SortOrder    SortOrder      // Defaults to descending for created_at, ascending otherwise
+	Limit        int            // 0 = no limit
+	Offset       int
+}
+
+// Search returns media matching the filter along with the total match count (ignoring limit/offset)
+func (r *MediaRepository) Search(ctx context.Context, filter MediaFilter) ([]*models.Media, int64, error) {
+	engine, err := r.searchEngine(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.SortBy == MediaSortRelevance && engine != SearchEngineFTS5 {
+		return nil, 0, ErrRelevanceUnavailable
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.Media{})
+
+	matchQuery := buildMatchQuery(filter.Query)
+	hasScore := false
+	if matchQuery != "" {
+		switch engine {
+		case SearchEngineFTS5:
+			query = query.Joins(
+				"JOIN (SELECT media_id, bm25(media_fts) AS score FROM media_fts WHERE media_fts MATCH ?) AS fts ON fts.media_id = media.id",
+				matchQuery,
+			)
+			hasScore = true
+		case SearchEngineFTS4:
+			query = query.Joins(
+				"JOIN (SELECT media_id FROM media_fts WHERE media_fts MATCH ?) AS fts ON fts.media_id = media.id",
+				matchQuery,
+			)
+		default:
+			query = applyLikeSearch(query, filter.Query)
+		}
+	}
+
+	// Session makes the filtered query safe to reuse for both the count and the page
+	query = applyMediaFilters(query, &filter).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count media search results: %w", MapGormError(err))
+	}
+
+	query = query.Select("media.*").Order(mediaSearchOrder(&filter, matchQuery != "", hasScore))
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var mediaList []*models.Media
+	if err := query.Find(&mediaList).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to search media: %w", MapGormError(err))
+	}
+
+	return mediaList, total, nil
+}
+
+// searchEngine reports which full-text engine is available for media searches
+func (r *MediaRepository) searchEngine(ctx context.Context) (SearchEngine, error) {
+	sqlDB, err := r.db.GetSQLDB()
+	if err != nil {
+		return SearchEngineNone, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	return detectSearchEngine(ctx, sqlDB)
+}
+
+// applyMediaFilters adds WHERE clauses for every set filter field
+func applyMediaFilters(query *gorm.DB, filter *MediaFilter) *gorm.DB {
+	if filter.ShowName != "" {
+		query = query.Where("media.show_name = ?", filter.ShowName)
+	}
+	if filter.Season != nil {
+		query = query.Where("media.season = ?", *filter.Season)
+	}
+	if filter.VideoCodec != "" {
+		query = query.Where("LOWER(media.video_codec) = ?", strings.ToLower(filter.VideoCodec))
+	}
+	if filter.AudioCodec != "" {
+		query = query.Where("LOWER(media.audio_codec) = ?", strings.ToLower(filter.AudioCodec))
+	}
+	if filter.Resolution != "" {
+		// "1080p" style values match on height, anything else must match exactly
+		if height, ok := strings.CutSuffix(strings.ToLower(filter.Resolution), "p"); ok {
+			query = query.Where("media.resolution LIKE ?", "%x"+height)
+		} else {
+			query = query.Where("media.resolution = ?", filter.Resolution)
+		}
+	}
+	if filter.MinDuration > 0 {
+		query = query.Where("media.duration >= ?", filter.MinDuration)
+	}
+	if filter.MaxDuration > 0 {
+		query = query.Where("media.duration <= ?", filter.MaxDuration)
+	}
+	if filter.Status != "" {
+		query = query.Where("media.status = ?", filter.Status)
+	}
+	if filter.NotInChannel {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM playlist_items WHERE playlist_items.media_id = media.id)")
+	}
+	return query
+}
+
+// applyLikeSearch is the fallback when no full-text index is available
+func applyLikeSearch(query *gorm.DB, input string) *gorm.DB {
+	for _, word := range strings.Fields(input) {
+		pattern := "%" + word + "%"
+		query = query.Where(
+			"(media.title LIKE ? OR media.show_name LIKE ? OR media.description LIKE ?)",
+			pattern, pattern, pattern,
+		)
+	}
+	return query
+}
+
+// mediaSearchOrder builds the ORDER BY clause for a search
+func mediaSearchOrder(filter *MediaFilter, hasQuery, hasScore bool) string {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		switch {
+		case hasScore:
+			sortBy = MediaSortRelevance
+		case hasQuery:
+			sortBy = MediaSortTitle // No ranking available (FTS4/LIKE)
+		case filter.ShowName != "":
+			sortBy = MediaSortEpisode
+		default:
+			sortBy = MediaSortCreatedAt
+		}
+	}
+
+	// Newest first by default; everything else reads naturally ascending
+	desc := sortBy == MediaSortCreatedAt
+	if filter.SortOrder != "" {
+		desc = filter.SortOrder == SortDesc
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	switch sortBy {
+	case MediaSortRelevance:
+		if hasScore {
+			// bm25 scores are negative; lower is a better match
+			return fmt.Sprintf("fts.score %s, media.title COLLATE NOCASE ASC", direction)
+		}
+		// Relevance without a query has nothing to rank; use a stable, readable order
+		return fmt.Sprintf("media.title COLLATE NOCASE %s", direction)
+	case MediaSortEpisode:
+		return fmt.Sprintf(episodeOrder, direction)
+	default:
+		return fmt.Sprintf("%s %s, media.id ASC", mediaSortColumns[sortBy], direction)
+	}
+}
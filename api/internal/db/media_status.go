@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"gorm.io/gorm"
+)
+
+// statusUpdateBatchSize keeps IN (...) lists well under SQLite's bound parameter limit
+const statusUpdateBatchSize = 500
+
+// MediaFileStatus is the minimal view of a media row needed to check its file on disk
+type MediaFileStatus struct {
+	ID       uuid.UUID
+	FilePath string
+	Status   string
+}
+
+// ListFileStatuses returns the id, path and status of every media item under dirPath.
+// An empty dirPath returns the whole library.
+func (r *MediaRepository) ListFileStatuses(ctx context.Context, dirPath string) ([]*MediaFileStatus, error) {
+	query := r.db.WithContext(ctx).Model(&models.Media{}).Select("id, file_path, status")
+
+	if dirPath != "" {
+		prefix := filepath.Clean(dirPath)
+		if prefix[len(prefix)-1] != os.PathSeparator {
+			prefix += string(os.PathSeparator)
+		}
+		// substr counts characters, not bytes; avoids LIKE wildcard escaping
+		query = query.Where("substr(file_path, 1, ?) = ?", utf8.RuneCountInString(prefix), prefix)
+	}
+
+	var statuses []*MediaFileStatus
+	if err := query.Order("file_path ASC").Scan(&statuses).Error; err != nil {
+		return nil, fmt.Errorf("failed to list media file statuses: %w", MapGormError(err))
+	}
+	return statuses, nil
+}
+
+// ListMissing returns every media item whose file is marked missing, oldest first
+func (r *MediaRepository) ListMissing(ctx context.Context) ([]*models.Media, error) {
+	var mediaList []*models.Media
+	result := r.db.WithContext(ctx).
+		Where("status = ?", models.MediaStatusMissing).
+		Order("missing_since ASC, file_path ASC").
+		Find(&mediaList)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list missing media: %w", MapGormError(result.Error))
+	}
+	return mediaList, nil
+}
+
+// SetStatus sets the availability status of the given media items and returns how many changed.
+// Items already in the requested status are left untouched so missing_since is preserved.
+func (r *MediaRepository) SetStatus(ctx context.Context, ids []uuid.UUID, status string) (int64, error) {
+	if status != models.MediaStatusAvailable && status != models.MediaStatusMissing {
+		return 0, fmt.Errorf("%w: unknown media status %q", ErrInvalidInput, status)
+	}
+
+	updates := map[string]interface{}{
+		"status":        status,
+		"missing_since": nil,
+	}
+	if status == models.MediaStatusMissing {
+		updates["missing_since"] = time.Now().UTC()
+	}
+
+	var changed int64
+	for start := 0; start < len(ids); start += statusUpdateBatchSize {
+		end := min(start+statusUpdateBatchSize, len(ids))
+
+		idStrings := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			idStrings = append(idStrings, id.String())
+		}
+
+		result := r.db.WithContext(ctx).
+			Model(&models.Media{}).
+			Where("id IN ? AND status != ?", idStrings, status).
+			Updates(updates)
+		if result.Error != nil {
+			return changed, fmt.Errorf("failed to update media status: %w", MapGormError(result.Error))
+		}
+		changed += result.RowsAffected
+	}
+
+	return changed, nil
+}
+
+// MarkMissingByPath marks the media item at filePath as missing.
+// Returns false if no available media item has that path (unknown or already missing).
+func (r *MediaRepository) MarkMissingByPath(ctx context.Context, filePath string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Media{}).
+		Where("file_path = ? AND status = ?", filePath, models.MediaStatusAvailable).
+		Updates(map[string]interface{}{
+			"status":        models.MediaStatusMissing,
+			"missing_since": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark media missing: %w", MapGormError(result.Error))
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Relink points a media item at a new file path and marks it available
+func (r *MediaRepository) Relink(ctx context.Context, id uuid.UUID, filePath string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Media{}).
+		Where("id = ?", id.String()).
+		Updates(map[string]interface{}{
+			"file_path":     filePath,
+			"status":        models.MediaStatusAvailable,
+			"missing_since": nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to relink media: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeMissing deletes the given media items if they are marked missing, removing them from
+// every playlist and closing the resulting position gaps. An empty ids slice purges all
+// missing media. Returns the IDs that were actually deleted.
+func (r *MediaRepository) PurgeMissing(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	var purgedIDs []string
+
+	err := r.db.WithTransaction(ctx, func(tx *gorm.DB) error {
+		query := tx.Model(&models.Media{}).Where("status = ?", models.MediaStatusMissing)
+		if len(ids) > 0 {
+			idStrings := make([]string, len(ids))
+			for i, id := range ids {
+				idStrings[i] = id.String()
+			}
+			query = query.Where("id IN ?", idStrings)
+		}
+
+		if err := query.Pluck("id", &purgedIDs).Error; err != nil {
+			return fmt.Errorf("failed to find missing media: %w", err)
+		}
+		if len(purgedIDs) == 0 {
+			return nil
+		}
+
+		// Channels must be collected before the cascade removes their playlist items
+		var channelIDs []string
+		if err := tx.Model(&models.PlaylistItem{}).
+			Distinct("channel_id").
+			Where("media_id IN ?", purgedIDs).
+			Pluck("channel_id", &channelIDs).Error; err != nil {
+			return fmt.Errorf("failed to find affected channels: %w", err)
+		}
+
+		if err := tx.Where("id IN ?", purgedIDs).Delete(&models.Media{}).Error; err != nil {
+			return fmt.Errorf("failed to delete missing media: %w", err)
+		}
+
+		for _, channelID := range channelIDs {
+			if err := renumberPlaylist(tx, channelID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge missing media: %w", MapGormError(err))
+	}
+
+	purged := make([]uuid.UUID, 0, len(purgedIDs))
+	for _, idStr := range purgedIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse purged media id %q: %w", idStr, err)
+		}
+		purged = append(purged, id)
+	}
+
+	return purged, nil
+}
+
+// renumberPlaylist closes position gaps in a channel's playlist, keeping the existing order.
+// Positions are first negated so the renumbering never collides with UNIQUE (channel_id, position).
+func renumberPlaylist(tx *gorm.DB, channelID string) error {
+	if err := tx.Exec(
+		"UPDATE playlist_items SET position = -1 - position WHERE channel_id = ?", channelID,
+	).Error; err != nil {
+		return fmt.Errorf("failed to renumber playlist for channel %s: %w", channelID, err)
+	}
+
+	if err := tx.Exec(`
+		UPDATE playlist_items
+		SET position = numbered.new_pos
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY position DESC) - 1 AS new_pos
+			FROM playlist_items
+			WHERE channel_id = ?
+		) AS numbered
+		WHERE playlist_items.id = numbered.id
+	`, channelID).Error; err != nil {
+		return fmt.Errorf("failed to renumber playlist for channel %s: %w", channelID, err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,7 +13,8 @@ import (
 )
 
 // RunMigrations executes database migrations from the specified path.
-// It uses golang-migrate to apply migrations to the provided database connection.
+// It uses golang-migrate to apply migrations to the provided database connection,
+// then ensures the media full-text search index exists (see EnsureMediaSearchIndex).
 //
 // Parameters:
 //   - db: An open database connection
@@ -51,5 +53,9 @@ func RunMigrations(db *sql.DB, migrationsPath string) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if _, err := EnsureMediaSearchIndex(context.Background(), db); err != nil {
+		return fmt.Errorf("failed to set up search index: %w", err)
+	}
+
 	return nil
 }
@@ -25,6 +25,15 @@ type ReorderItem struct {
 	Position int
 }
 
+// MediaChannelRef identifies a channel playlist entry that references a media item
+type MediaChannelRef struct {
+	MediaID        uuid.UUID `json:"-"`
+	ChannelID      uuid.UUID `json:"channel_id"`
+	ChannelName    string    `json:"channel_name"`
+	PlaylistItemID uuid.UUID `json:"playlist_item_id"`
+	Position       int       `json:"position"`
+}
+
 // Create inserts a new playlist item into the database
 func (r *PlaylistItemRepository) Create(ctx context.Context, item *models.PlaylistItem) error {
 	result := r.db.WithContext(ctx).Create(item)
@@ -129,3 +138,34 @@ func (r *PlaylistItemRepository) Reorder(ctx context.Context, channelID uuid.UUI
 		return nil
 	})
 }
+
+// ListChannelRefsByMedia returns the channel playlist entries referencing each of the given media IDs
+// Media that no playlist references are absent from the map
+func (r *PlaylistItemRepository) ListChannelRefsByMedia(ctx context.Context, mediaIDs []uuid.UUID) (map[uuid.UUID][]*MediaChannelRef, error) {
+	refsByMedia := make(map[uuid.UUID][]*MediaChannelRef)
+	if len(mediaIDs) == 0 {
+		return refsByMedia, nil
+	}
+
+	idStrings := make([]string, len(mediaIDs))
+	for i, id := range mediaIDs {
+		idStrings[i] = id.String()
+	}
+
+	var refs []*MediaChannelRef
+	result := r.db.WithContext(ctx).
+		Table("playlist_items").
+		Select("playlist_items.media_id, playlist_items.channel_id, channels.name AS channel_name, playlist_items.id AS playlist_item_id, playlist_items.position").
+		Joins("JOIN channels ON channels.id = playlist_items.channel_id").
+		Where("playlist_items.media_id IN ?", idStrings).
+		Order("channels.name ASC, playlist_items.position ASC").
+		Scan(&refs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list channel references for media: %w", MapGormError(result.Error))
+	}
+
+	for _, ref := range refs {
+		refsByMedia[ref.MediaID] = append(refsByMedia[ref.MediaID], ref)
+	}
+	return refsByMedia, nil
+}
@@ -6,6 +6,11 @@ type Repositories struct {
 	Media         *MediaRepository
 	PlaylistItems *PlaylistItemRepository
 	Settings      *SettingsRepository
+	Users         *UserRepository
+	APIKeys       *APIKeyRepository
+	UserSessions  *UserSessionRepository
+	Groups        *GroupRepository
+	ChannelAccess *ChannelAccessRepository
 }
 
 // NewRepositories creates a new repository collection
@@ -15,5 +20,10 @@ func NewRepositories(db *DB) *Repositories {
 		Media:         NewMediaRepository(db),
 		PlaylistItems: NewPlaylistItemRepository(db),
 		Settings:      NewSettingsRepository(db),
+		Users:         NewUserRepository(db),
+		APIKeys:       NewAPIKeyRepository(db),
+		UserSessions:  NewUserSessionRepository(db),
+		Groups:        NewGroupRepository(db),
+		ChannelAccess: NewChannelAccessRepository(db),
 	}
 }
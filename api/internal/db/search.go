@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SearchEngine identifies the SQLite full-text module backing the media search index
+type SearchEngine string
+
+// Supported search engines, in order of preference
+const (
+	SearchEngineFTS5 SearchEngine = "fts5"
+	SearchEngineFTS4 SearchEngine = "fts4"
+	SearchEngineNone SearchEngine = "none" // Index missing; search falls back to LIKE
+)
+
+// mediaSearchTable is the name of the full-text index over media
+const mediaSearchTable = "media_fts"
+
+// FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build tag, so FTS4
+// (always available) is used as a fallback with the same columns and triggers.
+const (
+	createMediaFTS5 = `CREATE VIRTUAL TABLE media_fts USING fts5(
+		media_id UNINDEXED, title, show_name, description,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`
+	createMediaFTS4 = `CREATE VIRTUAL TABLE media_fts USING fts4(
+		media_id, title, show_name, description,
+		notindexed=media_id, tokenize=unicode61
+	)`
+)
+
+// mediaSearchTriggers keep media_fts in sync with the media table
+var mediaSearchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS media_fts_ai AFTER INSERT ON media BEGIN
+		INSERT INTO media_fts(media_id, title, show_name, description)
+		VALUES (new.id, new.title, COALESCE(new.show_name, ''), COALESCE(new.description, ''));
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS media_fts_ad AFTER DELETE ON media BEGIN
+		DELETE FROM media_fts WHERE media_id = old.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS media_fts_au AFTER UPDATE OF title, show_name, description ON media BEGIN
+		DELETE FROM media_fts WHERE media_id = old.id;
+		INSERT INTO media_fts(media_id, title, show_name, description)
+		VALUES (new.id, new.title, COALESCE(new.show_name, ''), COALESCE(new.description, ''));
+	END`,
+}
+
+// backfillMediaSearch indexes media rows that existed before the index was created
+const backfillMediaSearch = `INSERT INTO media_fts(media_id, title, show_name, description)
+	SELECT id, title, COALESCE(show_name, ''), COALESCE(description, '') FROM media`
+
+// EnsureMediaSearchIndex creates the media full-text index and its sync triggers if missing.
+// It prefers FTS5 and falls back to FTS4 when the driver was built without FTS5.
+// Safe to call on every startup; an existing index is left untouched.
+func EnsureMediaSearchIndex(ctx context.Context, db *sql.DB) (SearchEngine, error) {
+	engine, err := detectSearchEngine(ctx, db)
+	if err != nil {
+		return SearchEngineNone, err
+	}
+	if engine != SearchEngineNone {
+		return engine, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return SearchEngineNone, fmt.Errorf("failed to begin search index transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	engine = SearchEngineFTS5
+	if _, err := tx.ExecContext(ctx, createMediaFTS5); err != nil {
+		if !strings.Contains(err.Error(), "no such module") {
+			return SearchEngineNone, fmt.Errorf("failed to create fts5 search index: %w", err)
+		}
+		engine = SearchEngineFTS4
+		if _, err := tx.ExecContext(ctx, createMediaFTS4); err != nil {
+			return SearchEngineNone, fmt.Errorf("failed to create fts4 search index: %w", err)
+		}
+	}
+
+	for _, trigger := range mediaSearchTriggers {
+		if _, err := tx.ExecContext(ctx, trigger); err != nil {
+			return SearchEngineNone, fmt.Errorf("failed to create search index trigger: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, backfillMediaSearch); err != nil {
+		return SearchEngineNone, fmt.Errorf("failed to backfill search index: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SearchEngineNone, fmt.Errorf("failed to commit search index: %w", err)
+	}
+
+	return engine, nil
+}
+
+// detectSearchEngine reports which engine backs an existing media_fts table
+func detectSearchEngine(ctx context.Context, db *sql.DB) (SearchEngine, error) {
+	var createSQL string
+	err := db.QueryRowContext(ctx,
+		"SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", mediaSearchTable,
+	).Scan(&createSQL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SearchEngineNone, nil
+	}
+	if err != nil {
+		return SearchEngineNone, fmt.Errorf("failed to inspect search index: %w", err)
+	}
+
+	if strings.Contains(strings.ToLower(createSQL), "fts5") {
+		return SearchEngineFTS5, nil
+	}
+	return SearchEngineFTS4, nil
+}
+
+// buildMatchQuery turns free-form user input into a safe prefix-matching FTS query.
+// Every word must match (implicit AND); punctuation and FTS operators are stripped.
+// Returns "" when the input contains no searchable words.
+func buildMatchQuery(input string) string {
+	words := strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+"*")
+	}
+	return strings.Join(terms, " ")
+}
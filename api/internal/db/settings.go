@@ -40,6 +40,26 @@ func (r *SettingsRepository) Get(ctx context.Context) (*models.Settings, error)
 	return &settings, nil
 }
 
+// Initialize returns the stored settings, creating the row from the given defaults if it does not exist
+// Unlike Get, the caller decides the initial values (e.g. seeded from configuration)
+func (r *SettingsRepository) Initialize(ctx context.Context, defaults *models.Settings) (*models.Settings, error) {
+	var settings models.Settings
+	result := r.db.WithContext(ctx).Where("id = ?", 1).First(&settings)
+	if result.Error == nil {
+		return &settings, nil
+	}
+	if !errors.Is(MapGormError(result.Error), ErrNotFound) {
+		return nil, MapGormError(result.Error)
+	}
+
+	defaults.ID = 1
+	defaults.UpdatedAt = time.Now().UTC()
+	if err := r.db.WithContext(ctx).Create(defaults).Error; err != nil {
+		return nil, fmt.Errorf("failed to create initial settings: %w", MapGormError(err))
+	}
+	return defaults, nil
+}
+
 // Update updates the settings (singleton row)
 func (r *SettingsRepository) Update(ctx context.Context, settings *models.Settings) error {
 	// Ensure we're always updating the singleton row
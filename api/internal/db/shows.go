@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// ShowSummary aggregates the media belonging to a single show
+type ShowSummary struct {
+	ShowName      string `json:"show_name"`
+	SeasonCount   int64  `json:"season_count"`
+	EpisodeCount  int64  `json:"episode_count"`
+	TotalDuration int64  `json:"total_duration_seconds"`
+}
+
+// SeasonSummary aggregates the media belonging to a single season of a show.
+// Season is nil for episodes whose season could not be determined.
+type SeasonSummary struct {
+	Season        *int  `json:"season"`
+	EpisodeCount  int64 `json:"episode_count"`
+	TotalDuration int64 `json:"total_duration_seconds"`
+}
+
+// ListShows returns every show in the library with aggregated counts, ordered by name
+// Grouping on show_name is served by idx_media_show
+func (r *MediaRepository) ListShows(ctx context.Context) ([]*ShowSummary, error) {
+	var shows []*ShowSummary
+	result := r.db.WithContext(ctx).
+		Model(&models.Media{}).
+		Select("show_name, COUNT(DISTINCT season) AS season_count, COUNT(*) AS episode_count, COALESCE(SUM(duration), 0) AS total_duration").
+		Where("show_name IS NOT NULL AND show_name != ''").
+		Group("show_name").
+		Order("show_name COLLATE NOCASE ASC").
+		Scan(&shows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list shows: %w", MapGormError(result.Error))
+	}
+	return shows, nil
+}
+
+// ListSeasons returns the seasons of a show with aggregated counts, unknown season last
+// Returns an empty slice if the show has no media
+func (r *MediaRepository) ListSeasons(ctx context.Context, showName string) ([]*SeasonSummary, error) {
+	var seasons []*SeasonSummary
+	result := r.db.WithContext(ctx).
+		Model(&models.Media{}).
+		Select("season, COUNT(*) AS episode_count, COALESCE(SUM(duration), 0) AS total_duration").
+		Where("show_name = ?", showName).
+		Group("season").
+		Order("COALESCE(season, 9999999) ASC").
+		Scan(&seasons)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list seasons: %w", MapGormError(result.Error))
+	}
+	return seasons, nil
+}
+
+// ListEpisodes returns the media of a single season of a show ordered by episode number
+func (r *MediaRepository) ListEpisodes(ctx context.Context, showName string, season int) ([]*models.Media, error) {
+	var episodes []*models.Media
+	result := r.db.WithContext(ctx).
+		Where("show_name = ? AND season = ?", showName, season).
+		Order("COALESCE(episode, 9999999) ASC, title ASC").
+		Find(&episodes)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list episodes: %w", MapGormError(result.Error))
+	}
+	return episodes, nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// UserSessionRepository handles database operations for login sessions
+type UserSessionRepository struct {
+	db *DB
+}
+
+// NewUserSessionRepository creates a new user session repository
+func NewUserSessionRepository(db *DB) *UserSessionRepository {
+	return &UserSessionRepository{db: db}
+}
+
+// Create inserts a new session into the database
+func (r *UserSessionRepository) Create(ctx context.Context, session *models.UserSession) error {
+	result := r.db.WithContext(ctx).Create(session)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create session: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// GetByHash retrieves a session by the hash of its token
+func (r *UserSessionRepository) GetByHash(ctx context.Context, tokenHash string) (*models.UserSession, error) {
+	var session models.UserSession
+	result := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session)
+	if result.Error != nil {
+		return nil, MapGormError(result.Error)
+	}
+	return &session, nil
+}
+
+// DeleteByHash deletes the session with the given token hash
+func (r *UserSessionRepository) DeleteByHash(ctx context.Context, tokenHash string) error {
+	result := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&models.UserSession{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete session: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteByUser deletes every session of a user (e.g. after a password change)
+func (r *UserSessionRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID.String()).Delete(&models.UserSession{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete sessions: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// DeleteExpired deletes sessions that expired before now and returns how many were removed
+func (r *UserSessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.UserSession{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", MapGormError(result.Error))
+	}
+	return result.RowsAffected, nil
+}
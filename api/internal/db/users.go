@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrUsersExist is returned by CreateFirst when a user already exists
+var ErrUsersExist = errors.New("users already exist")
+
+// UserRepository handles database operations for user accounts
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create inserts a new user into the database
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	result := r.db.WithContext(ctx).Create(user)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create user: %w", MapGormError(result.Error))
+	}
+	return nil
+}
+
+// CreateFirst inserts a user only if no user exists yet, returning ErrUsersExist otherwise.
+// The check and the insert are a single statement, so concurrent callers cannot both succeed.
+func (r *UserRepository) CreateFirst(ctx context.Context, user *models.User) error {
+	err := r.db.WithTransaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Exec(
+			`INSERT INTO users (id, username, password_hash, role, created_at, updated_at)
+			SELECT ?, ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM users)`,
+			user.ID.String(), user.Username, user.PasswordHash, user.Role, user.CreatedAt, user.UpdatedAt,
+		)
+		if result.Error != nil {
+			return MapGormError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrUsersExist
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrUsersExist) {
+			return ErrUsersExist
+		}
+		return fmt.Errorf("failed to create first user: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a user by its UUID
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	result := r.db.WithContext(ctx).Where("id = ?", id.String()).First(&user)
+	if result.Error != nil {
+		return nil, MapGormError(result.Error)
+	}
+	return &user, nil
+}
+
+// GetByUsername retrieves a user by username (case-insensitive)
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	result := r.db.WithContext(ctx).Where("username = ? COLLATE NOCASE", username).First(&user)
+	if result.Error != nil {
+		return nil, MapGormError(result.Error)
+	}
+	return &user, nil
+}
+
+// List retrieves all users ordered by username
+func (r *UserRepository) List(ctx context.Context) ([]*models.User, error) {
+	var users []*models.User
+	result := r.db.WithContext(ctx).Order("username ASC").Find(&users)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list users: %w", MapGormError(result.Error))
+	}
+	return users, nil
+}
+
+// Count returns the number of users
+func (r *UserRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&models.User{}).Count(&count)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to count users: %w", MapGormError(result.Error))
+	}
+	return count, nil
+}
+
+// UpdatePassword replaces a user's password hash
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id.String()).
+		Updates(map[string]interface{}{
+			"password_hash": passwordHash,
+			"updated_at":    time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update password: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateRole changes a user's role
+func (r *UserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id.String()).
+		Updates(map[string]interface{}{
+			"role":       role,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update role: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CountByRole returns the number of users with the given role
+func (r *UserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("role = ?", role).Count(&count)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to count users: %w", MapGormError(result.Error))
+	}
+	return count, nil
+}
+
+// Delete deletes a user by its UUID (cascade delete to API keys and sessions)
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id.String()).Delete(&models.User{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete user: %w", MapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -43,6 +43,11 @@ type Stream struct {
 	Channels      int    `json:"channels,omitempty"`
 	SampleRate    string `json:"sample_rate,omitempty"`
 	ChannelLayout string `json:"channel_layout,omitempty"`
+
+	// Video color and scan information
+	ColorTransfer  string `json:"color_transfer,omitempty"`  // e.g. "smpte2084" (PQ), "arib-std-b67" (HLG), "bt709"
+	ColorPrimaries string `json:"color_primaries,omitempty"` // e.g. "bt2020", "bt709"
+	FieldOrder     string `json:"field_order,omitempty"`     // "progressive", or "tt", "bb", "tb", "bt" for interlaced video
 }
 
 // Format represents the file format information
@@ -66,6 +71,8 @@ type VideoMetadata struct {
 	FileSize   int64  // File size in bytes
 	Width      int
 	Height     int
+	HDR        bool // PQ or HLG transfer (or BT.2020 primaries without a transfer)
+	Interlaced bool // Interlaced field order
 }
 
 // CheckFFprobeInstalled checks if FFprobe is available in PATH
@@ -218,6 +225,8 @@ func extractCodecInfo(metadata *VideoMetadata, videoStream, audioStream *Stream)
 		if videoStream.Width > 0 && videoStream.Height > 0 {
 			metadata.Resolution = fmt.Sprintf("%dx%d", videoStream.Width, videoStream.Height)
 		}
+		metadata.HDR = isHDR(videoStream)
+		metadata.Interlaced = isInterlaced(videoStream)
 	}
 
 	// Extract audio metadata
@@ -226,6 +235,29 @@ func extractCodecInfo(metadata *VideoMetadata, videoStream, audioStream *Stream)
 	}
 }
 
+// isHDR reports whether a video stream uses an HDR transfer function (PQ or HLG).
+// Files that leave the transfer untagged are treated as HDR when their primaries are BT.2020.
+func isHDR(stream *Stream) bool {
+	switch stream.ColorTransfer {
+	case "smpte2084", "arib-std-b67":
+		return true
+	case "", "unknown":
+		return stream.ColorPrimaries == "bt2020"
+	default:
+		return false
+	}
+}
+
+// isInterlaced reports whether a video stream has an interlaced field order
+func isInterlaced(stream *Stream) bool {
+	switch stream.FieldOrder {
+	case "tt", "bb", "tb", "bt":
+		return true
+	default:
+		return false
+	}
+}
+
 // extractDuration extracts duration from video stream or format metadata
 func extractDuration(metadata *VideoMetadata, videoStream *Stream, result *FFprobeResult) {
 	// Try stream duration first
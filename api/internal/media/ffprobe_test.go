@@ -166,6 +166,75 @@ func TestExtractMetadata(t *testing.T) {
 	}
 }
 
+func TestExtractMetadata_VideoFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		stream         Stream
+		wantHDR        bool
+		wantInterlaced bool
+	}{
+		{
+			name:   "SDR progressive",
+			stream: Stream{ColorTransfer: "bt709", ColorPrimaries: "bt709", FieldOrder: "progressive"},
+		},
+		{
+			name:    "HDR10 (PQ)",
+			stream:  Stream{ColorTransfer: "smpte2084", ColorPrimaries: "bt2020", FieldOrder: "progressive"},
+			wantHDR: true,
+		},
+		{
+			name:    "HLG",
+			stream:  Stream{ColorTransfer: "arib-std-b67", ColorPrimaries: "bt2020"},
+			wantHDR: true,
+		},
+		{
+			name:    "BT.2020 without transfer",
+			stream:  Stream{ColorPrimaries: "bt2020"},
+			wantHDR: true,
+		},
+		{
+			name:   "BT.2020 SDR transfer",
+			stream: Stream{ColorTransfer: "bt2020-10", ColorPrimaries: "bt2020"},
+		},
+		{
+			name:           "top field first",
+			stream:         Stream{FieldOrder: "tt"},
+			wantInterlaced: true,
+		},
+		{
+			name:           "bottom field first",
+			stream:         Stream{FieldOrder: "bb"},
+			wantInterlaced: true,
+		},
+		{
+			name:   "untagged",
+			stream: Stream{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := tt.stream
+			stream.CodecType = "video"
+			stream.CodecName = "hevc"
+			metadata, err := extractMetadata(&FFprobeResult{
+				Streams: []Stream{stream},
+				Format:  Format{Duration: "60.0"},
+			})
+			if err != nil {
+				t.Fatalf("extractMetadata() unexpected error: %v", err)
+			}
+
+			if metadata.HDR != tt.wantHDR {
+				t.Errorf("HDR = %v, want %v", metadata.HDR, tt.wantHDR)
+			}
+			if metadata.Interlaced != tt.wantInterlaced {
+				t.Errorf("Interlaced = %v, want %v", metadata.Interlaced, tt.wantInterlaced)
+			}
+		})
+	}
+}
+
 func TestFFprobeJSONParsing(t *testing.T) {
 	// Test that we can parse real FFprobe JSON output format
 	sampleJSON := `{
@@ -0,0 +1,84 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stwalsh4118/hermes/internal/config"
+)
+
+// Metadata lookup errors
+var (
+	ErrMetadataNotFound    = errors.New("metadata not found")
+	ErrMetadataUnavailable = errors.New("metadata provider unavailable")
+)
+
+// airDateLayout is the date format used by metadata sources for air/release dates
+const airDateLayout = "2006-01-02"
+
+// MetadataResult contains descriptive metadata returned by a provider
+type MetadataResult struct {
+	Title       string     `json:"title"`
+	Plot        string     `json:"plot,omitempty"`
+	PosterURL   string     `json:"poster_url,omitempty"`
+	BackdropURL string     `json:"backdrop_url,omitempty"`
+	StillURL    string     `json:"still_url,omitempty"` // Episode still frame, if any
+	AirDate     *time.Time `json:"air_date,omitempty"`
+}
+
+// MetadataProvider looks up descriptive metadata (EPG data) for media items.
+// Implementations return ErrMetadataNotFound when no match exists.
+type MetadataProvider interface {
+	// Name returns a short identifier for the provider (e.g. "local", "tmdb")
+	Name() string
+
+	// LookupShow returns metadata for a TV show by name
+	LookupShow(ctx context.Context, showName string) (*MetadataResult, error)
+
+	// LookupEpisode returns metadata for a single episode of a TV show
+	LookupEpisode(ctx context.Context, showName string, season, episode int) (*MetadataResult, error)
+
+	// LookupMovie returns metadata for a movie by title, optionally narrowed by year (0 = any)
+	LookupMovie(ctx context.Context, title string, year int) (*MetadataResult, error)
+}
+
+// NewMetadataProvider creates the provider selected in configuration.
+// Returns nil (and no error) when no provider is configured.
+func NewMetadataProvider(cfg *config.MetadataConfig) (MetadataProvider, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "local":
+		return NewLocalMetadataProvider(cfg.LocalPath)
+	case "http":
+		return NewHTTPMetadataProvider(HTTPMetadataProviderConfig{
+			BaseURL:      cfg.BaseURL,
+			APIKey:       cfg.APIKey,
+			ImageBaseURL: cfg.ImageBaseURL,
+			Language:     cfg.Language,
+		})
+	default:
+		return nil, fmt.Errorf("unknown metadata provider: %s", cfg.Provider)
+	}
+}
+
+// normalizeLookupName normalizes a show or movie name for case-insensitive matching
+func normalizeLookupName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// parseAirDate parses an air date string, returning nil when empty or malformed
+func parseAirDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(airDateLayout, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
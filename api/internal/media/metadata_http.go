@@ -0,0 +1,224 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default HTTP metadata provider settings
+const (
+	defaultMetadataHTTPTimeout = 10 * time.Second
+	defaultImageBaseURL        = "https://image.tmdb.org/t/p/original"
+)
+
+// HTTPMetadataProviderConfig configures an HTTPMetadataProvider
+type HTTPMetadataProviderConfig struct {
+	BaseURL      string       // API root, e.g. "https://api.themoviedb.org/3"
+	APIKey       string       // Sent as the api_key query parameter
+	ImageBaseURL string       // Prefix for relative artwork paths (defaults to TMDB original size)
+	Language     string       // Optional language query parameter, e.g. "en-US"
+	Client       *http.Client // Optional HTTP client (defaults to one with a 10s timeout)
+}
+
+// HTTPMetadataProvider looks up metadata from a TMDB-compatible HTTP API.
+// It uses the endpoints /search/tv, /search/movie and /tv/{id}/season/{s}/episode/{e}.
+type HTTPMetadataProvider struct {
+	baseURL      string
+	apiKey       string
+	imageBaseURL string
+	language     string
+	client       *http.Client
+}
+
+// tmdbSearchResult is a single result from /search/tv or /search/movie
+type tmdbSearchResult struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`  // TV results
+	Title        string `json:"title"` // Movie results
+	Overview     string `json:"overview"`
+	PosterPath   string `json:"poster_path"`
+	BackdropPath string `json:"backdrop_path"`
+	FirstAirDate string `json:"first_air_date"`
+	ReleaseDate  string `json:"release_date"`
+}
+
+// tmdbSearchResponse is the envelope returned by search endpoints
+type tmdbSearchResponse struct {
+	Results []tmdbSearchResult `json:"results"`
+}
+
+// tmdbEpisode is the response from the episode details endpoint
+type tmdbEpisode struct {
+	Name      string `json:"name"`
+	Overview  string `json:"overview"`
+	StillPath string `json:"still_path"`
+	AirDate   string `json:"air_date"`
+}
+
+// NewHTTPMetadataProvider creates a provider for a TMDB-style HTTP API
+func NewHTTPMetadataProvider(cfg HTTPMetadataProviderConfig) (*HTTPMetadataProvider, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("metadata provider base URL is required")
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultMetadataHTTPTimeout}
+	}
+
+	imageBaseURL := cfg.ImageBaseURL
+	if imageBaseURL == "" {
+		imageBaseURL = defaultImageBaseURL
+	}
+
+	return &HTTPMetadataProvider{
+		baseURL:      strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:       cfg.APIKey,
+		imageBaseURL: strings.TrimRight(imageBaseURL, "/"),
+		language:     cfg.Language,
+		client:       client,
+	}, nil
+}
+
+// Name returns the provider identifier
+func (p *HTTPMetadataProvider) Name() string {
+	return "http"
+}
+
+// LookupShow returns metadata for the best-matching TV show
+func (p *HTTPMetadataProvider) LookupShow(ctx context.Context, showName string) (*MetadataResult, error) {
+	show, err := p.searchShow(ctx, showName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetadataResult{
+		Title:       show.Name,
+		Plot:        show.Overview,
+		PosterURL:   p.imageURL(show.PosterPath),
+		BackdropURL: p.imageURL(show.BackdropPath),
+		AirDate:     parseAirDate(show.FirstAirDate),
+	}, nil
+}
+
+// LookupEpisode returns metadata for a single episode of the best-matching TV show
+func (p *HTTPMetadataProvider) LookupEpisode(ctx context.Context, showName string, season, episode int) (*MetadataResult, error) {
+	show, err := p.searchShow(ctx, showName)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/tv/%d/season/%d/episode/%d", show.ID, season, episode)
+	var ep tmdbEpisode
+	if err := p.get(ctx, path, nil, &ep); err != nil {
+		return nil, err
+	}
+
+	return &MetadataResult{
+		Title:       ep.Name,
+		Plot:        ep.Overview,
+		PosterURL:   p.imageURL(show.PosterPath),
+		BackdropURL: p.imageURL(show.BackdropPath),
+		StillURL:    p.imageURL(ep.StillPath),
+		AirDate:     parseAirDate(ep.AirDate),
+	}, nil
+}
+
+// LookupMovie returns metadata for the best-matching movie
+func (p *HTTPMetadataProvider) LookupMovie(ctx context.Context, title string, year int) (*MetadataResult, error) {
+	params := url.Values{"query": {title}}
+	if year > 0 {
+		params.Set("year", strconv.Itoa(year))
+	}
+
+	var resp tmdbSearchResponse
+	if err := p.get(ctx, "/search/movie", params, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, ErrMetadataNotFound
+	}
+
+	movie := resp.Results[0]
+	return &MetadataResult{
+		Title:       movie.Title,
+		Plot:        movie.Overview,
+		PosterURL:   p.imageURL(movie.PosterPath),
+		BackdropURL: p.imageURL(movie.BackdropPath),
+		AirDate:     parseAirDate(movie.ReleaseDate),
+	}, nil
+}
+
+// searchShow returns the first /search/tv result for a show name
+func (p *HTTPMetadataProvider) searchShow(ctx context.Context, showName string) (*tmdbSearchResult, error) {
+	var resp tmdbSearchResponse
+	if err := p.get(ctx, "/search/tv", url.Values{"query": {showName}}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, ErrMetadataNotFound
+	}
+	return &resp.Results[0], nil
+}
+
+// get performs a GET request against the API and decodes the JSON response into out
+func (p *HTTPMetadataProvider) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if p.apiKey != "" {
+		params.Set("api_key", p.apiKey)
+	}
+	if p.language != "" {
+		params.Set("language", p.language)
+	}
+
+	reqURL := p.baseURL + path
+	if encoded := params.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build metadata request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMetadataUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrMetadataNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%w: unexpected status %d", ErrMetadataUnavailable, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode metadata response: %w", err)
+	}
+	return nil
+}
+
+// imageURL converts a relative artwork path into an absolute URL
+func (p *HTTPMetadataProvider) imageURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return p.imageBaseURL + path
+}
@@ -0,0 +1,167 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LocalMetadataDump is the on-disk JSON format read by LocalMetadataProvider
+type LocalMetadataDump struct {
+	Shows  []LocalShowEntry  `json:"shows"`
+	Movies []LocalMovieEntry `json:"movies"`
+}
+
+// LocalShowEntry describes a show and its episodes in a local metadata dump
+type LocalShowEntry struct {
+	Name        string              `json:"name"`
+	Plot        string              `json:"plot,omitempty"`
+	PosterURL   string              `json:"poster_url,omitempty"`
+	BackdropURL string              `json:"backdrop_url,omitempty"`
+	AirDate     string              `json:"air_date,omitempty"` // YYYY-MM-DD
+	Episodes    []LocalEpisodeEntry `json:"episodes,omitempty"`
+}
+
+// LocalEpisodeEntry describes a single episode in a local metadata dump
+type LocalEpisodeEntry struct {
+	Season   int    `json:"season"`
+	Episode  int    `json:"episode"`
+	Title    string `json:"title"`
+	Plot     string `json:"plot,omitempty"`
+	StillURL string `json:"still_url,omitempty"`
+	AirDate  string `json:"air_date,omitempty"` // YYYY-MM-DD
+}
+
+// LocalMovieEntry describes a movie in a local metadata dump
+type LocalMovieEntry struct {
+	Title       string `json:"title"`
+	Year        int    `json:"year,omitempty"`
+	Plot        string `json:"plot,omitempty"`
+	PosterURL   string `json:"poster_url,omitempty"`
+	BackdropURL string `json:"backdrop_url,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"` // YYYY-MM-DD
+}
+
+// episodeKey identifies an episode within a show
+type episodeKey struct {
+	season  int
+	episode int
+}
+
+// localShow is an indexed show entry
+type localShow struct {
+	entry    LocalShowEntry
+	episodes map[episodeKey]LocalEpisodeEntry
+}
+
+// LocalMetadataProvider serves metadata from an in-memory index built from a JSON dump.
+// It performs no network access and is safe for concurrent use.
+type LocalMetadataProvider struct {
+	shows  map[string]*localShow
+	movies map[string][]LocalMovieEntry
+}
+
+// NewLocalMetadataProvider loads a JSON metadata dump from disk
+func NewLocalMetadataProvider(path string) (*LocalMetadataProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata dump: %w", err)
+	}
+
+	var dump LocalMetadataDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata dump: %w", err)
+	}
+
+	return NewLocalMetadataProviderFromDump(&dump), nil
+}
+
+// NewLocalMetadataProviderFromDump builds a provider from an already-decoded dump
+func NewLocalMetadataProviderFromDump(dump *LocalMetadataDump) *LocalMetadataProvider {
+	p := &LocalMetadataProvider{
+		shows:  make(map[string]*localShow, len(dump.Shows)),
+		movies: make(map[string][]LocalMovieEntry, len(dump.Movies)),
+	}
+
+	for _, show := range dump.Shows {
+		indexed := &localShow{
+			entry:    show,
+			episodes: make(map[episodeKey]LocalEpisodeEntry, len(show.Episodes)),
+		}
+		for _, ep := range show.Episodes {
+			indexed.episodes[episodeKey{season: ep.Season, episode: ep.Episode}] = ep
+		}
+		p.shows[normalizeLookupName(show.Name)] = indexed
+	}
+
+	for _, movie := range dump.Movies {
+		key := normalizeLookupName(movie.Title)
+		p.movies[key] = append(p.movies[key], movie)
+	}
+
+	return p
+}
+
+// Name returns the provider identifier
+func (p *LocalMetadataProvider) Name() string {
+	return "local"
+}
+
+// LookupShow returns metadata for a TV show by name
+func (p *LocalMetadataProvider) LookupShow(_ context.Context, showName string) (*MetadataResult, error) {
+	show, ok := p.shows[normalizeLookupName(showName)]
+	if !ok {
+		return nil, ErrMetadataNotFound
+	}
+
+	return &MetadataResult{
+		Title:       show.entry.Name,
+		Plot:        show.entry.Plot,
+		PosterURL:   show.entry.PosterURL,
+		BackdropURL: show.entry.BackdropURL,
+		AirDate:     parseAirDate(show.entry.AirDate),
+	}, nil
+}
+
+// LookupEpisode returns metadata for a single episode of a TV show
+func (p *LocalMetadataProvider) LookupEpisode(_ context.Context, showName string, season, episode int) (*MetadataResult, error) {
+	show, ok := p.shows[normalizeLookupName(showName)]
+	if !ok {
+		return nil, ErrMetadataNotFound
+	}
+
+	ep, ok := show.episodes[episodeKey{season: season, episode: episode}]
+	if !ok {
+		return nil, ErrMetadataNotFound
+	}
+
+	// Episodes inherit show artwork so callers always have something to display
+	return &MetadataResult{
+		Title:       ep.Title,
+		Plot:        ep.Plot,
+		PosterURL:   show.entry.PosterURL,
+		BackdropURL: show.entry.BackdropURL,
+		StillURL:    ep.StillURL,
+		AirDate:     parseAirDate(ep.AirDate),
+	}, nil
+}
+
+// LookupMovie returns metadata for a movie by title, optionally narrowed by year (0 = any)
+func (p *LocalMetadataProvider) LookupMovie(_ context.Context, title string, year int) (*MetadataResult, error) {
+	candidates := p.movies[normalizeLookupName(title)]
+	for _, movie := range candidates {
+		if year != 0 && movie.Year != year {
+			continue
+		}
+		return &MetadataResult{
+			Title:       movie.Title,
+			Plot:        movie.Plot,
+			PosterURL:   movie.PosterURL,
+			BackdropURL: movie.BackdropURL,
+			AirDate:     parseAirDate(movie.ReleaseDate),
+		}, nil
+	}
+
+	return nil, ErrMetadataNotFound
+}
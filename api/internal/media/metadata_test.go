@@ -0,0 +1,242 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stwalsh4118/hermes/internal/config"
+)
+
+func testLocalDump() *LocalMetadataDump {
+	return &LocalMetadataDump{
+		Shows: []LocalShowEntry{
+			{
+				Name:      "The Office",
+				Plot:      "A mockumentary about office workers.",
+				PosterURL: "https://img.example.com/office.jpg",
+				AirDate:   "2005-03-24",
+				Episodes: []LocalEpisodeEntry{
+					{Season: 1, Episode: 1, Title: "Pilot", Plot: "The premiere.", AirDate: "2005-03-24"},
+				},
+			},
+		},
+		Movies: []LocalMovieEntry{
+			{Title: "Heat", Year: 1995, Plot: "A heist film.", ReleaseDate: "1995-12-15"},
+			{Title: "Heat", Year: 1986, Plot: "A different film."},
+		},
+	}
+}
+
+func TestLocalMetadataProvider_LookupShow(t *testing.T) {
+	p := NewLocalMetadataProviderFromDump(testLocalDump())
+	ctx := context.Background()
+
+	result, err := p.LookupShow(ctx, "  the   OFFICE ")
+	if err != nil {
+		t.Fatalf("LookupShow() error = %v", err)
+	}
+	if result.Title != "The Office" {
+		t.Errorf("Title = %q, want %q", result.Title, "The Office")
+	}
+	if result.AirDate == nil || result.AirDate.Year() != 2005 {
+		t.Errorf("AirDate = %v, want 2005-03-24", result.AirDate)
+	}
+
+	if _, err := p.LookupShow(ctx, "Unknown Show"); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupShow(unknown) error = %v, want ErrMetadataNotFound", err)
+	}
+}
+
+func TestLocalMetadataProvider_LookupEpisode(t *testing.T) {
+	p := NewLocalMetadataProviderFromDump(testLocalDump())
+	ctx := context.Background()
+
+	result, err := p.LookupEpisode(ctx, "The Office", 1, 1)
+	if err != nil {
+		t.Fatalf("LookupEpisode() error = %v", err)
+	}
+	if result.Title != "Pilot" {
+		t.Errorf("Title = %q, want %q", result.Title, "Pilot")
+	}
+	if result.PosterURL != "https://img.example.com/office.jpg" {
+		t.Errorf("PosterURL = %q, want show poster to be inherited", result.PosterURL)
+	}
+
+	if _, err := p.LookupEpisode(ctx, "The Office", 9, 9); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupEpisode(missing) error = %v, want ErrMetadataNotFound", err)
+	}
+}
+
+func TestLocalMetadataProvider_LookupMovie(t *testing.T) {
+	p := NewLocalMetadataProviderFromDump(testLocalDump())
+	ctx := context.Background()
+
+	result, err := p.LookupMovie(ctx, "heat", 1986)
+	if err != nil {
+		t.Fatalf("LookupMovie() error = %v", err)
+	}
+	if result.Plot != "A different film." {
+		t.Errorf("Plot = %q, want the 1986 entry", result.Plot)
+	}
+
+	result, err = p.LookupMovie(ctx, "Heat", 0)
+	if err != nil {
+		t.Fatalf("LookupMovie(any year) error = %v", err)
+	}
+	if result.Plot != "A heist film." {
+		t.Errorf("Plot = %q, want the first entry", result.Plot)
+	}
+
+	if _, err := p.LookupMovie(ctx, "Heat", 2020); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupMovie(wrong year) error = %v, want ErrMetadataNotFound", err)
+	}
+}
+
+func TestNewLocalMetadataProvider_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	data, err := json.Marshal(testLocalDump())
+	if err != nil {
+		t.Fatalf("Failed to marshal dump: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write dump: %v", err)
+	}
+
+	p, err := NewLocalMetadataProvider(path)
+	if err != nil {
+		t.Fatalf("NewLocalMetadataProvider() error = %v", err)
+	}
+	if _, err := p.LookupShow(context.Background(), "The Office"); err != nil {
+		t.Errorf("LookupShow() error = %v", err)
+	}
+
+	if _, err := NewLocalMetadataProvider(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing dump file")
+	}
+}
+
+// newTestMetadataServer returns a TMDB-style test server
+func newTestMetadataServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/tv", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("query") != "The Office" {
+			_, _ = w.Write([]byte(`{"results":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"id":2316,"name":"The Office","overview":"Office life.","poster_path":"/poster.jpg","first_air_date":"2005-03-24"}]}`))
+	})
+	mux.HandleFunc("/tv/2316/season/1/episode/1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"Pilot","overview":"The premiere.","still_path":"/still.jpg","air_date":"2005-03-24"}`))
+	})
+	mux.HandleFunc("/search/movie", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("year") != "1995" {
+			_, _ = w.Write([]byte(`{"results":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"id":949,"title":"Heat","overview":"A heist film.","backdrop_path":"/backdrop.jpg","release_date":"1995-12-15"}]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPMetadataProvider_Lookups(t *testing.T) {
+	server := newTestMetadataServer(t)
+	p, err := NewHTTPMetadataProvider(HTTPMetadataProviderConfig{
+		BaseURL:      server.URL + "/",
+		APIKey:       "secret",
+		ImageBaseURL: "https://img.example.com/t/p/w500",
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPMetadataProvider() error = %v", err)
+	}
+	ctx := context.Background()
+
+	show, err := p.LookupShow(ctx, "The Office")
+	if err != nil {
+		t.Fatalf("LookupShow() error = %v", err)
+	}
+	if show.Title != "The Office" || show.PosterURL != "https://img.example.com/t/p/w500/poster.jpg" {
+		t.Errorf("LookupShow() = %+v", show)
+	}
+
+	ep, err := p.LookupEpisode(ctx, "The Office", 1, 1)
+	if err != nil {
+		t.Fatalf("LookupEpisode() error = %v", err)
+	}
+	if ep.Title != "Pilot" || ep.StillURL != "https://img.example.com/t/p/w500/still.jpg" {
+		t.Errorf("LookupEpisode() = %+v", ep)
+	}
+	if ep.AirDate == nil || ep.AirDate.Format(airDateLayout) != "2005-03-24" {
+		t.Errorf("AirDate = %v, want 2005-03-24", ep.AirDate)
+	}
+
+	movie, err := p.LookupMovie(ctx, "Heat", 1995)
+	if err != nil {
+		t.Fatalf("LookupMovie() error = %v", err)
+	}
+	if movie.Title != "Heat" || movie.BackdropURL != "https://img.example.com/t/p/w500/backdrop.jpg" {
+		t.Errorf("LookupMovie() = %+v", movie)
+	}
+}
+
+func TestHTTPMetadataProvider_Errors(t *testing.T) {
+	server := newTestMetadataServer(t)
+	ctx := context.Background()
+
+	p, err := NewHTTPMetadataProvider(HTTPMetadataProviderConfig{BaseURL: server.URL, APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewHTTPMetadataProvider() error = %v", err)
+	}
+
+	if _, err := p.LookupShow(ctx, "Nope"); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupShow(no results) error = %v, want ErrMetadataNotFound", err)
+	}
+	if _, err := p.LookupEpisode(ctx, "The Office", 2, 5); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupEpisode(404) error = %v, want ErrMetadataNotFound", err)
+	}
+
+	unauthorized, err := NewHTTPMetadataProvider(HTTPMetadataProviderConfig{BaseURL: server.URL, APIKey: "wrong"})
+	if err != nil {
+		t.Fatalf("NewHTTPMetadataProvider() error = %v", err)
+	}
+	if _, err := unauthorized.LookupShow(ctx, "The Office"); !errors.Is(err, ErrMetadataUnavailable) {
+		t.Errorf("LookupShow(401) error = %v, want ErrMetadataUnavailable", err)
+	}
+
+	if _, err := NewHTTPMetadataProvider(HTTPMetadataProviderConfig{}); err == nil {
+		t.Error("Expected error for missing base URL")
+	}
+}
+
+func TestNewMetadataProvider(t *testing.T) {
+	provider, err := NewMetadataProvider(&config.MetadataConfig{Provider: "none"})
+	if err != nil || provider != nil {
+		t.Errorf("NewMetadataProvider(none) = %v, %v; want nil, nil", provider, err)
+	}
+
+	provider, err = NewMetadataProvider(&config.MetadataConfig{Provider: "http", BaseURL: "https://api.example.com/3"})
+	if err != nil {
+		t.Fatalf("NewMetadataProvider(http) error = %v", err)
+	}
+	if provider.Name() != "http" {
+		t.Errorf("Name() = %q, want http", provider.Name())
+	}
+
+	if _, err := NewMetadataProvider(&config.MetadataConfig{Provider: "bogus"}); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+}
@@ -0,0 +1,227 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// Reconciliation errors
+var (
+	ErrRelinkInvalidPath = errors.New("relink target must be an absolute path to a readable video file")
+	ErrRelinkPathInUse   = errors.New("relink target is already used by another media item")
+)
+
+// ReconcileResult summarises a pass comparing media records against files on disk
+type ReconcileResult struct {
+	Checked  int   `json:"checked"`
+	Missing  int64 `json:"newly_missing"`
+	Restored int64 `json:"restored"`
+}
+
+// MissingMediaEntry is a missing media item together with the playlists that still reference it
+type MissingMediaEntry struct {
+	Media    *models.Media         `json:"media"`
+	Channels []*db.MediaChannelRef `json:"channels"`
+}
+
+// ReconciliationReport lists missing media and the channels affected by them
+type ReconciliationReport struct {
+	Missing          []*MissingMediaEntry `json:"missing"`
+	Total            int                  `json:"total"`
+	AffectedChannels int                  `json:"affected_channels"`
+	Verified         *ReconcileResult     `json:"verified,omitempty"` // Set when the report re-checked files first
+	GeneratedAt      time.Time            `json:"generated_at"`
+}
+
+// Reconciler keeps media availability in sync with the filesystem and repairs missing entries
+type Reconciler struct {
+	repos *db.Repositories
+}
+
+// NewReconciler creates a new library reconciler
+func NewReconciler(repos *db.Repositories) *Reconciler {
+	return &Reconciler{
+		repos: repos,
+	}
+}
+
+// Verify checks every media file under dirPath (whole library when empty) and updates its status
+func (r *Reconciler) Verify(ctx context.Context, dirPath string) (*ReconcileResult, error) {
+	return r.reconcile(ctx, dirPath, fileStatus)
+}
+
+// ReconcileScan updates statuses under dirPath after a completed scan; found holds every
+// video file the scan saw, so anything else under dirPath is missing
+func (r *Reconciler) ReconcileScan(ctx context.Context, dirPath string, found []string) (*ReconcileResult, error) {
+	seen := make(map[string]struct{}, len(found))
+	for _, path := range found {
+		seen[path] = struct{}{}
+	}
+
+	return r.reconcile(ctx, dirPath, func(path string) (bool, bool) {
+		_, ok := seen[path]
+		return ok, true
+	})
+}
+
+// reconcile marks media missing or available according to check, which reports whether the
+// file exists and whether that answer is certain (uncertain results leave the status alone)
+func (r *Reconciler) reconcile(ctx context.Context, dirPath string, check func(path string) (exists, known bool)) (*ReconcileResult, error) {
+	statuses, err := r.repos.Media.ListFileStatuses(ctx, dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing, restored []uuid.UUID
+	for _, status := range statuses {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		exists, known := check(status.FilePath)
+		if !known {
+			continue
+		}
+
+		switch {
+		case !exists && status.Status != models.MediaStatusMissing:
+			missing = append(missing, status.ID)
+		case exists && status.Status == models.MediaStatusMissing:
+			restored = append(restored, status.ID)
+		}
+	}
+
+	result := &ReconcileResult{Checked: len(statuses)}
+
+	if result.Missing, err = r.repos.Media.SetStatus(ctx, missing, models.MediaStatusMissing); err != nil {
+		return nil, err
+	}
+	if result.Restored, err = r.repos.Media.SetStatus(ctx, restored, models.MediaStatusAvailable); err != nil {
+		return nil, err
+	}
+
+	if result.Missing > 0 || result.Restored > 0 {
+		logger.Log.Info().
+			Str("directory", dirPath).
+			Int("checked", result.Checked).
+			Int64("newly_missing", result.Missing).
+			Int64("restored", result.Restored).
+			Msg("Reconciled media availability")
+	}
+
+	return result, nil
+}
+
+// Report lists every missing media item and the channel playlists that reference it
+func (r *Reconciler) Report(ctx context.Context) (*ReconciliationReport, error) {
+	missing, err := r.repos.Media.ListMissing(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(missing))
+	for i, m := range missing {
+		ids[i] = m.ID
+	}
+
+	refs, err := r.repos.PlaylistItems.ListChannelRefsByMedia(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconciliationReport{
+		Missing:     make([]*MissingMediaEntry, 0, len(missing)),
+		Total:       len(missing),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	channels := make(map[uuid.UUID]struct{})
+	for _, m := range missing {
+		entry := &MissingMediaEntry{
+			Media:    m,
+			Channels: refs[m.ID],
+		}
+		if entry.Channels == nil {
+			entry.Channels = []*db.MediaChannelRef{}
+		}
+		for _, ref := range entry.Channels {
+			channels[ref.ChannelID] = struct{}{}
+		}
+		report.Missing = append(report.Missing, entry)
+	}
+	report.AffectedChannels = len(channels)
+
+	return report, nil
+}
+
+// Purge deletes missing media (all of it when ids is empty) and removes it from every playlist.
+// IDs that are not marked missing are ignored. Returns the IDs that were deleted.
+func (r *Reconciler) Purge(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	purged, err := r.repos.Media.PurgeMissing(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info().
+		Int("requested", len(ids)).
+		Int("purged", len(purged)).
+		Msg("Purged missing media")
+
+	return purged, nil
+}
+
+// Relink points a media item at a new file (e.g. after the library was moved) and marks it available
+func (r *Reconciler) Relink(ctx context.Context, id uuid.UUID, filePath string) (*models.Media, error) {
+	filePath = filepath.Clean(strings.TrimSpace(filePath))
+	if !filepath.IsAbs(filePath) || !isVideoFile(filePath) {
+		return nil, ErrRelinkInvalidPath
+	}
+	if validation := ValidateFile(filePath); !validation.Readable {
+		return nil, fmt.Errorf("%w: %s", ErrRelinkInvalidPath, strings.Join(validation.Reasons, ", "))
+	}
+
+	existing, err := r.repos.Media.GetByPath(ctx, filePath)
+	switch {
+	case err == nil && existing.ID != id:
+		return nil, ErrRelinkPathInUse
+	case err != nil && !db.IsNotFound(err):
+		return nil, err
+	}
+
+	if err := r.repos.Media.Relink(ctx, id, filePath); err != nil {
+		if db.IsDuplicate(err) {
+			return nil, ErrRelinkPathInUse
+		}
+		return nil, err
+	}
+
+	logger.Log.Info().
+		Str("media_id", id.String()).
+		Str("file_path", filePath).
+		Msg("Relinked media to new file")
+
+	return r.repos.Media.GetByID(ctx, id)
+}
+
+// fileStatus reports whether a media file exists; errors other than "not exist"
+// (e.g. permission or I/O errors) are treated as unknown
+func fileStatus(path string) (exists, known bool) {
+	info, err := os.Stat(path)
+	if err == nil {
+		return !info.IsDir(), true
+	}
+	if os.IsNotExist(err) {
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,102 @@
+package media
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stwalsh4118/hermes/internal/db"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// createStatusTestMedia inserts a media row for path with the given status
+func createStatusTestMedia(t *testing.T, repos *db.Repositories, path, status string) *models.Media {
+	t.Helper()
+
+	ctx := context.Background()
+	item := models.NewMedia(path, filepath.Base(path), 60)
+	require.NoError(t, repos.Media.Create(ctx, item))
+	if status == models.MediaStatusMissing {
+		_, err := repos.Media.SetStatus(ctx, []uuid.UUID{item.ID}, status)
+		require.NoError(t, err)
+	}
+	return item
+}
+
+// mediaStatus reads back the stored status of a media item
+func mediaStatus(t *testing.T, repos *db.Repositories, item *models.Media) string {
+	t.Helper()
+
+	stored, err := repos.Media.GetByID(context.Background(), item.ID)
+	require.NoError(t, err)
+	return stored.Status
+}
+
+func TestReconcileScan(t *testing.T) {
+	scanner, _, cleanup := setupTestScanner(t)
+	defer cleanup()
+	defer scanner.Stop()
+
+	repos := scanner.repos
+	libraryDir := t.TempDir()
+	otherDir := t.TempDir()
+
+	found := createStatusTestMedia(t, repos, filepath.Join(libraryDir, "found.mp4"), models.MediaStatusAvailable)
+	returned := createStatusTestMedia(t, repos, filepath.Join(libraryDir, "sub", "returned.mkv"), models.MediaStatusMissing)
+	gone := createStatusTestMedia(t, repos, filepath.Join(libraryDir, "gone.mp4"), models.MediaStatusAvailable)
+	outside := createStatusTestMedia(t, repos, filepath.Join(otherDir, "outside.mp4"), models.MediaStatusAvailable)
+	// Shares the library path as a string prefix but lives in a sibling directory
+	sibling := createStatusTestMedia(t, repos, libraryDir+"-extra/sibling.mp4", models.MediaStatusAvailable)
+
+	result, err := scanner.reconciler.ReconcileScan(context.Background(), libraryDir, []string{found.FilePath, returned.FilePath})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Checked)
+	assert.Equal(t, int64(1), result.Missing)
+	assert.Equal(t, int64(1), result.Restored)
+
+	assert.Equal(t, models.MediaStatusAvailable, mediaStatus(t, repos, found))
+	assert.Equal(t, models.MediaStatusAvailable, mediaStatus(t, repos, returned))
+	assert.Equal(t, models.MediaStatusMissing, mediaStatus(t, repos, gone))
+	assert.Equal(t, models.MediaStatusAvailable, mediaStatus(t, repos, outside))
+	assert.Equal(t, models.MediaStatusAvailable, mediaStatus(t, repos, sibling))
+}
+
+func TestReconcilerVerify(t *testing.T) {
+	scanner, _, cleanup := setupTestScanner(t)
+	defer cleanup()
+	defer scanner.Stop()
+
+	repos := scanner.repos
+	dir := t.TempDir()
+	createTestVideoFiles(t, dir, []string{"present.mp4"})
+
+	present := createStatusTestMedia(t, repos, filepath.Join(dir, "present.mp4"), models.MediaStatusMissing)
+	absent := createStatusTestMedia(t, repos, filepath.Join(dir, "absent.mp4"), models.MediaStatusAvailable)
+
+	result, err := NewReconciler(repos).Verify(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, int64(1), result.Missing)
+	assert.Equal(t, int64(1), result.Restored)
+
+	assert.Equal(t, models.MediaStatusAvailable, mediaStatus(t, repos, present))
+	assert.Equal(t, models.MediaStatusMissing, mediaStatus(t, repos, absent))
+
+	// Running again is a no-op and keeps the original missing_since
+	before, err := repos.Media.GetByID(context.Background(), absent.ID)
+	require.NoError(t, err)
+
+	result, err = NewReconciler(repos).Verify(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Missing)
+	assert.Equal(t, int64(0), result.Restored)
+
+	after, err := repos.Media.GetByID(context.Background(), absent.ID)
+	require.NoError(t, err)
+	require.NotNil(t, after.MissingSince)
+	assert.True(t, before.MissingSince.Equal(*after.MissingSince))
+}
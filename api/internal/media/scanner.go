@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stwalsh4118/hermes/internal/db"
 	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/metrics"
 	"github.com/stwalsh4118/hermes/internal/models"
 )
 
@@ -23,6 +24,7 @@ var supportedVideoFormats = []string{".mp4", ".mkv", ".avi", ".mov"}
 const (
 	scanRetentionPeriod = 1 * time.Hour    // Keep completed scans for 1 hour
 	cleanupInterval     = 15 * time.Minute // Run cleanup every 15 minutes
+	artworkQueueSize    = 1024             // Scanned files waiting for artwork; the media API generates the rest on demand
 )
 
 // ScanStatus represents the current state of a media scan
@@ -51,6 +53,7 @@ type ScanProgress struct {
 	ProcessedFiles int        `json:"processed_files"`
 	SuccessCount   int        `json:"success_count"`
 	FailedCount    int        `json:"failed_count"`
+	MissingCount   int64      `json:"missing_count"` // Previously known files under the directory that are now gone
 	CurrentFile    string     `json:"current_file"`
 	StartTime      time.Time  `json:"start_time"`
 	EndTime        *time.Time `json:"end_time,omitempty"`
@@ -64,14 +67,20 @@ type Scanner struct {
 	repos       *db.Repositories
 	activeScans map[string]*ScanProgress
 	mu          sync.RWMutex
-	stopCleanup chan struct{} // Signal to stop cleanup goroutine
-	cleanupDone chan struct{} // Signal when cleanup goroutine has stopped
+	stopCleanup chan struct{}       // Signal to stop cleanup goroutine
+	cleanupDone chan struct{}       // Signal when cleanup goroutine has stopped
+	thumbnails  *ThumbnailGenerator // Optional; artwork is skipped when nil
+	artwork     chan *models.Media  // Scanned files waiting for artwork generation
+	artworkDone chan struct{}       // Closed when the artwork worker has stopped (nil if never started)
+	reconciler  *Reconciler
+	libraryPath string // Default scan directory (see ApplySettings)
 }
 
 // NewScanner creates a new media scanner instance
 func NewScanner(repos *db.Repositories) *Scanner {
 	s := &Scanner{
 		repos:       repos,
+		reconciler:  NewReconciler(repos),
 		activeScans: make(map[string]*ScanProgress),
 		stopCleanup: make(chan struct{}),
 		cleanupDone: make(chan struct{}),
@@ -162,6 +171,7 @@ func (s *Scanner) GetScanProgress(scanID string) (*ScanProgress, error) {
 		ProcessedFiles: progress.ProcessedFiles,
 		SuccessCount:   progress.SuccessCount,
 		FailedCount:    progress.FailedCount,
+		MissingCount:   progress.MissingCount,
 		CurrentFile:    progress.CurrentFile,
 		StartTime:      progress.StartTime,
 		EndTime:        progress.EndTime,
@@ -217,6 +227,7 @@ func (s *Scanner) performScan(ctx context.Context, scanID, dirPath string) {
 
 	progress.mu.Lock()
 	progress.TotalFiles = len(videoFiles)
+	walkFailed := len(progress.Errors) > 0
 	progress.mu.Unlock()
 
 	logger.Log.Info().
@@ -237,10 +248,39 @@ func (s *Scanner) performScan(ctx context.Context, scanID, dirPath string) {
 		s.processVideoFile(ctx, filePath, progress)
 	}
 
+	// Only a complete walk can tell which known files have disappeared
+	if walkFailed {
+		logger.Log.Warn().
+			Str("scan_id", scanID).
+			Msg("Skipping missing-file detection because parts of the directory could not be read")
+	} else {
+		s.reconcileAvailability(ctx, dirPath, videoFiles, progress)
+	}
+
 	// Finalize scan
 	s.finalizeScan(progress, ScanStatusCompleted)
 }
 
+// reconcileAvailability marks media under dirPath missing (or available again) based on the files found
+func (s *Scanner) reconcileAvailability(ctx context.Context, dirPath string, videoFiles []string, progress *ScanProgress) {
+	result, err := s.reconciler.ReconcileScan(ctx, dirPath, videoFiles)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile media availability: %v", err)
+		logger.Log.Error().
+			Err(err).
+			Str("scan_id", progress.ScanID).
+			Msg("Failed to reconcile media availability")
+		progress.mu.Lock()
+		progress.Errors = append(progress.Errors, errMsg)
+		progress.mu.Unlock()
+		return
+	}
+
+	progress.mu.Lock()
+	progress.MissingCount = result.Missing
+	progress.mu.Unlock()
+}
+
 // findVideoFiles walks the directory tree and returns all video file paths
 func (s *Scanner) findVideoFiles(ctx context.Context, dirPath string, progress *ScanProgress) []string {
 	var videoFiles []string
@@ -330,6 +370,8 @@ func (s *Scanner) processVideoFile(ctx context.Context, filePath string, progres
 	media.AudioCodec = &metadata.AudioCodec
 	media.Resolution = &metadata.Resolution
 	media.FileSize = &metadata.FileSize
+	media.HDR = metadata.HDR
+	media.Interlaced = metadata.Interlaced
 
 	// Log transcoding requirement if needed
 	if codecValidation.RequiresTranscode {
@@ -346,11 +388,15 @@ func (s *Scanner) processVideoFile(ctx context.Context, filePath string, progres
 		return
 	}
 
+	// Artwork is generated in the background; failures are non-fatal, the item is still usable without a thumbnail
+	s.queueArtwork(media)
+
 	// Record success
 	progress.mu.Lock()
 	progress.SuccessCount++
 	progress.ProcessedFiles++
 	progress.mu.Unlock()
+	metrics.ScanFilesTotal.WithLabelValues("success").Inc()
 
 	logger.Log.Debug().
 		Str("file", filePath).
@@ -386,6 +432,126 @@ func (s *Scanner) upsertMedia(ctx context.Context, media *models.Media) error {
 	return s.repos.Media.Update(ctx, media)
 }
 
+// ApplySettings applies runtime settings to the scanner; the media library path
+// becomes the default directory for scans requested without a path
+func (s *Scanner) ApplySettings(settings *models.Settings) {
+	s.mu.Lock()
+	s.libraryPath = settings.MediaLibraryPath
+	s.mu.Unlock()
+
+	logger.Log.Info().
+		Str("library_path", settings.MediaLibraryPath).
+		Msg("Applied scanner settings")
+}
+
+// LibraryPath returns the configured media library path, or "" if none is set
+func (s *Scanner) LibraryPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.libraryPath
+}
+
+// SetThumbnailGenerator enables thumbnail (and sprite) generation for scanned files.
+// Artwork is generated by a background worker so it does not slow scans down.
+// Must be called before any scans are started
+func (s *Scanner) SetThumbnailGenerator(generator *ThumbnailGenerator) {
+	s.thumbnails = generator
+	s.artwork = make(chan *models.Media, artworkQueueSize)
+	s.artworkDone = make(chan struct{})
+	go s.runArtworkWorker()
+}
+
+// queueArtwork hands a scanned file to the artwork worker. When the queue is full the file is
+// skipped; its artwork is generated the first time the media API serves it.
+func (s *Scanner) queueArtwork(media *models.Media) {
+	if s.thumbnails == nil {
+		return
+	}
+	select {
+	case s.artwork <- media:
+	default:
+		logger.Log.Debug().
+			Str("file", media.FilePath).
+			Msg("Artwork queue full, leaving artwork to on-demand generation")
+	}
+}
+
+// runArtworkWorker generates the artwork of queued files one at a time until the scanner stops
+func (s *Scanner) runArtworkWorker() {
+	defer close(s.artworkDone)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopCleanup
+		cancel() // Abort a running ffmpeg on stop
+	}()
+
+	for {
+		select {
+		case <-s.stopCleanup:
+			return
+		case media := <-s.artwork:
+			s.generateArtwork(ctx, media)
+		}
+	}
+}
+
+// generateArtwork creates any missing thumbnail/sprite images and stores their paths on the media record
+func (s *Scanner) generateArtwork(ctx context.Context, media *models.Media) {
+	if s.thumbnails == nil {
+		return
+	}
+
+	thumbnailPath, err := ensureArtwork(ctx, media, s.thumbnails.ThumbnailPath(media.ID), s.thumbnails.GenerateThumbnail)
+	if err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("file", media.FilePath).
+			Msg("Failed to generate thumbnail")
+	}
+
+	var spritePath *string
+	if s.thumbnails.SpritesEnabled() {
+		spritePath, err = ensureArtwork(ctx, media, s.thumbnails.SpritePath(media.ID), s.thumbnails.GenerateSprite)
+		if err != nil {
+			logger.Log.Warn().
+				Err(err).
+				Str("file", media.FilePath).
+				Msg("Failed to generate sprite sheet")
+		}
+	}
+
+	if thumbnailPath == nil && spritePath == nil {
+		return
+	}
+
+	if err := s.repos.Media.UpdateArtwork(ctx, media.ID, thumbnailPath, spritePath); err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("file", media.FilePath).
+			Msg("Failed to store artwork paths")
+	}
+}
+
+// ensureArtwork returns the existing image at path, or generates it when missing (e.g. first scan)
+func ensureArtwork(
+	ctx context.Context,
+	media *models.Media,
+	path string,
+	generate func(context.Context, uuid.UUID, string, int64) (string, error),
+) (*string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return &path, nil
+	}
+
+	generated, err := generate(ctx, media.ID, media.FilePath, media.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return &generated, nil
+}
+
 // recordFileError logs and records an error for a specific file
 func (s *Scanner) recordFileError(progress *ScanProgress, filePath string, err error) {
 	errMsg := fmt.Sprintf("%s: %v", filePath, err)
@@ -399,6 +565,7 @@ func (s *Scanner) recordFileError(progress *ScanProgress, filePath string, err e
 	progress.ProcessedFiles++
 	progress.Errors = append(progress.Errors, errMsg)
 	progress.mu.Unlock()
+	metrics.ScanFilesTotal.WithLabelValues("failed").Inc()
 }
 
 // finalizeScan completes the scan and updates final status
@@ -411,6 +578,13 @@ func (s *Scanner) finalizeScan(progress *ScanProgress, status ScanStatus) {
 	progress.CurrentFile = ""
 	progress.mu.Unlock()
 
+	duration := endTime.Sub(progress.StartTime)
+	metrics.ScansTotal.WithLabelValues(string(status)).Inc()
+	metrics.ScanDurationSeconds.Observe(duration.Seconds())
+	if duration > 0 {
+		metrics.ScanFilesPerSecond.Set(float64(progress.ProcessedFiles) / duration.Seconds())
+	}
+
 	logger.Log.Info().
 		Str("scan_id", progress.ScanID).
 		Str("status", string(status)).
@@ -418,7 +592,7 @@ func (s *Scanner) finalizeScan(progress *ScanProgress, status ScanStatus) {
 		Int("success_count", progress.SuccessCount).
 		Int("failed_count", progress.FailedCount).
 		Int("error_count", len(progress.Errors)).
-		Dur("duration", endTime.Sub(progress.StartTime)).
+		Dur("duration", duration).
 		Msg("Media scan completed")
 }
 
@@ -438,6 +612,9 @@ func isVideoFile(filePath string) bool {
 func (s *Scanner) Stop() {
 	close(s.stopCleanup)
 	<-s.cleanupDone
+	if s.artworkDone != nil {
+		<-s.artworkDone
+	}
 	logger.Log.Debug().Msg("Scanner cleanup goroutine stopped")
 }
 
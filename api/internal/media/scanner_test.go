@@ -794,3 +794,25 @@ func TestScanner_CleanupRunning(t *testing.T) {
 		t.Fatal("Cleanup goroutine did not stop")
 	}
 }
+
+func TestQueueArtwork_DoesNotBlockScans(t *testing.T) {
+	// No worker drains the queue, as if it were busy with a long file
+	s := &Scanner{
+		thumbnails: NewThumbnailGenerator(t.TempDir(), false),
+		artwork:    make(chan *models.Media, 1),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.queueArtwork(models.NewMedia("/media/a.mp4", "A", 100))
+		s.queueArtwork(models.NewMedia("/media/b.mp4", "B", 100)) // Queue full: left to on-demand generation
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("queueArtwork blocked on a full queue")
+	}
+	assert.Len(t, s.artwork, 1)
+}
@@ -0,0 +1,206 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/logger"
+)
+
+// Thumbnail generation settings
+const (
+	ffmpegThumbnailTimeout = 60 * time.Second
+	thumbnailWidth         = 480 // Poster frame width in pixels (height keeps aspect ratio)
+	spriteTileWidth        = 160 // Width of each sprite sheet tile in pixels
+	spriteColumns          = 10  // Sprite sheet grid columns
+	spriteRows             = 10  // Sprite sheet grid rows
+	maxThumbnailOffset     = 300 // Never seek further than 5 minutes for the poster frame
+	thumbnailFileExt       = ".jpg"
+	spriteFileSuffix       = "_sprite"
+)
+
+// Thumbnail errors
+var (
+	ErrFFmpegNotFound       = errors.New("ffmpeg not found in PATH")
+	ErrThumbnailFailed      = errors.New("thumbnail generation failed")
+	ErrSpritesDisabled      = errors.New("sprite sheet generation is disabled")
+	ErrInvalidMediaDuration = errors.New("media duration must be positive")
+)
+
+// ThumbnailGenerator extracts poster frames and scrubbing sprite sheets from media files using FFmpeg
+type ThumbnailGenerator struct {
+	outputDir       string
+	generateSprites bool
+}
+
+// NewThumbnailGenerator creates a generator that writes images to outputDir
+func NewThumbnailGenerator(outputDir string, generateSprites bool) *ThumbnailGenerator {
+	return &ThumbnailGenerator{
+		outputDir:       outputDir,
+		generateSprites: generateSprites,
+	}
+}
+
+// SpritesEnabled reports whether sprite sheets are generated
+func (g *ThumbnailGenerator) SpritesEnabled() bool {
+	return g.generateSprites
+}
+
+// ThumbnailPath returns the poster frame path for a media ID
+func (g *ThumbnailGenerator) ThumbnailPath(id uuid.UUID) string {
+	return filepath.Join(g.outputDir, id.String()+thumbnailFileExt)
+}
+
+// SpritePath returns the sprite sheet path for a media ID
+func (g *ThumbnailGenerator) SpritePath(id uuid.UUID) string {
+	return filepath.Join(g.outputDir, id.String()+spriteFileSuffix+thumbnailFileExt)
+}
+
+// GenerateThumbnail extracts a representative frame from the media file and returns its path
+func (g *ThumbnailGenerator) GenerateThumbnail(ctx context.Context, id uuid.UUID, filePath string, duration int64) (string, error) {
+	if duration <= 0 {
+		return "", ErrInvalidMediaDuration
+	}
+
+	outputPath := g.ThumbnailPath(id)
+	args := buildThumbnailArgs(filePath, thumbnailOffset(duration), thumbnailWidth)
+	if err := g.runFFmpeg(ctx, args, outputPath); err != nil {
+		return "", err
+	}
+
+	logger.Log.Debug().
+		Str("media_id", id.String()).
+		Str("thumbnail_path", outputPath).
+		Msg("Generated thumbnail")
+
+	return outputPath, nil
+}
+
+// GenerateSprite builds a tiled sprite sheet spanning the whole media file and returns its path
+func (g *ThumbnailGenerator) GenerateSprite(ctx context.Context, id uuid.UUID, filePath string, duration int64) (string, error) {
+	if !g.generateSprites {
+		return "", ErrSpritesDisabled
+	}
+	if duration <= 0 {
+		return "", ErrInvalidMediaDuration
+	}
+
+	outputPath := g.SpritePath(id)
+	args := buildSpriteArgs(filePath, spriteInterval(duration), spriteTileWidth, spriteColumns, spriteRows)
+	if err := g.runFFmpeg(ctx, args, outputPath); err != nil {
+		return "", err
+	}
+
+	logger.Log.Debug().
+		Str("media_id", id.String()).
+		Str("sprite_path", outputPath).
+		Msg("Generated sprite sheet")
+
+	return outputPath, nil
+}
+
+// Remove deletes any generated images for a media ID
+func (g *ThumbnailGenerator) Remove(id uuid.UUID) {
+	for _, path := range []string{g.ThumbnailPath(id), g.SpritePath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Log.Warn().
+				Err(err).
+				Str("path", path).
+				Msg("Failed to remove generated artwork")
+		}
+	}
+}
+
+// runFFmpeg runs ffmpeg with the given args, writing to a temp file that is renamed into place on success
+func (g *ThumbnailGenerator) runFFmpeg(ctx context.Context, args []string, outputPath string) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return ErrFFmpegNotFound
+	}
+
+	if err := os.MkdirAll(g.outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create thumbnail directory: %w", err)
+	}
+
+	// Keep the image extension so ffmpeg picks the right muxer
+	tmpPath := outputPath + ".tmp" + thumbnailFileExt
+
+	ctx, cancel := context.WithTimeout(ctx, ffmpegThumbnailTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", append(args, tmpPath)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("%w: %w: %s", ErrThumbnailFailed, err, lastLines(string(output), 3))
+	}
+
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to move thumbnail into place: %w", err)
+	}
+
+	return nil
+}
+
+// buildThumbnailArgs returns ffmpeg args (without output path) that grab a single scaled frame
+func buildThumbnailArgs(inputPath string, offsetSeconds int64, width int) []string {
+	return []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-y",
+		"-ss", strconv.FormatInt(offsetSeconds, 10), // Input seeking is fast and keyframe-accurate enough
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("thumbnail,scale=%d:-2", width), // Pick the most representative of the next frames
+		"-q:v", "3",
+	}
+}
+
+// buildSpriteArgs returns ffmpeg args (without output path) that tile evenly spaced frames into one image.
+// Only keyframes are decoded, so feature-length media is tiled within the ffmpeg timeout.
+func buildSpriteArgs(inputPath string, intervalSeconds int64, tileWidth, columns, rows int) []string {
+	return []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-y",
+		"-skip_frame", "nokey", // Tiles snap to the nearest keyframe
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("fps=1/%d,scale=%d:-2,tile=%dx%d", intervalSeconds, tileWidth, columns, rows),
+		"-q:v", "5",
+	}
+}
+
+// thumbnailOffset picks a seek position ~10% into the media, skipping cold opens and black intros
+func thumbnailOffset(duration int64) int64 {
+	offset := duration / 10
+	if offset > maxThumbnailOffset {
+		offset = maxThumbnailOffset
+	}
+	return offset
+}
+
+// spriteInterval returns the seconds between sprite tiles so the grid spans the whole media
+func spriteInterval(duration int64) int64 {
+	interval := duration / int64(spriteColumns*spriteRows)
+	if interval < 1 {
+		interval = 1
+	}
+	return interval
+}
+
+// lastLines returns the last n non-empty lines of s for compact error messages
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "; ")
+}
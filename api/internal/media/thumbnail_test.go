@@ -0,0 +1,125 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestThumbnailOffset(t *testing.T) {
+	tests := []struct {
+		duration int64
+		want     int64
+	}{
+		{duration: 5, want: 0},
+		{duration: 600, want: 60},
+		{duration: 7200, want: maxThumbnailOffset},
+	}
+
+	for _, tt := range tests {
+		if got := thumbnailOffset(tt.duration); got != tt.want {
+			t.Errorf("thumbnailOffset(%d) = %d, want %d", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestSpriteInterval(t *testing.T) {
+	if got := spriteInterval(30); got != 1 {
+		t.Errorf("spriteInterval(30) = %d, want 1", got)
+	}
+	if got := spriteInterval(3000); got != 30 {
+		t.Errorf("spriteInterval(3000) = %d, want 30", got)
+	}
+}
+
+func TestBuildThumbnailArgs(t *testing.T) {
+	args := strings.Join(buildThumbnailArgs("/media/show.mkv", 60, 480), " ")
+
+	for _, want := range []string{"-ss 60 -i /media/show.mkv", "-frames:v 1", "thumbnail,scale=480:-2"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("buildThumbnailArgs() = %q, missing %q", args, want)
+		}
+	}
+}
+
+func TestBuildSpriteArgs(t *testing.T) {
+	args := strings.Join(buildSpriteArgs("/media/show.mkv", 30, 160, 10, 10), " ")
+
+	if !strings.Contains(args, "fps=1/30,scale=160:-2,tile=10x10") {
+		t.Errorf("buildSpriteArgs() = %q, missing tile filter", args)
+	}
+	if !strings.Contains(args, "-skip_frame nokey -i /media/show.mkv") {
+		t.Errorf("buildSpriteArgs() = %q, decodes every frame", args)
+	}
+}
+
+func TestThumbnailGenerator_Paths(t *testing.T) {
+	g := NewThumbnailGenerator("/data/thumbs", true)
+	id := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+
+	if got := g.ThumbnailPath(id); got != filepath.Join("/data/thumbs", id.String()+".jpg") {
+		t.Errorf("ThumbnailPath() = %q", got)
+	}
+	if got := g.SpritePath(id); got != filepath.Join("/data/thumbs", id.String()+"_sprite.jpg") {
+		t.Errorf("SpritePath() = %q", got)
+	}
+}
+
+func TestThumbnailGenerator_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	g := NewThumbnailGenerator(t.TempDir(), false)
+	if _, err := g.GenerateSprite(ctx, uuid.New(), "/missing.mp4", 100); !errors.Is(err, ErrSpritesDisabled) {
+		t.Errorf("GenerateSprite() error = %v, want ErrSpritesDisabled", err)
+	}
+	if _, err := g.GenerateThumbnail(ctx, uuid.New(), "/missing.mp4", 0); !errors.Is(err, ErrInvalidMediaDuration) {
+		t.Errorf("GenerateThumbnail() error = %v, want ErrInvalidMediaDuration", err)
+	}
+}
+
+func TestThumbnailGenerator_Generate(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("FFmpeg not installed, skipping tests")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	input := filepath.Join(dir, "test.mp4")
+
+	// Create a short synthetic test video
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "testsrc=duration=3:size=320x240:rate=10", input)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create test video: %v: %s", err, output)
+	}
+
+	g := NewThumbnailGenerator(filepath.Join(dir, "thumbs"), true)
+	id := uuid.New()
+
+	thumbPath, err := g.GenerateThumbnail(ctx, id, input, 3)
+	if err != nil {
+		t.Fatalf("GenerateThumbnail() error = %v", err)
+	}
+	if _, err := os.Stat(thumbPath); err != nil {
+		t.Errorf("Thumbnail not written: %v", err)
+	}
+
+	spritePath, err := g.GenerateSprite(ctx, id, input, 3)
+	if err != nil {
+		t.Fatalf("GenerateSprite() error = %v", err)
+	}
+	if _, err := os.Stat(spritePath); err != nil {
+		t.Errorf("Sprite not written: %v", err)
+	}
+
+	g.Remove(id)
+	if _, err := os.Stat(thumbPath); !os.IsNotExist(err) {
+		t.Errorf("Thumbnail not removed")
+	}
+}
@@ -0,0 +1,103 @@
+// Package metrics defines the Prometheus metrics exported by Hermes.
+//
+// Event metrics (latencies, failures, scan throughput) are package-level collectors
+// updated by the code that observes them. State that already lives in memory (sessions,
+// circuit breakers, playlists) is read at scrape time by collectors registered with Register.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace is the prefix for every Hermes metric
+const Namespace = "hermes"
+
+// Streaming metrics
+var (
+	// SegmentGenerationSeconds observes how long FFmpeg takes to produce a single segment
+	SegmentGenerationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "stream",
+		Name:      "segment_generation_seconds",
+		Help:      "Time taken to generate a single stream segment.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 3, 4, 6, 8, 12, 20},
+	}, []string{"quality", "hardware_accel"})
+
+	// SegmentRealtimeRatio observes generation time divided by segment duration (above 1 is slower than realtime)
+	SegmentRealtimeRatio = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "stream",
+		Name:      "segment_realtime_ratio",
+		Help:      "Segment generation time divided by segment duration; values above 1 are slower than realtime.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 4},
+	}, []string{"quality", "hardware_accel"})
+
+	// FFmpegFailuresTotal counts FFmpeg failures by classified error type
+	FFmpegFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "ffmpeg",
+		Name:      "failures_total",
+		Help:      "FFmpeg failures by classified error type.",
+	}, []string{"error_type"})
+)
+
+// Scanner metrics
+var (
+	// ScanFilesTotal counts scanned files by result (success, failed)
+	ScanFilesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "scan",
+		Name:      "files_total",
+		Help:      "Media files processed by the scanner, by result.",
+	}, []string{"result"})
+
+	// ScansTotal counts finished scans by final status
+	ScansTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "scan",
+		Name:      "scans_total",
+		Help:      "Finished media scans by final status.",
+	}, []string{"status"})
+
+	// ScanDurationSeconds observes the wall time of finished scans
+	ScanDurationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "scan",
+		Name:      "duration_seconds",
+		Help:      "Wall time of finished media scans.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	// ScanFilesPerSecond reports the throughput of the most recent scan
+	ScanFilesPerSecond = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "scan",
+		Name:      "last_files_per_second",
+		Help:      "Files processed per second by the most recently finished scan.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		SegmentGenerationSeconds,
+		SegmentRealtimeRatio,
+		FFmpegFailuresTotal,
+		ScanFilesTotal,
+		ScansTotal,
+		ScanDurationSeconds,
+		ScanFilesPerSecond,
+	)
+}
+
+// Register adds a scrape-time collector to the default registry
+func Register(collector prometheus.Collector) error {
+	return prometheus.Register(collector)
+}
+
+// Handler returns the HTTP handler serving the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
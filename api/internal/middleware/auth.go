@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/auth"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// Context keys set by the auth middleware
+const (
+	userContextKey        = "auth_user"
+	credentialContextKey  = "auth_credential"
+	streamTokenContextKey = "auth_stream_token"
+	shareTokenContextKey  = "auth_share_token"
+)
+
+// Query parameters carrying signed stream credentials
+const (
+	StreamTokenQueryParam = "token"
+	ShareTokenQueryParam  = "share"
+)
+
+// Authenticator resolves a session token or API key to a user
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*models.User, error)
+}
+
+// StreamTokenVerifier validates signed stream tokens and share links
+type StreamTokenVerifier interface {
+	VerifyStreamToken(token string, channelID uuid.UUID) (*auth.StreamTokenClaims, error)
+	VerifyShareToken(token string, channelID uuid.UUID) (*auth.ShareTokenClaims, error)
+}
+
+// RequireAuth returns a middleware that rejects requests without a valid session token or API key.
+// Credentials are read from "Authorization: Bearer <token>" or the X-API-Key header.
+func RequireAuth(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticateRequest(c, authenticator) {
+			abortUnauthorized(c, "Authentication required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireStreamAccess returns a middleware for stream routes that accepts a regular credential,
+// a signed stream token for the :channel_id in the "token" query parameter, or a share link
+// token in the "share" query parameter.
+// Query tokens let IPTV clients that cannot send headers play a stream URL directly.
+// Share links carry no user; stream handlers enforce their session limits.
+func RequireStreamAccess(authenticator Authenticator, verifier StreamTokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticateRequest(c, authenticator) {
+			c.Next()
+			return
+		}
+
+		token := c.Query(StreamTokenQueryParam)
+		shareToken := c.Query(ShareTokenQueryParam)
+		channelID, err := uuid.Parse(c.Param("channel_id"))
+		if (token == "" && shareToken == "") || err != nil {
+			abortUnauthorized(c, "Authentication or a stream token is required")
+			return
+		}
+
+		if token == "" {
+			if _, err := verifier.VerifyShareToken(shareToken, channelID); err != nil {
+				message := "Invalid share link"
+				if errors.Is(err, auth.ErrShareTokenExpired) {
+					message = "Share link has expired"
+				}
+				abortUnauthorized(c, message)
+				return
+			}
+			c.Set(shareTokenContextKey, shareToken)
+			c.Next()
+			return
+		}
+
+		claims, err := verifier.VerifyStreamToken(token, channelID)
+		if err != nil {
+			message := "Invalid stream token"
+			if errors.Is(err, auth.ErrStreamTokenExpired) {
+				message = "Stream token has expired"
+			}
+			abortUnauthorized(c, message)
+			return
+		}
+
+		c.Set(streamTokenContextKey, token)
+		c.Set(userContextKey, &models.User{ID: claims.UserID})
+		c.Next()
+	}
+}
+
+// RequireUser returns a middleware that rejects requests authorized only by a share link.
+// It must run after RequireStreamAccess; requests without any credential pass through unchanged
+// so it is a no-op when auth is disabled.
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentUser(c); !ok && ShareToken(c) != "" {
+			abortUnauthorized(c, "Share links can only be used for playback")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireUserRole returns a middleware for stream routes that rejects users below the given role
+// and share links. It must run after RequireStreamAccess; users from stream tokens carry no role,
+// so they are rejected too. Requests without any credential pass through unchanged, so it is a
+// no-op when auth is disabled.
+func RequireUserRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentUser(c); ok || ShareToken(c) != "" {
+			if !checkRole(c, role) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRole returns a middleware that rejects users below the given role.
+// It must run after RequireAuth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !checkRole(c, role) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRoleForWrites returns a middleware that requires the given role for every method
+// except GET, HEAD and OPTIONS, leaving reads open to any authenticated user.
+// It must run after RequireAuth.
+func RequireRoleForWrites(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if !checkRole(c, role) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user authenticated for the request.
+// Users from stream tokens only carry their ID.
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	value, ok := c.Get(userContextKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := value.(*models.User)
+	return user, ok && user != nil
+}
+
+// Credential returns the session token or API key used to authenticate the request
+func Credential(c *gin.Context) string {
+	return c.GetString(credentialContextKey)
+}
+
+// StreamToken returns the stream token used to authorize the request, if any
+func StreamToken(c *gin.Context) string {
+	return c.GetString(streamTokenContextKey)
+}
+
+// ShareToken returns the share link token verified for the request, if any
+func ShareToken(c *gin.Context) string {
+	return c.GetString(shareTokenContextKey)
+}
+
+// authenticateRequest resolves the request credential and stores the user in the context
+func authenticateRequest(c *gin.Context, authenticator Authenticator) bool {
+	credential := requestCredential(c.Request)
+	if credential == "" {
+		return false
+	}
+
+	user, err := authenticator.Authenticate(c.Request.Context(), credential)
+	if err != nil {
+		if !errors.Is(err, auth.ErrUnauthenticated) {
+			logger.Log.Error().
+				Err(err).
+				Str("path", c.Request.URL.Path).
+				Msg("Failed to authenticate request")
+		}
+		return false
+	}
+
+	c.Set(userContextKey, user)
+	c.Set(credentialContextKey, credential)
+	return true
+}
+
+// requestCredential extracts a bearer token or API key from the request headers
+func requestCredential(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// checkRole aborts the request unless the current user has at least the given role
+func checkRole(c *gin.Context, role string) bool {
+	user, ok := CurrentUser(c)
+	if !ok {
+		abortUnauthorized(c, "Authentication required")
+		return false
+	}
+	if !user.HasRole(role) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "This action requires the " + role + " role",
+		})
+		return false
+	}
+	return true
+}
+
+// abortUnauthorized ends the request with a 401 in the API error format
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="hermes"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":   "unauthorized",
+		"message": message,
+	})
+}
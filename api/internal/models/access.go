@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserGroup represents a named group of users that can be granted channel access together
+type UserGroup struct {
+	ID        uuid.UUID `json:"id" gorm:"type:text;primaryKey;column:id"`
+	Name      string    `json:"name" gorm:"type:text;not null;uniqueIndex;column:name" validate:"required,min=1,max=64"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
+}
+
+// NewUserGroup creates a new UserGroup with generated UUID and timestamp
+func NewUserGroup(name string) *UserGroup {
+	return &UserGroup{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// UserGroupMember links a user to a group
+type UserGroupMember struct {
+	GroupID uuid.UUID `json:"group_id" gorm:"type:text;primaryKey;column:group_id"`
+	UserID  uuid.UUID `json:"user_id" gorm:"type:text;primaryKey;column:user_id"`
+}
+
+// ChannelAccess grants a user or a group access to a channel
+// Exactly one of UserID and GroupID is set
+type ChannelAccess struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:text;primaryKey;column:id"`
+	ChannelID uuid.UUID  `json:"channel_id" gorm:"type:text;not null;column:channel_id"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"type:text;column:user_id"`
+	GroupID   *uuid.UUID `json:"group_id,omitempty" gorm:"type:text;column:group_id"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
+}
+
+// TableName overrides the default pluralized table name
+func (ChannelAccess) TableName() string {
+	return "channel_access"
+}
@@ -9,24 +9,85 @@ import (
 
 // Channel represents a TV channel entity
 type Channel struct {
-	ID        uuid.UUID `json:"id" gorm:"type:text;primaryKey;column:id"`
-	Name      string    `json:"name" gorm:"type:text;not null;column:name" validate:"required,min=1,max=255"`
-	Icon      *string   `json:"icon,omitempty" gorm:"type:text;column:icon"`
-	StartTime time.Time `json:"start_time" gorm:"type:datetime;not null;column:start_time" validate:"required"`
-	Loop      bool      `json:"loop" gorm:"type:integer;not null;default:0;column:loop"`
-	CreatedAt time.Time `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:updated_at"`
+	ID            uuid.UUID `json:"id" gorm:"type:text;primaryKey;column:id"`
+	Name          string    `json:"name" gorm:"type:text;not null;column:name" validate:"required,min=1,max=255"`
+	Icon          *string   `json:"icon,omitempty" gorm:"type:text;column:icon"`
+	StartTime     time.Time `json:"start_time" gorm:"type:datetime;not null;column:start_time" validate:"required"`
+	Loop          bool      `json:"loop" gorm:"type:integer;not null;default:0;column:loop"`
+	SegmentFormat string    `json:"segment_format" gorm:"type:text;not null;default:ts;column:segment_format" validate:"required,oneof=ts fmp4"`
+	Encryption    string    `json:"encryption" gorm:"type:text;not null;default:none;column:encryption" validate:"required,oneof=none aes-128"`
+	AlwaysOn      bool      `json:"always_on" gorm:"type:integer;not null;default:0;column:always_on"`
+	AlwaysOnStart *string   `json:"always_on_start,omitempty" gorm:"type:text;column:always_on_start"` // HH:MM server local time; nil = all day
+	AlwaysOnEnd   *string   `json:"always_on_end,omitempty" gorm:"type:text;column:always_on_end"`     // HH:MM server local time; nil = all day
+	CreatedAt     time.Time `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:updated_at"`
 }
 
 // NewChannel creates a new Channel with generated UUID and timestamps
 func NewChannel(name string, startTime time.Time, loop bool) *Channel {
 	now := time.Now().UTC()
 	return &Channel{
-		ID:        uuid.New(),
-		Name:      name,
-		StartTime: startTime,
-		Loop:      loop,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:            uuid.New(),
+		Name:          name,
+		StartTime:     startTime,
+		Loop:          loop,
+		SegmentFormat: SegmentFormatTS,
+		Encryption:    EncryptionNone,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// IsValidSegmentFormat reports whether format is a known segment format
+func IsValidSegmentFormat(format string) bool {
+	return format == SegmentFormatTS || format == SegmentFormatFMP4
+}
+
+// IsValidEncryption reports whether encryption is a known segment encryption method
+func IsValidEncryption(encryption string) bool {
+	return encryption == EncryptionNone || encryption == EncryptionAES128
+}
+
+// timeOfDayLayout is the HH:MM format of always-on window bounds
+const timeOfDayLayout = "15:04"
+
+// IsValidAlwaysOnWindow reports whether start and end form an always-on window:
+// both unset (all day) or both HH:MM times of day
+func IsValidAlwaysOnWindow(start, end *string) bool {
+	if start == nil || end == nil {
+		return start == nil && end == nil
+	}
+	_, startErr := time.Parse(timeOfDayLayout, *start)
+	_, endErr := time.Parse(timeOfDayLayout, *end)
+	return startErr == nil && endErr == nil
+}
+
+// IsAlwaysOnAt reports whether the channel keeps streaming without clients at t.
+// The window starts at AlwaysOnStart and ends before AlwaysOnEnd in t's location; a window whose
+// end is before its start runs past midnight, and one whose start equals its end covers the whole day.
+func (c *Channel) IsAlwaysOnAt(t time.Time) bool {
+	if !c.AlwaysOn {
+		return false
+	}
+	if c.AlwaysOnStart == nil || c.AlwaysOnEnd == nil {
+		return true
+	}
+
+	start, startErr := time.Parse(timeOfDayLayout, *c.AlwaysOnStart)
+	end, endErr := time.Parse(timeOfDayLayout, *c.AlwaysOnEnd)
+	if startErr != nil || endErr != nil {
+		return true
+	}
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	minute := t.Hour()*60 + t.Minute()
+
+	switch {
+	case startMinute == endMinute:
+		return true
+	case startMinute < endMinute:
+		return minute >= startMinute && minute < endMinute
+	default:
+		return minute >= startMinute || minute < endMinute
 	}
 }
@@ -21,6 +21,21 @@ type Media struct {
 	Resolution *string   `json:"resolution,omitempty" gorm:"type:text;column:resolution"`
 	FileSize   *int64    `json:"file_size,omitempty" gorm:"type:integer;column:file_size"`
 	CreatedAt  time.Time `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
+
+	// Free-text description (e.g. plot from a metadata provider); included in search
+	Description *string `json:"description,omitempty" gorm:"type:text;column:description"`
+
+	// Generated artwork (see media.ThumbnailGenerator)
+	ThumbnailPath *string `json:"thumbnail_path,omitempty" gorm:"type:text;column:thumbnail_path"`
+	SpritePath    *string `json:"sprite_path,omitempty" gorm:"type:text;column:sprite_path"`
+
+	// Availability of the underlying file (MediaStatusAvailable or MediaStatusMissing)
+	Status       string     `json:"status" gorm:"type:text;not null;default:available;column:status"`
+	MissingSince *time.Time `json:"missing_since,omitempty" gorm:"type:datetime;column:missing_since"`
+
+	// Source video characteristics from the scan-time probe; streams tone map HDR and deinterlace interlaced sources
+	HDR        bool `json:"hdr" gorm:"type:integer;not null;default:0;column:hdr"`
+	Interlaced bool `json:"interlaced" gorm:"type:integer;not null;default:0;column:interlaced"`
 }
 
 // NewMedia creates a new Media with generated UUID and timestamp
@@ -31,6 +46,7 @@ func NewMedia(filePath, title string, duration int64) *Media {
 		Title:     title,
 		Duration:  duration,
 		CreatedAt: time.Now().UTC(),
+		Status:    MediaStatusAvailable,
 	}
 }
 
@@ -41,3 +57,8 @@ func (m *Media) DurationString() string {
 	seconds := m.Duration % 60
 	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
 }
+
+// IsMissing reports whether the media file was last seen missing from disk
+func (m *Media) IsMissing() bool {
+	return m.Status == MediaStatusMissing
+}
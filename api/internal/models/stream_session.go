@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
 
@@ -17,7 +18,8 @@ type StreamState interface {
 
 // StreamQuality contains information about a quality variant for adaptive streaming
 type StreamQuality struct {
-	Level        string `json:"level"`         // Quality level: "1080p", "720p", "480p"
+	Level        string `json:"level"`         // Quality level: "2160p", "1080p", "720p", "480p"
+	Codec        string `json:"codec"`         // Video codec: "h264", "hevc", "av1"
 	Bitrate      int    `json:"bitrate"`       // Video bitrate in kbps
 	Resolution   string `json:"resolution"`    // Resolution: "1920x1080"
 	SegmentPath  string `json:"segment_path"`  // Path to segments for this quality
@@ -30,7 +32,7 @@ type BatchState struct {
 	StartSegment      int       `json:"start_segment"`      // First segment number in batch
 	EndSegment        int       `json:"end_segment"`        // Last segment number in batch
 	VideoSourcePath   string    `json:"video_source_path"`  // Media file being encoded
-	VideoStartOffset  int64     `json:"video_start_offset"` // Starting position in source video (seconds)
+	VideoStartOffset  float64   `json:"video_start_offset"` // Starting position in source video (seconds)
 	GenerationStarted time.Time `json:"generation_started"` // When batch generation began
 	GenerationEnded   time.Time `json:"generation_ended"`   // When batch generation completed (zero value = not complete)
 	IsComplete        bool      `json:"is_complete"`        // Whether batch finished generating
@@ -40,12 +42,13 @@ type BatchState struct {
 type ClientPosition struct {
 	SessionID     string    `json:"session_id"`     // Client session identifier
 	SegmentNumber int       `json:"segment_number"` // Current segment being played
-	Quality       string    `json:"quality"`        // Quality level (1080p, 720p, 480p)
+	Quality       string    `json:"quality"`        // Quality level (2160p, 1080p, 720p, 480p)
 	LastUpdated   time.Time `json:"last_updated"`   // When position was last updated
 }
 
 // StreamSession represents an active streaming session
-// This is NOT persisted to database, only kept in memory
+// This is NOT persisted to database; the stream manager saves it next to the stream's segments
+// so the stream can resume after a restart
 type StreamSession struct {
 	ID                  uuid.UUID                  `json:"id"`
 	ChannelID           uuid.UUID                  `json:"channel_id"`
@@ -65,6 +68,12 @@ type StreamSession struct {
 	CurrentBatch        *BatchState                `json:"current_batch"`         // Current batch state (nil = no batch)
 	ClientPositions     map[string]*ClientPosition `json:"client_positions"`      // Per-session client positions (key: session_id)
 	FurthestSegment     int                        `json:"furthest_segment"`      // Furthest segment any client has reached
+	RenditionRequests   map[string]time.Time       `json:"rendition_requests"`    // Last request per rendition (key: quality level)
+	SegmentFormat       string                     `json:"segment_format"`        // Segment container (SegmentFormatTS or SegmentFormatFMP4)
+	Encryption          string                     `json:"encryption"`            // Segment encryption (EncryptionNone or EncryptionAES128)
+	ChannelStartTime    time.Time                  `json:"channel_start_time"`    // Channel timeline anchor (DASH availabilityStartTime)
+	AlwaysOn            bool                       `json:"always_on"`             // Keep generating without clients (channel is always on right now)
+	Warm                bool                       `json:"warm"`                  // Kept generating at the lowest rung after the grace period, for quick zapping back
 	mu                  sync.RWMutex
 }
 
@@ -90,9 +99,20 @@ func NewStreamSession(channelID uuid.UUID) *StreamSession {
 		CurrentBatch:        nil,
 		ClientPositions:     make(map[string]*ClientPosition),
 		FurthestSegment:     0,
+		RenditionRequests:   make(map[string]time.Time),
+		SegmentFormat:       SegmentFormatTS,
+		Encryption:          EncryptionNone,
 	}
 }
 
+// MarshalJSON encodes the session (thread-safe)
+func (s *StreamSession) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	type session StreamSession // Without the method, so encoding does not recurse
+	return json.Marshal((*session)(s))
+}
+
 // IncrementClients increases the client count
 func (s *StreamSession) IncrementClients() {
 	s.mu.Lock()
@@ -231,11 +251,11 @@ func (s *StreamSession) IdleDuration() time.Duration {
 }
 
 // ShouldCleanup returns true if the session should be cleaned up
-// Requires both zero clients AND idle duration exceeding grace period (thread-safe)
+// Requires both zero clients AND idle duration exceeding grace period; always-on sessions are never cleaned up (thread-safe)
 func (s *StreamSession) ShouldCleanup(gracePeriod time.Duration) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.ClientCount == 0 && time.Since(s.LastAccessTime) > gracePeriod
+	return !s.AlwaysOn && s.ClientCount == 0 && time.Since(s.LastAccessTime) > gracePeriod
 }
 
 // GetSegmentPath returns the segment path (thread-safe)
@@ -266,6 +286,76 @@ func (s *StreamSession) SetOutputDir(dir string) {
 	s.OutputDir = dir
 }
 
+// GetSegmentFormat returns the segment container of the stream (thread-safe)
+func (s *StreamSession) GetSegmentFormat() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.SegmentFormat
+}
+
+// SetSegmentFormat sets the segment container of the stream (thread-safe)
+func (s *StreamSession) SetSegmentFormat(format string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.SegmentFormat = format
+}
+
+// GetEncryption returns the segment encryption of the stream (thread-safe)
+func (s *StreamSession) GetEncryption() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Encryption
+}
+
+// SetEncryption sets the segment encryption of the stream (thread-safe)
+func (s *StreamSession) SetEncryption(encryption string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Encryption = encryption
+}
+
+// GetChannelStartTime returns the start time of the channel's timeline (thread-safe)
+func (s *StreamSession) GetChannelStartTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ChannelStartTime
+}
+
+// SetChannelStartTime sets the start time of the channel's timeline (thread-safe)
+func (s *StreamSession) SetChannelStartTime(startTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ChannelStartTime = startTime
+}
+
+// IsAlwaysOn returns whether the stream keeps generating without clients (thread-safe)
+func (s *StreamSession) IsAlwaysOn() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.AlwaysOn
+}
+
+// SetAlwaysOn sets whether the stream keeps generating without clients (thread-safe)
+func (s *StreamSession) SetAlwaysOn(alwaysOn bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.AlwaysOn = alwaysOn
+}
+
+// IsWarm returns whether the stream is kept warm after its grace period (thread-safe)
+func (s *StreamSession) IsWarm() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Warm
+}
+
+// SetWarm sets whether the stream is kept warm after its grace period (thread-safe)
+func (s *StreamSession) SetWarm(warm bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Warm = warm
+}
+
 // GetRestartCount returns the restart count (thread-safe)
 func (s *StreamSession) GetRestartCount() int {
 	s.mu.RLock()
@@ -331,6 +421,14 @@ func (s *StreamSession) UnregisterSession(sessionID string) bool {
 	return true
 }
 
+// ClearSessions unregisters every client session and resets the client count (thread-safe)
+func (s *StreamSession) ClearSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.RegisteredSessions = make(map[string]bool)
+	s.ClientCount = 0
+}
+
 // UpdateClientPosition updates or creates a client position entry and updates FurthestSegment (thread-safe)
 func (s *StreamSession) UpdateClientPosition(sessionID string, segment int, quality string) {
 	s.mu.Lock()
@@ -349,6 +447,16 @@ func (s *StreamSession) UpdateClientPosition(sessionID string, segment int, qual
 	}
 }
 
+// AdvanceFurthestSegment moves FurthestSegment forward to segment without a client reporting it,
+// so batches keep being generated while nobody watches (thread-safe)
+func (s *StreamSession) AdvanceFurthestSegment(segment int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if segment > s.FurthestSegment {
+		s.FurthestSegment = segment
+	}
+}
+
 // GetFurthestPosition returns the furthest segment any client has reached (thread-safe)
 func (s *StreamSession) GetFurthestPosition() int {
 	s.mu.RLock()
@@ -373,6 +481,39 @@ func (s *StreamSession) GetClientPositions() map[string]*ClientPosition {
 	return positions
 }
 
+// RequestRendition records that a client requested a rendition (thread-safe)
+// Returns false if the quality is not one of the stream's quality variants
+func (s *StreamSession) RequestRendition(quality string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, q := range s.Qualities {
+		if q.Level == quality {
+			s.RenditionRequests[quality] = time.Now().UTC()
+			return true
+		}
+	}
+	return false
+}
+
+// GetRenditionRequests returns the last request time of each requested rendition (thread-safe)
+func (s *StreamSession) GetRenditionRequests() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	requests := make(map[string]time.Time, len(s.RenditionRequests))
+	for k, v := range s.RenditionRequests {
+		requests[k] = v
+	}
+	return requests
+}
+
+// ForgetRendition drops the request record of a rendition that is no longer generated (thread-safe)
+func (s *StreamSession) ForgetRendition(quality string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.RenditionRequests, quality)
+}
+
 // ShouldGenerateNextBatch returns true if the next batch should be generated (thread-safe)
 // Returns false if no batch exists, batch is not complete, or segments remaining > threshold
 func (s *StreamSession) ShouldGenerateNextBatch(threshold int) bool {
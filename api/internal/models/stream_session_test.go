@@ -334,6 +334,73 @@ func TestStreamSession_ShouldCleanup(t *testing.T) {
 	}
 }
 
+// TestStreamSession_AlwaysOn tests that always-on sessions are kept without clients
+func TestStreamSession_AlwaysOn(t *testing.T) {
+	session := NewStreamSession(uuid.New())
+	session.SetAlwaysOn(true)
+
+	time.Sleep(20 * time.Millisecond)
+	if session.ShouldCleanup(10 * time.Millisecond) {
+		t.Error("Should not cleanup an always-on session")
+	}
+
+	// Nobody reports positions, so the furthest segment is advanced on their behalf
+	session.AdvanceFurthestSegment(12)
+	session.AdvanceFurthestSegment(8)
+	if furthest := session.GetFurthestPosition(); furthest != 12 {
+		t.Errorf("Furthest segment = %d, want 12", furthest)
+	}
+
+	session.SetAlwaysOn(false)
+	if !session.ShouldCleanup(10 * time.Millisecond) {
+		t.Error("Should cleanup once the session is no longer always on")
+	}
+}
+
+// TestChannel_IsAlwaysOnAt tests always-on windows
+func TestChannel_IsAlwaysOnAt(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+	bound := func(value string) *string { return &value }
+
+	channel := &Channel{AlwaysOn: false}
+	if channel.IsAlwaysOnAt(at(12, 0)) {
+		t.Error("Channel without always-on should not be always on")
+	}
+
+	channel.AlwaysOn = true
+	if !channel.IsAlwaysOnAt(at(3, 0)) {
+		t.Error("Always-on channel without a window should be always on")
+	}
+
+	// Evening window
+	channel.AlwaysOnStart, channel.AlwaysOnEnd = bound("18:00"), bound("23:30")
+	for _, tc := range []struct {
+		at   time.Time
+		want bool
+	}{{at(17, 59), false}, {at(18, 0), true}, {at(23, 29), true}, {at(23, 30), false}} {
+		if got := channel.IsAlwaysOnAt(tc.at); got != tc.want {
+			t.Errorf("18:00-23:30 at %s = %v, want %v", tc.at.Format("15:04"), got, tc.want)
+		}
+	}
+
+	// Window past midnight
+	channel.AlwaysOnStart, channel.AlwaysOnEnd = bound("22:00"), bound("02:00")
+	for _, tc := range []struct {
+		at   time.Time
+		want bool
+	}{{at(21, 0), false}, {at(23, 0), true}, {at(1, 59), true}, {at(2, 0), false}} {
+		if got := channel.IsAlwaysOnAt(tc.at); got != tc.want {
+			t.Errorf("22:00-02:00 at %s = %v, want %v", tc.at.Format("15:04"), got, tc.want)
+		}
+	}
+
+	if !IsValidAlwaysOnWindow(nil, nil) || IsValidAlwaysOnWindow(bound("18:00"), nil) || IsValidAlwaysOnWindow(bound("6pm"), bound("23:00")) {
+		t.Error("IsValidAlwaysOnWindow accepts only both or neither bound as HH:MM")
+	}
+}
+
 // TestStreamSession_SegmentPath tests segment path operations
 func TestStreamSession_SegmentPath(t *testing.T) {
 	session := NewStreamSession(uuid.New())
@@ -511,7 +578,7 @@ func TestStreamSession_BatchState(t *testing.T) {
 		t.Errorf("VideoSourcePath = %s, want /media/video.mp4", batch.VideoSourcePath)
 	}
 	if batch.VideoStartOffset != 0 {
-		t.Errorf("VideoStartOffset = %d, want 0", batch.VideoStartOffset)
+		t.Errorf("VideoStartOffset = %v, want 0", batch.VideoStartOffset)
 	}
 	if batch.IsComplete {
 		t.Error("IsComplete should be false initially")
@@ -776,7 +843,7 @@ func TestStreamSession_ConcurrentBatchAccess(t *testing.T) {
 				StartSegment:      batchNum * 20,
 				EndSegment:        (batchNum+1)*20 - 1,
 				VideoSourcePath:   fmt.Sprintf("/media/video%d.mp4", batchNum),
-				VideoStartOffset:  int64(batchNum * 40),
+				VideoStartOffset:  float64(batchNum * 40),
 				GenerationStarted: time.Now().UTC(),
 				IsComplete:        true,
 			}
@@ -866,3 +933,29 @@ func TestStreamSession_ShouldGenerateNextBatchEdgeCases(t *testing.T) {
 		t.Error("ShouldGenerateNextBatch should return true when client is beyond batch end")
 	}
 }
+
+// TestStreamSession_RequestRendition tests rendition request tracking
+func TestStreamSession_RequestRendition(t *testing.T) {
+	session := NewStreamSession(uuid.New())
+	session.SetQualities([]StreamQuality{{Level: "720p"}, {Level: "480p"}})
+
+	if session.RequestRendition("1080p") {
+		t.Error("RequestRendition(1080p) = true for a quality the stream does not offer")
+	}
+	if !session.RequestRendition("480p") {
+		t.Error("RequestRendition(480p) = false, want true")
+	}
+
+	requests := session.GetRenditionRequests()
+	if len(requests) != 1 {
+		t.Fatalf("GetRenditionRequests() has %d entries, want 1", len(requests))
+	}
+	if time.Since(requests["480p"]) > time.Second {
+		t.Error("480p request time not set to recent time")
+	}
+
+	session.ForgetRendition("480p")
+	if len(session.GetRenditionRequests()) != 0 {
+		t.Error("ForgetRendition did not remove the request")
+	}
+}
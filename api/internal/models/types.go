@@ -2,6 +2,7 @@ package models
 
 // Quality constants for transcoding
 const (
+	QualityUltra  = "ultra"
 	QualityHigh   = "high"
 	QualityMedium = "medium"
 	QualityLow    = "low"
@@ -14,4 +15,30 @@ const (
 	HardwareAccelQSV          = "qsv"
 	HardwareAccelVAAPI        = "vaapi"
 	HardwareAccelVideoToolbox = "videotoolbox"
+	HardwareAccelAuto         = "auto"
+)
+
+// Media availability status constants
+const (
+	MediaStatusAvailable = "available"
+	MediaStatusMissing   = "missing"
+)
+
+// User role constants, from most to least privileged
+const (
+	RoleAdmin  = "admin"  // Everything, including users, access lists and settings
+	RoleEditor = "editor" // Manage channels, playlists and media; sees every channel
+	RoleViewer = "viewer" // Read-only; only sees channels granted to them or their groups
+)
+
+// Segment format constants for channel streams
+const (
+	SegmentFormatTS   = "ts"   // MPEG-TS segments; plays everywhere
+	SegmentFormatFMP4 = "fmp4" // Fragmented MP4 (CMAF) with an init segment; needed for HEVC and DASH
+)
+
+// Segment encryption constants for channel streams
+const (
+	EncryptionNone   = "none"    // Segments are served in the clear
+	EncryptionAES128 = "aes-128" // Whole MPEG-TS segments encrypted with rotating AES-128 keys (EXT-X-KEY METHOD=AES-128)
 )
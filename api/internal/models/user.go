@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User represents an account that can authenticate against the API
+type User struct {
+	ID           uuid.UUID `json:"id" gorm:"type:text;primaryKey;column:id"`
+	Username     string    `json:"username" gorm:"type:text;not null;uniqueIndex;column:username" validate:"required,min=1,max=64"`
+	PasswordHash string    `json:"-" gorm:"type:text;not null;column:password_hash"`
+	Role         string    `json:"role" gorm:"type:text;not null;default:admin;column:role" validate:"required,oneof=admin editor viewer"`
+	CreatedAt    time.Time `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:updated_at"`
+}
+
+// roleRanks orders roles so a higher rank includes the permissions of lower ones
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// IsValidRole reports whether role is a known user role
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// NewUser creates a new User with generated UUID and timestamps
+func NewUser(username, passwordHash, role string) *User {
+	now := time.Now().UTC()
+	return &User{
+		ID:           uuid.New(),
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// HasRole reports whether the user's role is at least as privileged as role
+func (u *User) HasRole(role string) bool {
+	return roleRanks[u.Role] >= roleRanks[role] && roleRanks[role] > 0
+}
+
+// SeesAllChannels reports whether channel access lists are bypassed for the user
+func (u *User) SeesAllChannels() bool {
+	return u.HasRole(RoleEditor)
+}
+
+// APIKey represents a long-lived credential belonging to a user
+// Only the hash of the key is stored; the plaintext is shown once on creation
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:text;primaryKey;column:id"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:text;not null;column:user_id"`
+	Name       string     `json:"name" gorm:"type:text;not null;column:name"`
+	Prefix     string     `json:"prefix" gorm:"type:text;not null;column:prefix"` // Leading characters of the key, for identification
+	KeyHash    string     `json:"-" gorm:"type:text;not null;uniqueIndex;column:key_hash"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"type:datetime;column:expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" gorm:"type:datetime;column:last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
+}
+
+// IsExpired reports whether the key has an expiry in the past
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// UserSession represents a login session created by username/password authentication
+type UserSession struct {
+	ID        uuid.UUID `json:"id" gorm:"type:text;primaryKey;column:id"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:text;not null;column:user_id"`
+	TokenHash string    `json:"-" gorm:"type:text;not null;uniqueIndex;column:token_hash"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"type:datetime;not null;column:expires_at"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:datetime;default:CURRENT_TIMESTAMP;column:created_at"`
+}
+
+// IsExpired reports whether the session has expired
+func (s *UserSession) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
@@ -5,16 +5,20 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/stwalsh4118/hermes/internal/api"
+	"github.com/stwalsh4118/hermes/internal/auth"
 	"github.com/stwalsh4118/hermes/internal/channel"
 	"github.com/stwalsh4118/hermes/internal/config"
 	"github.com/stwalsh4118/hermes/internal/db"
 	"github.com/stwalsh4118/hermes/internal/logger"
 	"github.com/stwalsh4118/hermes/internal/media"
+	"github.com/stwalsh4118/hermes/internal/metrics"
 	"github.com/stwalsh4118/hermes/internal/middleware"
+	"github.com/stwalsh4118/hermes/internal/models"
 	"github.com/stwalsh4118/hermes/internal/streaming"
 	"github.com/stwalsh4118/hermes/internal/timeline"
 )
@@ -25,33 +29,82 @@ type Server struct {
 	db              *db.DB
 	repos           *db.Repositories
 	scanner         *media.Scanner
+	metadata        media.MetadataProvider
+	thumbnails      *media.ThumbnailGenerator
 	channelService  *channel.ChannelService
 	playlistService *channel.PlaylistService
 	timelineService *timeline.TimelineService
 	streamManager   *streaming.StreamManager
+	encoders        *streaming.EncoderDetector
+	auth            *auth.Service
 	router          *gin.Engine
 	server          *http.Server
 }
 
 // New creates a new server instance
-func New(cfg *config.Config, database *db.DB) *Server {
+func New(cfg *config.Config, database *db.DB) (*Server, error) {
 	repos := db.NewRepositories(database)
 	scanner := media.NewScanner(repos)
+	thumbnails := media.NewThumbnailGenerator(cfg.Media.ThumbnailPath, cfg.Media.GenerateSprites)
+	scanner.SetThumbnailGenerator(thumbnails)
+	metadataProvider, err := media.NewMetadataProvider(&cfg.Metadata)
+	if err != nil {
+		// Metadata is optional enrichment; run without it rather than failing startup
+		logger.Log.Warn().Err(err).Str("provider", cfg.Metadata.Provider).Msg("Failed to initialize metadata provider")
+		metadataProvider = nil
+	} else if metadataProvider != nil {
+		logger.Log.Info().Str("provider", metadataProvider.Name()).Msg("Metadata provider initialized")
+	}
 	channelService := channel.NewChannelService(repos)
 	playlistService := channel.NewPlaylistService(database, repos)
 	timelineService := timeline.NewTimelineService(repos)
 	streamManager := streaming.NewStreamManager(repos, timelineService, &cfg.Streaming)
+	encoders := streaming.NewEncoderDetector()
+	streamManager.SetEncoderDetector(encoders)
+	if err := metrics.Register(streamManager.Collector()); err != nil {
+		logger.Log.Warn().Err(err).Msg("Failed to register stream metrics collector")
+	}
+
+	authService, err := auth.NewService(repos, &cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize auth: %w", err)
+	}
+	// Share link players cannot unregister, so their sessions are released once they go idle
+	streamManager.SetIdleSessionSource(authService)
+	if cfg.Auth.Enabled {
+		if setupRequired, err := authService.SetupRequired(context.Background()); err == nil && setupRequired {
+			logger.Log.Warn().Msg("Auth is enabled but no user exists; create one with POST /api/auth/setup")
+		}
+	}
+
+	// Stored settings take precedence over config once seeded on first run
+	settings, err := repos.Settings.Initialize(context.Background(), &models.Settings{
+		MediaLibraryPath: cfg.Media.LibraryPath,
+		TranscodeQuality: models.QualityHigh,
+		HardwareAccel:    cfg.Streaming.HardwareAccel,
+		ServerPort:       cfg.Server.Port,
+	})
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("Failed to load settings, using config defaults")
+	} else {
+		scanner.ApplySettings(settings)
+		streamManager.ApplySettings(settings)
+	}
 
 	return &Server{
 		config:          cfg,
 		db:              database,
 		repos:           repos,
 		scanner:         scanner,
+		metadata:        metadataProvider,
+		thumbnails:      thumbnails,
 		channelService:  channelService,
 		playlistService: playlistService,
 		timelineService: timelineService,
 		streamManager:   streamManager,
-	}
+		encoders:        encoders,
+		auth:            authService,
+	}, nil
 }
 
 // setupRouter initializes the Gin router with middleware and routes
@@ -69,27 +122,82 @@ func (s *Server) setupRouter() {
 	// Add middleware stack
 	s.router.Use(middleware.RequestLogger()) // Custom zerolog request logger
 	s.router.Use(gin.Recovery())             // Panic recovery
-	s.router.Use(cors.Default())             // CORS support (allows all origins)
+	if len(s.config.Server.AllowedOrigins) > 0 {
+		s.router.Use(cors.New(s.corsConfig())) // CORS restricted to configured origins
+	}
 
-	// Create API route group
-	apiGroup := s.router.Group("/api")
+	// Management routes and metrics are only protected when auth is enabled.
+	// Viewers get read-only access; admin routes manage users, access lists and settings.
+	var requireAuth, requireEditorForWrites, requireAdmin, requireStreamAccess []gin.HandlerFunc
+	if s.config.Auth.Enabled {
+		authenticate := middleware.RequireAuth(s.auth)
+		requireAuth = []gin.HandlerFunc{authenticate}
+		requireEditorForWrites = []gin.HandlerFunc{authenticate, middleware.RequireRoleForWrites(models.RoleEditor)}
+		requireAdmin = []gin.HandlerFunc{authenticate, middleware.RequireRole(models.RoleAdmin)}
+		requireStreamAccess = []gin.HandlerFunc{middleware.RequireStreamAccess(s.auth, s.auth)}
+	}
+
+	// Prometheus scrape endpoint (outside /api, as scrapers expect); scrape with an API key when auth is enabled
+	s.router.GET("/metrics", append(requireAuth, gin.WrapH(metrics.Handler()))...)
+
+	// Public routes: health probes, login and streams (which also accept signed stream tokens)
+	publicGroup := s.router.Group("/api")
+	api.SetupHealthRoutes(publicGroup, s.db, s.streamManager, s.scanner)
+	api.SetupAuthRoutes(publicGroup, s.auth, s.repos, s.config.Auth.Enabled)
+	api.SetupStreamRoutes(publicGroup, s.streamManager, s.auth, s.auth, requireStreamAccess...)
+
+	// Management routes
+	apiGroup := s.router.Group("/api", requireEditorForWrites...)
+	api.SetupMediaRoutes(apiGroup, s.scanner, s.repos, s.metadata, s.thumbnails)
+	api.SetupShowRoutes(apiGroup, s.repos)
+	api.SetupChannelRoutes(apiGroup, s.channelService, s.playlistService, s.timelineService, s.auth)
+
+	// Admin routes
+	adminGroup := s.router.Group("/api", requireAdmin...)
+	api.SetupAccessRoutes(adminGroup, s.repos)
+	api.SetupSettingsRoutes(adminGroup, s.repos, s.scanner, s.streamManager)
+	api.SetupSystemRoutes(adminGroup, s.encoders)
+}
 
-	// Register service routes
-	api.SetupHealthRoutes(apiGroup, s.db)
-	api.SetupMediaRoutes(apiGroup, s.scanner, s.repos)
-	api.SetupChannelRoutes(apiGroup, s.channelService, s.playlistService, s.timelineService)
-	api.SetupStreamRoutes(apiGroup, s.streamManager)
+// corsConfig allows the configured origins (same-origin only when none are configured) and the headers used for authentication
+func (s *Server) corsConfig() cors.Config {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, "Authorization", "X-API-Key")
+	if slices.Contains(s.config.Server.AllowedOrigins, "*") {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = s.config.Server.AllowedOrigins
+	}
+	return corsConfig
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	s.setupRouter()
 
+	// Detect hardware encoders once; the result is cached and resolves "auto" acceleration
+	caps := s.encoders.Detect(context.Background())
+	logger.Log.Info().
+		Str("selected", caps.Selected.String()).
+		Int("verified", len(caps.Verified)).
+		Str("tone_map", string(caps.ToneMap)).
+		Msg("Hardware encoder detection complete")
+	if caps.FFmpegAvailable && caps.ToneMap == streaming.ToneMapNone {
+		logger.Log.Warn().Msg("FFmpeg has no tone mapping filter (zscale needs libzimg); HDR sources will play without tone mapping")
+	}
+
 	// Start stream manager
 	if err := s.streamManager.Start(); err != nil {
 		return fmt.Errorf("failed to start stream manager: %w", err)
 	}
 
+	// Without auth every management route is open to anyone who can reach the server
+	if !s.config.Auth.Enabled && !s.config.Server.IsLoopback() {
+		logger.Log.Warn().
+			Str("host", s.config.Server.Host).
+			Msg("AUTH IS DISABLED and the server listens on a non-loopback address: anyone on the network can manage this server. Enable auth (HERMES_AUTH_ENABLED=true) or bind to 127.0.0.1")
+	}
+
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
 
 	s.server = &http.Server{
@@ -0,0 +1,71 @@
+package streaming
+
+import (
+	"context"
+	"time"
+
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// syncAlwaysOnChannels starts the streams of channels that are always on right now and
+// updates the always-on flag of running streams, so streams whose window ended are cleaned up
+// like any other idle stream
+func (m *StreamManager) syncAlwaysOnChannels(ctx context.Context) {
+	if m.repos == nil {
+		return
+	}
+
+	channels, err := m.repos.Channels.List(ctx)
+	if err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Msg("Failed to list channels for always-on streams")
+		return
+	}
+
+	now := time.Now()
+	for _, channel := range channels {
+		alwaysOn := channel.IsAlwaysOnAt(now)
+		if session, ok := m.sessionManager.Get(channel.ID.String()); ok {
+			session.SetAlwaysOn(alwaysOn)
+			continue
+		}
+		if !alwaysOn {
+			continue
+		}
+
+		logger.Log.Info().
+			Str("channel_id", channel.ID.String()).
+			Str("channel_name", channel.Name).
+			Msg("Starting always-on stream")
+
+		if _, err := m.StartStream(ctx, channel.ID); err != nil {
+			logger.Log.Warn().
+				Err(err).
+				Str("channel_id", channel.ID.String()).
+				Msg("Failed to start always-on stream")
+		}
+	}
+}
+
+// keepAlwaysOnStreamLive keeps an always-on stream without clients generating: it requests the
+// top rendition if none is generated and follows the live edge
+func (m *StreamManager) keepAlwaysOnStreamLive(session *models.StreamSession) {
+	live, joining := m.renditionsFor(session).tracked()
+	if qualities := session.GetQualities(); len(live)+len(joining) == 0 && len(qualities) > 0 {
+		session.RequestRendition(qualities[0].Level)
+	}
+	m.followLiveEdge(session)
+}
+
+// followLiveEdge moves the furthest position of a stream without clients along with the wall
+// clock, so batches are triggered as if a client was watching live
+func (m *StreamManager) followLiveEdge(session *models.StreamSession) {
+	segmentDuration := time.Duration(m.config.StreamSegmentDuration) * time.Second
+	if segmentDuration <= 0 || session.GetCurrentBatch() == nil {
+		return
+	}
+	// Segment n plays at StartedAt + n segment durations (see segmentProgramTime)
+	session.AdvanceFurthestSegment(int(time.Since(session.StartedAt) / segmentDuration))
+}
@@ -0,0 +1,46 @@
+package streaming
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestKeepAlwaysOnStreamLive(t *testing.T) {
+	segmentPath := t.TempDir()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		SegmentPath:           segmentPath,
+		BatchSize:             10,
+		StreamSegmentDuration: 4,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(filepath.Join(segmentPath, session.ChannelID.String()))
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}, {Level: Quality480p}})
+	session.SetAlwaysOn(true)
+	m.sessionManager.Set(session.ChannelID.String(), session)
+
+	// Without clients nothing requests a rendition, so the top one is requested for them
+	m.keepAlwaysOnStreamLive(session)
+	if m.syncRenditions(session) != 1 {
+		t.Fatal("always-on stream did not start a rendition")
+	}
+	if live, _ := m.renditionsFor(session).tracked(); !live[Quality720p] {
+		t.Errorf("live renditions = %v, want 720p", live)
+	}
+
+	// The stream has been live for a minute: segment 15 plays now
+	session.StartedAt = time.Now().UTC().Add(-time.Minute)
+	session.SetCurrentBatch(&models.BatchState{BatchNumber: 1, StartSegment: 10, EndSegment: 19, IsComplete: true})
+	m.keepAlwaysOnStreamLive(session)
+	if furthest := session.GetFurthestPosition(); furthest != 15 {
+		t.Errorf("furthest segment = %d, want 15", furthest)
+	}
+	if !session.ShouldGenerateNextBatch(7) {
+		t.Error("next batch not due for an always-on stream nobody watches")
+	}
+}
@@ -0,0 +1,235 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrTranscodeCapacity is returned when a new stream cannot start because the transcode budget is used up
+var ErrTranscodeCapacity = errors.New("transcode capacity exhausted")
+
+// TranscodePriority orders FFmpeg processes waiting for transcode budget
+type TranscodePriority int
+
+const (
+	// PriorityStartup is the first batch of a newly started stream
+	PriorityStartup TranscodePriority = iota
+	// PriorityViewer is a follow-up batch for a stream that viewers are already watching
+	PriorityViewer
+)
+
+// String returns the string representation of TranscodePriority
+func (p TranscodePriority) String() string {
+	switch p {
+	case PriorityStartup:
+		return "startup"
+	case PriorityViewer:
+		return "viewer"
+	default:
+		return "unknown"
+	}
+}
+
+// Relative cost of one FFmpeg process when the budget is weighted, as a fraction of a 1080p software encode
+var qualityTranscodeCost = map[string]float64{
+	Quality2160p: 4,
+	Quality1080p: 1,
+	Quality720p:  0.5,
+	Quality480p:  0.25,
+}
+
+// hardwareTranscodeDiscount scales the cost of hardware encodes, which barely use the CPU
+const hardwareTranscodeDiscount = 0.5
+
+// TranscodeCost returns the budget cost of one FFmpeg process.
+// Unweighted budgets count every process as 1.
+func TranscodeCost(quality string, hwAccel HardwareAccel, weighted bool) float64 {
+	if !weighted {
+		return 1
+	}
+	cost, ok := qualityTranscodeCost[quality]
+	if !ok {
+		cost = 1
+	}
+	if hwAccel != HardwareAccelNone && hwAccel != "" {
+		cost *= hardwareTranscodeDiscount
+	}
+	return cost
+}
+
+// TranscodeBudgetStats is a snapshot of transcode budget usage
+type TranscodeBudgetStats struct {
+	Capacity float64 `json:"capacity"` // 0 means unlimited
+	InUse    float64 `json:"in_use"`
+	Running  int     `json:"running"`
+	Queued   int     `json:"queued"`
+}
+
+// TranscodeBudget limits concurrent FFmpeg processes.
+// Processes acquire their cost before launching; when the budget is used up they queue,
+// and higher priority waiters are served first (FIFO within a priority).
+type TranscodeBudget struct {
+	mu       sync.Mutex
+	capacity float64 // 0 means unlimited
+	inUse    float64
+	running  int
+	waiters  []*budgetWaiter
+}
+
+// budgetWaiter is a queued acquisition; ready is closed once the cost has been granted
+type budgetWaiter struct {
+	cost     float64
+	priority TranscodePriority
+	ready    chan struct{}
+}
+
+// NewTranscodeBudget creates a budget with the given capacity (0 means unlimited)
+func NewTranscodeBudget(capacity float64) *TranscodeBudget {
+	return &TranscodeBudget{capacity: capacity}
+}
+
+// Acquire blocks until cost fits in the budget or ctx is done, and returns a function that
+// gives the cost back. Costs larger than the whole capacity are clamped so they can still run alone.
+func (b *TranscodeBudget) Acquire(ctx context.Context, cost float64, priority TranscodePriority) (func(), error) {
+	b.mu.Lock()
+	cost = b.clampLocked(cost)
+	if len(b.waiters) == 0 && b.fitsLocked(cost) {
+		b.takeLocked(cost)
+		b.mu.Unlock()
+		return b.releaseFunc(cost), nil
+	}
+
+	waiter := &budgetWaiter{cost: cost, priority: priority, ready: make(chan struct{})}
+	b.enqueueLocked(waiter)
+	b.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return b.releaseFunc(cost), nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		select {
+		case <-waiter.ready:
+			// Granted while we were giving up; hand the cost back
+			b.giveBackLocked(cost)
+		default:
+			b.removeLocked(waiter)
+			b.grantLocked()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// TryAcquire takes cost from the budget if it fits now without queueing, and returns a function
+// that gives it back
+func (b *TranscodeBudget) TryAcquire(cost float64) (func(), bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cost = b.clampLocked(cost)
+	if len(b.waiters) > 0 || !b.fitsLocked(cost) {
+		return nil, false
+	}
+	b.takeLocked(cost)
+	return b.releaseFunc(cost), true
+}
+
+// HasCapacity reports whether a process of the given cost could start now without queueing
+func (b *TranscodeBudget) HasCapacity(cost float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.waiters) == 0 && b.fitsLocked(b.clampLocked(cost))
+}
+
+// Stats returns a snapshot of budget usage
+func (b *TranscodeBudget) Stats() TranscodeBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return TranscodeBudgetStats{
+		Capacity: b.capacity,
+		InUse:    b.inUse,
+		Running:  b.running,
+		Queued:   len(b.waiters),
+	}
+}
+
+// releaseFunc returns an idempotent function that gives cost back to the budget
+func (b *TranscodeBudget) releaseFunc(cost float64) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.giveBackLocked(cost)
+		})
+	}
+}
+
+// clampLocked caps cost at the capacity so a single expensive process is never stuck forever
+func (b *TranscodeBudget) clampLocked(cost float64) float64 {
+	if b.capacity > 0 && cost > b.capacity {
+		return b.capacity
+	}
+	return cost
+}
+
+// fitsLocked reports whether cost fits in the remaining budget
+func (b *TranscodeBudget) fitsLocked(cost float64) bool {
+	return b.capacity <= 0 || b.running == 0 || b.inUse+cost <= b.capacity
+}
+
+// takeLocked records a running process
+func (b *TranscodeBudget) takeLocked(cost float64) {
+	b.inUse += cost
+	b.running++
+}
+
+// giveBackLocked records a finished process and admits queued ones
+func (b *TranscodeBudget) giveBackLocked(cost float64) {
+	b.inUse -= cost
+	b.running--
+	if b.running == 0 {
+		b.inUse = 0 // Avoid drift from float rounding
+	}
+	b.grantLocked()
+}
+
+// enqueueLocked inserts a waiter after every waiter of the same or higher priority
+func (b *TranscodeBudget) enqueueLocked(waiter *budgetWaiter) {
+	idx := len(b.waiters)
+	for i, w := range b.waiters {
+		if waiter.priority > w.priority {
+			idx = i
+			break
+		}
+	}
+	b.waiters = append(b.waiters, nil)
+	copy(b.waiters[idx+1:], b.waiters[idx:])
+	b.waiters[idx] = waiter
+}
+
+// removeLocked drops a waiter that gave up
+func (b *TranscodeBudget) removeLocked(waiter *budgetWaiter) {
+	for i, w := range b.waiters {
+		if w == waiter {
+			b.waiters = append(b.waiters[:i], b.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// grantLocked admits waiters from the head of the queue while they fit.
+// It stops at the first waiter that does not fit so large or low priority work is not starved
+// by smaller work queued behind it.
+func (b *TranscodeBudget) grantLocked() {
+	for len(b.waiters) > 0 {
+		head := b.waiters[0]
+		if !b.fitsLocked(head.cost) {
+			return
+		}
+		b.waiters = b.waiters[1:]
+		b.takeLocked(head.cost)
+		close(head.ready)
+	}
+}
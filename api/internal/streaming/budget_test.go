@@ -0,0 +1,189 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// acquireAsync starts an Acquire in the background and returns a channel that receives its release func
+func acquireAsync(b *TranscodeBudget, cost float64, priority TranscodePriority) <-chan func() {
+	granted := make(chan func(), 1)
+	go func() {
+		release, err := b.Acquire(context.Background(), cost, priority)
+		if err == nil {
+			granted <- release
+		}
+	}()
+	return granted
+}
+
+// waitQueued waits until the budget has n queued waiters
+func waitQueued(t *testing.T, b *TranscodeBudget, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for b.Stats().Queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued waiters, got %d", n, b.Stats().Queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTranscodeBudget_LimitsConcurrency(t *testing.T) {
+	b := NewTranscodeBudget(2)
+
+	first, err := b.Acquire(context.Background(), 1, PriorityViewer)
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	second, err := b.Acquire(context.Background(), 1, PriorityViewer)
+	if err != nil {
+		t.Fatalf("second acquire failed: %v", err)
+	}
+	if b.HasCapacity(1) {
+		t.Error("expected budget to be full")
+	}
+
+	third := acquireAsync(b, 1, PriorityViewer)
+	waitQueued(t, b, 1)
+
+	first()
+	first() // Releasing twice must not free extra budget
+	select {
+	case release := <-third:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("queued process was not admitted after release")
+	}
+
+	second()
+	if stats := b.Stats(); stats.Running != 0 || stats.InUse != 0 {
+		t.Errorf("expected empty budget, got %+v", stats)
+	}
+}
+
+func TestTranscodeBudget_ViewersBeforeStartups(t *testing.T) {
+	b := NewTranscodeBudget(1)
+
+	running, err := b.Acquire(context.Background(), 1, PriorityViewer)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	startup := acquireAsync(b, 1, PriorityStartup)
+	waitQueued(t, b, 1)
+	viewer := acquireAsync(b, 1, PriorityViewer)
+	waitQueued(t, b, 2)
+
+	running()
+	select {
+	case release := <-viewer:
+		select {
+		case <-startup:
+			t.Fatal("startup admitted while the viewer batch was running")
+		default:
+		}
+		release()
+	case <-startup:
+		t.Fatal("startup admitted before the queued viewer batch")
+	case <-time.After(time.Second):
+		t.Fatal("viewer batch was not admitted")
+	}
+
+	select {
+	case release := <-startup:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("startup was not admitted")
+	}
+}
+
+func TestTranscodeBudget_CancelWhileQueued(t *testing.T) {
+	b := NewTranscodeBudget(1)
+
+	running, err := b.Acquire(context.Background(), 1, PriorityViewer)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	defer running()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.Acquire(ctx, 1, PriorityStartup); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if queued := b.Stats().Queued; queued != 0 {
+		t.Errorf("expected cancelled waiter to leave the queue, %d queued", queued)
+	}
+}
+
+func TestTranscodeBudget_Unlimited(t *testing.T) {
+	b := NewTranscodeBudget(0)
+	for i := 0; i < 10; i++ {
+		if _, err := b.Acquire(context.Background(), 1, PriorityStartup); err != nil {
+			t.Fatalf("acquire %d failed: %v", i, err)
+		}
+	}
+	if !b.HasCapacity(100) {
+		t.Error("unlimited budget should always have capacity")
+	}
+}
+
+func TestTranscodeBudget_OversizedCostRunsAlone(t *testing.T) {
+	b := NewTranscodeBudget(0.5)
+	release, err := b.Acquire(context.Background(), 1, PriorityViewer)
+	if err != nil {
+		t.Fatalf("oversized acquire failed: %v", err)
+	}
+	if b.HasCapacity(0.25) {
+		t.Error("oversized process should use the whole budget")
+	}
+	release()
+}
+
+func TestTranscodeCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		quality  string
+		hwAccel  HardwareAccel
+		weighted bool
+		want     float64
+	}{
+		{"unweighted", Quality480p, HardwareAccelNone, false, 1},
+		{"software 1080p", Quality1080p, HardwareAccelNone, true, 1},
+		{"software 720p", Quality720p, HardwareAccelNone, true, 0.5},
+		{"hardware 1080p", Quality1080p, HardwareAccelNVENC, true, 0.5},
+		{"hardware 480p", Quality480p, HardwareAccelVAAPI, true, 0.125},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TranscodeCost(tt.quality, tt.hwAccel, tt.weighted); got != tt.want {
+				t.Errorf("TranscodeCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscodeBudget_TryAcquire(t *testing.T) {
+	b := NewTranscodeBudget(1)
+
+	release, ok := b.TryAcquire(1)
+	if !ok {
+		t.Fatal("expected the first process to fit")
+	}
+	if _, ok := b.TryAcquire(1); ok {
+		t.Error("expected a full budget to refuse without queueing")
+	}
+	if stats := b.Stats(); stats.Queued != 0 || stats.Running != 1 {
+		t.Errorf("stats = %+v, want 1 running and nothing queued", stats)
+	}
+
+	release()
+	if release, ok := b.TryAcquire(1); !ok {
+		t.Error("expected released budget to be available")
+	} else {
+		release()
+	}
+}
@@ -0,0 +1,72 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+	"github.com/stwalsh4118/hermes/internal/timeline"
+)
+
+// Catch-up errors
+var (
+	ErrCatchUpDisabled     = errors.New("catch-up is disabled")
+	ErrCatchUpNotAvailable = errors.New("program is not available for catch-up")
+)
+
+// CatchUpPlaylist renders a media playlist of the program that was playing on a channel at the
+// given time, from the segments of the quality kept for catch-up (streaming.catchupseconds).
+// It starts at the program's start, or at the oldest segment kept if that is later. The
+// program playing now is rendered as an EVENT playlist that grows as it is generated
+// (start-over); programs whose last segment is generated are VOD playlists.
+//
+// Segment URIs are relative to the quality's media playlist, so the playlist is served next to it.
+func (m *StreamManager) CatchUpPlaylist(ctx context.Context, channelID uuid.UUID, quality string, at time.Time) (string, error) {
+	if m.config.CatchUpSeconds <= 0 {
+		return "", ErrCatchUpDisabled
+	}
+	session, ok := m.sessionManager.Get(channelID.String())
+	if !ok {
+		return "", ErrStreamNotFound
+	}
+
+	now := time.Now()
+	retention := time.Duration(m.config.CatchUpSeconds) * time.Second
+	if at.After(now) || now.Sub(at) > retention {
+		return "", ErrCatchUpNotAvailable
+	}
+
+	pm, err := m.getPlaylistManager(session, quality)
+	if err != nil {
+		return "", ErrCatchUpNotAvailable
+	}
+
+	position, err := m.timelineService.GetPositionAt(ctx, channelID, at)
+	switch {
+	case errors.Is(err, timeline.ErrChannelNotStarted), errors.Is(err, timeline.ErrEmptyPlaylist), errors.Is(err, timeline.ErrPlaylistFinished):
+		return "", ErrCatchUpNotAvailable // Nothing was playing
+	case err != nil:
+		return "", fmt.Errorf("failed to get program: %w", err)
+	}
+
+	mediaSequence, segments := pm.GetRange(position.StartedAt, position.EndsAt)
+	if len(segments) == 0 {
+		return "", ErrCatchUpNotAvailable
+	}
+
+	return playlist.RenderCatchUp(mediaSequence, segments, programGenerated(pm, position.EndsAt)), nil
+}
+
+// programGenerated reports whether a playlist has a segment playing at or after a program's end,
+// i.e. whether every segment of the program has been generated
+func programGenerated(pm playlist.Manager, endsAt time.Time) bool {
+	_, window := pm.GetWindow()
+	if len(window) == 0 {
+		return false
+	}
+	newest := window[len(window)-1].ProgramDateTime
+	return newest != nil && !newest.Before(endsAt)
+}
@@ -0,0 +1,52 @@
+package streaming
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestCatchUpPlaylist_Unavailable(t *testing.T) {
+	segmentPath := t.TempDir()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		SegmentPath:           segmentPath,
+		BatchSize:             10,
+		StreamSegmentDuration: 4,
+		CatchUpSeconds:        3600,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(filepath.Join(segmentPath, session.ChannelID.String()))
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}})
+	m.sessionManager.Set(session.ChannelID.String(), session)
+
+	now := time.Now()
+	tests := []struct {
+		name      string
+		channelID uuid.UUID
+		at        time.Time
+		want      error
+	}{
+		{name: "inactive stream", channelID: uuid.New(), at: now, want: ErrStreamNotFound},
+		{name: "before the window", channelID: session.ChannelID, at: now.Add(-2 * time.Hour), want: ErrCatchUpNotAvailable},
+		{name: "in the future", channelID: session.ChannelID, at: now.Add(time.Hour), want: ErrCatchUpNotAvailable},
+		{name: "rendition not generated", channelID: session.ChannelID, at: now, want: ErrCatchUpNotAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.CatchUpPlaylist(t.Context(), tt.channelID, Quality720p, tt.at); !errors.Is(err, tt.want) {
+				t.Errorf("CatchUpPlaylist err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	m.config.CatchUpSeconds = 0
+	if _, err := m.CatchUpPlaylist(t.Context(), session.ChannelID, Quality720p, now); !errors.Is(err, ErrCatchUpDisabled) {
+		t.Errorf("CatchUpPlaylist err = %v, want ErrCatchUpDisabled", err)
+	}
+}
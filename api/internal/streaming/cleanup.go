@@ -23,8 +23,7 @@ func createSegmentDirectories(baseDir, channelID string) error {
 	}
 
 	// Create quality-specific directories
-	qualities := []string{Quality1080p, Quality720p, Quality480p}
-	for _, quality := range qualities {
+	for _, quality := range qualityLadderOrder {
 		qualityDir := filepath.Join(baseDir, quality)
 		if err := os.MkdirAll(qualityDir, 0755); err != nil {
 			return fmt.Errorf("%w for quality %s: %w", ErrDirectoryCreation, quality, err)
@@ -0,0 +1,133 @@
+package streaming
+
+import (
+	"errors"
+	"fmt"
+)
+
+// VideoCodec is the video codec a rendition is encoded with
+type VideoCodec string
+
+// Video codec constants
+const (
+	VideoCodecH264 VideoCodec = "h264"
+	VideoCodecHEVC VideoCodec = "hevc"
+	VideoCodecAV1  VideoCodec = "av1"
+)
+
+// Codec errors
+var (
+	ErrInvalidVideoCodec = errors.New("invalid video codec")
+	ErrCodecRequiresFMP4 = errors.New("video codec requires fmp4 segments")
+)
+
+// videoCodecs lists every supported codec; H.264 is the fallback every client can decode
+var videoCodecs = []VideoCodec{VideoCodecH264, VideoCodecHEVC, VideoCodecAV1}
+
+// String returns the string representation of the video codec
+func (c VideoCodec) String() string {
+	return string(c)
+}
+
+// IsValid checks if the video codec is a known valid value
+func (c VideoCodec) IsValid() bool {
+	switch c {
+	case VideoCodecH264, VideoCodecHEVC, VideoCodecAV1:
+		return true
+	default:
+		return false
+	}
+}
+
+// orDefault returns the codec, or H.264 when it is unset
+func (c VideoCodec) orDefault() VideoCodec {
+	if c == "" {
+		return VideoCodecH264
+	}
+	return c
+}
+
+// softwareEncoders maps each codec to its FFmpeg software encoder
+var softwareEncoders = map[VideoCodec]string{
+	VideoCodecH264: "libx264",
+	VideoCodecHEVC: "libx265",
+	VideoCodecAV1:  "libsvtav1",
+}
+
+// hardwareEncoders maps each codec and hardware acceleration method to its FFmpeg encoder.
+// VideoToolbox has no AV1 encoder.
+var hardwareEncoders = map[VideoCodec]map[HardwareAccel]string{
+	VideoCodecH264: {
+		HardwareAccelNVENC:        "h264_nvenc",
+		HardwareAccelQSV:          "h264_qsv",
+		HardwareAccelVAAPI:        "h264_vaapi",
+		HardwareAccelVideoToolbox: "h264_videotoolbox",
+	},
+	VideoCodecHEVC: {
+		HardwareAccelNVENC:        "hevc_nvenc",
+		HardwareAccelQSV:          "hevc_qsv",
+		HardwareAccelVAAPI:        "hevc_vaapi",
+		HardwareAccelVideoToolbox: "hevc_videotoolbox",
+	},
+	VideoCodecAV1: {
+		HardwareAccelNVENC: "av1_nvenc",
+		HardwareAccelQSV:   "av1_qsv",
+		HardwareAccelVAAPI: "av1_vaapi",
+	},
+}
+
+// videoEncoderName returns the FFmpeg encoder for a codec and hardware acceleration method.
+// Software encoding (and a method without an encoder for the codec) uses the software encoder.
+func videoEncoderName(codec VideoCodec, hwaccel HardwareAccel) string {
+	codec = codec.orDefault()
+	if name, ok := hardwareEncoders[codec][hwaccel]; ok {
+		return name
+	}
+	return softwareEncoders[codec]
+}
+
+// hasEncoder reports whether a hardware acceleration method can encode a codec
+func hasEncoder(codec VideoCodec, hwaccel HardwareAccel) bool {
+	if hwaccel == HardwareAccelNone {
+		return true
+	}
+	_, ok := hardwareEncoders[codec.orDefault()][hwaccel]
+	return ok
+}
+
+// codecBitratePercent scales each quality's H.264 bitrate for codecs that compress better
+var codecBitratePercent = map[VideoCodec]int{
+	VideoCodecH264: 100,
+	VideoCodecHEVC: 60,
+	VideoCodecAV1:  50,
+}
+
+// codecBitrate returns the bitrate in kbps that gives a codec the quality of an H.264 bitrate
+func codecBitrate(h264Bitrate int, codec VideoCodec) int {
+	return h264Bitrate * codecBitratePercent[codec.orDefault()] / 100
+}
+
+// audioCodecString is the RFC 6381 codec of the AAC-LC audio track
+const audioCodecString = "mp4a.40.2"
+
+// videoCodecStrings holds the RFC 6381 codec of each quality and codec: H.264 High, HEVC Main and
+// AV1 Main 8-bit, at the lowest level that fits the quality's resolution at up to 30 fps
+var videoCodecStrings = map[string]map[VideoCodec]string{
+	Quality2160p: {VideoCodecH264: "avc1.640033", VideoCodecHEVC: "hvc1.1.6.L150.90", VideoCodecAV1: "av01.0.12M.08"},
+	Quality1080p: {VideoCodecH264: "avc1.640028", VideoCodecHEVC: "hvc1.1.6.L120.90", VideoCodecAV1: "av01.0.08M.08"},
+	Quality720p:  {VideoCodecH264: "avc1.64001f", VideoCodecHEVC: "hvc1.1.6.L93.90", VideoCodecAV1: "av01.0.05M.08"},
+	Quality480p:  {VideoCodecH264: "avc1.64001e", VideoCodecHEVC: "hvc1.1.6.L90.90", VideoCodecAV1: "av01.0.04M.08"},
+}
+
+// CodecsForQuality returns the RFC 6381 codecs (video and audio) of a rendition,
+// as used in the CODECS attribute of the master playlist
+func CodecsForQuality(quality string, codec VideoCodec) (string, error) {
+	video, ok := videoCodecStrings[quality][codec.orDefault()]
+	if !ok {
+		if _, known := videoCodecStrings[quality]; !known {
+			return "", fmt.Errorf("%w: %s", ErrInvalidQuality, quality)
+		}
+		return "", fmt.Errorf("%w: %s", ErrInvalidVideoCodec, codec)
+	}
+	return video + "," + audioCodecString, nil
+}
@@ -0,0 +1,69 @@
+package streaming
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVideoCodec_IsValid(t *testing.T) {
+	for _, codec := range videoCodecs {
+		if !codec.IsValid() {
+			t.Errorf("%s should be valid", codec)
+		}
+	}
+	for _, codec := range []VideoCodec{"", "vp9", "H264"} {
+		if codec.IsValid() {
+			t.Errorf("%q should be invalid", codec)
+		}
+	}
+}
+
+func TestVideoEncoderName(t *testing.T) {
+	tests := []struct {
+		codec   VideoCodec
+		hwaccel HardwareAccel
+		want    string
+	}{
+		{"", HardwareAccelNone, "libx264"},
+		{VideoCodecH264, HardwareAccelNVENC, "h264_nvenc"},
+		{VideoCodecHEVC, HardwareAccelNone, "libx265"},
+		{VideoCodecHEVC, HardwareAccelAuto, "libx265"},
+		{VideoCodecHEVC, HardwareAccelQSV, "hevc_qsv"},
+		{VideoCodecAV1, HardwareAccelNone, "libsvtav1"},
+		{VideoCodecAV1, HardwareAccelVAAPI, "av1_vaapi"},
+		{VideoCodecAV1, HardwareAccelVideoToolbox, "libsvtav1"},
+	}
+	for _, tt := range tests {
+		if got := videoEncoderName(tt.codec, tt.hwaccel); got != tt.want {
+			t.Errorf("videoEncoderName(%q, %s) = %s, want %s", tt.codec, tt.hwaccel, got, tt.want)
+		}
+	}
+}
+
+func TestCodecsForQuality(t *testing.T) {
+	tests := []struct {
+		quality string
+		codec   VideoCodec
+		want    string
+	}{
+		{Quality2160p, VideoCodecHEVC, "hvc1.1.6.L150.90,mp4a.40.2"},
+		{Quality2160p, VideoCodecAV1, "av01.0.12M.08,mp4a.40.2"},
+		{Quality2160p, VideoCodecH264, "avc1.640033,mp4a.40.2"},
+		{Quality1080p, "", "avc1.640028,mp4a.40.2"},
+		{Quality720p, VideoCodecH264, "avc1.64001f,mp4a.40.2"},
+		{Quality480p, VideoCodecAV1, "av01.0.04M.08,mp4a.40.2"},
+	}
+	for _, tt := range tests {
+		got, err := CodecsForQuality(tt.quality, tt.codec)
+		if err != nil || got != tt.want {
+			t.Errorf("CodecsForQuality(%s, %q) = %q, %v; want %q", tt.quality, tt.codec, got, err, tt.want)
+		}
+	}
+
+	if _, err := CodecsForQuality("4k", VideoCodecH264); !errors.Is(err, ErrInvalidQuality) {
+		t.Errorf("unknown quality: err = %v, want ErrInvalidQuality", err)
+	}
+	if _, err := CodecsForQuality(Quality1080p, "vp9"); !errors.Is(err, ErrInvalidVideoCodec) {
+		t.Errorf("unknown codec: err = %v, want ErrInvalidVideoCodec", err)
+	}
+}
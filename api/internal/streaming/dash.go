@@ -0,0 +1,363 @@
+package streaming
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// DASH constants
+const (
+	DASHManifestFilename = "manifest.mpd"
+	dashNamespace        = "urn:mpeg:dash:schema:mpd:2011"
+	dashProfileLive      = "urn:mpeg:dash:profile:isoff-live:2011"
+	dashTimescale        = 1000 // SegmentTimeline units per second (milliseconds)
+	// SegmentTemplate media attribute, formatted with the session's segment prefix
+	dashMediaTemplate = "%s-$Number%%06d$.m4s"
+)
+
+// DASH errors
+var (
+	ErrEmptyPeriods         = errors.New("manifest must have at least one period")
+	ErrEmptyRepresentations = errors.New("period must have at least one representation")
+	ErrEmptySegments        = errors.New("representation must have at least one segment")
+)
+
+// DASHManifest describes a live (dynamic) MPEG-DASH manifest
+type DASHManifest struct {
+	AvailabilityStartTime      time.Time     // Channel timeline anchor; period starts are relative to it
+	PublishTime                time.Time     // When the manifest was generated
+	MinimumUpdatePeriod        time.Duration // How often players reload the manifest
+	MinBufferTime              time.Duration
+	TimeShiftBufferDepth       time.Duration // Length of the segment window
+	SuggestedPresentationDelay time.Duration // Distance players keep from the live edge
+	MaxSegmentDuration         time.Duration
+	Periods                    []DASHPeriod
+}
+
+// DASHPeriod is a stretch of the stream with one init segment per representation.
+// A new period starts at every program boundary and wherever an encoder restarts.
+type DASHPeriod struct {
+	ID              string
+	Start           time.Duration // Offset from AvailabilityStartTime
+	MediaTimeOffset time.Duration // Media time at the start of the period (presentationTimeOffset)
+	Representations []DASHRepresentation
+}
+
+// DASHRepresentation is one rendition within a period
+type DASHRepresentation struct {
+	ID             string // Quality level; also the segment directory
+	Bandwidth      int    // Bits per second
+	Width          int
+	Height         int
+	Codecs         string // RFC 6381 codecs of the muxed video and audio (optional)
+	Initialization string // Init segment URI, relative to the manifest
+	Media          string // Segment URI template with $Number$, relative to the manifest
+	StartNumber    int    // Number of the first segment
+	Segments       []DASHSegment
+}
+
+// DASHSegment is one entry of a representation's segment timeline
+type DASHSegment struct {
+	Start    time.Duration // Media time
+	Duration time.Duration
+}
+
+// mpdXML and the types below mirror the MPD schema elements the manifest uses
+type mpdXML struct {
+	XMLName                    xml.Name    `xml:"MPD"`
+	XMLNS                      string      `xml:"xmlns,attr"`
+	Profiles                   string      `xml:"profiles,attr"`
+	Type                       string      `xml:"type,attr"`
+	AvailabilityStartTime      string      `xml:"availabilityStartTime,attr"`
+	PublishTime                string      `xml:"publishTime,attr"`
+	MinimumUpdatePeriod        string      `xml:"minimumUpdatePeriod,attr"`
+	MinBufferTime              string      `xml:"minBufferTime,attr"`
+	TimeShiftBufferDepth       string      `xml:"timeShiftBufferDepth,attr"`
+	SuggestedPresentationDelay string      `xml:"suggestedPresentationDelay,attr"`
+	MaxSegmentDuration         string      `xml:"maxSegmentDuration,attr"`
+	Periods                    []periodXML `xml:"Period"`
+}
+
+type periodXML struct {
+	ID            string           `xml:"id,attr"`
+	Start         string           `xml:"start,attr"`
+	AdaptationSet adaptationSetXML `xml:"AdaptationSet"`
+}
+
+type adaptationSetXML struct {
+	ID               int                 `xml:"id,attr"`
+	MimeType         string              `xml:"mimeType,attr"`
+	SegmentAlignment bool                `xml:"segmentAlignment,attr"`
+	StartWithSAP     int                 `xml:"startWithSAP,attr"`
+	Representations  []representationXML `xml:"Representation"`
+}
+
+type representationXML struct {
+	ID              string             `xml:"id,attr"`
+	Bandwidth       int                `xml:"bandwidth,attr"`
+	Width           int                `xml:"width,attr,omitempty"`
+	Height          int                `xml:"height,attr,omitempty"`
+	Codecs          string             `xml:"codecs,attr,omitempty"`
+	SegmentTemplate segmentTemplateXML `xml:"SegmentTemplate"`
+}
+
+type segmentTemplateXML struct {
+	Timescale              int                `xml:"timescale,attr"`
+	Initialization         string             `xml:"initialization,attr"`
+	Media                  string             `xml:"media,attr"`
+	StartNumber            int                `xml:"startNumber,attr"`
+	PresentationTimeOffset int64              `xml:"presentationTimeOffset,attr"`
+	Timeline               []timelineEntryXML `xml:"SegmentTimeline>S"`
+}
+
+type timelineEntryXML struct {
+	T *int64 `xml:"t,attr,omitempty"`
+	D int64  `xml:"d,attr"`
+	R int    `xml:"r,attr,omitempty"`
+}
+
+// GenerateDASHManifest generates a dynamic MPD with one SegmentTemplate ($Number$ plus
+// SegmentTimeline) per representation and period
+func GenerateDASHManifest(manifest DASHManifest) (string, error) {
+	if len(manifest.Periods) == 0 {
+		return "", ErrEmptyPeriods
+	}
+
+	mpd := mpdXML{
+		XMLNS:                      dashNamespace,
+		Profiles:                   dashProfileLive,
+		Type:                       "dynamic",
+		AvailabilityStartTime:      manifest.AvailabilityStartTime.UTC().Format(time.RFC3339),
+		PublishTime:                manifest.PublishTime.UTC().Format(time.RFC3339),
+		MinimumUpdatePeriod:        formatDASHDuration(manifest.MinimumUpdatePeriod),
+		MinBufferTime:              formatDASHDuration(manifest.MinBufferTime),
+		TimeShiftBufferDepth:       formatDASHDuration(manifest.TimeShiftBufferDepth),
+		SuggestedPresentationDelay: formatDASHDuration(manifest.SuggestedPresentationDelay),
+		MaxSegmentDuration:         formatDASHDuration(manifest.MaxSegmentDuration),
+	}
+
+	for i, period := range manifest.Periods {
+		if len(period.Representations) == 0 {
+			return "", fmt.Errorf("period %s: %w", period.ID, ErrEmptyRepresentations)
+		}
+
+		adaptationSet := adaptationSetXML{
+			MimeType:         "video/mp4",
+			SegmentAlignment: true,
+			StartWithSAP:     1,
+		}
+		for _, rep := range period.Representations {
+			if len(rep.Segments) == 0 {
+				return "", fmt.Errorf("period %s, representation %s: %w", period.ID, rep.ID, ErrEmptySegments)
+			}
+			if rep.Bandwidth <= 0 {
+				return "", fmt.Errorf("period %s, representation %s: %w", period.ID, rep.ID, ErrInvalidBandwidth)
+			}
+			adaptationSet.Representations = append(adaptationSet.Representations, representationXML{
+				ID:        rep.ID,
+				Bandwidth: rep.Bandwidth,
+				Width:     rep.Width,
+				Height:    rep.Height,
+				Codecs:    rep.Codecs,
+				SegmentTemplate: segmentTemplateXML{
+					Timescale:              dashTimescale,
+					Initialization:         rep.Initialization,
+					Media:                  rep.Media,
+					StartNumber:            rep.StartNumber,
+					PresentationTimeOffset: dashMediaTime(period.MediaTimeOffset),
+					Timeline:               buildSegmentTimeline(rep.Segments),
+				},
+			})
+		}
+
+		if period.ID == "" {
+			period.ID = strconv.Itoa(i)
+		}
+		mpd.Periods = append(mpd.Periods, periodXML{
+			ID:            period.ID,
+			Start:         formatDASHDuration(period.Start),
+			AdaptationSet: adaptationSet,
+		})
+	}
+
+	content, err := xml.MarshalIndent(mpd, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return xml.Header + string(content) + "\n", nil
+}
+
+// buildSegmentTimeline converts segments into SegmentTimeline entries. Consecutive segments of
+// equal duration share one entry (r repeats); t is only written where the timeline is not contiguous.
+func buildSegmentTimeline(segments []DASHSegment) []timelineEntryXML {
+	var entries []timelineEntryXML
+	var end int64
+	for i, seg := range segments {
+		start, duration := dashMediaTime(seg.Start), dashMediaTime(seg.Duration)
+		contiguous := i > 0 && start == end
+		if contiguous && entries[len(entries)-1].D == duration {
+			entries[len(entries)-1].R++
+		} else {
+			entry := timelineEntryXML{D: duration}
+			if !contiguous {
+				entry.T = &start
+			}
+			entries = append(entries, entry)
+		}
+		end = start + duration
+	}
+	return entries
+}
+
+// dashMediaTime converts a duration to SegmentTimeline units
+func dashMediaTime(d time.Duration) int64 {
+	return d.Milliseconds() * dashTimescale / 1000
+}
+
+// formatDASHDuration formats a duration as an xs:duration in seconds (e.g. "PT4S", "PT1.5S")
+func formatDASHDuration(d time.Duration) string {
+	return "PT" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S"
+}
+
+// writeDASHManifest regenerates a stream's MPD from the segments in its renditions' playlist windows.
+// Only fMP4 streams get a manifest; DASH players do not accept MPEG-TS segments.
+func (m *StreamManager) writeDASHManifest(session *models.StreamSession, rs *renditionSet) {
+	if session.GetSegmentFormat() != SegmentFormatFMP4 {
+		return
+	}
+
+	// Serialize writes so a manifest built from older segment windows never replaces a newer one
+	rs.manifestMu.Lock()
+	defer rs.manifestMu.Unlock()
+
+	manifest, err := m.buildDASHManifest(session, rs)
+	if err != nil {
+		logger.Log.Debug().
+			Err(err).
+			Str("channel_id", session.ChannelID.String()).
+			Msg("DASH manifest not written")
+		return
+	}
+
+	content, err := GenerateDASHManifest(manifest)
+	if err == nil {
+		err = WritePlaylistAtomic(filepath.Join(session.GetOutputDir(), DASHManifestFilename), content)
+	}
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", session.ChannelID.String()).
+			Msg("Failed to write DASH manifest")
+	}
+}
+
+// buildDASHManifest collects each rendition's segments into the stream's periods.
+// Segment n plays at media time n*segmentDuration, matching the encoders' output timestamp offset.
+func (m *StreamManager) buildDASHManifest(session *models.StreamSession, rs *renditionSet) (DASHManifest, error) {
+	segmentDuration := time.Duration(m.config.StreamSegmentDuration) * time.Second
+	anchor := session.GetChannelStartTime()
+	if anchor.IsZero() {
+		anchor = session.StartedAt.UTC()
+	}
+
+	starts := rs.periodStarts()
+	periods := make([]DASHPeriod, len(starts))
+	for i, start := range starts {
+		periods[i] = DASHPeriod{
+			ID:              strconv.Itoa(start.number),
+			Start:           start.programDateTime.Sub(anchor),
+			MediaTimeOffset: time.Duration(start.number) * segmentDuration,
+		}
+	}
+
+	// periodOf returns the period a segment belongs to, or -1 if it precedes every period
+	periodOf := func(number int) int {
+		index := -1
+		for i, start := range starts {
+			if start.number > number {
+				break
+			}
+			index = i
+		}
+		return index
+	}
+
+	for _, quality := range qualityLadderOrder {
+		pm, err := m.getPlaylistManager(session, quality)
+		if err != nil {
+			continue // Rendition not generated
+		}
+		codec := sessionCodec(session, quality)
+		bandwidth, err := GetBandwidthForQuality(quality, codec)
+		if err != nil {
+			return DASHManifest{}, err
+		}
+		codecs, err := CodecsForQuality(quality, codec)
+		if err != nil {
+			return DASHManifest{}, err
+		}
+		resolution, err := GetResolutionForQuality(quality)
+		if err != nil {
+			return DASHManifest{}, err
+		}
+		var width, height int
+		if _, err := fmt.Sscanf(resolution, "%dx%d", &width, &height); err != nil {
+			return DASHManifest{}, fmt.Errorf("%w: %s", ErrInvalidResolution, resolution)
+		}
+
+		first := int(pm.GetMediaSequence()) // nolint:gosec // segment numbers fit in an int
+		current := -1
+		for i, seg := range pm.GetSegments() {
+			number := first + i
+			index := periodOf(number)
+			if index < 0 || seg.Map == "" {
+				continue
+			}
+			if index != current {
+				current = index
+				periods[index].Representations = append(periods[index].Representations, DASHRepresentation{
+					ID:             quality,
+					Bandwidth:      bandwidth,
+					Width:          width,
+					Height:         height,
+					Codecs:         codecs,
+					Initialization: quality + "/" + seg.Map,
+					Media:          quality + "/" + fmt.Sprintf(dashMediaTemplate, SegmentPrefix(session)),
+					StartNumber:    number,
+				})
+			}
+			reps := periods[index].Representations
+			reps[len(reps)-1].Segments = append(reps[len(reps)-1].Segments, DASHSegment{
+				Start:    time.Duration(number) * segmentDuration,
+				Duration: time.Duration(seg.Duration * float64(time.Second)),
+			})
+		}
+	}
+
+	// Periods whose segments have all left the window are dropped
+	manifest := DASHManifest{
+		AvailabilityStartTime:      anchor,
+		PublishTime:                time.Now().UTC(),
+		MinimumUpdatePeriod:        segmentDuration,
+		MinBufferTime:              2 * segmentDuration,
+		TimeShiftBufferDepth:       time.Duration(m.config.BatchSize*3) * segmentDuration,
+		SuggestedPresentationDelay: 3 * segmentDuration,
+		MaxSegmentDuration:         segmentDuration,
+	}
+	for _, period := range periods {
+		if len(period.Representations) > 0 {
+			manifest.Periods = append(manifest.Periods, period)
+		}
+	}
+	if len(manifest.Periods) == 0 {
+		return DASHManifest{}, ErrEmptyPeriods
+	}
+	return manifest, nil
+}
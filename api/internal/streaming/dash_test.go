@@ -0,0 +1,261 @@
+package streaming
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+)
+
+func TestGenerateDASHManifest(t *testing.T) {
+	anchor := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	manifest := DASHManifest{
+		AvailabilityStartTime:      anchor,
+		PublishTime:                anchor.Add(time.Hour),
+		MinimumUpdatePeriod:        4 * time.Second,
+		MinBufferTime:              8 * time.Second,
+		TimeShiftBufferDepth:       60 * time.Second,
+		SuggestedPresentationDelay: 12 * time.Second,
+		MaxSegmentDuration:         4 * time.Second,
+		Periods: []DASHPeriod{{
+			ID:              "10",
+			Start:           time.Hour,
+			MediaTimeOffset: 40 * time.Second,
+			Representations: []DASHRepresentation{{
+				ID:             Quality720p,
+				Bandwidth:      3192000,
+				Width:          1280,
+				Height:         720,
+				Initialization: "720p/init-10.mp4",
+				Media:          "720p/seg-$Number%06d$.m4s",
+				StartNumber:    10,
+				Segments: []DASHSegment{
+					{Start: 40 * time.Second, Duration: 4 * time.Second},
+					{Start: 44 * time.Second, Duration: 4 * time.Second},
+					{Start: 48 * time.Second, Duration: 4 * time.Second},
+					{Start: 52 * time.Second, Duration: 2500 * time.Millisecond},
+				},
+			}},
+		}},
+	}
+
+	content, err := GenerateDASHManifest(manifest)
+	if err != nil {
+		t.Fatalf("GenerateDASHManifest failed: %v", err)
+	}
+
+	var parsed mpdXML
+	if err := xml.Unmarshal([]byte(content), &parsed); err != nil {
+		t.Fatalf("manifest is not valid XML: %v\n%s", err, content)
+	}
+
+	if parsed.Type != "dynamic" || parsed.Profiles != dashProfileLive {
+		t.Errorf("type/profiles = %s/%s", parsed.Type, parsed.Profiles)
+	}
+	if parsed.AvailabilityStartTime != "2025-01-01T00:00:00Z" {
+		t.Errorf("availabilityStartTime = %s", parsed.AvailabilityStartTime)
+	}
+	if parsed.MinimumUpdatePeriod != "PT4S" || parsed.TimeShiftBufferDepth != "PT60S" {
+		t.Errorf("minimumUpdatePeriod/timeShiftBufferDepth = %s/%s", parsed.MinimumUpdatePeriod, parsed.TimeShiftBufferDepth)
+	}
+	if len(parsed.Periods) != 1 {
+		t.Fatalf("periods = %d, want 1", len(parsed.Periods))
+	}
+
+	period := parsed.Periods[0]
+	if period.ID != "10" || period.Start != "PT3600S" {
+		t.Errorf("period id/start = %s/%s, want 10/PT3600S", period.ID, period.Start)
+	}
+	if len(period.AdaptationSet.Representations) != 1 {
+		t.Fatalf("representations = %d, want 1", len(period.AdaptationSet.Representations))
+	}
+	template := period.AdaptationSet.Representations[0].SegmentTemplate
+	if template.Media != "720p/seg-$Number%06d$.m4s" || template.Initialization != "720p/init-10.mp4" {
+		t.Errorf("media/initialization = %s/%s", template.Media, template.Initialization)
+	}
+	if template.StartNumber != 10 || template.PresentationTimeOffset != 40000 || template.Timescale != 1000 {
+		t.Errorf("startNumber/presentationTimeOffset/timescale = %d/%d/%d", template.StartNumber, template.PresentationTimeOffset, template.Timescale)
+	}
+
+	// Three equal segments share one entry; the short last one gets its own
+	timeline := template.Timeline
+	if len(timeline) != 2 {
+		t.Fatalf("timeline entries = %d, want 2", len(timeline))
+	}
+	if timeline[0].T == nil || *timeline[0].T != 40000 || timeline[0].D != 4000 || timeline[0].R != 2 {
+		t.Errorf("first entry = %+v, want t=40000 d=4000 r=2", timeline[0])
+	}
+	if timeline[1].T != nil || timeline[1].D != 2500 || timeline[1].R != 0 {
+		t.Errorf("second entry = %+v, want d=2500 without t", timeline[1])
+	}
+}
+
+func TestGenerateDASHManifest_Errors(t *testing.T) {
+	if _, err := GenerateDASHManifest(DASHManifest{}); !errors.Is(err, ErrEmptyPeriods) {
+		t.Errorf("no periods: err = %v, want ErrEmptyPeriods", err)
+	}
+
+	noRepresentations := DASHManifest{Periods: []DASHPeriod{{ID: "0"}}}
+	if _, err := GenerateDASHManifest(noRepresentations); !errors.Is(err, ErrEmptyRepresentations) {
+		t.Errorf("no representations: err = %v, want ErrEmptyRepresentations", err)
+	}
+
+	noSegments := DASHManifest{Periods: []DASHPeriod{{ID: "0", Representations: []DASHRepresentation{{ID: Quality720p, Bandwidth: 1}}}}}
+	if _, err := GenerateDASHManifest(noSegments); !errors.Is(err, ErrEmptySegments) {
+		t.Errorf("no segments: err = %v, want ErrEmptySegments", err)
+	}
+}
+
+func TestBuildSegmentTimeline_Gap(t *testing.T) {
+	timeline := buildSegmentTimeline([]DASHSegment{
+		{Start: 0, Duration: 4 * time.Second},
+		{Start: 8 * time.Second, Duration: 4 * time.Second}, // Segment 1 missing
+	})
+	if len(timeline) != 2 || timeline[1].T == nil || *timeline[1].T != 8000 {
+		t.Errorf("timeline = %+v, want an explicit t after the gap", timeline)
+	}
+}
+
+func TestFormatDASHDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		4 * time.Second:         "PT4S",
+		1500 * time.Millisecond: "PT1.5S",
+		0:                       "PT0S",
+	}
+	for d, want := range tests {
+		if got := formatDASHDuration(d); got != want {
+			t.Errorf("formatDASHDuration(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
+
+// addTestSegments records segment sources and adds the segments to a rendition's playlist
+func addTestSegments(t *testing.T, m *StreamManager, session *models.StreamSession, quality string, first int, videos []string, initFile string) {
+	t.Helper()
+	pm, err := m.getPlaylistManager(session, quality)
+	if err != nil {
+		t.Fatalf("playlist manager for %s: %v", quality, err)
+	}
+	rs := m.renditionsFor(session)
+	for i, video := range videos {
+		number := first + i
+		programDateTime := session.StartedAt.Add(time.Duration(number) * 4 * time.Second)
+		if _, ok := rs.source(number); !ok {
+			rs.record(segmentSource{number: number, videoPath: video, programDateTime: programDateTime})
+		}
+		if _, err := pm.AddSegment(playlist.SegmentMeta{
+			URI:             "seg.m4s",
+			Duration:        4,
+			ProgramDateTime: &programDateTime,
+			Map:             initFile,
+		}); err != nil {
+			t.Fatalf("AddSegment failed: %v", err)
+		}
+	}
+}
+
+func TestWriteDASHManifest(t *testing.T) {
+	outputDir := t.TempDir()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		BatchSize:             5,
+		StreamSegmentDuration: 4,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(outputDir)
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p, Codec: string(VideoCodecHEVC)}, {Level: Quality480p}})
+	session.SetSegmentFormat(SegmentFormatFMP4)
+	session.SetChannelStartTime(session.StartedAt.Add(-time.Hour))
+	session.RequestRendition(Quality720p)
+	session.RequestRendition(Quality480p)
+	m.syncRenditions(session)
+
+	// 720p plays two programs; 480p joins at segment 3
+	addTestSegments(t, m, session, Quality720p, 0, []string{"a.mp4", "a.mp4", "b.mp4", "b.mp4", "b.mp4"}, "init-0.mp4")
+	if pm, err := m.getPlaylistManager(session, Quality480p); err == nil {
+		pm.SetMediaSequence(3)
+	}
+	addTestSegments(t, m, session, Quality480p, 3, []string{"b.mp4", "b.mp4"}, "init-3.mp4")
+
+	rs := m.renditionsFor(session)
+	m.writeDASHManifest(session, rs)
+
+	data, err := os.ReadFile(filepath.Join(outputDir, DASHManifestFilename))
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var parsed mpdXML
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("manifest is not valid XML: %v", err)
+	}
+
+	if len(parsed.Periods) != 2 {
+		t.Fatalf("periods = %d, want 2 (one per program)", len(parsed.Periods))
+	}
+	if parsed.Periods[0].ID != "0" || parsed.Periods[0].Start != "PT3600S" {
+		t.Errorf("first period id/start = %s/%s, want 0/PT3600S", parsed.Periods[0].ID, parsed.Periods[0].Start)
+	}
+	if parsed.Periods[1].ID != "2" || parsed.Periods[1].Start != "PT3608S" {
+		t.Errorf("second period id/start = %s/%s, want 2/PT3608S", parsed.Periods[1].ID, parsed.Periods[1].Start)
+	}
+
+	first := parsed.Periods[0].AdaptationSet.Representations
+	if len(first) != 1 || first[0].ID != Quality720p {
+		t.Fatalf("first period representations = %+v, want only 720p", first)
+	}
+
+	second := parsed.Periods[1].AdaptationSet.Representations
+	if len(second) != 2 {
+		t.Fatalf("second period representations = %d, want 2", len(second))
+	}
+	if tmpl := second[0].SegmentTemplate; tmpl.StartNumber != 2 || tmpl.Initialization != "720p/init-0.mp4" || tmpl.PresentationTimeOffset != 8000 {
+		t.Errorf("720p template = %+v", tmpl)
+	}
+	if tmpl := second[1].SegmentTemplate; tmpl.StartNumber != 3 || tmpl.Initialization != "480p/init-3.mp4" {
+		t.Errorf("480p template = %+v", tmpl)
+	}
+	if t0 := second[1].SegmentTemplate.Timeline[0].T; t0 == nil || *t0 != 12000 {
+		t.Errorf("480p timeline should start at its first segment (12000)")
+	}
+	if second[1].Width != 854 || second[1].Height != 480 {
+		t.Errorf("480p size = %dx%d", second[1].Width, second[1].Height)
+	}
+	if second[0].Codecs != "hvc1.1.6.L93.90,mp4a.40.2" || second[1].Codecs != "avc1.64001e,mp4a.40.2" {
+		t.Errorf("codecs = %q, %q; want HEVC 720p and H.264 480p", second[0].Codecs, second[1].Codecs)
+	}
+	if second[0].Bandwidth != 1992000 {
+		t.Errorf("720p HEVC bandwidth = %d, want 1992000", second[0].Bandwidth)
+	}
+}
+
+func TestWriteDASHManifest_TSStreamsHaveNone(t *testing.T) {
+	outputDir := t.TempDir()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		BatchSize:             5,
+		StreamSegmentDuration: 4,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(outputDir)
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}})
+	session.RequestRendition(Quality720p)
+	m.syncRenditions(session)
+	addTestSegments(t, m, session, Quality720p, 0, []string{"a.mp4"}, "")
+
+	m.writeDASHManifest(session, m.renditionsFor(session))
+
+	entries, _ := os.ReadDir(outputDir)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".mpd") {
+			t.Errorf("unexpected manifest %s for an MPEG-TS stream", entry.Name())
+		}
+	}
+}
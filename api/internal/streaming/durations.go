@@ -0,0 +1,94 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// durationProbeTimeout bounds the ffprobe call that reads a media file's exact duration
+const durationProbeTimeout = 10 * time.Second
+
+// errPlaylistEnded is returned when planning runs past the last item of a channel that does not loop
+var errPlaylistEnded = errors.New("reached end of playlist and channel does not loop")
+
+// probeMediaDuration returns the duration of a media file in seconds as reported by ffprobe
+func probeMediaDuration(ctx context.Context, path string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, durationProbeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error",
+		"-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", path).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", strings.TrimSpace(string(out)))
+	}
+	return duration, nil
+}
+
+// mediaDuration returns how long a media item plays in an encoder, in exact seconds.
+// Media.Duration is truncated to whole seconds, but encoders play items back to back, so planning
+// with it would drift by the dropped fractions at every program boundary and renditions starting
+// later would seek to different frames than the encoders already running. The exact duration is
+// probed once per file; if probing fails the stored duration is used.
+func (m *StreamManager) mediaDuration(ctx context.Context, media *models.Media) float64 {
+	m.durationsMu.Lock()
+	duration, ok := m.durations[media.FilePath]
+	m.durationsMu.Unlock()
+	if ok {
+		return duration
+	}
+
+	duration, err := m.probeDuration(ctx, media.FilePath)
+	if err != nil {
+		logger.Log.Debug().
+			Err(err).
+			Str("file_path", media.FilePath).
+			Int64("duration", media.Duration).
+			Msg("Could not probe exact media duration, using stored duration")
+		return float64(media.Duration) // Not cached, so the next plan probes again
+	}
+
+	m.durationsMu.Lock()
+	m.durations[media.FilePath] = duration
+	m.durationsMu.Unlock()
+	return duration
+}
+
+// advancePlaylist moves a planned offset that reached the end of its media item into the items
+// after it, as encoders carry whatever runs past the end into the next item. loop reports whether
+// the channel loops; it is only called when planning runs past the last item.
+func (m *StreamManager) advancePlaylist(ctx context.Context, items []*models.PlaylistItem, index int, offset float64, loop func() bool) (int, float64, error) {
+	for {
+		media := items[index].Media
+		if media == nil {
+			return 0, 0, fmt.Errorf("playlist item at index %d has no media", index)
+		}
+		duration := m.mediaDuration(ctx, media)
+		if duration <= 0 {
+			return 0, 0, fmt.Errorf("playlist item at index %d has no duration", index)
+		}
+		if offset < duration {
+			return index, offset, nil
+		}
+		offset -= duration
+
+		index++
+		if index >= len(items) {
+			if !loop() {
+				return 0, 0, errPlaylistEnded
+			}
+			index = 0
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// stubDurations makes a manager probe the given exact durations, failing for other files
+func stubDurations(m *StreamManager, durations map[string]float64) *int {
+	probes := 0
+	m.probeDuration = func(_ context.Context, path string) (float64, error) {
+		probes++
+		if duration, ok := durations[path]; ok {
+			return duration, nil
+		}
+		return 0, errors.New("not probed")
+	}
+	return &probes
+}
+
+func TestMediaDuration_UsesExactDuration(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{})
+	probes := stubDurations(m, map[string]float64{"a.mp4": 10.52})
+
+	a := &models.Media{FilePath: "a.mp4", Duration: 10}
+	if got := m.mediaDuration(t.Context(), a); got != 10.52 {
+		t.Errorf("mediaDuration = %v, want the probed 10.52", got)
+	}
+	m.mediaDuration(t.Context(), a)
+	if *probes != 1 {
+		t.Errorf("probed %d times, want 1 (cached)", *probes)
+	}
+
+	// Files that cannot be probed fall back to the stored whole seconds
+	if got := m.mediaDuration(t.Context(), &models.Media{FilePath: "b.mp4", Duration: 7}); got != 7 {
+		t.Errorf("mediaDuration = %v, want the stored 7", got)
+	}
+}
+
+func TestAdvancePlaylist_NonIntegerDurations(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{})
+	stubDurations(m, map[string]float64{"a.mp4": 10.52, "b.mp4": 5.25})
+	items := []*models.PlaylistItem{
+		{Media: &models.Media{FilePath: "a.mp4", Duration: 10}},
+		{Media: &models.Media{FilePath: "b.mp4", Duration: 5}},
+	}
+	loops := func() bool { return true }
+
+	tests := []struct {
+		name       string
+		index      int
+		offset     float64
+		wantIndex  int
+		wantOffset float64
+	}{
+		// Stored duration 10 would move this segment into b.mp4, but a.mp4 still plays for 0.52s
+		{name: "before exact end", index: 0, offset: 10.4, wantIndex: 0, wantOffset: 10.4},
+		// The 4s segment at 8 ends 1.48s into b.mp4; the next one starts there, not at 2
+		{name: "carries fraction", index: 0, offset: 12, wantIndex: 1, wantOffset: 1.48},
+		{name: "skips short item", index: 0, offset: 16, wantIndex: 0, wantOffset: 0.23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, offset, err := m.advancePlaylist(t.Context(), items, tt.index, tt.offset, loops)
+			if err != nil {
+				t.Fatalf("advancePlaylist failed: %v", err)
+			}
+			if index != tt.wantIndex || math.Abs(offset-tt.wantOffset) > 1e-9 {
+				t.Errorf("advancePlaylist = (%d, %v), want (%d, %v)", index, offset, tt.wantIndex, tt.wantOffset)
+			}
+		})
+	}
+
+	if _, _, err := m.advancePlaylist(t.Context(), items, 1, 6, func() bool { return false }); !errors.Is(err, errPlaylistEnded) {
+		t.Errorf("advancePlaylist past the end = %v, want errPlaylistEnded", err)
+	}
+}
@@ -0,0 +1,518 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stwalsh4118/hermes/internal/logger"
+)
+
+// errEncoderFinished is returned once an encoder has consumed its whole input and exited cleanly.
+// The rendition continues with a new encoder from the next segment.
+var errEncoderFinished = errors.New("encoder finished its input")
+
+// encodedSegment is a segment a continuous encoder has finished writing
+type encodedSegment struct {
+	number     int
+	filename   string
+	initFile   string        // fMP4 init segment the segment depends on; empty for MPEG-TS
+	duration   float64       // Seconds, as reported by the muxer
+	encodeTime time.Duration // Wall time spent encoding it
+}
+
+// encodedPart is a Low-Latency HLS part of an fMP4 segment the encoder is still writing:
+// a complete fragment, served as a byte range of the segment file
+type encodedPart struct {
+	number      int    // Segment the part belongs to
+	filename    string // Segment file
+	start       int64
+	length      int64
+	duration    float64 // Seconds
+	independent bool    // First part of the segment, which starts with a keyframe
+}
+
+// segmentListEntry is one completed segment in the list FFmpeg writes
+type segmentListEntry struct {
+	filename string
+	initFile string
+	duration float64
+	err      error // Set for entries that could not be parsed
+}
+
+// segmentEncoder is a long-lived FFmpeg process encoding one rendition of a channel.
+// FFmpeg reads the upcoming playlist through a concat list and writes numbered segments (stream_segment
+// for MPEG-TS, the hls muxer for fMP4); the encoder watches the segment list in the output directory
+// and hands each completed segment to onSegment. Between batches the process is suspended so it never runs further
+// ahead of clients than the batch loop asks for.
+type segmentEncoder struct {
+	quality   string
+	hwAccel   HardwareAccel
+	dir       string // Rendition output directory (watched)
+	listPath  string // Segment list written by FFmpeg (CSV, or m3u8 for fMP4)
+	inputPath string // Concat list read by FFmpeg
+	start     int    // Number of the first segment
+	onSegment func(encodedSegment)
+
+	// onPart, when set, receives the fragments of fMP4 segments as they are written.
+	// initFile is the init segment the fragment timing is read from.
+	onPart   func(encodedPart)
+	initFile string
+
+	// onExit, when set, is called once the FFmpeg process has exited (releases transcode budget)
+	onExit func()
+
+	cmd *exec.Cmd
+
+	readMu      sync.Mutex // Serializes segment list reads and part scans
+	entriesRead int
+	track       *fmp4Track // Parsed from initFile once it exists
+	noParts     bool       // The init segment could not be parsed; publish whole segments only
+	partNumber  int        // Segment being scanned for parts
+	partOffset  int64      // End of its last complete fragment
+	partIndex   int        // Parts reported for it so far
+
+	mu           sync.Mutex
+	next         int // Next segment number FFmpeg will complete
+	pauseAt      int // Suspend once this segment is complete
+	suspended    bool
+	stopping     bool
+	lastProgress time.Time
+	progress     chan struct{} // Closed and replaced whenever segments complete
+	exited       chan struct{}
+	exitErr      error
+}
+
+// newSegmentEncoder creates an encoder whose first segment is number start
+func newSegmentEncoder(quality string, hwAccel HardwareAccel, dir, listPath, inputPath string, start int, onSegment func(encodedSegment)) *segmentEncoder {
+	return &segmentEncoder{
+		quality:   quality,
+		hwAccel:   hwAccel,
+		dir:       dir,
+		listPath:  listPath,
+		inputPath: inputPath,
+		start:     start,
+		onSegment: onSegment,
+		next:      start,
+		pauseAt:   start - 1,
+		progress:  make(chan struct{}),
+		exited:    make(chan struct{}),
+	}
+}
+
+// launch starts watching the output directory and then the FFmpeg process
+func (e *segmentEncoder) launch(cmd *FFmpegCommand) error {
+	_ = os.Remove(e.listPath) // Stale list from an earlier encoder (best effort)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create segment watcher: %w", err)
+	}
+	if err := watcher.Add(e.dir); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", e.dir, err)
+	}
+
+	execCmd, err := launchFFmpeg(cmd)
+	if err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	e.mu.Lock()
+	e.cmd = execCmd
+	e.lastProgress = time.Now()
+	e.mu.Unlock()
+
+	go e.watch(watcher)
+	go e.wait(watcher)
+
+	logger.Log.Debug().
+		Str("quality", e.quality).
+		Int("start_segment", e.start).
+		Int("ffmpeg_pid", execCmd.Process.Pid).
+		Msg("Continuous encoder started")
+
+	return nil
+}
+
+// watch reads the segment list whenever FFmpeg updates it, and scans fMP4 segments for
+// new parts as they are written
+func (e *segmentEncoder) watch(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			switch {
+			case filepath.Clean(event.Name) == filepath.Clean(e.listPath) && event.Has(fsnotify.Write|fsnotify.Create):
+				e.readSegmentList()
+			case e.onPart != nil && strings.HasSuffix(event.Name, ".m4s") && event.Has(fsnotify.Write):
+				e.readParts(filepath.Base(event.Name))
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Log.Warn().
+				Err(err).
+				Str("quality", e.quality).
+				Msg("Segment watcher error")
+		}
+	}
+}
+
+// wait reaps the FFmpeg process, picks up the segments it finished last and cleans up its lists
+func (e *segmentEncoder) wait(watcher *fsnotify.Watcher) {
+	err := e.cmd.Wait()
+	_ = watcher.Close()
+	e.readSegmentList()
+
+	_ = os.Remove(e.listPath)
+	_ = os.Remove(e.inputPath)
+
+	e.mu.Lock()
+	if !e.stopping {
+		e.exitErr = err
+	}
+	e.mu.Unlock()
+	if e.onExit != nil {
+		e.onExit() // Before waiters see the exit, so a replacement encoder finds the budget free
+	}
+	close(e.exited)
+
+	if err != nil && !e.isStopping() {
+		recordFFmpegFailure(err)
+		logger.Log.Error().
+			Err(err).
+			Str("quality", e.quality).
+			Int("start_segment", e.start).
+			Msg("Continuous encoder failed")
+	}
+}
+
+// readSegmentList hands segments FFmpeg has completed since the last read to onSegment,
+// then wakes waiters and suspends the process once it reaches pauseAt
+func (e *segmentEncoder) readSegmentList() {
+	e.readMu.Lock()
+	defer e.readMu.Unlock()
+
+	data, err := os.ReadFile(e.listPath)
+	if err != nil {
+		return // Not written yet
+	}
+
+	entries := parseSegmentList(string(data), strings.HasSuffix(e.listPath, ".m3u8"))
+	if len(entries) <= e.entriesRead {
+		return
+	}
+
+	e.mu.Lock()
+	next := e.next
+	lastProgress := e.lastProgress
+	e.mu.Unlock()
+
+	now := time.Now()
+	completed := 0
+	for _, entry := range entries[e.entriesRead:] {
+		e.entriesRead++
+		if entry.err != nil {
+			logger.Log.Warn().
+				Err(entry.err).
+				Str("quality", e.quality).
+				Msg("Skipping unreadable segment list entry")
+			completed++ // FFmpeg still wrote the segment, keep numbering aligned
+			continue
+		}
+		if e.onPart != nil {
+			e.readPartsLocked(entry.filename) // The segment's last fragment
+		}
+		e.onSegment(encodedSegment{
+			number:     next + completed,
+			filename:   entry.filename,
+			initFile:   entry.initFile,
+			duration:   entry.duration,
+			encodeTime: now.Sub(lastProgress),
+		})
+		completed++
+		lastProgress = now
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.next += completed
+	e.lastProgress = now
+	close(e.progress)
+	e.progress = make(chan struct{})
+	if e.next > e.pauseAt && !e.suspended && !e.stopping {
+		e.suspendLocked()
+	}
+}
+
+// readParts hands fragments FFmpeg has completed in an fMP4 segment since the last scan to onPart
+func (e *segmentEncoder) readParts(filename string) {
+	e.readMu.Lock()
+	defer e.readMu.Unlock()
+	e.readPartsLocked(filename)
+}
+
+// readPartsLocked scans a segment file for new fragments. The encoder's first segment has no parts:
+// playlists only learn its init segment once it is complete. Caller must hold readMu.
+func (e *segmentEncoder) readPartsLocked(filename string) {
+	number, ok := SegmentNumber(filename)
+	if !ok || number <= e.start || e.noParts {
+		return
+	}
+	if number < e.partNumber {
+		return // A late event for a segment that is already complete
+	}
+	if number != e.partNumber {
+		e.partNumber, e.partOffset, e.partIndex = number, 0, 0
+	}
+
+	if e.track == nil {
+		data, err := os.ReadFile(filepath.Join(e.dir, e.initFile))
+		if err != nil {
+			return // Not written yet
+		}
+		track, err := readInitTrack(data)
+		if err != nil {
+			logger.Log.Warn().
+				Err(err).
+				Str("quality", e.quality).
+				Str("init_file", e.initFile).
+				Msg("Cannot read init segment, segments are published without parts")
+			e.noParts = true
+			return
+		}
+		e.track = &track
+	}
+
+	file, err := os.Open(filepath.Join(e.dir, filename))
+	if err != nil {
+		return
+	}
+	defer func() { _ = file.Close() }()
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+
+	fragments, next, err := scanFragments(file, info.Size(), e.partOffset, *e.track)
+	if err != nil {
+		logger.Log.Debug().
+			Err(err).
+			Str("quality", e.quality).
+			Str("segment_filename", filename).
+			Msg("Failed to scan segment for parts")
+	}
+	e.partOffset = next
+	for _, fragment := range fragments {
+		if fragment.duration <= 0 {
+			continue
+		}
+		e.onPart(encodedPart{
+			number:      number,
+			filename:    filename,
+			start:       fragment.start,
+			length:      fragment.length,
+			duration:    fragment.duration,
+			independent: e.partIndex == 0,
+		})
+		e.partIndex++
+	}
+}
+
+// parseSegmentList returns the completed segments in a segment list. Only complete lines count;
+// FFmpeg may be in the middle of writing the next one.
+func parseSegmentList(content string, m3u8 bool) []segmentListEntry {
+	content = content[:strings.LastIndex(content, "\n")+1]
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	var entries []segmentListEntry
+	if !m3u8 {
+		for _, line := range lines {
+			filename, duration, err := parseSegmentListLine(line)
+			entries = append(entries, segmentListEntry{filename: filename, duration: duration, err: err})
+		}
+		return entries
+	}
+
+	// An m3u8 list: each URI line completes the EXTINF before it; EXT-X-MAP applies until replaced
+	initFile := ""
+	var pending *segmentListEntry
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			if _, uri, ok := strings.Cut(line, `URI="`); ok {
+				initFile = filepath.Base(strings.TrimSuffix(uri, `"`))
+			}
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			duration, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				err = fmt.Errorf("invalid segment duration: %w", err)
+			}
+			pending = &segmentListEntry{duration: duration, initFile: initFile, err: err}
+		case line == "" || strings.HasPrefix(line, "#"):
+		case pending != nil:
+			pending.filename = filepath.Base(line)
+			entries = append(entries, *pending)
+			pending = nil
+		}
+	}
+	return entries
+}
+
+// parseSegmentListLine parses a "filename,start,end" entry of an FFmpeg CSV segment list
+func parseSegmentListLine(line string) (filename string, duration float64, err error) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) != 3 || fields[0] == "" {
+		return "", 0, fmt.Errorf("expected filename,start,end: %q", line)
+	}
+	start, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid segment start: %w", err)
+	}
+	end, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid segment end: %w", err)
+	}
+	return fields[0], end - start, nil
+}
+
+// runUntil lets the encoder run until segment target is complete.
+// Returns errEncoderFinished if FFmpeg consumed its input first.
+func (e *segmentEncoder) runUntil(ctx context.Context, target int) error {
+	for {
+		e.mu.Lock()
+		if e.next > target {
+			e.mu.Unlock()
+			return nil
+		}
+		select {
+		case <-e.exited:
+			err, last := e.exitErr, e.next-1
+			e.mu.Unlock()
+			if err != nil {
+				return fmt.Errorf("FFmpeg failed after segment %d: %w", last, err)
+			}
+			return errEncoderFinished
+		default:
+		}
+		if target > e.pauseAt {
+			e.pauseAt = target
+		}
+		if e.suspended {
+			e.resumeLocked()
+		}
+		progress := e.progress
+		e.mu.Unlock()
+
+		select {
+		case <-progress:
+		case <-e.exited:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// produced returns the number of segments the encoder has completed
+func (e *segmentEncoder) produced() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.next - e.start
+}
+
+// done reports whether the FFmpeg process has exited
+func (e *segmentEncoder) done() bool {
+	select {
+	case <-e.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// isStopping reports whether stop has been called
+func (e *segmentEncoder) isStopping() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stopping
+}
+
+// suspendLocked pauses the FFmpeg process. Where processes cannot be suspended the
+// encoder keeps running ahead, which costs disk space but not correctness.
+func (e *segmentEncoder) suspendLocked() {
+	if e.cmd == nil || e.cmd.Process == nil {
+		return
+	}
+	if err := suspendProcess(e.cmd.Process); err != nil {
+		logger.Log.Debug().
+			Err(err).
+			Str("quality", e.quality).
+			Msg("Could not suspend encoder, letting it run ahead")
+		return
+	}
+	e.suspended = true
+}
+
+// resumeLocked continues a suspended FFmpeg process
+func (e *segmentEncoder) resumeLocked() {
+	if e.cmd == nil || e.cmd.Process == nil {
+		return
+	}
+	if err := resumeProcess(e.cmd.Process); err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("quality", e.quality).
+			Msg("Failed to resume encoder")
+		return
+	}
+	e.suspended = false
+	e.lastProgress = time.Now() // Time spent suspended is not encoding time
+}
+
+// stop terminates the FFmpeg process (SIGTERM, then SIGKILL after terminationTimeout)
+func (e *segmentEncoder) stop() {
+	e.mu.Lock()
+	e.stopping = true
+	if e.suspended {
+		e.resumeLocked() // A stopped process only handles SIGTERM once continued
+	}
+	cmd := e.cmd
+	e.mu.Unlock()
+
+	if cmd == nil || cmd.Process == nil || e.done() {
+		return
+	}
+
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-e.exited:
+	case <-time.After(terminationTimeout):
+		logger.Log.Warn().
+			Str("quality", e.quality).
+			Int("ffmpeg_pid", cmd.Process.Pid).
+			Msg("Encoder did not exit after SIGTERM, killing it")
+		_ = cmd.Process.Kill()
+		<-e.exited
+	}
+}
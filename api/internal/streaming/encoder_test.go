@@ -0,0 +1,233 @@
+package streaming
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestParseSegmentListLine(t *testing.T) {
+	filename, duration, err := parseSegmentListLine("seg-000012.ts,48.000000,52.033333")
+	if err != nil {
+		t.Fatalf("parseSegmentListLine failed: %v", err)
+	}
+	if filename != "seg-000012.ts" {
+		t.Errorf("filename = %q, want seg-000012.ts", filename)
+	}
+	if duration < 4.03 || duration > 4.04 {
+		t.Errorf("duration = %f, want ~4.033", duration)
+	}
+
+	for _, line := range []string{"", "seg-000012.ts", "seg.ts,a,4", "seg.ts,0,b", ",0,4"} {
+		if _, _, err := parseSegmentListLine(line); err == nil {
+			t.Errorf("parseSegmentListLine(%q) should fail", line)
+		}
+	}
+}
+
+func TestParseSegmentList_M3U8(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:4\n" +
+		"#EXT-X-MAP:URI=\"init-12.mp4\"\n" +
+		"#EXTINF:4.000000,\n/streams/channel1/720p/seg-000012.m4s\n" +
+		"#EXTINF:3.966667,\nseg-000013.m4s\n" +
+		"#EXTINF:4.0" // Still being written
+
+	entries := parseSegmentList(content, true)
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	if entries[0].filename != "seg-000012.m4s" || entries[1].filename != "seg-000013.m4s" {
+		t.Errorf("filenames = %q, %q", entries[0].filename, entries[1].filename)
+	}
+	for _, entry := range entries {
+		if entry.initFile != "init-12.mp4" {
+			t.Errorf("initFile = %q, want init-12.mp4", entry.initFile)
+		}
+		if entry.err != nil {
+			t.Errorf("unexpected entry error: %v", entry.err)
+		}
+	}
+	if entries[1].duration < 3.96 || entries[1].duration > 3.97 {
+		t.Errorf("duration = %f, want ~3.967", entries[1].duration)
+	}
+
+	// An EXTINF whose URI line has not been written yet is not complete
+	if entries := parseSegmentList("#EXTINF:4.000000,\n", true); len(entries) != 0 {
+		t.Errorf("entries without URI = %d, want 0", len(entries))
+	}
+}
+
+// newTestEncoder creates an encoder without an FFmpeg process; tests write its segment list directly
+func newTestEncoder(t *testing.T, start int) (*segmentEncoder, *[]encodedSegment) {
+	t.Helper()
+	dir := t.TempDir()
+	var segments []encodedSegment
+	e := newSegmentEncoder(Quality720p, HardwareAccelNone, dir,
+		filepath.Join(dir, "segments.csv"), filepath.Join(dir, "input.txt"), start,
+		func(seg encodedSegment) { segments = append(segments, seg) })
+	return e, &segments
+}
+
+func writeSegmentList(t *testing.T, e *segmentEncoder, content string) {
+	t.Helper()
+	if err := os.WriteFile(e.listPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write segment list: %v", err)
+	}
+}
+
+func TestSegmentEncoder_ReadSegmentList(t *testing.T) {
+	e, segments := newTestEncoder(t, 7)
+
+	// Nothing written yet
+	e.readSegmentList()
+	if len(*segments) != 0 {
+		t.Fatalf("segments before the list exists = %d, want 0", len(*segments))
+	}
+
+	// The second entry is still being written
+	writeSegmentList(t, e, "seg-000007.ts,28.000000,32.000000\nseg-000008.ts,32.0")
+	e.readSegmentList()
+	if len(*segments) != 1 || (*segments)[0].number != 7 || (*segments)[0].filename != "seg-000007.ts" {
+		t.Fatalf("segments after first read = %+v, want only segment 7", *segments)
+	}
+
+	writeSegmentList(t, e, "seg-000007.ts,28.000000,32.000000\nseg-000008.ts,32.000000,36.000000\n")
+	e.readSegmentList()
+	e.readSegmentList() // Reading again does not repeat segments
+	if len(*segments) != 2 || (*segments)[1].number != 8 || (*segments)[1].duration != 4 {
+		t.Fatalf("segments after second read = %+v, want segments 7 and 8", *segments)
+	}
+	if got := e.produced(); got != 2 {
+		t.Errorf("produced = %d, want 2", got)
+	}
+}
+
+func TestSegmentEncoder_ReadParts(t *testing.T) {
+	e, _ := newTestEncoder(t, 7)
+	var parts []encodedPart
+	e.initFile = "init-7.mp4"
+	e.onPart = func(part encodedPart) { parts = append(parts, part) }
+
+	writeFile := func(name string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(e.dir, name), data, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	first, second := testFragment(false), testFragment(false)
+
+	// The encoder's first segment has no parts
+	writeFile("init-7.mp4", testInitSegment())
+	writeFile("seg-000007.m4s", first)
+	e.readParts("seg-000007.m4s")
+	if len(parts) != 0 {
+		t.Fatalf("parts of the first segment = %+v, want none", parts)
+	}
+
+	writeFile("seg-000008.m4s", append(append([]byte{}, first...), second[:20]...))
+	e.readParts("seg-000008.m4s")
+	if len(parts) != 1 || parts[0].number != 8 || parts[0].start != 0 || !parts[0].independent || parts[0].duration != 1.0 {
+		t.Fatalf("parts = %+v, want the independent first part of segment 8", parts)
+	}
+
+	// Scanning resumes after the last complete fragment
+	writeFile("seg-000008.m4s", bytes.Join([][]byte{first, second}, nil))
+	e.readParts("seg-000008.m4s")
+	if len(parts) != 2 || parts[1].start != int64(len(first)) || parts[1].length != int64(len(second)) || parts[1].independent {
+		t.Fatalf("parts = %+v, want a second, dependent part at %d", parts, len(first))
+	}
+
+	// The next segment starts over
+	writeFile("seg-000009.m4s", first)
+	e.readParts("seg-000009.m4s")
+	if len(parts) != 3 || parts[2].number != 9 || parts[2].start != 0 || !parts[2].independent {
+		t.Fatalf("parts = %+v, want the first part of segment 9", parts)
+	}
+}
+
+func TestSegmentEncoder_RunUntil(t *testing.T) {
+	e, _ := newTestEncoder(t, 0)
+
+	result := make(chan error, 1)
+	go func() { result <- e.runUntil(context.Background(), 1) }()
+
+	writeSegmentList(t, e, "seg-000000.ts,0.000000,4.000000\n")
+	e.readSegmentList()
+	select {
+	case err := <-result:
+		t.Fatalf("runUntil returned %v before segment 1 was complete", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writeSegmentList(t, e, "seg-000000.ts,0.000000,4.000000\nseg-000001.ts,4.000000,8.000000\n")
+	e.readSegmentList()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("runUntil failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runUntil did not return once segment 1 was complete")
+	}
+
+	// An encoder whose input ran out reports it so the rendition can start the next one
+	close(e.exited)
+	if err := e.runUntil(context.Background(), 5); !errors.Is(err, errEncoderFinished) {
+		t.Errorf("runUntil after exit = %v, want errEncoderFinished", err)
+	}
+}
+
+func TestSegmentEncoder_RunUntilCancelled(t *testing.T) {
+	e, _ := newTestEncoder(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.runUntil(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("runUntil with cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestSegmentEncoder_HoldsBudgetWhileSuspended(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	budget := NewTranscodeBudget(1)
+	release, err := budget.Acquire(context.Background(), 1, PriorityViewer)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	e, _ := newTestEncoder(t, 0)
+	e.onExit = release
+	e.cmd = exec.Command(sleep, "30")
+	if err := e.cmd.Start(); err != nil {
+		t.Fatalf("failed to start process: %v", err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	go e.wait(watcher)
+
+	// A suspended encoder is still a live process, so it keeps its budget
+	e.mu.Lock()
+	e.suspendLocked()
+	e.mu.Unlock()
+	if budget.HasCapacity(1) {
+		t.Fatal("suspended encoder released its budget")
+	}
+
+	e.stop()
+	if stats := budget.Stats(); stats.Running != 0 || stats.InUse != 0 {
+		t.Errorf("budget not released once the encoder exited: %+v", stats)
+	}
+}
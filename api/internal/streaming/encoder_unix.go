@@ -0,0 +1,18 @@
+//go:build !windows
+
+package streaming
+
+import (
+	"os"
+	"syscall"
+)
+
+// suspendProcess pauses a process until resumeProcess is called (Unix implementation)
+func suspendProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGSTOP)
+}
+
+// resumeProcess continues a process paused by suspendProcess (Unix implementation)
+func resumeProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGCONT)
+}
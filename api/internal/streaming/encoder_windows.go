@@ -0,0 +1,18 @@
+//go:build windows
+
+package streaming
+
+import (
+	"errors"
+	"os"
+)
+
+// suspendProcess is not supported on Windows; encoders run ahead instead
+func suspendProcess(_ *os.Process) error {
+	return errors.ErrUnsupported
+}
+
+// resumeProcess is a no-op on Windows since processes are never suspended
+func resumeProcess(_ *os.Process) error {
+	return nil
+}
@@ -0,0 +1,219 @@
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stwalsh4118/hermes/internal/logger"
+)
+
+const (
+	// encoderVerifyTimeout bounds a single test encode; hardware init can take a few seconds
+	encoderVerifyTimeout = 15 * time.Second
+
+	// vaapiDevice is the render node used when verifying VAAPI
+	vaapiDevice = "/dev/dri/renderD128"
+)
+
+// EncoderCapabilities describes the encoders available on this machine.
+// Available, Verified and Selected describe H.264 encoding; Codecs covers every codec.
+type EncoderCapabilities struct {
+	FFmpegAvailable bool                           `json:"ffmpeg_available"`
+	Available       []HardwareAccel                `json:"available"`    // Listed by ffmpeg -encoders
+	Verified        []HardwareAccel                `json:"verified"`     // Passed a test encode
+	Selected        HardwareAccel                  `json:"selected"`     // SelectBestEncoder over Verified; used for "auto"
+	Codecs          map[VideoCodec][]HardwareAccel `json:"codecs"`       // Verified methods per codec, in priority order
+	ToneMappers     []ToneMapper                   `json:"tone_mappers"` // Usable tone mappers, in priority order
+	ToneMap         ToneMapper                     `json:"tone_map"`     // Tone mapper used for HDR sources; empty if none
+	Failures        map[string]string              `json:"failures,omitempty"`
+	Error           string                         `json:"error,omitempty"`
+	DetectedAt      time.Time                      `json:"detected_at"`
+}
+
+// IsVerified reports whether the given method passed its test encode
+func (c *EncoderCapabilities) IsVerified(method HardwareAccel) bool {
+	for _, encoder := range c.Verified {
+		if encoder == method {
+			return true
+		}
+	}
+	return false
+}
+
+// CanEncode reports whether a codec can be encoded with the given method; with "auto" any verified method will do
+func (c *EncoderCapabilities) CanEncode(codec VideoCodec, method HardwareAccel) bool {
+	verified := c.Codecs[codec]
+	if method == HardwareAccelAuto {
+		return len(verified) > 0
+	}
+	for _, encoder := range verified {
+		if encoder == method {
+			return true
+		}
+	}
+	return false
+}
+
+// EncoderDetector detects and caches hardware encoder capabilities
+type EncoderDetector struct {
+	mu     sync.RWMutex
+	caps   *EncoderCapabilities
+	detect func(ctx context.Context) (map[VideoCodec][]HardwareAccel, error)
+	verify func(ctx context.Context, codec VideoCodec, method HardwareAccel) error
+
+	// Optional; without filters no tone mapper is detected
+	filters       func(ctx context.Context) (map[string]bool, error)
+	verifyToneMap func(ctx context.Context, mapper ToneMapper) error
+}
+
+// NewEncoderDetector creates a detector backed by the local ffmpeg binary
+func NewEncoderDetector() *EncoderDetector {
+	return &EncoderDetector{
+		detect:        DetectHardwareEncoders,
+		verify:        verifyEncoder,
+		filters:       detectFilters,
+		verifyToneMap: verifyToneMapper,
+	}
+}
+
+// Detect probes ffmpeg for encoders and tone mapping filters, verifies each hardware candidate with
+// a test run and caches the result.
+// Detection never fails outright: without ffmpeg the result only contains H.264 software encoding.
+func (d *EncoderDetector) Detect(ctx context.Context) *EncoderCapabilities {
+	caps := &EncoderCapabilities{
+		Available:  []HardwareAccel{HardwareAccelNone},
+		Verified:   []HardwareAccel{HardwareAccelNone},
+		Selected:   HardwareAccelNone,
+		Codecs:     map[VideoCodec][]HardwareAccel{VideoCodecH264: {HardwareAccelNone}},
+		Failures:   make(map[string]string),
+		DetectedAt: time.Now().UTC(),
+	}
+
+	available, err := d.detect(ctx)
+	if err != nil {
+		caps.Error = err.Error()
+		logger.Log.Warn().
+			Err(err).
+			Msg("Hardware encoder detection failed, using software encoding")
+	} else {
+		caps.FFmpegAvailable = true
+		caps.Available = sortEncoders(available[VideoCodecH264])
+		caps.Verified = d.verifyAll(ctx, VideoCodecH264, caps.Available, caps.Failures)
+		caps.Selected = SelectBestEncoder(caps.Verified)
+
+		caps.Codecs[VideoCodecH264] = caps.Verified
+		for _, codec := range videoCodecs[1:] {
+			caps.Codecs[codec] = d.verifyAll(ctx, codec, sortEncoders(available[codec]), caps.Failures)
+		}
+
+		caps.ToneMappers = d.detectToneMappers(ctx, caps.Failures)
+		if len(caps.ToneMappers) > 0 {
+			caps.ToneMap = caps.ToneMappers[0]
+		}
+	}
+
+	d.mu.Lock()
+	d.caps = caps
+	d.mu.Unlock()
+
+	return caps
+}
+
+// verifyAll test-encodes a codec with each available method and returns the ones that work.
+// Software encoders listed by ffmpeg are trusted. Failures are keyed by method for H.264
+// and by encoder name for other codecs.
+func (d *EncoderDetector) verifyAll(ctx context.Context, codec VideoCodec, available []HardwareAccel, failures map[string]string) []HardwareAccel {
+	verified := make([]HardwareAccel, 0, len(available))
+	for _, method := range available {
+		if method == HardwareAccelNone {
+			verified = append(verified, method)
+			continue
+		}
+		if err := d.verify(ctx, codec, method); err != nil {
+			key := method.String()
+			if codec != VideoCodecH264 {
+				key = videoEncoderName(codec, method)
+			}
+			failures[key] = err.Error()
+			logger.Log.Warn().
+				Err(err).
+				Str("encoder", videoEncoderName(codec, method)).
+				Msg("Hardware encoder failed test encode")
+			continue
+		}
+		verified = append(verified, method)
+	}
+	return verified
+}
+
+// Capabilities returns the cached detection result, or nil if Detect has not run
+func (d *EncoderDetector) Capabilities() *EncoderCapabilities {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.caps
+}
+
+// Resolve maps a configured hardware acceleration method to the one to encode with.
+// "auto" resolves to the selected encoder (software until detection has run).
+func (d *EncoderDetector) Resolve(method HardwareAccel) HardwareAccel {
+	if method != HardwareAccelAuto {
+		return method
+	}
+	caps := d.Capabilities()
+	if caps == nil {
+		return HardwareAccelNone
+	}
+	return caps.Selected
+}
+
+// verifyEncoder runs a tiny test encode of a generated source with the given codec and method
+func verifyEncoder(ctx context.Context, codec VideoCodec, method HardwareAccel) error {
+	ctx, cancel := context.WithTimeout(ctx, encoderVerifyTimeout)
+	defer cancel()
+
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	if method == HardwareAccelVAAPI {
+		args = append(args, "-vaapi_device", vaapiDevice)
+	}
+	args = append(args, "-f", "lavfi", "-i", "color=c=black:s=256x144:r=25:d=0.2")
+	if method == HardwareAccelVAAPI {
+		args = append(args, "-vf", "format=nv12,hwupload")
+	}
+	args = append(args, buildVideoEncodeArgs(codec, method, "veryfast")...)
+	args = append(args, "-frames:v", "5", "-f", "null", "-")
+
+	output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("test encode timed out: %w", ctx.Err())
+		}
+		return fmt.Errorf("test encode failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// sortEncoders orders encoders by SelectBestEncoder priority so API output is stable
+func sortEncoders(encoders []HardwareAccel) []HardwareAccel {
+	priority := []HardwareAccel{
+		HardwareAccelNVENC,
+		HardwareAccelQSV,
+		HardwareAccelVideoToolbox,
+		HardwareAccelVAAPI,
+		HardwareAccelNone,
+	}
+
+	sorted := make([]HardwareAccel, 0, len(encoders))
+	for _, preferred := range priority {
+		for _, encoder := range encoders {
+			if encoder == preferred {
+				sorted = append(sorted, encoder)
+				break
+			}
+		}
+	}
+	return sorted
+}
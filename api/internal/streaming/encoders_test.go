@@ -0,0 +1,154 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stwalsh4118/hermes/internal/config"
+)
+
+// newFakeEncoderDetector creates a detector with stubbed ffmpeg probing of H.264 encoders
+func newFakeEncoderDetector(available []HardwareAccel, detectErr error, failing ...HardwareAccel) *EncoderDetector {
+	return &EncoderDetector{
+		detect: func(ctx context.Context) (map[VideoCodec][]HardwareAccel, error) {
+			if detectErr != nil {
+				return nil, detectErr
+			}
+			return map[VideoCodec][]HardwareAccel{VideoCodecH264: available}, nil
+		},
+		verify: func(ctx context.Context, codec VideoCodec, method HardwareAccel) error {
+			for _, f := range failing {
+				if f == method {
+					return errors.New("device not found")
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func TestEncoderDetector_Detect(t *testing.T) {
+	detector := newFakeEncoderDetector(
+		[]HardwareAccel{HardwareAccelNone, HardwareAccelVAAPI, HardwareAccelNVENC},
+		nil,
+		HardwareAccelNVENC,
+	)
+
+	if detector.Capabilities() != nil {
+		t.Fatal("Capabilities() should be nil before Detect")
+	}
+
+	caps := detector.Detect(context.Background())
+
+	if !caps.FFmpegAvailable {
+		t.Error("FFmpegAvailable = false, want true")
+	}
+	if len(caps.Available) != 3 || caps.Available[0] != HardwareAccelNVENC {
+		t.Errorf("Available = %v, want nvenc first of 3", caps.Available)
+	}
+	if caps.IsVerified(HardwareAccelNVENC) {
+		t.Error("nvenc failed its test encode and should not be verified")
+	}
+	if _, ok := caps.Failures["nvenc"]; !ok {
+		t.Error("expected a failure entry for nvenc")
+	}
+	if !caps.IsVerified(HardwareAccelVAAPI) || !caps.IsVerified(HardwareAccelNone) {
+		t.Errorf("Verified = %v, want vaapi and none", caps.Verified)
+	}
+	if caps.Selected != HardwareAccelVAAPI {
+		t.Errorf("Selected = %v, want vaapi", caps.Selected)
+	}
+	if detector.Capabilities() != caps {
+		t.Error("Capabilities() should return the cached result")
+	}
+}
+
+func TestEncoderDetector_DetectCodecs(t *testing.T) {
+	detector := &EncoderDetector{
+		detect: func(ctx context.Context) (map[VideoCodec][]HardwareAccel, error) {
+			return map[VideoCodec][]HardwareAccel{
+				VideoCodecH264: {HardwareAccelNone, HardwareAccelNVENC},
+				VideoCodecHEVC: {HardwareAccelQSV, HardwareAccelNone, HardwareAccelNVENC},
+				VideoCodecAV1:  {HardwareAccelNVENC},
+			}, nil
+		},
+		verify: func(ctx context.Context, codec VideoCodec, method HardwareAccel) error {
+			if codec == VideoCodecHEVC && method == HardwareAccelQSV {
+				return errors.New("unsupported profile")
+			}
+			return nil
+		},
+	}
+
+	caps := detector.Detect(context.Background())
+
+	if !reflect.DeepEqual(caps.Codecs[VideoCodecH264], caps.Verified) {
+		t.Errorf("Codecs[h264] = %v, want the verified H.264 methods %v", caps.Codecs[VideoCodecH264], caps.Verified)
+	}
+	if want := []HardwareAccel{HardwareAccelNVENC, HardwareAccelNone}; !reflect.DeepEqual(caps.Codecs[VideoCodecHEVC], want) {
+		t.Errorf("Codecs[hevc] = %v, want %v", caps.Codecs[VideoCodecHEVC], want)
+	}
+	if _, ok := caps.Failures["hevc_qsv"]; !ok {
+		t.Errorf("Failures = %v, want an entry for hevc_qsv", caps.Failures)
+	}
+	if !caps.CanEncode(VideoCodecAV1, HardwareAccelNVENC) || !caps.CanEncode(VideoCodecAV1, HardwareAccelAuto) {
+		t.Error("AV1 should be encodable with nvenc")
+	}
+	if caps.CanEncode(VideoCodecAV1, HardwareAccelNone) {
+		t.Error("AV1 software encoding was not detected")
+	}
+}
+
+func TestEncoderDetector_DetectWithoutFFmpeg(t *testing.T) {
+	detector := newFakeEncoderDetector(nil, ErrFFmpegNotFound)
+
+	caps := detector.Detect(context.Background())
+
+	if caps.FFmpegAvailable {
+		t.Error("FFmpegAvailable = true, want false")
+	}
+	if caps.Selected != HardwareAccelNone {
+		t.Errorf("Selected = %v, want none", caps.Selected)
+	}
+	if caps.Error == "" {
+		t.Error("expected detection error to be reported")
+	}
+	if !caps.CanEncode(VideoCodecH264, HardwareAccelNone) || caps.CanEncode(VideoCodecHEVC, HardwareAccelAuto) {
+		t.Errorf("Codecs = %v, want only H.264 software encoding", caps.Codecs)
+	}
+}
+
+func TestEncoderDetector_Resolve(t *testing.T) {
+	detector := newFakeEncoderDetector([]HardwareAccel{HardwareAccelNone, HardwareAccelQSV}, nil)
+
+	if got := detector.Resolve(HardwareAccelAuto); got != HardwareAccelNone {
+		t.Errorf("Resolve(auto) before detection = %v, want none", got)
+	}
+
+	detector.Detect(context.Background())
+
+	if got := detector.Resolve(HardwareAccelAuto); got != HardwareAccelQSV {
+		t.Errorf("Resolve(auto) = %v, want qsv", got)
+	}
+	if got := detector.Resolve(HardwareAccelNVENC); got != HardwareAccelNVENC {
+		t.Errorf("Resolve(nvenc) = %v, want explicit method unchanged", got)
+	}
+}
+
+func TestStreamManager_ResolvesAutoHardwareAccel(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{HardwareAccel: string(HardwareAccelAuto)})
+
+	if got := m.currentHardwareAccel(); got != HardwareAccelNone {
+		t.Errorf("currentHardwareAccel() without detector = %v, want none", got)
+	}
+
+	detector := newFakeEncoderDetector([]HardwareAccel{HardwareAccelNone, HardwareAccelVideoToolbox}, nil)
+	detector.Detect(context.Background())
+	m.SetEncoderDetector(detector)
+
+	if got := m.currentHardwareAccel(); got != HardwareAccelVideoToolbox {
+		t.Errorf("currentHardwareAccel() = %v, want videotoolbox", got)
+	}
+}
@@ -0,0 +1,133 @@
+package streaming
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// defaultKeyRotationSegments is the number of segments per key when the configuration leaves it unset
+const defaultKeyRotationSegments = 15
+
+// segmentKeyURIPattern is the URI of a segment key in media playlists. Relative to the media playlist
+// (/stream/:channel_id/1080p.m3u8) it resolves to GET /stream/:channel_id/keys/:key.
+const segmentKeyURIPattern = "keys/%d.key"
+
+// Segment encryption errors
+var (
+	ErrEncryptionDisabled = errors.New("stream segments are not encrypted")
+	ErrKeyNotAvailable    = errors.New("encryption key is not available")
+)
+
+// keyRotation returns the number of segments encrypted with each key
+func (m *StreamManager) keyRotation() int {
+	if m.config.KeyRotationSegments > 0 {
+		return m.config.KeyRotationSegments
+	}
+	return defaultKeyRotationSegments
+}
+
+// keyIndex returns the index of the key a segment is encrypted with
+func (m *StreamManager) keyIndex(segmentNumber int) int {
+	return segmentNumber / m.keyRotation()
+}
+
+// segmentKey derives a stream session's AES-128 key with the given index from the key secret.
+// Keys are never stored: every rendition and every request derives the same key. The session ID
+// (saved with the stream's state, so resumed streams keep it) makes every session of a channel
+// use different keys; otherwise a restarted stream would reuse the keys of the one before it.
+func (m *StreamManager) segmentKey(session *models.StreamSession, index int) []byte {
+	mac := hmac.New(sha256.New, m.keySecret)
+	mac.Write(session.ChannelID[:])
+	mac.Write(session.ID[:])
+	_ = binary.Write(mac, binary.BigEndian, uint64(index)) // nolint:gosec // key indexes are never negative
+	return mac.Sum(nil)[:aes.BlockSize]
+}
+
+// EncryptionKey returns the AES-128 key with the given index of an encrypted stream.
+// Keys of segments that are not planned yet (beyond the next rotation) are withheld.
+func (m *StreamManager) EncryptionKey(channelID uuid.UUID, index int) ([]byte, error) {
+	session, ok := m.sessionManager.Get(channelID.String())
+	if !ok {
+		return nil, ErrStreamNotFound
+	}
+	if session.GetEncryption() != models.EncryptionAES128 {
+		return nil, ErrEncryptionDisabled
+	}
+
+	newest := 0
+	if src, ok := m.renditionsFor(session).newest(); ok {
+		newest = src.number
+	}
+	if index < 0 || index > m.keyIndex(newest)+1 {
+		return nil, ErrKeyNotAvailable
+	}
+	return m.segmentKey(session, index), nil
+}
+
+// segmentIV derives the IV of a stream session's segment from the key secret, the session ID and
+// the segment's media sequence number, so no two segments of any session share an IV. Players
+// cannot derive it, so media playlists carry it in the EXT-X-KEY IV attribute (see formatIV).
+func (m *StreamManager) segmentIV(session *models.StreamSession, segmentNumber int) []byte {
+	mac := hmac.New(sha256.New, m.keySecret)
+	mac.Write([]byte("iv"))
+	mac.Write(session.ID[:])
+	_ = binary.Write(mac, binary.BigEndian, uint64(segmentNumber)) // nolint:gosec // segment numbers are never negative
+	return mac.Sum(nil)[:aes.BlockSize]
+}
+
+// formatIV formats an IV as the hexadecimal-sequence of an EXT-X-KEY IV attribute
+func formatIV(iv []byte) string {
+	return "0x" + hex.EncodeToString(iv)
+}
+
+// encryptSegmentFile encrypts a segment in place with AES-128-CBC and PKCS#7 padding
+// (EXT-X-KEY METHOD=AES-128). The encrypted file replaces the original atomically.
+func encryptSegmentFile(path string, key, iv []byte) error {
+	plaintext, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read segment: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertext := make([]byte, len(plaintext)+padding)
+	copy(ciphertext, plaintext)
+	for i := len(plaintext); i < len(ciphertext); i++ {
+		ciphertext[i] = byte(padding)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ".segment-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+	if _, err := tempFile.Write(ciphertext); err != nil {
+		_ = tempFile.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write encrypted segment: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to close encrypted segment: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to replace segment: %w", err)
+	}
+	return nil
+}
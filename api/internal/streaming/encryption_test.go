@@ -0,0 +1,124 @@
+package streaming
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestEncryptSegmentFile_DecryptsWithPlaylistIV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seg-000042.ts")
+	plaintext := bytes.Repeat([]byte{0x47, 0x01, 0x02}, 100) // Not a multiple of the block size
+	if err := os.WriteFile(path, plaintext, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{KeySecret: "secret"})
+	session := models.NewStreamSession(uuid.New())
+	key := m.segmentKey(session, 4)
+	ivAttribute := formatIV(m.segmentIV(session, 42))
+
+	if err := encryptSegmentFile(path, key, m.segmentIV(session, 42)); err != nil {
+		t.Fatalf("encryptSegmentFile failed: %v", err)
+	}
+
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ciphertext)%aes.BlockSize != 0 || len(ciphertext) <= len(plaintext) {
+		t.Fatalf("ciphertext length %d is not padded plaintext length %d", len(ciphertext), len(plaintext))
+	}
+
+	// Decrypt as a player would: IV from the EXT-X-KEY IV attribute, PKCS#7 padding
+	if len(ivAttribute) != 34 || ivAttribute[:2] != "0x" {
+		t.Fatalf("IV attribute = %q, want 0x and 32 hex digits", ivAttribute)
+	}
+	iv, err := hex.DecodeString(ivAttribute[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := aes.NewCipher(key)
+	decrypted := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, ciphertext)
+	padding := int(decrypted[len(decrypted)-1])
+	if !bytes.Equal(decrypted[:len(decrypted)-padding], plaintext) {
+		t.Error("decrypted segment does not match the original")
+	}
+}
+
+func TestSegmentKey_RotatesPerSessionAndIndex(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{KeyRotationSegments: 10, KeySecret: "secret"})
+	sessionA, sessionB := models.NewStreamSession(uuid.New()), models.NewStreamSession(uuid.New())
+
+	if m.keyIndex(9) != 0 || m.keyIndex(10) != 1 {
+		t.Errorf("key indexes of segments 9 and 10 = %d, %d; want 0, 1", m.keyIndex(9), m.keyIndex(10))
+	}
+
+	key := m.segmentKey(sessionA, 1)
+	if len(key) != 16 {
+		t.Fatalf("key length = %d, want 16", len(key))
+	}
+	if !bytes.Equal(key, m.segmentKey(sessionA, 1)) {
+		t.Error("keys are not derived deterministically")
+	}
+	if bytes.Equal(key, m.segmentKey(sessionA, 2)) || bytes.Equal(key, m.segmentKey(sessionB, 1)) {
+		t.Error("keys must differ between rotations and channels")
+	}
+
+	// A new session of the same channel gets new keys and IVs
+	restartedStream := models.NewStreamSession(sessionA.ChannelID)
+	if bytes.Equal(key, m.segmentKey(restartedStream, 1)) {
+		t.Error("keys must differ between sessions of a channel")
+	}
+	iv := m.segmentIV(sessionA, 12)
+	if bytes.Equal(iv, m.segmentIV(restartedStream, 12)) || bytes.Equal(iv, m.segmentIV(sessionA, 13)) {
+		t.Error("IVs must differ between sessions and segments")
+	}
+
+	// The same secret derives the same keys and IVs after a restart that resumes the session
+	restarted := NewStreamManager(nil, nil, &config.StreamingConfig{KeyRotationSegments: 10, KeySecret: "secret"})
+	if !bytes.Equal(key, restarted.segmentKey(sessionA, 1)) || !bytes.Equal(iv, restarted.segmentIV(sessionA, 12)) {
+		t.Error("a configured key secret must survive restarts")
+	}
+}
+
+func TestEncryptionKey(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{BatchSize: 5, KeyRotationSegments: 10})
+
+	if _, err := m.EncryptionKey(uuid.New(), 0); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("inactive stream: err = %v, want ErrStreamNotFound", err)
+	}
+
+	clear := models.NewStreamSession(uuid.New())
+	m.sessionManager.Set(clear.ChannelID.String(), clear)
+	if _, err := m.EncryptionKey(clear.ChannelID, 0); !errors.Is(err, ErrEncryptionDisabled) {
+		t.Errorf("unencrypted stream: err = %v, want ErrEncryptionDisabled", err)
+	}
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetEncryption(models.EncryptionAES128)
+	m.sessionManager.Set(session.ChannelID.String(), session)
+	m.renditionsFor(session).record(segmentSource{number: 12, videoPath: "a.mp4"})
+
+	// Segment 12 uses key 1; the next key is handed out ahead of the rotation
+	for _, index := range []int{0, 1, 2} {
+		key, err := m.EncryptionKey(session.ChannelID, index)
+		if err != nil {
+			t.Errorf("key %d: unexpected error %v", index, err)
+		} else if !bytes.Equal(key, m.segmentKey(session, index)) {
+			t.Errorf("key %d does not match the key segments are encrypted with", index)
+		}
+	}
+	if _, err := m.EncryptionKey(session.ChannelID, 3); !errors.Is(err, ErrKeyNotAvailable) {
+		t.Errorf("future key: err = %v, want ErrKeyNotAvailable", err)
+	}
+}
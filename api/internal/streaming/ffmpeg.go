@@ -12,6 +12,7 @@ import (
 
 // Quality level constants
 const (
+	Quality2160p = "2160p"
 	Quality1080p = "1080p"
 	Quality720p  = "720p"
 	Quality480p  = "480p"
@@ -19,6 +20,7 @@ const (
 
 // Bitrate constants (in kbps)
 const (
+	bitrate2160p  = 16000
 	bitrate1080p  = 5000
 	bitrate720p   = 3000
 	bitrate480p   = 1500
@@ -28,6 +30,7 @@ const (
 
 // Resolution constants
 const (
+	resolution2160p = "3840x2160"
 	resolution1080p = "1920x1080"
 	resolution720p  = "1280x720"
 	resolution480p  = "854x480"
@@ -40,6 +43,16 @@ const (
 	hlsFlags               = "delete_segments"
 )
 
+// Segment containers for continuous segment mode
+const (
+	SegmentFormatTS   = "ts"   // MPEG-TS segments written by the stream_segment muxer
+	SegmentFormatFMP4 = "fmp4" // CMAF fragments plus an init segment, written by the hls muxer
+)
+
+// Continuous encoders name segments by segment number ({prefix}-000012.ts),
+// so numbering stays aligned across renditions and encoder restarts
+const defaultSegmentPrefix = "seg"
+
 // Common errors
 var (
 	ErrInvalidQuality              = errors.New("invalid quality level")
@@ -52,13 +65,16 @@ var (
 	ErrEmptySegmentOutputDir       = errors.New("segment output directory cannot be empty when stream segment mode is enabled")
 	ErrEmptySegmentFilenamePattern = errors.New("segment filename pattern cannot be empty when stream segment mode is enabled")
 	ErrInvalidFPS                  = errors.New("FPS must be positive")
+	ErrEmptySegmentListPath        = errors.New("segment list path cannot be empty when continuous segment mode is enabled")
+	ErrInvalidSegmentFormat        = errors.New("segment format must be ts or fmp4")
 )
 
 // StreamParams contains all parameters needed to build an FFmpeg HLS command
 type StreamParams struct {
 	InputFile              string        // Path to input video file
 	OutputPath             string        // Full path to output .m3u8 playlist (HLS mode) or segment directory (stream_segment mode)
-	Quality                string        // Quality level (1080p, 720p, 480p)
+	Quality                string        // Quality level (2160p, 1080p, 720p, 480p)
+	VideoCodec             VideoCodec    // Video codec (h264, hevc, av1; empty = h264). AV1 requires fmp4 segments
 	HardwareAccel          HardwareAccel // Hardware acceleration method
 	SeekSeconds            int64         // Starting position in seconds (0 = beginning) - position within current video file
 	StreamPositionSeconds  int64         // Cumulative stream position in seconds (segmentNumber * segmentDuration) - for PTS timestamps
@@ -71,6 +87,16 @@ type StreamParams struct {
 	SegmentOutputDir       string        // Directory for segment output (required when StreamSegmentMode is true)
 	SegmentFilenamePattern string        // Filename pattern for segments with strftime (e.g., seg-%Y%m%dT%H%M%S.ts)
 	FPS                    int           // Frames per second for GOP calculations (default: 30 if not provided)
+	ConcatInput            bool          // InputFile is an FFmpeg concat demuxer list rather than a media file
+	ContinuousSegmentMode  bool          // Encode continuously into numbered segments (requires StreamSegmentMode)
+	SegmentStartNumber     int           // Number of the first segment written in continuous segment mode
+	SegmentListPath        string        // List FFmpeg adds each completed segment to: CSV for ts, m3u8 for fmp4 (continuous segment mode)
+	SegmentFormat          string        // Segment container in continuous segment mode: ts (default) or fmp4
+	InitFilename           string        // fMP4 init segment filename, relative to SegmentOutputDir (fmp4 only)
+	SegmentPrefix          string        // Filename prefix of numbered segments in continuous segment mode (default: seg)
+	PartDurationMs         int           // Low-Latency HLS part duration: fragments are cut this often within each segment (fmp4 only, 0 = one fragment per segment)
+	ToneMap                ToneMapper    // Tone map HDR (PQ/HLG) input to SDR BT.709 with this filter implementation (empty = off)
+	Deinterlace            bool          // Deinterlace interlaced input
 }
 
 // FFmpegCommand represents a built FFmpeg command
@@ -86,34 +112,36 @@ type qualitySpec struct {
 	resolution   string
 }
 
-// getQualitySpec returns the quality specifications for a given quality level
-func getQualitySpec(quality string) (*qualitySpec, error) {
+// getQualitySpec returns the quality specifications for a given quality level and video codec.
+// Bitrates are defined for H.264 and scaled down for codecs that compress better.
+func getQualitySpec(quality string, codec VideoCodec) (*qualitySpec, error) {
+	if !codec.orDefault().IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidVideoCodec, codec)
+	}
+
+	var bitrate int
+	var resolution string
 	switch quality {
+	case Quality2160p:
+		bitrate, resolution = bitrate2160p, resolution2160p
 	case Quality1080p:
-		return &qualitySpec{
-			videoBitrate: bitrate1080p,
-			maxrate:      bitrate1080p,
-			bufsize:      bitrate1080p * 2,
-			resolution:   resolution1080p,
-		}, nil
+		bitrate, resolution = bitrate1080p, resolution1080p
 	case Quality720p:
-		return &qualitySpec{
-			videoBitrate: bitrate720p,
-			maxrate:      bitrate720p,
-			bufsize:      bitrate720p * 2,
-			resolution:   resolution720p,
-		}, nil
+		bitrate, resolution = bitrate720p, resolution720p
 	case Quality480p:
-		return &qualitySpec{
-			videoBitrate: bitrate480p,
-			maxrate:      bitrate480p,
-			bufsize:      bitrate480p * 2,
-			resolution:   resolution480p,
-		}, nil
+		bitrate, resolution = bitrate480p, resolution480p
 	default:
-		return nil, fmt.Errorf("%w: %s (must be one of: %s, %s, %s)",
-			ErrInvalidQuality, quality, Quality1080p, Quality720p, Quality480p)
+		return nil, fmt.Errorf("%w: %s (must be one of: %s, %s, %s, %s)",
+			ErrInvalidQuality, quality, Quality2160p, Quality1080p, Quality720p, Quality480p)
 	}
+
+	bitrate = codecBitrate(bitrate, codec)
+	return &qualitySpec{
+		videoBitrate: bitrate,
+		maxrate:      bitrate,
+		bufsize:      bitrate * 2,
+		resolution:   resolution,
+	}, nil
 }
 
 // BuildHLSCommand builds a complete FFmpeg command for HLS stream generation
@@ -127,20 +155,30 @@ func BuildHLSCommand(params StreamParams) (*FFmpegCommand, error) {
 	// Build command arguments in correct order
 	args := make([]string, 0, 40)
 
+	// Hardware tone mappers need their filter device before the input
+	args = append(args, params.ToneMap.deviceArgs()...)
+
 	// 1. Input args (with seeking if specified)
 	inputArgs := buildInputArgs(params)
 	args = append(args, inputArgs...)
 
 	// 2. Video encoding args (with hardware acceleration and preset)
-	videoArgs := buildVideoEncodeArgs(params.HardwareAccel, params.EncodingPreset)
+	videoArgs := buildVideoEncodeArgs(params.VideoCodec, params.HardwareAccel, params.EncodingPreset)
 	args = append(args, videoArgs...)
+	if params.VideoCodec == VideoCodecHEVC && params.SegmentFormat == SegmentFormatFMP4 {
+		// Apple players only decode HEVC in fMP4 with the hvc1 sample entry (FFmpeg defaults to hev1)
+		args = append(args, "-tag:v", "hvc1")
+	}
+
+	// Video filters (deinterlacing, tone mapping) run before the encoder
+	args = append(args, buildVideoFilterArgs(params.Deinterlace, params.ToneMap)...)
 
 	// 3. Audio encoding args
 	audioArgs := buildAudioEncodeArgs()
 	args = append(args, audioArgs...)
 
 	// 4. Quality/bitrate args
-	qualityArgs, err := buildQualityArgs(params.Quality)
+	qualityArgs, err := buildQualityArgs(params.Quality, params.VideoCodec)
 	if err != nil {
 		return nil, err
 	}
@@ -161,8 +199,11 @@ func BuildHLSCommand(params StreamParams) (*FFmpegCommand, error) {
 		args = append(args, mappingArgs...)
 
 		// Stream segment output args (includes output path)
-		segmentArgs := buildStreamSegmentArgs(params)
-		args = append(args, segmentArgs...)
+		if params.ContinuousSegmentMode {
+			args = append(args, buildContinuousSegmentArgs(params)...)
+		} else {
+			args = append(args, buildStreamSegmentArgs(params)...)
+		}
 	} else {
 		// HLS output args
 		hlsArgs := buildHLSArgs(params)
@@ -177,11 +218,16 @@ func BuildHLSCommand(params StreamParams) (*FFmpegCommand, error) {
 
 // validateStreamParams validates all stream parameters
 func validateStreamParams(params StreamParams) error {
-	// Validate quality
-	if _, err := getQualitySpec(params.Quality); err != nil {
+	// Validate quality and codec
+	if _, err := getQualitySpec(params.Quality, params.VideoCodec); err != nil {
 		return err
 	}
 
+	// AV1 is only supported by HLS in fMP4 segments
+	if params.VideoCodec == VideoCodecAV1 && (!params.ContinuousSegmentMode || params.SegmentFormat != SegmentFormatFMP4) {
+		return fmt.Errorf("%w: %s", ErrCodecRequiresFMP4, params.VideoCodec)
+	}
+
 	// Validate hardware acceleration
 	if !params.HardwareAccel.IsValid() {
 		return fmt.Errorf("%w: %s", ErrInvalidHardwareAccel, params.HardwareAccel)
@@ -232,6 +278,12 @@ func validateStreamParams(params StreamParams) error {
 		if params.FPS <= 0 {
 			return ErrInvalidFPS
 		}
+		if params.ContinuousSegmentMode && params.SegmentListPath == "" {
+			return ErrEmptySegmentListPath
+		}
+		if params.SegmentFormat != "" && params.SegmentFormat != SegmentFormatTS && params.SegmentFormat != SegmentFormatFMP4 {
+			return fmt.Errorf("%w: %s", ErrInvalidSegmentFormat, params.SegmentFormat)
+		}
 	}
 
 	return nil
@@ -257,6 +309,11 @@ func buildInputArgs(params StreamParams) []string {
 		args = append(args, "-stream_loop", "-1")
 	}
 
+	// Concat lists reference absolute media paths, which the demuxer rejects in safe mode
+	if params.ConcatInput {
+		args = append(args, "-f", "concat", "-safe", "0")
+	}
+
 	// Add input file
 	args = append(args, "-i", params.InputFile)
 
@@ -281,31 +338,84 @@ func mapToNVENCPreset(softwarePreset string) string {
 	}
 }
 
-// buildVideoEncodeArgs builds video encoding arguments based on hardware acceleration
-func buildVideoEncodeArgs(hwaccel HardwareAccel, preset string) []string {
+// mapToSVTAV1Preset maps software encoding presets to SVT-AV1 presets (0-13, higher is faster)
+func mapToSVTAV1Preset(softwarePreset string) string {
+	switch softwarePreset {
+	case "ultrafast":
+		return "12"
+	case "veryfast":
+		return "10"
+	case "fast":
+		return "8"
+	case "medium":
+		return "6"
+	case "slow":
+		return "4"
+	default:
+		return "12" // Default to fastest
+	}
+}
+
+// buildVideoEncodeArgs builds video encoding arguments based on codec and hardware acceleration
+// A method without an encoder for the codec (VideoToolbox AV1) falls back to software encoding
+func buildVideoEncodeArgs(codec VideoCodec, hwaccel HardwareAccel, preset string) []string {
+	codec = codec.orDefault()
+	if !hasEncoder(codec, hwaccel) {
+		hwaccel = HardwareAccelNone
+	}
+	encoder := videoEncoderName(codec, hwaccel)
+
 	switch hwaccel {
 	case HardwareAccelNVENC:
 		// NVENC uses p1-p7 presets
 		nvencPreset := mapToNVENCPreset(preset)
-		return []string{"-c:v", "h264_nvenc", "-preset", nvencPreset}
+		return []string{"-c:v", encoder, "-preset", nvencPreset}
 	case HardwareAccelQSV:
 		// QSV uses same preset names as software
-		return []string{"-c:v", "h264_qsv", "-preset", preset}
-	case HardwareAccelVAAPI:
-		// VAAPI doesn't support presets in the same way
-		return []string{"-c:v", "h264_vaapi"}
-	case HardwareAccelVideoToolbox:
-		// VideoToolbox doesn't support presets in the same way
-		return []string{"-c:v", "h264_videotoolbox"}
-	case HardwareAccelNone, HardwareAccelAuto:
-		// Software encoding (libx264) with preset
-		return []string{"-c:v", "libx264", "-preset", preset}
+		return []string{"-c:v", encoder, "-preset", preset}
+	case HardwareAccelVAAPI, HardwareAccelVideoToolbox:
+		// VAAPI and VideoToolbox don't support presets in the same way
+		return []string{"-c:v", encoder}
 	default:
-		// Fallback to software encoding with preset
-		return []string{"-c:v", "libx264", "-preset", preset}
+		// Software encoding with preset (libx264 and libx265 share preset names)
+		if codec == VideoCodecAV1 {
+			return []string{"-c:v", encoder, "-preset", mapToSVTAV1Preset(preset)}
+		}
+		return []string{"-c:v", encoder, "-preset", preset}
 	}
 }
 
+// Video filter chains
+const (
+	// deinterlaceFilter outputs one frame per frame (not per field) so the frame rate, and
+	// with it the GOP size, stays the same
+	deinterlaceFilter = "bwdif=mode=send_frame"
+
+	// toneMapFilter converts PQ/HLG BT.2020 to SDR BT.709: linearize, map to BT.709 primaries,
+	// compress the highlights with the Hable curve and re-encode with the BT.709 transfer.
+	// zscale requires FFmpeg built with zimg (--enable-libzimg); see ToneMapZscale.
+	toneMapFilter = "zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709," +
+		"tonemap=tonemap=hable:desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p"
+)
+
+// buildVideoFilterArgs builds the -vf filter chain for deinterlacing and tone mapping.
+// Sources are decoded in software for every hardware acceleration method, so the software
+// filters apply to all of them.
+// Deinterlacing runs first because it needs the original field structure.
+func buildVideoFilterArgs(deinterlace bool, toneMap ToneMapper) []string {
+	filters := make([]string, 0, 2)
+	if deinterlace {
+		filters = append(filters, deinterlaceFilter)
+	}
+	if toneMap != ToneMapNone {
+		filters = append(filters, toneMap.filter())
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return []string{"-vf", strings.Join(filters, ",")}
+}
+
 // buildAudioEncodeArgs builds audio encoding arguments (constant across all qualities)
 func buildAudioEncodeArgs() []string {
 	return []string{
@@ -316,8 +426,8 @@ func buildAudioEncodeArgs() []string {
 }
 
 // buildQualityArgs builds quality-specific arguments (bitrate, resolution)
-func buildQualityArgs(quality string) ([]string, error) {
-	spec, err := getQualitySpec(quality)
+func buildQualityArgs(quality string, codec VideoCodec) ([]string, error) {
+	spec, err := getQualitySpec(quality, codec)
 	if err != nil {
 		return nil, err
 	}
@@ -391,6 +501,99 @@ func buildStreamSegmentArgs(params StreamParams) []string {
 	return args
 }
 
+// buildContinuousSegmentArgs builds output arguments for a long-running encoder
+// Uses the stream_segment muxer to split one continuous encode into numbered TS segments
+func buildContinuousSegmentArgs(params StreamParams) []string {
+	if params.SegmentFormat == SegmentFormatFMP4 {
+		return buildContinuousFMP4Args(params)
+	}
+
+	args := []string{
+		"-f", "stream_segment",
+		"-segment_format", "mpegts",
+		"-segment_time", strconv.Itoa(params.SegmentDuration),
+		"-segment_start_number", strconv.Itoa(params.SegmentStartNumber),
+		// FFmpeg appends "filename,start,end" once a segment is closed; Go watches this list
+		"-segment_list", params.SegmentListPath,
+		"-segment_list_type", "csv",
+		// Keep timestamps running across segments so they play back as one timeline
+		"-reset_timestamps", "0",
+		// The encoder may start mid-stream (late rendition or restart), so offset PTS to its first segment
+		"-output_ts_offset", strconv.FormatInt(params.StreamPositionSeconds, 10),
+	}
+
+	// Output pattern must be last
+	args = append(args, filepath.Join(params.SegmentOutputDir, continuousSegmentPattern(params.SegmentPrefix, SegmentFormatTS)))
+
+	return args
+}
+
+// buildContinuousFMP4Args builds output arguments for a long-running fMP4 (CMAF) encoder
+// The segment muxer cannot share one init segment between fragments, so fMP4 uses the hls muxer;
+// its playlist (SegmentListPath) only serves as the list of completed segments
+func buildContinuousFMP4Args(params StreamParams) []string {
+	initFilename := params.InitFilename
+	if initFilename == "" {
+		initFilename = fmt.Sprintf("init-%d.mp4", params.SegmentStartNumber)
+	}
+
+	args := []string{
+		"-f", "hls",
+		"-hls_segment_type", "fmp4",
+		"-hls_fmp4_init_filename", initFilename,
+		"-hls_time", strconv.Itoa(params.SegmentDuration),
+		"-hls_list_size", "0", // Keep every entry; Go tracks how many it has read
+	}
+	if params.PartDurationMs > 0 {
+		// Low-Latency HLS parts are the fragments of a segment still being written, so segments
+		// are written in place rather than renamed from a temp file once complete
+		args = append(args,
+			"-hls_flags", "independent_segments",
+			"-hls_segment_options", fmt.Sprintf("frag_duration=%d", params.PartDurationMs*1000),
+		)
+	} else {
+		args = append(args, "-hls_flags", "temp_file+independent_segments")
+	}
+
+	return append(args,
+		"-start_number", strconv.Itoa(params.SegmentStartNumber),
+		"-hls_segment_filename", filepath.Join(params.SegmentOutputDir, continuousSegmentPattern(params.SegmentPrefix, SegmentFormatFMP4)),
+		"-output_ts_offset", strconv.FormatInt(params.StreamPositionSeconds, 10),
+		params.SegmentListPath,
+	)
+}
+
+// continuousSegmentPattern returns the FFmpeg filename pattern of numbered segments in continuous segment mode
+func continuousSegmentPattern(prefix, format string) string {
+	if prefix == "" {
+		prefix = defaultSegmentPrefix
+	}
+	if format == SegmentFormatFMP4 {
+		return prefix + "-%06d.m4s"
+	}
+	return prefix + "-%06d.ts"
+}
+
+// SegmentNumber returns the segment number of a continuous encoder segment
+// (seg-000012.ts, or seg-1a2b3c4d-000012.m4s with a session prefix); init segments and other files have none
+func SegmentNumber(filename string) (int, bool) {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if !strings.HasPrefix(name, defaultSegmentPrefix+"-") {
+		return 0, false
+	}
+	digits := name[strings.LastIndex(name, "-")+1:]
+	if len(digits) < 6 {
+		return 0, false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	number, err := strconv.Atoi(digits)
+	return number, err == nil
+}
+
 // buildGOPArgs builds GOP alignment arguments for deterministic segment boundaries
 func buildGOPArgs(fps int, segmentDuration int) []string {
 	if fps <= 0 {
@@ -636,6 +636,10 @@ func TestGetPlaylistFilename(t *testing.T) {
 
 // TestQualityConstants tests that quality constants are defined
 func TestQualityConstants(t *testing.T) {
+	if Quality2160p != "2160p" {
+		t.Errorf("Expected Quality2160p to be '2160p', got '%s'", Quality2160p)
+	}
+
 	if Quality1080p != "1080p" {
 		t.Errorf("Expected Quality1080p to be '1080p', got '%s'", Quality1080p)
 	}
@@ -808,6 +812,363 @@ func TestBuildHLSCommand_StreamSegmentMode_SingleSegment(t *testing.T) {
 	}
 }
 
+// TestBuildHLSCommand_ContinuousSegmentMode tests the long-running encoder command
+func TestBuildHLSCommand_ContinuousSegmentMode(t *testing.T) {
+	params := StreamParams{
+		InputFile:              "/streams/channel1/720p/input-12.txt",
+		ConcatInput:            true,
+		Quality:                Quality720p,
+		HardwareAccel:          HardwareAccelNone,
+		StreamPositionSeconds:  48,
+		SegmentDuration:        4,
+		StreamSegmentMode:      true,
+		ContinuousSegmentMode:  true,
+		SegmentStartNumber:     12,
+		SegmentListPath:        "/streams/channel1/720p/segments-12.csv",
+		SegmentOutputDir:       "/streams/channel1/720p",
+		SegmentFilenamePattern: "seg-%Y%m%dT%H%M%S.ts",
+		FPS:                    30,
+	}
+
+	cmd, err := BuildHLSCommand(params)
+	if err != nil {
+		t.Fatalf("BuildHLSCommand failed: %v", err)
+	}
+
+	// Concat input must come before -i
+	concatIdx := findArgIndex(cmd.Args, "concat")
+	inputIdx := findArgIndex(cmd.Args, "-i")
+	if concatIdx == -1 || concatIdx > inputIdx {
+		t.Error("Expected -f concat before the input")
+	}
+	if !containsConsecutiveArgs(cmd.Args, "-safe", "0") {
+		t.Error("Expected -safe 0 for absolute concat paths")
+	}
+
+	expected := [][2]string{
+		{"-f", "stream_segment"},
+		{"-segment_format", "mpegts"},
+		{"-segment_time", "4"},
+		{"-segment_start_number", "12"},
+		{"-segment_list", "/streams/channel1/720p/segments-12.csv"},
+		{"-segment_list_type", "csv"},
+		{"-output_ts_offset", "48"},
+	}
+	for _, pair := range expected {
+		if !containsConsecutiveArgs(cmd.Args, pair[0], pair[1]) {
+			t.Errorf("Expected %s %s", pair[0], pair[1])
+		}
+	}
+
+	// Runs until the concat list ends instead of stopping after one segment
+	if containsArg(cmd.Args, "-t") {
+		t.Error("Did not expect -t in continuous segment mode")
+	}
+	if containsArg(cmd.Args, "-stream_loop") {
+		t.Error("Did not expect -stream_loop in continuous segment mode")
+	}
+
+	if got := cmd.Args[len(cmd.Args)-1]; got != "/streams/channel1/720p/seg-%06d.ts" {
+		t.Errorf("Expected numbered segment pattern as output, got %s", got)
+	}
+}
+
+// TestBuildHLSCommand_ContinuousSegmentMode_NoSegmentList tests that the segment list is required
+func TestBuildHLSCommand_ContinuousSegmentMode_NoSegmentList(t *testing.T) {
+	params := StreamParams{
+		InputFile:              "/media/video.mp4",
+		Quality:                Quality720p,
+		HardwareAccel:          HardwareAccelNone,
+		SegmentDuration:        4,
+		StreamSegmentMode:      true,
+		ContinuousSegmentMode:  true,
+		SegmentOutputDir:       "/streams/channel1/720p",
+		SegmentFilenamePattern: "seg-%Y%m%dT%H%M%S.ts",
+		FPS:                    30,
+	}
+
+	if _, err := BuildHLSCommand(params); !errors.Is(err, ErrEmptySegmentListPath) {
+		t.Errorf("Expected ErrEmptySegmentListPath, got %v", err)
+	}
+}
+
+// TestBuildHLSCommand_ContinuousSegmentMode_FMP4 tests fMP4 output through the hls muxer
+func TestBuildHLSCommand_ContinuousSegmentMode_FMP4(t *testing.T) {
+	params := StreamParams{
+		InputFile:              "/streams/channel1/720p/input-12.txt",
+		ConcatInput:            true,
+		Quality:                Quality720p,
+		HardwareAccel:          HardwareAccelNone,
+		StreamPositionSeconds:  48,
+		SegmentDuration:        4,
+		StreamSegmentMode:      true,
+		ContinuousSegmentMode:  true,
+		SegmentFormat:          SegmentFormatFMP4,
+		InitFilename:           "init-1a2b3c4d-12.mp4",
+		SegmentPrefix:          "seg-1a2b3c4d",
+		SegmentStartNumber:     12,
+		SegmentListPath:        "/streams/channel1/720p/segments-12.m3u8",
+		SegmentOutputDir:       "/streams/channel1/720p",
+		SegmentFilenamePattern: "seg-%Y%m%dT%H%M%S.ts",
+		FPS:                    30,
+	}
+
+	cmd, err := BuildHLSCommand(params)
+	if err != nil {
+		t.Fatalf("BuildHLSCommand failed: %v", err)
+	}
+
+	expected := [][2]string{
+		{"-f", "hls"},
+		{"-hls_segment_type", "fmp4"},
+		{"-hls_fmp4_init_filename", "init-1a2b3c4d-12.mp4"},
+		{"-hls_time", "4"},
+		{"-hls_list_size", "0"},
+		{"-start_number", "12"},
+		{"-hls_segment_filename", "/streams/channel1/720p/seg-1a2b3c4d-%06d.m4s"},
+		{"-output_ts_offset", "48"},
+	}
+	for _, pair := range expected {
+		if !containsConsecutiveArgs(cmd.Args, pair[0], pair[1]) {
+			t.Errorf("Expected %s %s", pair[0], pair[1])
+		}
+	}
+
+	if containsArg(cmd.Args, "stream_segment") {
+		t.Error("Did not expect the stream_segment muxer for fMP4")
+	}
+	if containsArg(cmd.Args, "-t") {
+		t.Error("Did not expect -t in continuous segment mode")
+	}
+
+	if got := cmd.Args[len(cmd.Args)-1]; got != "/streams/channel1/720p/segments-12.m3u8" {
+		t.Errorf("Expected the segment list as output, got %s", got)
+	}
+
+	if !containsConsecutiveArgs(cmd.Args, "-hls_flags", "temp_file+independent_segments") {
+		t.Error("Expected segments to be written through temp files without parts")
+	}
+}
+
+// TestSegmentNumber tests reading segment numbers from continuous encoder segment filenames
+func TestSegmentNumber(t *testing.T) {
+	for filename, want := range map[string]int{"seg-000012.m4s": 12, "seg-1000000.ts": 1000000, "seg-1a2b3c4d-000012.m4s": 12} {
+		number, ok := SegmentNumber(filename)
+		if !ok || number != want {
+			t.Errorf("SegmentNumber(%q) = %d, %v, want %d", filename, number, ok, want)
+		}
+	}
+	for _, filename := range []string{"init-12.mp4", "init-1a2b3c4d-12.mp4", "seg-20250111T120000.ts", "000012.ts", "seg-12.ts", "seg-1a2b3c4d-12.ts", "1080p_segment_000.ts"} {
+		if _, ok := SegmentNumber(filename); ok {
+			t.Errorf("SegmentNumber(%q) should not find a segment number", filename)
+		}
+	}
+}
+
+// TestBuildHLSCommand_ContinuousSegmentMode_FMP4Parts tests that Low-Latency HLS parts cut fragments
+// within segments, which are then written in place
+func TestBuildHLSCommand_ContinuousSegmentMode_FMP4Parts(t *testing.T) {
+	params := StreamParams{
+		InputFile:              "/streams/channel1/720p/input-12.txt",
+		ConcatInput:            true,
+		Quality:                Quality720p,
+		HardwareAccel:          HardwareAccelNone,
+		SegmentDuration:        4,
+		StreamSegmentMode:      true,
+		ContinuousSegmentMode:  true,
+		SegmentFormat:          SegmentFormatFMP4,
+		SegmentStartNumber:     12,
+		SegmentListPath:        "/streams/channel1/720p/segments-12.m3u8",
+		SegmentOutputDir:       "/streams/channel1/720p",
+		SegmentFilenamePattern: "seg-%Y%m%dT%H%M%S.ts",
+		FPS:                    30,
+		PartDurationMs:         1000,
+	}
+
+	cmd, err := BuildHLSCommand(params)
+	if err != nil {
+		t.Fatalf("BuildHLSCommand failed: %v", err)
+	}
+
+	if !containsConsecutiveArgs(cmd.Args, "-hls_segment_options", "frag_duration=1000000") {
+		t.Error("Expected -hls_segment_options frag_duration=1000000")
+	}
+	if !containsConsecutiveArgs(cmd.Args, "-hls_flags", "independent_segments") {
+		t.Error("Expected segments to be written in place (no temp_file)")
+	}
+	if got := cmd.Args[len(cmd.Args)-1]; got != "/streams/channel1/720p/segments-12.m3u8" {
+		t.Errorf("Expected the segment list as output, got %s", got)
+	}
+}
+
+// TestBuildHLSCommand_ContinuousSegmentMode_InvalidFormat tests that unknown segment formats are rejected
+func TestBuildHLSCommand_ContinuousSegmentMode_InvalidFormat(t *testing.T) {
+	params := StreamParams{
+		InputFile:              "/media/video.mp4",
+		Quality:                Quality720p,
+		HardwareAccel:          HardwareAccelNone,
+		SegmentDuration:        4,
+		StreamSegmentMode:      true,
+		ContinuousSegmentMode:  true,
+		SegmentFormat:          "webm",
+		SegmentListPath:        "/streams/channel1/720p/segments-0.csv",
+		SegmentOutputDir:       "/streams/channel1/720p",
+		SegmentFilenamePattern: "seg-%Y%m%dT%H%M%S.ts",
+		FPS:                    30,
+	}
+
+	if _, err := BuildHLSCommand(params); !errors.Is(err, ErrInvalidSegmentFormat) {
+		t.Errorf("Expected ErrInvalidSegmentFormat, got %v", err)
+	}
+}
+
+// continuousCodecParams returns continuous fMP4 encoder parameters for a codec
+func continuousCodecParams(quality string, codec VideoCodec, hwaccel HardwareAccel) StreamParams {
+	return StreamParams{
+		InputFile:              "/streams/channel1/input-0.txt",
+		ConcatInput:            true,
+		Quality:                quality,
+		VideoCodec:             codec,
+		HardwareAccel:          hwaccel,
+		EncodingPreset:         "veryfast",
+		SegmentDuration:        4,
+		StreamSegmentMode:      true,
+		ContinuousSegmentMode:  true,
+		SegmentFormat:          SegmentFormatFMP4,
+		SegmentListPath:        "/streams/channel1/segments-0.m3u8",
+		SegmentOutputDir:       "/streams/channel1",
+		SegmentFilenamePattern: "seg-%Y%m%dT%H%M%S.ts",
+		FPS:                    30,
+	}
+}
+
+// TestBuildHLSCommand_VideoCodecs tests the encoder and bitrate chosen for each codec
+func TestBuildHLSCommand_VideoCodecs(t *testing.T) {
+	tests := []struct {
+		name    string
+		quality string
+		codec   VideoCodec
+		hwaccel HardwareAccel
+		encoder string
+		preset  string // Empty when the encoder takes no preset
+		bitrate string
+	}{
+		{"h264 software", Quality1080p, VideoCodecH264, HardwareAccelNone, "libx264", "veryfast", "5000k"},
+		{"hevc software", Quality2160p, VideoCodecHEVC, HardwareAccelNone, "libx265", "veryfast", "9600k"},
+		{"hevc nvenc", Quality1080p, VideoCodecHEVC, HardwareAccelNVENC, "hevc_nvenc", "p2", "3000k"},
+		{"hevc qsv", Quality720p, VideoCodecHEVC, HardwareAccelQSV, "hevc_qsv", "veryfast", "1800k"},
+		{"hevc vaapi", Quality2160p, VideoCodecHEVC, HardwareAccelVAAPI, "hevc_vaapi", "", "9600k"},
+		{"hevc videotoolbox", Quality1080p, VideoCodecHEVC, HardwareAccelVideoToolbox, "hevc_videotoolbox", "", "3000k"},
+		{"av1 software", Quality2160p, VideoCodecAV1, HardwareAccelNone, "libsvtav1", "10", "8000k"},
+		{"av1 nvenc", Quality1080p, VideoCodecAV1, HardwareAccelNVENC, "av1_nvenc", "p2", "2500k"},
+		{"av1 qsv", Quality1080p, VideoCodecAV1, HardwareAccelQSV, "av1_qsv", "veryfast", "2500k"},
+		{"av1 vaapi", Quality480p, VideoCodecAV1, HardwareAccelVAAPI, "av1_vaapi", "", "750k"},
+		{"av1 videotoolbox falls back to software", Quality1080p, VideoCodecAV1, HardwareAccelVideoToolbox, "libsvtav1", "10", "2500k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := BuildHLSCommand(continuousCodecParams(tt.quality, tt.codec, tt.hwaccel))
+			if err != nil {
+				t.Fatalf("BuildHLSCommand failed: %v", err)
+			}
+
+			if !containsConsecutiveArgs(cmd.Args, "-c:v", tt.encoder) {
+				t.Errorf("Expected -c:v %s in %v", tt.encoder, cmd.Args)
+			}
+			if tt.preset != "" && !containsConsecutiveArgs(cmd.Args, "-preset", tt.preset) {
+				t.Errorf("Expected -preset %s in %v", tt.preset, cmd.Args)
+			}
+			if tt.preset == "" && containsArg(cmd.Args, "-preset") {
+				t.Errorf("Did not expect a preset for %s", tt.encoder)
+			}
+			if !containsConsecutiveArgs(cmd.Args, "-b:v", tt.bitrate) || !containsConsecutiveArgs(cmd.Args, "-maxrate", tt.bitrate) {
+				t.Errorf("Expected -b:v and -maxrate %s in %v", tt.bitrate, cmd.Args)
+			}
+
+			// Apple players need the hvc1 sample entry for HEVC in fMP4
+			if got := containsConsecutiveArgs(cmd.Args, "-tag:v", "hvc1"); got != (tt.codec == VideoCodecHEVC) {
+				t.Errorf("-tag:v hvc1 present = %v, want %v", got, tt.codec == VideoCodecHEVC)
+			}
+		})
+	}
+}
+
+// TestBuildHLSCommand_HEVCInTS tests that HEVC TS segments keep FFmpeg's default sample entry
+func TestBuildHLSCommand_HEVCInTS(t *testing.T) {
+	params := continuousCodecParams(Quality1080p, VideoCodecHEVC, HardwareAccelNone)
+	params.SegmentFormat = SegmentFormatTS
+	params.SegmentListPath = "/streams/channel1/segments-0.csv"
+
+	cmd, err := BuildHLSCommand(params)
+	if err != nil {
+		t.Fatalf("BuildHLSCommand failed: %v", err)
+	}
+	if !containsConsecutiveArgs(cmd.Args, "-c:v", "libx265") {
+		t.Error("Expected libx265")
+	}
+	if containsArg(cmd.Args, "-tag:v") {
+		t.Error("Did not expect -tag:v for TS segments")
+	}
+}
+
+// TestBuildHLSCommand_VideoFilters tests deinterlacing and tone mapping filters and their order
+func TestBuildHLSCommand_VideoFilters(t *testing.T) {
+	tests := []struct {
+		name        string
+		deinterlace bool
+		toneMap     ToneMapper
+		hwaccel     HardwareAccel
+		wantFilter  string
+	}{
+		{name: "no filters", hwaccel: HardwareAccelNone},
+		{name: "deinterlace", deinterlace: true, hwaccel: HardwareAccelNone, wantFilter: deinterlaceFilter},
+		{name: "tone map", toneMap: ToneMapZscale, hwaccel: HardwareAccelNone, wantFilter: toneMapFilter},
+		{name: "deinterlace before tone map", deinterlace: true, toneMap: ToneMapZscale, hwaccel: HardwareAccelNone, wantFilter: deinterlaceFilter + "," + toneMapFilter},
+		{name: "hardware encoder", toneMap: ToneMapZscale, hwaccel: HardwareAccelNVENC, wantFilter: toneMapFilter},
+		{name: "hardware tone map", toneMap: ToneMapOpenCL, hwaccel: HardwareAccelNone, wantFilter: ToneMapOpenCL.filter()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := continuousCodecParams(Quality1080p, VideoCodecH264, tt.hwaccel)
+			params.Deinterlace = tt.deinterlace
+			params.ToneMap = tt.toneMap
+
+			cmd, err := BuildHLSCommand(params)
+			if err != nil {
+				t.Fatalf("BuildHLSCommand failed: %v", err)
+			}
+			if hasDevice := containsArg(cmd.Args, "-init_hw_device"); hasDevice != (len(tt.toneMap.deviceArgs()) > 0) {
+				t.Errorf("-init_hw_device present = %v for tone mapper %q", hasDevice, tt.toneMap)
+			}
+			if tt.wantFilter == "" {
+				if containsArg(cmd.Args, "-vf") {
+					t.Errorf("Did not expect -vf, got %v", cmd.Args)
+				}
+				return
+			}
+			if !containsConsecutiveArgs(cmd.Args, "-vf", tt.wantFilter) {
+				t.Errorf("Expected -vf %s, got %v", tt.wantFilter, cmd.Args)
+			}
+		})
+	}
+}
+
+// TestBuildHLSCommand_InvalidVideoCodec tests that unknown codecs and AV1 outside fMP4 are rejected
+func TestBuildHLSCommand_InvalidVideoCodec(t *testing.T) {
+	if _, err := BuildHLSCommand(continuousCodecParams(Quality1080p, "vp9", HardwareAccelNone)); !errors.Is(err, ErrInvalidVideoCodec) {
+		t.Errorf("Expected ErrInvalidVideoCodec, got %v", err)
+	}
+
+	params := continuousCodecParams(Quality1080p, VideoCodecAV1, HardwareAccelNone)
+	params.SegmentFormat = SegmentFormatTS
+	params.SegmentListPath = "/streams/channel1/segments-0.csv"
+	if _, err := BuildHLSCommand(params); !errors.Is(err, ErrCodecRequiresFMP4) {
+		t.Errorf("Expected ErrCodecRequiresFMP4 for AV1 in TS, got %v", err)
+	}
+}
+
 // Helper functions for testing
 
 // containsArg checks if an argument exists in the args slice
@@ -0,0 +1,315 @@
+package streaming
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNoFMP4Track is returned when an fMP4 init segment has no track to time parts by
+var ErrNoFMP4Track = errors.New("init segment has no track")
+
+// fmp4Track is the track of an fMP4 stream whose sample timing defines part durations
+type fmp4Track struct {
+	id              uint32
+	timescale       uint32
+	defaultDuration uint32 // Default sample duration from the init segment's trex box
+}
+
+// fmp4Fragment is a complete fragment of an fMP4 segment: a moof box, its mdat box and any
+// boxes written before them (e.g. styp). Fragments are contiguous, so they make up the segment.
+type fmp4Fragment struct {
+	start    int64
+	length   int64
+	duration float64 // Seconds
+}
+
+// fmp4Box is one ISO BMFF box within a buffer
+type fmp4Box struct {
+	typ     string
+	payload []byte
+}
+
+// parseBoxes splits a buffer into its boxes; a box that does not fit is an error
+func parseBoxes(data []byte) ([]fmp4Box, error) {
+	var boxes []fmp4Box
+	for len(data) > 0 {
+		size, header, typ, err := parseBoxHeader(data)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			size = uint64(len(data)) // Extends to the end
+		}
+		if size < uint64(header) || size > uint64(len(data)) {
+			return nil, fmt.Errorf("box %s size %d exceeds %d available bytes", typ, size, len(data))
+		}
+		boxes = append(boxes, fmp4Box{typ: typ, payload: data[header:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// parseBoxHeader returns a box's total size (0 = to the end of the file), header length and type
+func parseBoxHeader(data []byte) (size uint64, header int, typ string, err error) {
+	if len(data) < 8 {
+		return 0, 0, "", fmt.Errorf("truncated box header")
+	}
+	size = uint64(binary.BigEndian.Uint32(data))
+	typ = string(data[4:8])
+	header = 8
+	if size == 1 {
+		if len(data) < 16 {
+			return 0, 0, "", fmt.Errorf("truncated %s box header", typ)
+		}
+		size = binary.BigEndian.Uint64(data[8:16])
+		header = 16
+	}
+	return size, header, typ, nil
+}
+
+// findBox returns the payload of the first box of a type
+func findBox(boxes []fmp4Box, typ string) ([]byte, bool) {
+	for _, box := range boxes {
+		if box.typ == typ {
+			return box.payload, true
+		}
+	}
+	return nil, false
+}
+
+// childBoxes parses the boxes inside the first box of a type
+func childBoxes(boxes []fmp4Box, typ string) ([]fmp4Box, error) {
+	payload, ok := findBox(boxes, typ)
+	if !ok {
+		return nil, fmt.Errorf("missing %s box", typ)
+	}
+	return parseBoxes(payload)
+}
+
+// readInitTrack returns the video track of an fMP4 init segment, or its first track if it has no video
+func readInitTrack(data []byte) (fmp4Track, error) {
+	top, err := parseBoxes(data)
+	if err != nil {
+		return fmp4Track{}, err
+	}
+	moov, err := childBoxes(top, "moov")
+	if err != nil {
+		return fmp4Track{}, err
+	}
+
+	var tracks []fmp4Track
+	video := -1
+	for _, box := range moov {
+		if box.typ != "trak" {
+			continue
+		}
+		track, handler, err := parseTrak(box.payload)
+		if err != nil {
+			return fmp4Track{}, err
+		}
+		if handler == "vide" && video < 0 {
+			video = len(tracks)
+		}
+		tracks = append(tracks, track)
+	}
+	if len(tracks) == 0 {
+		return fmp4Track{}, ErrNoFMP4Track
+	}
+	track := tracks[0]
+	if video >= 0 {
+		track = tracks[video]
+	}
+
+	// Default sample duration for fragments that do not carry their own
+	if mvex, err := childBoxes(moov, "mvex"); err == nil {
+		for _, box := range mvex {
+			if box.typ == "trex" && len(box.payload) >= 16 && binary.BigEndian.Uint32(box.payload[4:8]) == track.id {
+				track.defaultDuration = binary.BigEndian.Uint32(box.payload[12:16])
+			}
+		}
+	}
+	return track, nil
+}
+
+// parseTrak reads the track ID, media timescale and handler type of a trak box
+func parseTrak(payload []byte) (fmp4Track, string, error) {
+	boxes, err := parseBoxes(payload)
+	if err != nil {
+		return fmp4Track{}, "", err
+	}
+
+	var track fmp4Track
+	tkhd, ok := findBox(boxes, "tkhd")
+	if !ok || len(tkhd) < 1 {
+		return fmp4Track{}, "", fmt.Errorf("missing tkhd box")
+	}
+	idOffset := 12 // version 0: flags, creation and modification times
+	if tkhd[0] == 1 {
+		idOffset = 20
+	}
+	if len(tkhd) < idOffset+4 {
+		return fmp4Track{}, "", fmt.Errorf("truncated tkhd box")
+	}
+	track.id = binary.BigEndian.Uint32(tkhd[idOffset:])
+
+	mdia, err := childBoxes(boxes, "mdia")
+	if err != nil {
+		return fmp4Track{}, "", err
+	}
+	mdhd, ok := findBox(mdia, "mdhd")
+	if !ok || len(mdhd) < 1 {
+		return fmp4Track{}, "", fmt.Errorf("missing mdhd box")
+	}
+	timescaleOffset := 12
+	if mdhd[0] == 1 {
+		timescaleOffset = 20
+	}
+	if len(mdhd) < timescaleOffset+4 {
+		return fmp4Track{}, "", fmt.Errorf("truncated mdhd box")
+	}
+	track.timescale = binary.BigEndian.Uint32(mdhd[timescaleOffset:])
+	if track.timescale == 0 {
+		return fmp4Track{}, "", fmt.Errorf("track %d has no timescale", track.id)
+	}
+
+	handler := ""
+	if hdlr, ok := findBox(mdia, "hdlr"); ok && len(hdlr) >= 12 {
+		handler = string(hdlr[8:12])
+	}
+	return track, handler, nil
+}
+
+// moofDuration returns the duration in seconds of the track's samples in a moof box
+func moofDuration(payload []byte, track fmp4Track) (float64, error) {
+	boxes, err := parseBoxes(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, box := range boxes {
+		if box.typ != "traf" {
+			continue
+		}
+		traf, err := parseBoxes(box.payload)
+		if err != nil {
+			return 0, err
+		}
+		tfhd, ok := findBox(traf, "tfhd")
+		if !ok || len(tfhd) < 8 || binary.BigEndian.Uint32(tfhd[4:8]) != track.id {
+			continue
+		}
+
+		defaultDuration := track.defaultDuration
+		flags := binary.BigEndian.Uint32(tfhd[0:4]) & 0xFFFFFF
+		offset := 8
+		if flags&0x01 != 0 { // base-data-offset
+			offset += 8
+		}
+		if flags&0x02 != 0 { // sample-description-index
+			offset += 4
+		}
+		if flags&0x08 != 0 { // default-sample-duration
+			if len(tfhd) < offset+4 {
+				return 0, fmt.Errorf("truncated tfhd box")
+			}
+			defaultDuration = binary.BigEndian.Uint32(tfhd[offset:])
+		}
+
+		var ticks uint64
+		for _, trun := range traf {
+			if trun.typ != "trun" {
+				continue
+			}
+			runTicks, err := trunDuration(trun.payload, defaultDuration)
+			if err != nil {
+				return 0, err
+			}
+			ticks += runTicks
+		}
+		return float64(ticks) / float64(track.timescale), nil
+	}
+	return 0, fmt.Errorf("moof has no fragment of track %d", track.id)
+}
+
+// trunDuration sums the sample durations of a trun box in timescale ticks
+func trunDuration(trun []byte, defaultDuration uint32) (uint64, error) {
+	if len(trun) < 8 {
+		return 0, fmt.Errorf("truncated trun box")
+	}
+	flags := binary.BigEndian.Uint32(trun[0:4]) & 0xFFFFFF
+	count := binary.BigEndian.Uint32(trun[4:8])
+	if flags&0x100 == 0 { // No per-sample durations
+		return uint64(count) * uint64(defaultDuration), nil
+	}
+
+	offset := 8
+	if flags&0x01 != 0 { // data-offset
+		offset += 4
+	}
+	if flags&0x04 != 0 { // first-sample-flags
+		offset += 4
+	}
+	// Each sample has a duration, plus its size, flags and composition time offset if present
+	sampleSize := 4
+	for _, flag := range []uint32{0x200, 0x400, 0x800} {
+		if flags&flag != 0 {
+			sampleSize += 4
+		}
+	}
+	if uint64(len(trun)) < uint64(offset)+uint64(count)*uint64(sampleSize) {
+		return 0, fmt.Errorf("truncated trun box")
+	}
+
+	var ticks uint64
+	for i := uint32(0); i < count; i++ {
+		ticks += uint64(binary.BigEndian.Uint32(trun[offset:]))
+		offset += sampleSize
+	}
+	return ticks, nil
+}
+
+// scanFragments returns the complete fragments of a segment file that is still being written,
+// starting at offset (the end of the last fragment found). next is where the following scan resumes.
+func scanFragments(r io.ReaderAt, size, offset int64, track fmp4Track) (fragments []fmp4Fragment, next int64, err error) {
+	next = offset
+	pos := offset
+	duration := -1.0 // Duration of the current fragment's moof; negative until one is found
+	header := make([]byte, 16)
+
+	for pos+8 <= size {
+		n, err := r.ReadAt(header, pos)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fragments, next, err
+		}
+		boxSize, _, typ, err := parseBoxHeader(header[:n])
+		if err != nil || boxSize == 0 || pos+int64(boxSize) > size {
+			break // Still being written
+		}
+
+		switch typ {
+		case "moof":
+			payload := make([]byte, boxSize)
+			if _, err := r.ReadAt(payload, pos); err != nil {
+				return fragments, next, err
+			}
+			boxes, err := parseBoxes(payload)
+			if err != nil || len(boxes) != 1 {
+				return fragments, next, fmt.Errorf("invalid moof box at %d: %w", pos, err)
+			}
+			if duration, err = moofDuration(boxes[0].payload, track); err != nil {
+				return fragments, next, err
+			}
+		case "mdat":
+			if duration >= 0 {
+				end := pos + int64(boxSize)
+				fragments = append(fragments, fmp4Fragment{start: next, length: end - next, duration: duration})
+				next = end
+				duration = -1
+			}
+		}
+		pos += int64(boxSize)
+	}
+	return fragments, next, nil
+}
@@ -0,0 +1,131 @@
+package streaming
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testBox builds an ISO BMFF box
+func testBox(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], typ)
+	return append(box, body...)
+}
+
+// testFullBox builds a box with a version and flags header
+func testFullBox(typ string, version byte, flags uint32, fields ...uint32) []byte {
+	payload := make([]byte, 4+4*len(fields))
+	binary.BigEndian.PutUint32(payload, flags)
+	payload[0] = version
+	for i, field := range fields {
+		binary.BigEndian.PutUint32(payload[4+4*i:], field)
+	}
+	return testBox(typ, payload)
+}
+
+// testTrak builds a trak box with a version 0 tkhd, an mdhd and an hdlr
+func testTrak(id, timescale uint32, handler string) []byte {
+	hdlr := make([]byte, 12)
+	copy(hdlr[8:], handler)
+	return testBox("trak",
+		testFullBox("tkhd", 0, 0, 0, 0, id),
+		testBox("mdia",
+			testFullBox("mdhd", 0, 0, 0, 0, timescale),
+			testBox("hdlr", hdlr),
+		),
+	)
+}
+
+// testInitSegment builds an init segment with an audio track and a video track (ID 1, 1/15360s ticks)
+// whose fragments default to 512-tick (1/30s) samples
+func testInitSegment() []byte {
+	return bytes.Join([][]byte{
+		testBox("ftyp", []byte("iso5")),
+		testBox("moov",
+			testTrak(2, 48000, "soun"),
+			testTrak(1, 15360, "vide"),
+			testBox("mvex",
+				testFullBox("trex", 0, 0, 2, 1, 1024, 0, 0),
+				testFullBox("trex", 0, 0, 1, 1, 512, 0, 0),
+			),
+		),
+	}, nil)
+}
+
+// testFragment builds a moof with a 30-sample video run (and an audio run) followed by its mdat
+func testFragment(perSampleDurations bool) []byte {
+	video := testFullBox("trun", 0, 0x01, 30, 0)
+	if perSampleDurations {
+		durations := []uint32{30, 0}
+		for i := 0; i < 30; i++ {
+			durations = append(durations, 1024)
+		}
+		video = testFullBox("trun", 0, 0x101, durations...)
+	}
+	return bytes.Join([][]byte{
+		testBox("moof",
+			testFullBox("mfhd", 0, 0, 1),
+			testBox("traf", testFullBox("tfhd", 0, 0, 2), testFullBox("trun", 0, 0, 47)),
+			testBox("traf", testFullBox("tfhd", 0, 0, 1), video),
+		),
+		testBox("mdat", make([]byte, 64)),
+	}, nil)
+}
+
+func TestReadInitTrack(t *testing.T) {
+	track, err := readInitTrack(testInitSegment())
+	if err != nil {
+		t.Fatalf("readInitTrack failed: %v", err)
+	}
+	if track.id != 1 || track.timescale != 15360 || track.defaultDuration != 512 {
+		t.Errorf("track = %+v, want the video track (id 1, timescale 15360, default duration 512)", track)
+	}
+
+	if _, err := readInitTrack(testBox("moov")); !errors.Is(err, ErrNoFMP4Track) {
+		t.Errorf("moov without tracks: err = %v, want ErrNoFMP4Track", err)
+	}
+	if _, err := readInitTrack(testBox("ftyp")); err == nil {
+		t.Error("expected an error for an init segment without moov")
+	}
+}
+
+func TestScanFragments(t *testing.T) {
+	track, err := readInitTrack(testInitSegment())
+	if err != nil {
+		t.Fatalf("readInitTrack failed: %v", err)
+	}
+
+	first := append(testBox("styp", []byte("msdh")), testFragment(false)...)
+	second := testFragment(true)
+	partial := testFragment(false)[:40] // moof still being written
+	data := bytes.Join([][]byte{first, second, partial}, nil)
+
+	fragments, next, err := scanFragments(bytes.NewReader(data), int64(len(data)), 0, track)
+	if err != nil {
+		t.Fatalf("scanFragments failed: %v", err)
+	}
+	if len(fragments) != 2 {
+		t.Fatalf("fragments = %+v, want 2", fragments)
+	}
+
+	// The first fragment includes the styp box before it
+	if fragments[0].start != 0 || fragments[0].length != int64(len(first)) || fragments[0].duration != 1.0 {
+		t.Errorf("first fragment = %+v, want 0+%d lasting 1s", fragments[0], len(first))
+	}
+	if fragments[1].start != int64(len(first)) || fragments[1].length != int64(len(second)) || fragments[1].duration != 2.0 {
+		t.Errorf("second fragment = %+v, want %d+%d lasting 2s", fragments[1], len(first), len(second))
+	}
+	if next != int64(len(first)+len(second)) {
+		t.Errorf("next = %d, want %d", next, len(first)+len(second))
+	}
+
+	// Resuming finds nothing new until the partial fragment is complete
+	fragments, _, err = scanFragments(bytes.NewReader(data), int64(len(data)), next, track)
+	if err != nil || len(fragments) != 0 {
+		t.Errorf("resumed scan = %+v, %v; want no fragments", fragments, err)
+	}
+}
@@ -59,8 +59,8 @@ func CheckFFmpegInstalled() error {
 	return nil
 }
 
-// DetectHardwareEncoders probes FFmpeg for available hardware encoders
-func DetectHardwareEncoders(ctx context.Context) ([]HardwareAccel, error) {
+// DetectHardwareEncoders probes FFmpeg for the hardware encoders available for each video codec
+func DetectHardwareEncoders(ctx context.Context) (map[VideoCodec][]HardwareAccel, error) {
 	// Check FFmpeg is available
 	if err := CheckFFmpegInstalled(); err != nil {
 		return nil, err
@@ -89,32 +89,38 @@ func DetectHardwareEncoders(ctx context.Context) ([]HardwareAccel, error) {
 		return nil, fmt.Errorf("failed to detect encoders: %w", err)
 	}
 
-	// Parse output for H.264 hardware encoders
-	encoders := parseHardwareEncoders(string(output))
+	// Parse output for the hardware encoders of each codec
+	encoders := make(map[VideoCodec][]HardwareAccel, len(videoCodecs))
+	for _, codec := range videoCodecs {
+		encoders[codec] = parseHardwareEncoders(string(output), codec)
 
-	// Convert to string slice for logging
-	encoderStrs := make([]string, len(encoders))
-	for i, e := range encoders {
-		encoderStrs[i] = e.String()
-	}
+		// Convert to string slice for logging
+		encoderStrs := make([]string, len(encoders[codec]))
+		for i, e := range encoders[codec] {
+			encoderStrs[i] = e.String()
+		}
 
-	logger.Log.Info().
-		Strs("encoders", encoderStrs).
-		Msg("Detected hardware encoders")
+		logger.Log.Info().
+			Str("codec", codec.String()).
+			Strs("encoders", encoderStrs).
+			Msg("Detected hardware encoders")
+	}
 
 	return encoders, nil
 }
 
-// parseHardwareEncoders extracts hardware encoder names from FFmpeg output
-func parseHardwareEncoders(output string) []HardwareAccel {
+// parseHardwareEncoders extracts the hardware encoders of a video codec from FFmpeg output.
+// Software encoding ("none") is included when FFmpeg lists the codec's software encoder;
+// H.264 software encoding is always assumed to be available.
+func parseHardwareEncoders(output string, codec VideoCodec) []HardwareAccel {
 	encoderSet := make(map[HardwareAccel]bool)
 
 	// Map of encoder names to look for
 	encoderMap := map[string]HardwareAccel{
-		"h264_nvenc":        HardwareAccelNVENC,
-		"h264_qsv":          HardwareAccelQSV,
-		"h264_vaapi":        HardwareAccelVAAPI,
-		"h264_videotoolbox": HardwareAccelVideoToolbox,
+		softwareEncoders[codec]: HardwareAccelNone,
+	}
+	for accelType, name := range hardwareEncoders[codec] {
+		encoderMap[name] = accelType
 	}
 
 	// Parse line by line
@@ -145,8 +151,10 @@ func parseHardwareEncoders(output string) []HardwareAccel {
 		}
 	}
 
-	// Always include "none" as it's always available (software encoding)
-	encoderSet[HardwareAccelNone] = true
+	// H.264 software encoding is always available
+	if codec == VideoCodecH264 {
+		encoderSet[HardwareAccelNone] = true
+	}
 
 	// Convert set to slice
 	encoders := make([]HardwareAccel, 0, len(encoderSet))
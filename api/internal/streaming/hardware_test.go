@@ -3,6 +3,7 @@ package streaming
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -136,7 +137,7 @@ func TestParseHardwareEncoders(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseHardwareEncoders(tt.output)
+			result := parseHardwareEncoders(tt.output, VideoCodecH264)
 
 			// Convert slice to map for comparison
 			resultMap := make(map[HardwareAccel]bool)
@@ -161,6 +162,34 @@ func TestParseHardwareEncoders(t *testing.T) {
 	}
 }
 
+func TestParseHardwareEncoders_Codecs(t *testing.T) {
+	output := `Encoders:
+ V..... libx264              libx264 H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10
+ V..... h264_nvenc           NVIDIA NVENC H.264 encoder (codec h264)
+ V..... libx265              libx265 H.265 / HEVC (codec hevc)
+ V..... hevc_nvenc           NVIDIA NVENC hevc encoder (codec hevc)
+ V..... hevc_vaapi           H.265/HEVC (VAAPI) (codec hevc)
+ V..... av1_qsv              AV1 (Intel Quick Sync Video acceleration) (codec av1)`
+
+	tests := []struct {
+		codec    VideoCodec
+		expected []HardwareAccel
+	}{
+		{codec: VideoCodecH264, expected: []HardwareAccel{HardwareAccelNVENC, HardwareAccelNone}},
+		{codec: VideoCodecHEVC, expected: []HardwareAccel{HardwareAccelNVENC, HardwareAccelVAAPI, HardwareAccelNone}},
+		{codec: VideoCodecAV1, expected: []HardwareAccel{HardwareAccelQSV}}, // No libsvtav1, so no software encoding
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codec.String(), func(t *testing.T) {
+			result := sortEncoders(parseHardwareEncoders(output, tt.codec))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseHardwareEncoders(%s) = %v, want %v", tt.codec, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestValidateHardwareAccel(t *testing.T) {
 	available := []HardwareAccel{HardwareAccelNVENC, HardwareAccelQSV, HardwareAccelNone}
 
@@ -264,13 +293,14 @@ func TestDetectHardwareEncoders(t *testing.T) {
 	// This is an integration test that requires FFmpeg to be installed
 	ctx := context.Background()
 
-	encoders, err := DetectHardwareEncoders(ctx)
+	detected, err := DetectHardwareEncoders(ctx)
 	if err != nil {
 		if errors.Is(err, ErrFFmpegNotFound) {
 			t.Skip("FFmpeg not installed, skipping integration test")
 		}
 		t.Fatalf("DetectHardwareEncoders() unexpected error: %v", err)
 	}
+	encoders := detected[VideoCodecH264]
 
 	// Should always have at least "none" (software encoding)
 	if len(encoders) == 0 {
@@ -290,9 +320,11 @@ func TestDetectHardwareEncoders(t *testing.T) {
 	}
 
 	// Validate all returned encoders are known types (use IsValid method)
-	for _, encoder := range encoders {
-		if !encoder.IsValid() {
-			t.Errorf("DetectHardwareEncoders() returned unknown encoder: %s", encoder)
+	for codec, codecEncoders := range detected {
+		for _, encoder := range codecEncoders {
+			if !encoder.IsValid() {
+				t.Errorf("DetectHardwareEncoders() returned unknown %s encoder: %s", codec, encoder)
+			}
 		}
 	}
 }
@@ -0,0 +1,103 @@
+package streaming
+
+import (
+	"context"
+	"time"
+)
+
+// Stream playlist health states
+const (
+	PlaylistHealthOK       = "ok"
+	PlaylistHealthStarting = "starting" // No segment written yet, still within the stale threshold
+	PlaylistHealthStale    = "stale"
+)
+
+// PlaylistHealth reports the playlist health of one stream quality
+type PlaylistHealth struct {
+	ChannelID         string     `json:"channel_id"`
+	Quality           string     `json:"quality"`
+	Status            string     `json:"status"`
+	State             string     `json:"state"`
+	ClientCount       int        `json:"client_count"`
+	LastWrite         *time.Time `json:"last_write,omitempty"`
+	SecondsSinceWrite float64    `json:"seconds_since_write"`
+}
+
+// Healthy reports whether the playlist is not stale
+func (h PlaylistHealth) Healthy() bool {
+	return h.Status != PlaylistHealthStale
+}
+
+// PlaylistHealth returns the health of every active stream playlist, based on the playlist HealthCheck
+func (m *StreamManager) PlaylistHealth() []PlaylistHealth {
+	staleThreshold := m.playlistStaleThreshold()
+	managers := m.playlistManagersSnapshot()
+
+	report := make([]PlaylistHealth, 0, len(managers))
+	for key, pm := range managers {
+		channelID, quality := splitPlaylistManagerKey(key)
+		status := pm.HealthCheck(staleThreshold)
+
+		entry := PlaylistHealth{
+			ChannelID:         channelID,
+			Quality:           quality,
+			Status:            PlaylistHealthOK,
+			LastWrite:         status.LastWriteTime,
+			SecondsSinceWrite: status.TimeSinceLastWrite.Seconds(),
+		}
+
+		var startedAt time.Time
+		if session, ok := m.sessionManager.Get(channelID); ok {
+			entry.State = session.GetState()
+			entry.ClientCount = session.GetClientCount()
+			startedAt = session.StartedAt
+		}
+
+		if !status.Healthy {
+			entry.Status = PlaylistHealthStale
+			// A stream that has not written its first segment yet gets one threshold of grace
+			if status.LastWriteTime == nil && time.Since(startedAt) < staleThreshold {
+				entry.Status = PlaylistHealthStarting
+			}
+		}
+
+		report = append(report, entry)
+	}
+	return report
+}
+
+// SegmentPath returns the directory where stream segments are written
+func (m *StreamManager) SegmentPath() string {
+	return m.config.SegmentPath
+}
+
+// Responsive reports whether the manager's state lock can be acquired before ctx expires.
+// A false result indicates a deadlock or a stuck operation holding the lock.
+func (m *StreamManager) Responsive(ctx context.Context) bool {
+	acquired := make(chan struct{})
+	go func() {
+		m.mu.RLock()
+		m.mu.RUnlock() // nolint:staticcheck // empty critical section is the probe
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// AvailableDiskSpace returns the free space in bytes available to Hermes at path
+func AvailableDiskSpace(path string) (uint64, error) {
+	return getAvailableSpace(path)
+}
+
+// playlistStaleThreshold is how long a playlist may go without a write before it is stale. Once a
+// batch is encoded nothing is written until clients are TriggerThreshold segments from its end, so
+// playlists normally pause for BatchSize - TriggerThreshold segments; the threshold adds a margin.
+func (m *StreamManager) playlistStaleThreshold() time.Duration {
+	batchPause := max(m.config.BatchSize-m.config.TriggerThreshold, 0)
+	return time.Duration((batchPause+playlistStaleSegments)*m.config.StreamSegmentDuration) * time.Second
+}
@@ -0,0 +1,84 @@
+package streaming
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+)
+
+func TestStreamManager_PlaylistHealth(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		SegmentPath:           t.TempDir(),
+		StreamSegmentDuration: 4,
+		BatchSize:             10,
+		TriggerThreshold:      3,
+	})
+
+	addStream := func(startedAt time.Time) string {
+		channelID := uuid.New()
+		session := models.NewStreamSession(channelID)
+		session.StartedAt = startedAt
+		m.sessionManager.Set(channelID.String(), session)
+
+		pm, err := playlist.NewManager(10, filepath.Join(t.TempDir(), "index.m3u8"), 4)
+		if err != nil {
+			t.Fatalf("failed to create playlist manager: %v", err)
+		}
+		m.playlistManagers[channelID.String()+"_"+Quality720p] = pm
+		return channelID.String()
+	}
+
+	starting := addStream(time.Now())
+	stalled := addStream(time.Now().Add(-time.Minute))
+
+	statuses := make(map[string]PlaylistHealth)
+	for _, entry := range m.PlaylistHealth() {
+		statuses[entry.ChannelID] = entry
+	}
+
+	if got := statuses[starting]; got.Status != PlaylistHealthStarting || !got.Healthy() {
+		t.Errorf("new stream status = %q, want %q", got.Status, PlaylistHealthStarting)
+	}
+	if got := statuses[stalled]; got.Status != PlaylistHealthStale || got.Healthy() {
+		t.Errorf("stalled stream status = %q, want %q", got.Status, PlaylistHealthStale)
+	}
+	if got := statuses[starting].Quality; got != Quality720p {
+		t.Errorf("quality = %q, want %q", got, Quality720p)
+	}
+}
+
+func TestStreamManager_PlaylistStaleThreshold(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		StreamSegmentDuration: 4,
+		BatchSize:             10,
+		TriggerThreshold:      3,
+	})
+
+	// Playlists pause for 7 segments between batches, plus a 3 segment margin
+	if got := m.playlistStaleThreshold(); got != 40*time.Second {
+		t.Errorf("playlistStaleThreshold() = %s, want 40s", got)
+	}
+}
+
+func TestStreamManager_Responsive(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{})
+
+	if !m.Responsive(context.Background()) {
+		t.Error("Responsive() = false for an idle manager")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if m.Responsive(ctx) {
+		t.Error("Responsive() = true while the lock is held")
+	}
+}
@@ -2,9 +2,9 @@ package streaming
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"sync"
@@ -44,6 +44,18 @@ type StreamManager struct {
 	batchDone            chan struct{}
 	playlistManagers     map[string]playlist.Manager // key: channelID_quality (e.g., "uuid-1080p")
 	playlistManagersMu   sync.RWMutex
+	renditions           map[string]*renditionSet // key: channelID; renditions generated per stream
+	renditionsMu         sync.Mutex
+	quality              string            // Quality level for new streams (see ApplySettings)
+	encoders             *EncoderDetector  // Optional; resolves "auto" hardware acceleration
+	idleSessions         IdleSessionSource // Optional; sessions released by the cleanup loop
+	budget               *TranscodeBudget  // Limits concurrent FFmpeg processes
+	admissions           map[string]func() // key: channelID; budget reserved by admitNewStream until the first encoder starts
+	admissionsMu         sync.Mutex
+	keySecret            []byte             // Segment encryption keys are derived from it
+	durations            map[string]float64 // Exact media durations by file path (see mediaDuration)
+	durationsMu          sync.Mutex
+	probeDuration        func(ctx context.Context, path string) (float64, error)
 	mu                   sync.RWMutex
 	stopped              bool
 }
@@ -54,6 +66,13 @@ func NewStreamManager(
 	timelineService *timeline.TimelineService,
 	cfg *config.StreamingConfig,
 ) *StreamManager {
+	// Without a configured key secret a random one is generated, so players that resume
+	// across a restart cannot fetch the keys of segments encrypted before it
+	keySecret := []byte(cfg.KeySecret)
+	if len(keySecret) == 0 {
+		keySecret = []byte(rand.Text())
+	}
+
 	return &StreamManager{
 		repos:                repos,
 		timelineService:      timelineService,
@@ -64,6 +83,13 @@ func NewStreamManager(
 		cleanupDone:          make(chan struct{}),
 		batchDone:            make(chan struct{}),
 		playlistManagers:     make(map[string]playlist.Manager),
+		renditions:           make(map[string]*renditionSet),
+		quality:              Quality1080p,
+		budget:               NewTranscodeBudget(float64(cfg.MaxConcurrentTranscodes)),
+		admissions:           make(map[string]func()),
+		keySecret:            keySecret,
+		durations:            make(map[string]float64),
+		probeDuration:        probeMediaDuration,
 		stopped:              false,
 	}
 }
@@ -77,6 +103,9 @@ func (m *StreamManager) Start() error {
 		return ErrManagerStopped
 	}
 
+	// Resume the streams that were running before the last shutdown before cleanup can remove them
+	m.restoreStreams(context.Background())
+
 	// Create cleanup ticker
 	cleanupInterval := time.Duration(m.config.CleanupInterval) * time.Second
 	m.cleanupTicker = time.NewTicker(cleanupInterval)
@@ -90,6 +119,9 @@ func (m *StreamManager) Start() error {
 	// Start batch coordinator goroutine
 	go m.runBatchCoordinator()
 
+	// Warm up always-on channels right away rather than at the first cleanup cycle
+	go m.syncAlwaysOnChannels(context.Background())
+
 	logger.Log.Info().
 		Int("cleanup_interval_seconds", m.config.CleanupInterval).
 		Int("grace_period_seconds", m.config.GracePeriodSeconds).
@@ -126,16 +158,10 @@ func (m *StreamManager) Stop() {
 		m.batchTicker.Stop()
 	}
 
-	// Stop all active streams
+	// Stop all active streams, keeping their segments and state so they resume on the next start
 	sessions := m.sessionManager.List()
 	for _, session := range sessions {
-		channelID := session.ChannelID.String()
-		if err := m.StopStream(context.Background(), session.ChannelID); err != nil {
-			logger.Log.Error().
-				Err(err).
-				Str("channel_id", channelID).
-				Msg("Failed to stop stream during shutdown")
-		}
+		m.suspendStream(session)
 	}
 
 	logger.Log.Info().
@@ -156,6 +182,9 @@ func (m *StreamManager) StartStream(ctx context.Context, channelID uuid.UUID) (*
 
 	// Check if stream already exists
 	if existingSession, ok := m.sessionManager.Get(channelIDStr); ok {
+		if existingSession.IsWarm() {
+			m.wakeWarmStream(existingSession)
+		}
 		logger.Log.Debug().
 			Str("channel_id", channelIDStr).
 			Int("client_count", existingSession.GetClientCount()).
@@ -193,7 +222,23 @@ func (m *StreamManager) StartStream(ctx context.Context, channelID uuid.UUID) (*
 
 	// Build output directory
 	outputDir := fmt.Sprintf("%s/%s", m.config.SegmentPath, channelIDStr)
-	quality := Quality1080p // Start with 1080p for now
+	quality := m.currentQuality()
+
+	// Refuse (or wait) rather than overload the machine when the transcode budget is used up
+	release, err := m.admitNewStream(ctx, quality)
+	if err != nil {
+		logger.Log.Warn().
+			Str("channel_id", channelIDStr).
+			Interface("transcode_budget", m.budget.Stats()).
+			Msg("Transcode budget exhausted, not starting stream")
+		return nil, err
+	}
+	started := false
+	defer func() {
+		if !started {
+			release()
+		}
+	}()
 
 	// Create segment directories
 	if err := createSegmentDirectories(outputDir, channelIDStr); err != nil {
@@ -205,21 +250,40 @@ func (m *StreamManager) StartStream(ctx context.Context, channelID uuid.UUID) (*
 	session.SetState(StateIdle.String()) // Start in idle state, batch generation will activate it
 	session.SetOutputDir(outputDir)
 	session.SetSegmentPath(filepath.Join(outputDir, quality))
+	if models.IsValidSegmentFormat(channel.SegmentFormat) {
+		session.SetSegmentFormat(channel.SegmentFormat)
+	}
+	// Whole-segment AES-128 is only offered for MPEG-TS (parts and DASH need clear fMP4 segments)
+	if channel.Encryption == models.EncryptionAES128 && session.GetSegmentFormat() == SegmentFormatTS {
+		session.SetEncryption(models.EncryptionAES128)
+	}
+	session.SetChannelStartTime(channel.StartTime.UTC())
+	session.SetAlwaysOn(channel.IsAlwaysOnAt(time.Now()))
 	session.UpdateLastAccess()
 
-	// Set quality information
-	qualities := []models.StreamQuality{
-		{
-			Level:       quality,
-			Bitrate:     5000, // 1080p bitrate
-			Resolution:  "1920x1080",
-			SegmentPath: session.GetSegmentPath(),
+	// Offer every rendition up to the configured quality; each is only generated once a client requests it
+	ladder := qualityLadder(quality)
+	qualities := make([]models.StreamQuality, 0, len(ladder))
+	for _, level := range ladder {
+		codec := m.renditionCodec(level, session.GetSegmentFormat())
+		spec, err := getQualitySpec(level, codec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quality spec: %w", err)
+		}
+		qualities = append(qualities, models.StreamQuality{
+			Level:       level,
+			Codec:       codec.String(),
+			Bitrate:     spec.videoBitrate,
+			Resolution:  spec.resolution,
+			SegmentPath: filepath.Join(outputDir, level),
 			// PlaylistPath will be set by playlist manager
-		},
+		})
 	}
 	session.SetQualities(qualities)
 
-	// Store session in manager
+	// Store session in manager; the stream's first encoder takes over the admission reservation
+	m.reserveAdmission(channelIDStr, release)
+	started = true
 	m.sessionManager.Set(channelIDStr, session)
 
 	// Generate and write master playlist
@@ -245,6 +309,69 @@ func (m *StreamManager) StartStream(ctx context.Context, channelID uuid.UUID) (*
 	return session, nil
 }
 
+// admitNewStream reserves the cost of a new stream's top quality in the transcode budget and
+// returns the function that gives it back. The reservation is held until the stream's first
+// encoder takes it over (see takeAdmission), so concurrent starts cannot all be admitted into the
+// same capacity. When the budget is used up the start is rejected with ErrTranscodeCapacity, or
+// waits in the queue for up to the configured transcode queue timeout (bounded by ctx).
+func (m *StreamManager) admitNewStream(ctx context.Context, quality string) (func(), error) {
+	cost := TranscodeCost(quality, m.currentHardwareAccel(), m.config.WeightedTranscodes)
+	if release, ok := m.budget.TryAcquire(cost); ok {
+		return release, nil
+	}
+
+	timeout := time.Duration(m.config.TranscodeQueueTimeout) * time.Second
+
+	// Warm streams only speed up zapping; their budget goes to the stream a client asked for
+	if m.stopWarmStreams(ctx) > 0 {
+		timeout = max(timeout, warmReleaseWait)
+	}
+
+	if timeout <= 0 {
+		return nil, ErrTranscodeCapacity
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	release, err := m.budget.Acquire(waitCtx, cost, PriorityStartup)
+	if err != nil {
+		return nil, ErrTranscodeCapacity
+	}
+	return release, nil
+}
+
+// reserveAdmission keeps a new stream's admission reservation until its first encoder starts
+func (m *StreamManager) reserveAdmission(channelID string, release func()) {
+	m.admissionsMu.Lock()
+	previous := m.admissions[channelID]
+	m.admissions[channelID] = release
+	m.admissionsMu.Unlock()
+	if previous != nil {
+		previous()
+	}
+}
+
+// takeAdmission removes and returns a stream's admission reservation, if it still has one
+func (m *StreamManager) takeAdmission(channelID string) (func(), bool) {
+	m.admissionsMu.Lock()
+	defer m.admissionsMu.Unlock()
+	release, ok := m.admissions[channelID]
+	delete(m.admissions, channelID)
+	return release, ok
+}
+
+// releaseAdmission gives back the admission reservation of a stream stopped before any encoder started
+func (m *StreamManager) releaseAdmission(channelID string) {
+	if release, ok := m.takeAdmission(channelID); ok {
+		release()
+	}
+}
+
+// TranscodeBudgetStats returns current transcode budget usage
+func (m *StreamManager) TranscodeBudgetStats() TranscodeBudgetStats {
+	return m.budget.Stats()
+}
+
 // StopStream stops a stream and cleans up resources
 func (m *StreamManager) StopStream(_ context.Context, channelID uuid.UUID) error {
 	channelIDStr := channelID.String()
@@ -262,6 +389,7 @@ func (m *StreamManager) StopStream(_ context.Context, channelID uuid.UUID) error
 
 	// Set state to stopping
 	session.SetState(StateStopping.String())
+	m.releaseAdmission(channelIDStr)
 
 	// Terminate FFmpeg process
 	pid := session.GetFFmpegPID()
@@ -275,9 +403,11 @@ func (m *StreamManager) StopStream(_ context.Context, channelID uuid.UUID) error
 		}
 	}
 
-	// Clean up segment files
+	// Clean up segment files; the state lock keeps a concurrent state save from recreating the directory
 	outputDir := session.GetOutputDir()
 	if outputDir != "" {
+		rs := m.renditionsFor(session)
+		rs.stateMu.Lock()
 		if err := cleanupSegments(outputDir); err != nil {
 			logger.Log.Warn().
 				Err(err).
@@ -285,10 +415,12 @@ func (m *StreamManager) StopStream(_ context.Context, channelID uuid.UUID) error
 				Str("output_dir", outputDir).
 				Msg("Failed to cleanup segments")
 		}
+		rs.stateMu.Unlock()
 	}
 
 	// Close all playlist managers for this channel
 	m.closePlaylistManagersForChannel(channelIDStr)
+	m.deleteRenditions(channelIDStr)
 
 	// Remove session from manager
 	m.sessionManager.Delete(channelIDStr)
@@ -380,6 +512,54 @@ func (m *StreamManager) UnregisterClient(_ context.Context, channelID uuid.UUID)
 	return nil
 }
 
+// IdleSessionSource hands out player sessions that stopped fetching without unregistering,
+// such as players watching through a share link
+type IdleSessionSource interface {
+	TakeIdleSessions() map[uuid.UUID][]string // channel ID -> session IDs
+}
+
+// SetIdleSessionSource sets the source of idle player sessions the cleanup loop unregisters
+// Must be called before the manager is started
+func (m *StreamManager) SetIdleSessionSource(source IdleSessionSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.idleSessions = source
+}
+
+// ReleaseSession unregisters a player session from a channel's stream and reports whether it was registered
+func (m *StreamManager) ReleaseSession(ctx context.Context, channelID uuid.UUID, sessionID string) (bool, error) {
+	session, ok := m.sessionManager.Get(channelID.String())
+	if !ok {
+		return false, ErrStreamNotFound
+	}
+	if !session.UnregisterSession(sessionID) {
+		return false, nil
+	}
+	return true, m.UnregisterClient(ctx, channelID)
+}
+
+// releaseIdleSessions unregisters the sessions reported idle by the idle session source
+func (m *StreamManager) releaseIdleSessions() {
+	m.mu.RLock()
+	source := m.idleSessions
+	m.mu.RUnlock()
+	if source == nil {
+		return
+	}
+
+	for channelID, sessionIDs := range source.TakeIdleSessions() {
+		for _, sessionID := range sessionIDs {
+			released, err := m.ReleaseSession(context.Background(), channelID, sessionID)
+			if released && err == nil {
+				logger.Log.Debug().
+					Str("channel_id", channelID.String()).
+					Str("session_id", sessionID).
+					Msg("Idle client session released")
+			}
+		}
+	}
+}
+
 // runCleanupLoop runs periodic cleanup of idle streams
 func (m *StreamManager) runCleanupLoop() {
 	defer close(m.cleanupDone)
@@ -420,8 +600,20 @@ func (m *StreamManager) checkAndTriggerBatches() {
 	sessions := m.sessionManager.List()
 
 	for _, session := range sessions {
-		// Skip if no active clients
+		// Skip if no active clients, unless the channel is always on or kept warm
 		if session.GetClientCount() == 0 {
+			switch {
+			case session.IsAlwaysOn():
+				m.keepAlwaysOnStreamLive(session)
+			case session.IsWarm():
+				m.keepWarmStreamLive(session)
+			default:
+				continue
+			}
+		}
+
+		// Start requested renditions and stop unused ones; nothing to generate until one is requested
+		if m.syncRenditions(session) == 0 {
 			continue
 		}
 
@@ -505,6 +697,7 @@ func (m *StreamManager) generateNextBatch(ctx context.Context, session *models.S
 	// So we can use it directly without adding batch duration
 	currentOffset := currentBatch.VideoStartOffset
 	currentPlaylistIndex := 0
+	discontinuityNext := false // Whether the next segment starts a different source file
 
 	// Find current video index in playlist
 	for i, item := range playlistItems {
@@ -516,62 +709,25 @@ func (m *StreamManager) generateNextBatch(ctx context.Context, session *models.S
 
 	// Check if we've moved to a new video file since the last batch
 	// This handles video transitions that occur between batches
-	currentItem := playlistItems[currentPlaylistIndex]
-	if currentItem.Media != nil {
-		// Check if currentOffset exceeds current video duration (moved to next video)
-		if currentOffset >= int64(currentItem.Media.Duration) {
-			// Calculate which video we should be on now
-			remainingOffset := currentOffset
-			for remainingOffset >= int64(currentItem.Media.Duration) && currentPlaylistIndex < len(playlistItems) {
-				remainingOffset -= int64(currentItem.Media.Duration)
-				currentPlaylistIndex++
-				if currentPlaylistIndex >= len(playlistItems) {
-					// Loop back to beginning if channel loops
-					channel, err := m.repos.Channels.GetByID(ctx, channelID)
-					if err == nil && channel.Loop {
-						currentPlaylistIndex = 0
-					} else {
-						return fmt.Errorf("reached end of playlist and channel does not loop")
-					}
-				}
-				currentItem = playlistItems[currentPlaylistIndex]
-				if currentItem.Media == nil {
-					return fmt.Errorf("playlist item at index %d has no media", currentPlaylistIndex)
-				}
-			}
-			currentVideoPath = currentItem.Media.FilePath
-			currentOffset = remainingOffset
-
-			// Mark discontinuity if video file changed between batches
-			if currentVideoPath != previousBatchVideoPath {
-				// Get playlist manager (quality is defined below, but we can use the constant here)
-				pm, err := m.getPlaylistManager(session, Quality1080p)
-				if err == nil {
-					pm.SetDiscontinuityNext()
-					logger.Log.Debug().
-						Str("channel_id", channelIDStr).
-						Str("previous_video", previousBatchVideoPath).
-						Str("new_video", currentVideoPath).
-						Int("batch_number", nextBatchNumber).
-						Msg("Video switch detected between batches, marking discontinuity")
-				}
-			}
-		}
+	channelLoops := func() bool {
+		channel, err := m.repos.Channels.GetByID(ctx, channelID)
+		return err == nil && channel.Loop
 	}
+	currentPlaylistIndex, currentOffset, err = m.advancePlaylist(ctx, playlistItems, currentPlaylistIndex, currentOffset, channelLoops)
+	if err != nil {
+		return err
+	}
+	currentVideoPath = playlistItems[currentPlaylistIndex].Media.FilePath
 
-	// Build output directory
-	outputDir := session.GetOutputDir()
-	quality := Quality1080p
-	qualityDir := filepath.Join(outputDir, quality)
-
-	// Ensure playlist manager is initialized for this quality
-	if err := m.ensurePlaylistManager(session, quality, qualityDir); err != nil {
-		logger.Log.Error().
-			Err(err).
+	// Mark discontinuity if video file changed between batches
+	if currentVideoPath != previousBatchVideoPath {
+		discontinuityNext = true
+		logger.Log.Debug().
 			Str("channel_id", channelIDStr).
-			Str("quality", quality).
-			Msg("Failed to initialize playlist manager (continuing anyway)")
-		// Continue anyway - segments will still be generated
+			Str("previous_video", previousBatchVideoPath).
+			Str("new_video", currentVideoPath).
+			Int("batch_number", nextBatchNumber).
+			Msg("Video switch detected between batches, marking discontinuity")
 	}
 
 	// Create new BatchState
@@ -588,7 +744,7 @@ func (m *StreamManager) generateNextBatch(ctx context.Context, session *models.S
 	// Update session with new batch (atomic update with lock)
 	session.SetCurrentBatch(newBatch)
 
-	// Generate batch by looping BatchSize times, generating one segment at a time
+	// Plan the batch segment by segment, then let the encoders produce it
 	previousVideoPath := currentVideoPath // Track previous video to detect switches
 	for segmentNum := 0; segmentNum < m.config.BatchSize; segmentNum++ {
 		// Get current playlist item
@@ -598,24 +754,14 @@ func (m *StreamManager) generateNextBatch(ctx context.Context, session *models.S
 		}
 
 		// Check if we need to advance to next video
-		if currentOffset >= int64(currentItem.Media.Duration) {
-			// Move to next playlist item
-			currentPlaylistIndex++
-			if currentPlaylistIndex >= len(playlistItems) {
-				// Loop back to beginning if channel loops
-				channel, err := m.repos.Channels.GetByID(ctx, channelID)
-				if err == nil && channel.Loop {
-					currentPlaylistIndex = 0
-				} else {
-					return fmt.Errorf("reached end of playlist and channel does not loop")
-				}
+		if currentOffset >= m.mediaDuration(ctx, currentItem.Media) {
+			// Encoders play videos back to back, so whatever runs past the end carries into the next one
+			currentPlaylistIndex, currentOffset, err = m.advancePlaylist(ctx, playlistItems, currentPlaylistIndex, currentOffset, channelLoops)
+			if err != nil {
+				return err
 			}
 			currentItem = playlistItems[currentPlaylistIndex]
-			if currentItem.Media == nil {
-				return fmt.Errorf("playlist item at index %d has no media", currentPlaylistIndex)
-			}
 			currentVideoPath = currentItem.Media.FilePath
-			currentOffset = 0
 
 			// Update batch state with new video
 			newBatch.VideoSourcePath = currentVideoPath
@@ -623,41 +769,38 @@ func (m *StreamManager) generateNextBatch(ctx context.Context, session *models.S
 
 			// Mark discontinuity when switching videos (different source file)
 			if currentVideoPath != previousVideoPath {
-				pm, err := m.getPlaylistManager(session, quality)
-				if err == nil {
-					pm.SetDiscontinuityNext()
-					logger.Log.Debug().
-						Str("channel_id", channelIDStr).
-						Str("previous_video", previousVideoPath).
-						Str("new_video", currentVideoPath).
-						Int("segment_number", nextStartSegment+segmentNum).
-						Msg("Video switch detected, marking discontinuity")
-				}
+				discontinuityNext = true
+				logger.Log.Debug().
+					Str("channel_id", channelIDStr).
+					Str("previous_video", previousVideoPath).
+					Str("new_video", currentVideoPath).
+					Int("segment_number", nextStartSegment+segmentNum).
+					Msg("Video switch detected, marking discontinuity")
 				previousVideoPath = currentVideoPath
 			}
 		}
 
-		// Generate single segment synchronously
-		if err := m.generateSingleSegment(
-			ctx,
-			session,
-			currentVideoPath,
-			currentOffset,
-			quality,
-			qualityDir,
-			nextStartSegment+segmentNum,
-		); err != nil {
-			logger.Log.Error().
-				Err(err).
-				Str("channel_id", channelIDStr).
-				Int("segment_number", nextStartSegment+segmentNum).
-				Int("batch_number", nextBatchNumber).
-				Msg("Failed to generate segment in batch")
-			return fmt.Errorf("failed to generate segment %d: %w", nextStartSegment+segmentNum, err)
-		}
+		// Plan the segment; the encoders of every rendition pick it up after the loop
+		m.recordSegment(ctx, session, segmentSource{
+			number:        nextStartSegment + segmentNum,
+			videoPath:     currentVideoPath,
+			offsetSeconds: currentOffset,
+			discontinuity: discontinuityNext,
+		})
+		discontinuityNext = false
 
 		// Advance offset for next segment
-		currentOffset += int64(m.config.StreamSegmentDuration)
+		currentOffset += float64(m.config.StreamSegmentDuration)
+	}
+
+	// Let every live rendition's encoder run until the batch is complete
+	if err := m.encodeBatch(ctx, session, nextEndSegment); err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Int("batch_number", nextBatchNumber).
+			Msg("Failed to encode batch")
+		return fmt.Errorf("failed to encode batch %d: %w", nextBatchNumber, err)
 	}
 
 	// Update final batch state
@@ -716,191 +859,37 @@ func (m *StreamManager) generateNextBatch(ctx context.Context, session *models.S
 	return nil
 }
 
-// generateSingleSegment generates exactly one segment synchronously
-// This function launches FFmpeg, waits for it to complete, adds the segment to the playlist, and returns
-func (m *StreamManager) generateSingleSegment(
-	ctx context.Context,
-	session *models.StreamSession,
-	videoPath string,
-	offsetSeconds int64,
-	quality string,
-	qualityDir string,
-	segmentNumber int,
-) error {
-	channelIDStr := session.ChannelID.String()
-
-	// Build StreamParams for single segment (1 segment = SegmentDuration seconds)
-	// Calculate cumulative stream position for PTS timestamps and ProgramDateTime
-	streamPositionSeconds := int64(segmentNumber) * int64(m.config.StreamSegmentDuration)
-	params := StreamParams{
-		InputFile:              videoPath,
-		Quality:                quality,
-		HardwareAccel:          HardwareAccel(m.config.HardwareAccel),
-		SeekSeconds:            offsetSeconds,         // Position within current video file (for FFmpeg -ss)
-		StreamPositionSeconds:  streamPositionSeconds, // Cumulative stream position (for -output_ts_offset and ProgramDateTime)
-		EncodingPreset:         m.config.EncodingPreset,
-		BatchMode:              false, // Not batch mode - generate exactly 1 segment
-		StreamSegmentMode:      true,
-		SegmentOutputDir:       qualityDir,
-		SegmentFilenamePattern: m.config.StreamSegmentFilenamePattern,
-		SegmentDuration:        m.config.StreamSegmentDuration,
-		FPS:                    m.config.FPS,
-	}
-
-	// Build FFmpeg command
-	ffmpegCmd, err := BuildHLSCommand(params)
-	if err != nil {
-		return fmt.Errorf("failed to build FFmpeg command for segment %d: %w", segmentNumber, err)
-	}
-
-	// Extract output filename from command (last argument is the output path)
-	var segmentFilename string
-	if len(ffmpegCmd.Args) > 0 {
-		outputPath := ffmpegCmd.Args[len(ffmpegCmd.Args)-1]
-		segmentFilename = filepath.Base(outputPath)
-	} else {
-		return fmt.Errorf("FFmpeg command has no output path")
-	}
-
-	// Launch FFmpeg process
-	execCmd, err := launchFFmpeg(ffmpegCmd)
-	if err != nil {
-		return fmt.Errorf("failed to launch FFmpeg for segment %d: %w", segmentNumber, err)
-	}
-
-	segmentStartTime := time.Now()
-	logger.Log.Debug().
-		Str("channel_id", channelIDStr).
-		Int("segment_number", segmentNumber).
-		Int64("offset_seconds", offsetSeconds).
-		Str("video_path", videoPath).
-		Str("segment_filename", segmentFilename).
-		Int("ffmpeg_pid", execCmd.Process.Pid).
-		Msg("Generating single segment")
-
-	// Wait for FFmpeg to complete (synchronous - blocks until segment is created)
-	err = execCmd.Wait()
-	segmentGenerationTime := time.Since(segmentStartTime)
-	if err != nil {
-		logger.Log.Error().
-			Err(err).
-			Str("channel_id", channelIDStr).
-			Int("segment_number", segmentNumber).
-			Int64("offset_seconds", offsetSeconds).
-			Msg("Failed to generate segment")
-		return fmt.Errorf("FFmpeg failed for segment %d: %w", segmentNumber, err)
-	}
-
-	// Segment generated successfully - add it directly to the playlist
-	pm, err := m.getPlaylistManager(session, quality)
-	if err != nil {
-		logger.Log.Warn().
-			Err(err).
-			Str("channel_id", channelIDStr).
-			Str("quality", quality).
-			Str("segment_filename", segmentFilename).
-			Msg("Failed to get playlist manager, segment generated but not added to playlist")
-		// Don't fail - segment was generated successfully
-		return nil
-	}
-
-	// Add segment to playlist
-	seg := playlist.SegmentMeta{
-		URI:      segmentFilename,
-		Duration: float64(m.config.StreamSegmentDuration),
-	}
-	// Set ProgramDateTime based on when the segment should be played according to the channel timeline
-	// ProgramDateTime should represent when the segment should be played, not when it was generated
+// segmentProgramTime returns when a segment should be played according to the channel timeline.
+// ProgramDateTime should represent when the segment should be played, not when it was generated.
+// It is computed once per segment number so every rendition carries the same value.
+func (m *StreamManager) segmentProgramTime(ctx context.Context, session *models.StreamSession, segmentNumber int) time.Time {
 	// For segment 0, get the timeline position to determine when it should start playing
 	// For subsequent segments, add segment duration to get sequential timestamps
-	var segmentProgramTime time.Time
-	if segmentNumber == 0 {
-		// For the first segment, calculate when it should start playing based on timeline
-		position, err := m.timelineService.GetCurrentPosition(ctx, session.ChannelID)
-		if err != nil {
-			// Fallback to session start time if timeline calculation fails
-			logger.Log.Warn().
-				Err(err).
-				Str("channel_id", channelIDStr).
-				Msg("Failed to get timeline position for ProgramDateTime, using session start time")
-			sessionStartTime := session.StartedAt.UTC()
-			segmentProgramTime = sessionStartTime
-		} else {
-			// Calculate when segment 0 should start playing:
-			// position.StartedAt is when the current media item started playing
-			// position.OffsetSeconds is how far into that item we are
-			// So the current timeline time is: position.StartedAt + position.OffsetSeconds
-			// Segment 0 should start playing at the current timeline time
-			currentTimelineTime := position.StartedAt.Add(time.Duration(position.OffsetSeconds) * time.Second)
-			segmentProgramTime = currentTimelineTime
-			// Update session.StartedAt to this calculated time for future segments
-			// Since streamPositionSeconds is 0 for segment 0, this sets session.StartedAt = currentTimelineTime
-			// This ensures subsequent segments have correct ProgramDateTime
-			session.StartedAt = currentTimelineTime
-		}
-	} else {
-		// For subsequent segments, use session start time + cumulative stream position
-		sessionStartTime := session.StartedAt.UTC()
-		segmentProgramTime = sessionStartTime.Add(time.Duration(streamPositionSeconds) * time.Second)
+	if segmentNumber != 0 {
+		// Use session start time + cumulative stream position
+		streamPositionSeconds := int64(segmentNumber) * int64(m.config.StreamSegmentDuration)
+		return session.StartedAt.UTC().Add(time.Duration(streamPositionSeconds) * time.Second)
 	}
-	seg.ProgramDateTime = &segmentProgramTime
 
-	prunedURIs, err := pm.AddSegment(seg)
+	position, err := m.timelineService.GetCurrentPosition(ctx, session.ChannelID)
 	if err != nil {
-		logger.Log.Error().
-			Err(err).
-			Str("channel_id", channelIDStr).
-			Str("segment_filename", segmentFilename).
-			Msg("Failed to add segment to playlist")
-		return fmt.Errorf("failed to add segment to playlist: %w", err)
-	}
-
-	// Delete pruned segment files from disk
-	for _, prunedURI := range prunedURIs {
-		segmentPath := filepath.Join(qualityDir, prunedURI)
-		if err := os.Remove(segmentPath); err != nil {
-			// Log warning but don't fail - file may already be deleted or not exist
-			logger.Log.Warn().
-				Err(err).
-				Str("channel_id", channelIDStr).
-				Str("segment_path", segmentPath).
-				Str("pruned_uri", prunedURI).
-				Msg("Failed to delete pruned segment file")
-		} else {
-			logger.Log.Debug().
-				Str("channel_id", channelIDStr).
-				Str("segment_path", segmentPath).
-				Str("pruned_uri", prunedURI).
-				Msg("Deleted pruned segment file")
-		}
-	}
-
-	// Write playlist to disk
-	if err := pm.Write(); err != nil {
-		logger.Log.Error().
+		// Fallback to session start time if timeline calculation fails
+		logger.Log.Warn().
 			Err(err).
-			Str("channel_id", channelIDStr).
-			Str("segment_filename", segmentFilename).
-			Msg("Failed to write playlist")
-		return fmt.Errorf("failed to write playlist: %w", err)
-	}
-
-	// Calculate generation speed ratio (generation_time / content_duration)
-	// If ratio > 1.0, generation is slower than real-time (bad)
-	// If ratio < 1.0, generation is faster than real-time (good)
-	segmentContentDuration := time.Duration(m.config.StreamSegmentDuration) * time.Second
-	generationSpeedRatio := float64(segmentGenerationTime) / float64(segmentContentDuration)
-
-	logger.Log.Debug().
-		Str("channel_id", channelIDStr).
-		Int("segment_number", segmentNumber).
-		Int64("offset_seconds", offsetSeconds).
-		Str("segment_filename", segmentFilename).
-		Dur("generation_time", segmentGenerationTime).
-		Float64("generation_speed_ratio", generationSpeedRatio).
-		Msg("Segment generated and added to playlist successfully")
-
-	return nil
+			Str("channel_id", session.ChannelID.String()).
+			Msg("Failed to get timeline position for ProgramDateTime, using session start time")
+		return session.StartedAt.UTC()
+	}
+
+	// Calculate when segment 0 should start playing:
+	// position.StartedAt is when the current media item started playing
+	// position.OffsetSeconds is how far into that item we are
+	// So the current timeline time is: position.StartedAt + position.OffsetSeconds
+	currentTimelineTime := position.StartedAt.Add(time.Duration(position.OffsetSeconds) * time.Second)
+	// Update session.StartedAt to this calculated time for future segments
+	// This ensures subsequent segments have correct ProgramDateTime
+	session.StartedAt = currentTimelineTime
+	return currentTimelineTime
 }
 
 // initializeFirstBatch initializes the first batch when currentBatch is nil
@@ -932,7 +921,7 @@ func (m *StreamManager) initializeFirstBatch(ctx context.Context, session *model
 	// Get current timeline position to determine where to start streaming
 	var firstItem *models.PlaylistItem
 	var currentPlaylistIndex int
-	var nextOffset int64
+	var nextOffset float64
 
 	position, err := m.timelineService.GetCurrentPosition(ctx, channelID)
 	if err != nil {
@@ -944,7 +933,7 @@ func (m *StreamManager) initializeFirstBatch(ctx context.Context, session *model
 			Msg("Failed to get timeline position, falling back to start of playlist")
 		firstItem = playlistItems[0]
 		currentPlaylistIndex = 0
-		nextOffset = int64(0)
+		nextOffset = 0
 	} else {
 		// Log timeline position details for debugging
 		logger.Log.Debug().
@@ -981,7 +970,7 @@ func (m *StreamManager) initializeFirstBatch(ctx context.Context, session *model
 			if item.MediaID == position.MediaID {
 				firstItem = item
 				currentPlaylistIndex = i
-				nextOffset = position.OffsetSeconds
+				nextOffset = float64(position.OffsetSeconds)
 				found = true
 				logger.Log.Debug().
 					Str("channel_id", channelIDStr).
@@ -1001,7 +990,7 @@ func (m *StreamManager) initializeFirstBatch(ctx context.Context, session *model
 				Msg("MediaID from timeline not found in playlist, falling back to start of playlist")
 			firstItem = playlistItems[0]
 			currentPlaylistIndex = 0
-			nextOffset = int64(0)
+			nextOffset = 0
 		} else {
 			logger.Log.Info().
 				Str("channel_id", channelIDStr).
@@ -1027,32 +1016,14 @@ func (m *StreamManager) initializeFirstBatch(ctx context.Context, session *model
 	nextStartSegment := 0
 	nextEndSegment := m.config.BatchSize - 1
 
-	// Build output directory
-	outputDir := session.GetOutputDir()
-	quality := Quality1080p
-	qualityDir := filepath.Join(outputDir, quality)
-
-	// Initialize playlist manager for this quality (if not already done)
-	if err := m.ensurePlaylistManager(session, quality, qualityDir); err != nil {
-		logger.Log.Error().
-			Err(err).
-			Str("channel_id", channelIDStr).
-			Str("quality", quality).
-			Msg("Failed to initialize playlist manager (continuing anyway)")
-		// Continue anyway - segments will still be generated
-	}
-
 	// Mark discontinuity at start if we're starting from middle of video
 	// This tells HLS players that we're jumping into the middle of content
-	if nextOffset > 0 {
-		pm, err := m.getPlaylistManager(session, quality)
-		if err == nil {
-			pm.SetDiscontinuityNext()
-			logger.Log.Debug().
-				Str("channel_id", channelIDStr).
-				Int64("offset_seconds", nextOffset).
-				Msg("Marking discontinuity at stream start (starting from middle of video)")
-		}
+	discontinuityNext := nextOffset > 0
+	if discontinuityNext {
+		logger.Log.Debug().
+			Str("channel_id", channelIDStr).
+			Float64("offset_seconds", nextOffset).
+			Msg("Marking discontinuity at stream start (starting from middle of video)")
 	}
 
 	// Create first BatchState
@@ -1069,7 +1040,11 @@ func (m *StreamManager) initializeFirstBatch(ctx context.Context, session *model
 	// Update session with first batch
 	session.SetCurrentBatch(newBatch)
 
-	// Generate batch by looping BatchSize times, generating one segment at a time
+	// Plan the batch segment by segment, then let the encoders produce it
+	channelLoops := func() bool {
+		channel, err := m.repos.Channels.GetByID(ctx, channelID)
+		return err == nil && channel.Loop
+	}
 	currentVideoPath := mediaFilePath
 	currentOffset := nextOffset
 	previousVideoPath := currentVideoPath // Track previous video to detect switches
@@ -1082,62 +1057,49 @@ func (m *StreamManager) initializeFirstBatch(ctx context.Context, session *model
 		}
 
 		// Check if we need to advance to next video
-		if currentOffset >= int64(currentItem.Media.Duration) {
-			// Move to next playlist item
-			currentPlaylistIndex++
-			if currentPlaylistIndex >= len(playlistItems) {
-				// Loop back to beginning if channel loops
-				channel, err := m.repos.Channels.GetByID(ctx, channelID)
-				if err == nil && channel.Loop {
-					currentPlaylistIndex = 0
-				} else {
-					return fmt.Errorf("reached end of playlist and channel does not loop")
-				}
+		if currentOffset >= m.mediaDuration(ctx, currentItem.Media) {
+			// Encoders play videos back to back, so whatever runs past the end carries into the next one
+			currentPlaylistIndex, currentOffset, err = m.advancePlaylist(ctx, playlistItems, currentPlaylistIndex, currentOffset, channelLoops)
+			if err != nil {
+				return err
 			}
 			currentItem = playlistItems[currentPlaylistIndex]
-			if currentItem.Media == nil {
-				return fmt.Errorf("playlist item at index %d has no media", currentPlaylistIndex)
-			}
 			previousVideoPath = currentVideoPath
 			currentVideoPath = currentItem.Media.FilePath
-			currentOffset = 0
 
 			// Mark discontinuity when switching videos (different source file)
 			if currentVideoPath != previousVideoPath {
-				pm, err := m.getPlaylistManager(session, quality)
-				if err == nil {
-					pm.SetDiscontinuityNext()
-					logger.Log.Debug().
-						Str("channel_id", channelIDStr).
-						Str("previous_video", previousVideoPath).
-						Str("new_video", currentVideoPath).
-						Int("segment_number", segmentNum).
-						Msg("Video switch detected in first batch, marking discontinuity")
-				}
+				discontinuityNext = true
+				logger.Log.Debug().
+					Str("channel_id", channelIDStr).
+					Str("previous_video", previousVideoPath).
+					Str("new_video", currentVideoPath).
+					Int("segment_number", segmentNum).
+					Msg("Video switch detected in first batch, marking discontinuity")
 			}
 		}
 
-		// Generate single segment synchronously
-		if err := m.generateSingleSegment(
-			ctx,
-			session,
-			currentVideoPath,
-			currentOffset,
-			quality,
-			qualityDir,
-			segmentNum,
-		); err != nil {
-			logger.Log.Error().
-				Err(err).
-				Str("channel_id", channelIDStr).
-				Int("segment_number", segmentNum).
-				Int("batch_number", nextBatchNumber).
-				Msg("Failed to generate segment in batch")
-			return fmt.Errorf("failed to generate segment %d: %w", segmentNum, err)
-		}
+		// Plan the segment; the encoders of every rendition pick it up after the loop
+		m.recordSegment(ctx, session, segmentSource{
+			number:        segmentNum,
+			videoPath:     currentVideoPath,
+			offsetSeconds: currentOffset,
+			discontinuity: discontinuityNext,
+		})
+		discontinuityNext = false
 
 		// Advance offset for next segment
-		currentOffset += int64(m.config.StreamSegmentDuration)
+		currentOffset += float64(m.config.StreamSegmentDuration)
+	}
+
+	// Let every live rendition's encoder run until the batch is complete
+	if err := m.encodeBatch(ctx, session, nextEndSegment); err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Int("batch_number", nextBatchNumber).
+			Msg("Failed to encode batch")
+		return fmt.Errorf("failed to encode batch %d: %w", nextBatchNumber, err)
 	}
 
 	// Update final batch state
@@ -1251,18 +1213,37 @@ func (m *StreamManager) monitorBatchCompletion(session *models.StreamSession, cm
 		// Clean up old batches (N-2) after successful completion
 		// This keeps N-1 batch available during N batch generation
 		outputDir := session.GetOutputDir()
-		quality := Quality1080p // Default to 1080p for now (matches current implementation)
+		quality := sessionQuality(session)
 		cleanupOldBatches(session, m.config.BatchSize, outputDir, quality)
 	}
 }
 
-// performCleanup checks all sessions and stops idle ones past grace period
+// performCleanup starts always-on streams and stops idle ones past grace period
 func (m *StreamManager) performCleanup() {
+	// Players that went away without unregistering stop counting as clients first
+	m.releaseIdleSessions()
+
+	// Always-on streams are exempt from cleanup; refresh which ones are before looking for idle streams
+	m.syncAlwaysOnChannels(context.Background())
+
 	gracePeriod := time.Duration(m.config.GracePeriodSeconds) * time.Second
 	sessions := m.sessionManager.List()
 
+	// Recently watched streams past their grace period are kept warm instead of stopped
+	warm := m.warmSessions(sessions, gracePeriod)
+
 	stoppedCount := 0
 	for _, session := range sessions {
+		if warm[session.ID] {
+			if !session.IsWarm() {
+				logger.Log.Info().
+					Str("channel_id", session.ChannelID.String()).
+					Dur("idle_duration", session.IdleDuration()).
+					Msg("Keeping idle stream warm")
+				session.SetWarm(true)
+			}
+			continue
+		}
 		if session.ShouldCleanup(gracePeriod) {
 			channelID := session.ChannelID
 			logger.Log.Info().
@@ -1371,6 +1352,7 @@ func (m *StreamManager) monitorFFmpegProcess(channelID uuid.UUID, cmd interface{
 
 		// Classify the error
 		streamErr := ClassifyError(err)
+		recordFFmpegFailure(streamErr)
 
 		// Attempt recovery only if we have active clients
 		ctx := context.Background()
@@ -1468,6 +1450,12 @@ func (m *StreamManager) ensurePlaylistManager(session *models.StreamSession, qua
 	if err != nil {
 		return fmt.Errorf("failed to create playlist manager: %w", err)
 	}
+	// fMP4 encoders publish each segment's fragments as Low-Latency HLS parts
+	if session.GetSegmentFormat() == SegmentFormatFMP4 && m.config.PartDuration > 0 {
+		pm.SetPartTarget(float64(m.config.PartDuration) / 1000)
+	}
+	// Segments leaving the window stay on disk for start-over and catch-up playlists
+	pm.SetRetention(time.Duration(m.config.CatchUpSeconds) * time.Second)
 
 	// Store playlist manager
 	m.playlistManagersMu.Lock()
@@ -1494,6 +1482,14 @@ func (m *StreamManager) ensurePlaylistManager(session *models.StreamSession, qua
 	return nil
 }
 
+// PlaylistManager returns the media playlist manager of a stream's rendition, if it is being generated
+func (m *StreamManager) PlaylistManager(channelID uuid.UUID, quality string) (playlist.Manager, bool) {
+	m.playlistManagersMu.RLock()
+	defer m.playlistManagersMu.RUnlock()
+	pm, ok := m.playlistManagers[fmt.Sprintf("%s_%s", channelID.String(), quality)]
+	return pm, ok
+}
+
 // getPlaylistManager retrieves the playlist manager for a quality
 func (m *StreamManager) getPlaylistManager(session *models.StreamSession, quality string) (playlist.Manager, error) {
 	channelIDStr := session.ChannelID.String()
@@ -1522,7 +1518,8 @@ func (m *StreamManager) generateMasterPlaylist(outputDir string, qualities []mod
 	// Convert StreamQuality to PlaylistVariant
 	variants := make([]PlaylistVariant, 0, len(qualities))
 	for _, q := range qualities {
-		bandwidth, err := GetBandwidthForQuality(q.Level)
+		codec := VideoCodec(q.Codec)
+		bandwidth, err := GetBandwidthForQuality(q.Level, codec)
 		if err != nil {
 			return fmt.Errorf("failed to get bandwidth for quality %s: %w", q.Level, err)
 		}
@@ -1532,9 +1529,15 @@ func (m *StreamManager) generateMasterPlaylist(outputDir string, qualities []mod
 			return fmt.Errorf("failed to get resolution for quality %s: %w", q.Level, err)
 		}
 
+		codecs, err := CodecsForQuality(q.Level, codec)
+		if err != nil {
+			return fmt.Errorf("failed to get codecs for quality %s: %w", q.Level, err)
+		}
+
 		variants = append(variants, PlaylistVariant{
 			Bandwidth:  bandwidth,
 			Resolution: resolution,
+			Codecs:     codecs,
 			Path:       fmt.Sprintf("%s.m3u8", q.Level),
 		})
 	}
@@ -1561,6 +1564,12 @@ func (m *StreamManager) generateMasterPlaylist(outputDir string, qualities []mod
 
 // advanceToNextVideo stops the current stream and starts the next video in the playlist
 func (m *StreamManager) advanceToNextVideo(ctx context.Context, channelID uuid.UUID) error {
+	return m.reloadStream(ctx, channelID, "advancing to next video")
+}
+
+// reloadStream stops a stream and starts it again from the current timeline position,
+// preserving its client count. Used for video transitions and settings changes.
+func (m *StreamManager) reloadStream(ctx context.Context, channelID uuid.UUID, reason string) error {
 	channelIDStr := channelID.String()
 
 	// Get current session to preserve client count
@@ -1573,22 +1582,23 @@ func (m *StreamManager) advanceToNextVideo(ctx context.Context, channelID uuid.U
 
 	logger.Log.Debug().
 		Str("channel_id", channelIDStr).
+		Str("reason", reason).
 		Int("client_count", clientCount).
-		Msg("Advancing to next video in playlist")
+		Msg("Reloading stream")
 
 	// Stop current stream (cleans up FFmpeg process and files)
 	if err := m.StopStream(ctx, channelID); err != nil {
 		logger.Log.Warn().
 			Err(err).
 			Str("channel_id", channelIDStr).
-			Msg("Error stopping stream before advancing (continuing anyway)")
+			Msg("Error stopping stream before reload (continuing anyway)")
 	}
 
 	// Start new stream (timeline service will calculate current position)
 	// This automatically handles looping back to first video if at end
 	newSession, err := m.StartStream(ctx, channelID)
 	if err != nil {
-		return fmt.Errorf("failed to start next video: %w", err)
+		return fmt.Errorf("failed to restart stream (%s): %w", reason, err)
 	}
 
 	// Restore client count from previous session
@@ -1600,8 +1610,9 @@ func (m *StreamManager) advanceToNextVideo(ctx context.Context, channelID uuid.U
 
 	logger.Log.Info().
 		Str("channel_id", channelIDStr).
+		Str("reason", reason).
 		Int("client_count", clientCount).
-		Msg("Successfully advanced to next video")
+		Msg("Successfully reloaded stream")
 
 	return nil
 }
@@ -1,7 +1,12 @@
 package streaming
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
 )
 
 // All unit tests for StreamManager are skipped because they require real database
@@ -59,3 +64,81 @@ func TestStreamManager_DoubleStop(t *testing.T) {
 func TestStreamManager_OperationsAfterStop(t *testing.T) {
 	t.Skip("Requires real database repositories - covered in integration tests")
 }
+
+// idleSessions is an IdleSessionSource handing out a fixed set of sessions once
+type idleSessions map[uuid.UUID][]string
+
+func (s idleSessions) TakeIdleSessions() map[uuid.UUID][]string {
+	taken := make(map[uuid.UUID][]string, len(s))
+	for channelID, sessionIDs := range s {
+		taken[channelID] = sessionIDs
+		delete(s, channelID)
+	}
+	return taken
+}
+
+func TestStreamManager_ReleasesIdleSessions(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{})
+
+	session := models.NewStreamSession(uuid.New())
+	m.sessionManager.Set(session.ChannelID.String(), session)
+	for _, sessionID := range []string{"share-1", "share-2"} {
+		session.RegisterSession(sessionID)
+		session.IncrementClients()
+	}
+
+	// Once the share link's players go idle the stream has no clients and its grace period starts
+	m.SetIdleSessionSource(idleSessions{session.ChannelID: {"share-1", "share-2", "unknown"}})
+	m.releaseIdleSessions()
+	if got := session.GetClientCount(); got != 0 {
+		t.Fatalf("client count = %d after share sessions went idle, want 0", got)
+	}
+	if len(session.RegisteredSessions) != 0 {
+		t.Errorf("registered sessions = %v, want none", session.RegisteredSessions)
+	}
+
+	// Released sessions are not released twice
+	if released, err := m.ReleaseSession(t.Context(), session.ChannelID, "share-1"); released || err != nil {
+		t.Errorf("ReleaseSession() = %v, %v for a released session", released, err)
+	}
+}
+
+func TestStreamManager_AdmissionReservedUntilFirstEncoder(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{MaxConcurrentTranscodes: 1})
+	channelA, channelB := uuid.New().String(), uuid.New().String()
+
+	release, err := m.admitNewStream(t.Context(), Quality1080p)
+	if err != nil {
+		t.Fatalf("first stream not admitted: %v", err)
+	}
+	m.reserveAdmission(channelA, release)
+
+	// The reservation counts until an encoder takes it over, so a concurrent start is refused
+	if _, err := m.admitNewStream(t.Context(), Quality1080p); !errors.Is(err, ErrTranscodeCapacity) {
+		t.Fatalf("second stream: err = %v, want ErrTranscodeCapacity", err)
+	}
+
+	// The first encoder takes the reservation over instead of acquiring its own budget
+	taken, ok := m.takeAdmission(channelA)
+	if !ok {
+		t.Fatal("reservation was not handed to the first encoder")
+	}
+	if _, ok := m.takeAdmission(channelA); ok {
+		t.Error("reservation was handed out twice")
+	}
+	if stats := m.TranscodeBudgetStats(); stats.Running != 1 {
+		t.Errorf("running = %d, want the reservation still held", stats.Running)
+	}
+	taken()
+
+	// A stream stopped before any encoder started gives its reservation back
+	release, err = m.admitNewStream(t.Context(), Quality1080p)
+	if err != nil {
+		t.Fatalf("stream not admitted after release: %v", err)
+	}
+	m.reserveAdmission(channelB, release)
+	m.releaseAdmission(channelB)
+	if stats := m.TranscodeBudgetStats(); stats.Running != 0 {
+		t.Errorf("running = %d, want the reservation given back", stats.Running)
+	}
+}
@@ -11,6 +11,9 @@ import (
 // TriggerFirstBatchForTest triggers the first batch generation for testing purposes.
 // This is a test helper that allows integration tests to manually trigger the first batch
 // since the batch coordinator doesn't automatically handle nil batch cases.
+// The top rendition is requested first, as a player fetching its media playlist would.
 func TriggerFirstBatchForTest(manager *StreamManager, session *models.StreamSession) error {
+	session.RequestRendition(sessionQuality(session))
+	manager.syncRenditions(session)
 	return manager.generateNextBatch(context.Background(), session)
 }
@@ -0,0 +1,183 @@
+package streaming
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stwalsh4118/hermes/internal/metrics"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+)
+
+// playlistStaleSegments is how many segment durations a playlist may go without a write beyond
+// the pause between batches before it is reported unhealthy
+const playlistStaleSegments = 3
+
+var (
+	activeStreamsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "stream", "active"),
+		"Number of active stream sessions.",
+		nil, nil,
+	)
+	streamClientsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "stream", "clients"),
+		"Registered clients per channel.",
+		[]string{"channel_id"}, nil,
+	)
+	circuitBreakerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "stream", "circuit_breaker_state"),
+		"Circuit breaker state per channel (0 closed, 1 open, 2 half-open).",
+		[]string{"channel_id"}, nil,
+	)
+	playlistSinceWriteDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "playlist", "seconds_since_write"),
+		"Seconds since the playlist was last written successfully (0 if never written).",
+		[]string{"channel_id", "quality"}, nil,
+	)
+	playlistHealthyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "playlist", "healthy"),
+		"Whether the playlist was written within the stale threshold (1 healthy, 0 stale).",
+		[]string{"channel_id", "quality"}, nil,
+	)
+	segmentDirBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "stream", "segment_directory_bytes"),
+		"Total size of files in the segment directory.",
+		nil, nil,
+	)
+	transcodeBudgetCapacityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "transcode", "budget_capacity"),
+		"Transcode budget capacity (0 means unlimited).",
+		nil, nil,
+	)
+	transcodeBudgetInUseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "transcode", "budget_in_use"),
+		"Transcode budget used by running FFmpeg processes.",
+		nil, nil,
+	)
+	transcodeRunningDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "transcode", "running"),
+		"Number of running FFmpeg segment processes.",
+		nil, nil,
+	)
+	transcodeQueuedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "transcode", "queued"),
+		"Number of FFmpeg segment processes waiting for transcode budget.",
+		nil, nil,
+	)
+)
+
+// streamCollector reads stream manager state at scrape time
+type streamCollector struct {
+	manager *StreamManager
+}
+
+// Collector returns a Prometheus collector exposing the manager's live stream state
+func (m *StreamManager) Collector() prometheus.Collector {
+	return &streamCollector{manager: m}
+}
+
+// Describe implements prometheus.Collector
+func (c *streamCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeStreamsDesc
+	ch <- streamClientsDesc
+	ch <- circuitBreakerStateDesc
+	ch <- playlistSinceWriteDesc
+	ch <- playlistHealthyDesc
+	ch <- segmentDirBytesDesc
+	ch <- transcodeBudgetCapacityDesc
+	ch <- transcodeBudgetInUseDesc
+	ch <- transcodeRunningDesc
+	ch <- transcodeQueuedDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *streamCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.manager
+
+	sessions := m.sessionManager.List()
+	ch <- prometheus.MustNewConstMetric(activeStreamsDesc, prometheus.GaugeValue, float64(len(sessions)))
+	for _, session := range sessions {
+		ch <- prometheus.MustNewConstMetric(streamClientsDesc, prometheus.GaugeValue,
+			float64(session.GetClientCount()), session.ChannelID.String())
+	}
+
+	for channelID, cb := range m.sessionManager.CircuitBreakers() {
+		ch <- prometheus.MustNewConstMetric(circuitBreakerStateDesc, prometheus.GaugeValue,
+			float64(cb.GetState()), channelID)
+	}
+
+	staleThreshold := m.playlistStaleThreshold()
+	for key, pm := range m.playlistManagersSnapshot() {
+		channelID, quality := splitPlaylistManagerKey(key)
+		status := pm.HealthCheck(staleThreshold)
+
+		healthy := 0.0
+		if status.Healthy {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(playlistSinceWriteDesc, prometheus.GaugeValue,
+			status.TimeSinceLastWrite.Seconds(), channelID, quality)
+		ch <- prometheus.MustNewConstMetric(playlistHealthyDesc, prometheus.GaugeValue,
+			healthy, channelID, quality)
+	}
+
+	ch <- prometheus.MustNewConstMetric(segmentDirBytesDesc, prometheus.GaugeValue,
+		float64(directorySize(m.config.SegmentPath)))
+
+	budget := m.budget.Stats()
+	ch <- prometheus.MustNewConstMetric(transcodeBudgetCapacityDesc, prometheus.GaugeValue, budget.Capacity)
+	ch <- prometheus.MustNewConstMetric(transcodeBudgetInUseDesc, prometheus.GaugeValue, budget.InUse)
+	ch <- prometheus.MustNewConstMetric(transcodeRunningDesc, prometheus.GaugeValue, float64(budget.Running))
+	ch <- prometheus.MustNewConstMetric(transcodeQueuedDesc, prometheus.GaugeValue, float64(budget.Queued))
+}
+
+// playlistManagersSnapshot returns a copy of the playlist manager map
+func (m *StreamManager) playlistManagersSnapshot() map[string]playlist.Manager {
+	m.playlistManagersMu.RLock()
+	defer m.playlistManagersMu.RUnlock()
+
+	managers := make(map[string]playlist.Manager, len(m.playlistManagers))
+	for key, pm := range m.playlistManagers {
+		managers[key] = pm
+	}
+	return managers
+}
+
+// splitPlaylistManagerKey splits a "channelID_quality" key into its parts
+func splitPlaylistManagerKey(key string) (channelID, quality string) {
+	idx := strings.LastIndex(key, "_")
+	if idx < 0 {
+		return key, ""
+	}
+	return key[:idx], key[idx+1:]
+}
+
+// directorySize sums the size of all regular files under dir; unreadable entries are skipped
+func directorySize(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error { // nolint:errcheck // best-effort gauge
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// observeSegmentGeneration records segment generation latency and realtime ratio
+func observeSegmentGeneration(quality string, hwAccel HardwareAccel, generationTime time.Duration, ratio float64) {
+	metrics.SegmentGenerationSeconds.WithLabelValues(quality, hwAccel.String()).Observe(generationTime.Seconds())
+	metrics.SegmentRealtimeRatio.WithLabelValues(quality, hwAccel.String()).Observe(ratio)
+}
+
+// recordFFmpegFailure counts an FFmpeg failure by its classified error type
+func recordFFmpegFailure(err error) {
+	metrics.FFmpegFailuresTotal.WithLabelValues(ClassifyError(err).Type.String()).Inc()
+}
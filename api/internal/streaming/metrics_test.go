@@ -0,0 +1,74 @@
+package streaming
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestStreamCollector(t *testing.T) {
+	segmentDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(segmentDir, "seg-1.ts"), make([]byte, 1024), 0o644); err != nil {
+		t.Fatalf("failed to write segment: %v", err)
+	}
+
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		SegmentPath:             segmentDir,
+		StreamSegmentDuration:   4,
+		MaxConcurrentTranscodes: 2,
+	})
+
+	channelID := uuid.New()
+	session := models.NewStreamSession(channelID)
+	session.IncrementClients()
+	session.IncrementClients()
+	m.sessionManager.Set(channelID.String(), session)
+
+	cb := m.sessionManager.GetOrCreateCircuitBreaker(channelID.String())
+	for i := 0; i < CircuitBreakerThreshold; i++ {
+		cb.RecordFailure()
+	}
+
+	expected := `
+# HELP hermes_stream_active Number of active stream sessions.
+# TYPE hermes_stream_active gauge
+hermes_stream_active 1
+# HELP hermes_stream_clients Registered clients per channel.
+# TYPE hermes_stream_clients gauge
+hermes_stream_clients{channel_id="` + channelID.String() + `"} 2
+# HELP hermes_stream_circuit_breaker_state Circuit breaker state per channel (0 closed, 1 open, 2 half-open).
+# TYPE hermes_stream_circuit_breaker_state gauge
+hermes_stream_circuit_breaker_state{channel_id="` + channelID.String() + `"} 1
+# HELP hermes_stream_segment_directory_bytes Total size of files in the segment directory.
+# TYPE hermes_stream_segment_directory_bytes gauge
+hermes_stream_segment_directory_bytes 1024
+# HELP hermes_transcode_budget_capacity Transcode budget capacity (0 means unlimited).
+# TYPE hermes_transcode_budget_capacity gauge
+hermes_transcode_budget_capacity 2
+# HELP hermes_transcode_budget_in_use Transcode budget used by running FFmpeg processes.
+# TYPE hermes_transcode_budget_in_use gauge
+hermes_transcode_budget_in_use 0
+# HELP hermes_transcode_queued Number of FFmpeg segment processes waiting for transcode budget.
+# TYPE hermes_transcode_queued gauge
+hermes_transcode_queued 0
+# HELP hermes_transcode_running Number of running FFmpeg segment processes.
+# TYPE hermes_transcode_running gauge
+hermes_transcode_running 0
+`
+	if err := testutil.CollectAndCompare(m.Collector(), strings.NewReader(expected)); err != nil {
+		t.Errorf("unexpected collector output: %v", err)
+	}
+}
+
+func TestSplitPlaylistManagerKey(t *testing.T) {
+	channelID, quality := splitPlaylistManagerKey("0b6f6c1e-2f4a-4c41-9d55-7a0e1b2c3d4e_720p")
+	if channelID != "0b6f6c1e-2f4a-4c41-9d55-7a0e1b2c3d4e" || quality != "720p" {
+		t.Errorf("splitPlaylistManagerKey() = %q, %q", channelID, quality)
+	}
+}
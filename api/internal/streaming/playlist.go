@@ -47,6 +47,7 @@ type MasterPlaylist struct {
 type PlaylistVariant struct {
 	Bandwidth  int    // Video + audio bitrate in bits per second
 	Resolution string // Format: "1920x1080"
+	Codecs     string // RFC 6381 codecs, e.g. "avc1.640028,mp4a.40.2" (optional)
 	Path       string // Relative path to media playlist
 }
 
@@ -99,8 +100,12 @@ func GenerateMasterPlaylist(variants []PlaylistVariant) (string, error) {
 
 	// Write each variant
 	for _, variant := range variants {
-		builder.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%s\n",
+		builder.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%s",
 			variant.Bandwidth, variant.Resolution))
+		if variant.Codecs != "" {
+			builder.WriteString(fmt.Sprintf(",CODECS=\"%s\"", variant.Codecs))
+		}
+		builder.WriteString("\n")
 		builder.WriteString(fmt.Sprintf("%s\n", variant.Path))
 	}
 
@@ -415,23 +420,20 @@ func isValidResolution(resolution string) bool {
 	return err1 == nil && err2 == nil && width > 0 && height > 0
 }
 
-// GetBandwidthForQuality returns the bandwidth in bps for a quality level
-func GetBandwidthForQuality(quality string) (int, error) {
-	switch quality {
-	case Quality1080p:
-		return (bitrate1080p + audioBitrate) * 1000, nil // Convert kbps to bps
-	case Quality720p:
-		return (bitrate720p + audioBitrate) * 1000, nil
-	case Quality480p:
-		return (bitrate480p + audioBitrate) * 1000, nil
-	default:
-		return 0, fmt.Errorf("%w: %s", ErrInvalidQuality, quality)
+// GetBandwidthForQuality returns the bandwidth in bps for a quality level encoded with a video codec
+func GetBandwidthForQuality(quality string, codec VideoCodec) (int, error) {
+	spec, err := getQualitySpec(quality, codec)
+	if err != nil {
+		return 0, err
 	}
+	return (spec.videoBitrate + audioBitrate) * 1000, nil // Convert kbps to bps
 }
 
 // GetResolutionForQuality returns the resolution string for a quality level
 func GetResolutionForQuality(quality string) (string, error) {
 	switch quality {
+	case Quality2160p:
+		return resolution2160p, nil
 	case Quality1080p:
 		return resolution1080p, nil
 	case Quality720p:
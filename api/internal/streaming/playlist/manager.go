@@ -2,6 +2,8 @@
 package playlist
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -15,12 +17,36 @@ import (
 
 // SegmentMeta contains metadata for a single HLS segment
 type SegmentMeta struct {
-	URI             string     // Segment filename (e.g., "seg-20250111T120000.ts")
-	Duration        float64    // Segment duration in seconds (typically 4.0)
-	ProgramDateTime *time.Time // Optional program date-time
-	Discontinuity   bool       // Whether to insert discontinuity before this segment
+	URI             string     `json:"uri"`                         // Segment filename (e.g., "seg-20250111T120000.ts")
+	Duration        float64    `json:"duration"`                    // Segment duration in seconds (typically 4.0)
+	ProgramDateTime *time.Time `json:"program_date_time,omitempty"` // Optional program date-time
+	Discontinuity   bool       `json:"discontinuity,omitempty"`     // Whether to insert discontinuity before this segment
+	Map             string     `json:"map,omitempty"`               // Init segment URI for fMP4 segments (e.g., "init-0.mp4"); empty for MPEG-TS
+	Parts           []PartMeta `json:"parts,omitempty"`             // Low-Latency HLS parts the segment was published as (set by AddSegment)
+	KeyURI          string     `json:"key_uri,omitempty"`           // AES-128 key URI the segment is encrypted with; empty if unencrypted
+	KeyIV           string     `json:"key_iv,omitempty"`            // Hexadecimal IV the segment is encrypted with (e.g., "0x0f1e..."); empty to use its media sequence number
 }
 
+// PartMeta contains metadata for a Low-Latency HLS partial segment.
+// Parts are byte ranges of their parent segment's file, published while the segment is still being written.
+type PartMeta struct {
+	URI         string  `json:"uri"`                   // Parent segment filename (e.g., "seg-000012.m4s")
+	Duration    float64 `json:"duration"`              // Part duration in seconds
+	ByteStart   int64   `json:"byte_start"`            // Offset of the part within URI
+	ByteLength  int64   `json:"byte_length"`           // Length of the part in bytes
+	Independent bool    `json:"independent,omitempty"` // Whether the part starts with an independent frame
+}
+
+// PreloadHint is the next part players may request before it is complete (EXT-X-PRELOAD-HINT)
+type PreloadHint struct {
+	URI       string // Segment filename the part will be written to
+	ByteStart int64  // Offset the part will start at
+}
+
+// ErrSequenceTooFar is returned by WaitForPlaylist for a segment more than two segments past the
+// end of the playlist; blocking reload requests for it are rejected instead of held
+var ErrSequenceTooFar = errors.New("media sequence number is too far beyond the end of the playlist")
+
 // HealthStatus represents the health status of a playlist manager
 type HealthStatus struct {
 	Healthy            bool          // Whether the playlist is healthy
@@ -40,6 +66,25 @@ type Manager interface {
 	Write() error
 	Close() error
 	GetCurrentSegments() []string
+	// GetSegments returns the segments currently in the playlist window, oldest first.
+	// The first segment's sequence number is GetMediaSequence().
+	GetSegments() []SegmentMeta
+	// GetWindow returns the media sequence number and the segments of the playlist window together,
+	// so they cannot be split by a segment added in between
+	GetWindow() (uint64, []SegmentMeta)
+	// SetRetention keeps segments pruned from the window for retention, measured by program date-time,
+	// before AddSegment returns them for deletion, so catch-up playlists can list them (0 disables it)
+	SetRetention(retention time.Duration)
+	// GetRetained returns the retained segments followed by the playlist window, and the media
+	// sequence number of the first one
+	GetRetained() (uint64, []SegmentMeta)
+	// GetRange returns the retained and window segments whose program date-time is in [from, to),
+	// and the media sequence number of the first one
+	GetRange(from, to time.Time) (uint64, []SegmentMeta)
+	// HasSegment reports whether a retained or window segment has the given URI, or uses it as its
+	// init segment (EXT-X-MAP). Files are only listed once complete (and encrypted), so callers can
+	// tell published segments from ones still being written.
+	HasSegment(uri string) bool
 	GetLastSuccessfulWrite() *time.Time
 	GetWindowSize() uint
 	GetMaxDuration() float64
@@ -49,10 +94,31 @@ type Manager interface {
 	// from the front of the playlist (when windowSize > 0).
 	// For VOD/EVENT mode (windowSize == 0), media sequence stays at 0.
 	GetMediaSequence() uint64
+	// SetMediaSequence sets the media sequence number of the first segment.
+	// Renditions that join a stream late use it to stay aligned with the other renditions.
+	// Must be called before any segment is added.
+	SetMediaSequence(seq uint64)
 	// GetSegmentCount returns the total number of segments currently in the playlist.
 	// This is the length of the segments slice, which may be less than totalSegments
 	// if segments have been pruned in sliding window mode.
 	GetSegmentCount() uint
+	// SetPartTarget enables Low-Latency HLS with the given part target duration in seconds (0 disables it).
+	// The playlist then advertises blocking reload (EXT-X-SERVER-CONTROL), lists the parts of recent
+	// segments and of the segment being written, and hints the next part (EXT-X-PRELOAD-HINT).
+	SetPartTarget(seconds float64)
+	// GetPartTarget returns the part target duration in seconds (0 when Low-Latency HLS is disabled)
+	GetPartTarget() float64
+	// AddPart adds a completed part of the segment being written, i.e. the segment after the last one added.
+	// AddSegment attaches the pending parts to that segment.
+	AddPart(part PartMeta) error
+	// SetPreloadHint sets the part players may request next
+	SetPreloadHint(hint PreloadHint)
+	// WaitForPlaylist blocks until the playlist written to disk contains segment msn, or part `part` of
+	// it when part >= 0 (blocking playlist reload). Returns ErrSequenceTooFar for a segment more than
+	// two past the last one, or the context's error.
+	WaitForPlaylist(ctx context.Context, msn uint64, part int) error
+	// WaitForPart blocks until the part of uri starting at byteStart is complete and returns it
+	WaitForPart(ctx context.Context, uri string, byteStart int64) (PartMeta, error)
 }
 
 // playlistManager implements Manager using simple Go data structures.
@@ -103,8 +169,27 @@ type playlistManager struct {
 	// Used for sequence number calculation: mediaSequence = totalSegments - len(segments)
 	totalSegments uint64
 
+	// Catch-up retention (retention == 0 disables it): segments pruned from the window, oldest first,
+	// kept until they are older than retention. They precede the window, so the first one's
+	// sequence number is mediaSequence - len(retained).
+	retention time.Duration
+	retained  []SegmentMeta
+
 	discontinuityNext   bool       // Flag to insert discontinuity tag before next segment
 	lastSuccessfulWrite *time.Time // Timestamp of last successful playlist write (for health checks)
+
+	// Low-Latency HLS state (partTarget == 0 disables it)
+	partTarget      float64      // Configured part target duration in seconds
+	maxPartDuration float64      // Maximum observed part duration (PART-TARGET never understates it)
+	pendingParts    []PartMeta   // Parts of the segment being written
+	preloadHint     *PreloadHint // Next part players may request
+
+	// writtenEnd and writtenParts describe the playlist last written to disk: the sequence number
+	// after its last segment and how many parts of that next segment it lists. Blocking reloads
+	// wait on them rather than on the in-memory state, which may not be on disk yet.
+	writtenEnd   uint64
+	writtenParts int
+	changed      chan struct{} // Closed and replaced when parts are added or the playlist is written
 }
 
 // NewManager creates a new playlist manager instance.
@@ -139,11 +224,13 @@ func NewManager(windowSize uint, outputPath string, initialTargetDuration float6
 		totalSegments:       0, // No segments added yet
 		discontinuityNext:   false,
 		lastSuccessfulWrite: nil, // No write has occurred yet
+		changed:             make(chan struct{}),
 	}, nil
 }
 
 // AddSegment adds a new segment to the playlist.
-// Returns a list of segment URIs that were pruned (for file deletion).
+// Returns a list of segment URIs that were pruned (for file deletion), including init segments
+// no remaining segment refers to.
 // When windowSize > 0 and len(segments) >= windowSize, oldest segments are pruned from front.
 func (pm *playlistManager) AddSegment(seg SegmentMeta) ([]string, error) {
 	pm.mu.Lock()
@@ -171,6 +258,15 @@ func (pm *playlistManager) AddSegment(seg SegmentMeta) ([]string, error) {
 		pm.discontinuityNext = false
 	}
 
+	// The segment's pending parts are complete now; it is published as a whole from here on
+	if len(pm.pendingParts) > 0 && pm.pendingParts[0].URI == seg.URI && seg.Parts == nil {
+		seg.Parts = pm.pendingParts
+	}
+	pm.pendingParts = nil
+	if pm.preloadHint != nil && pm.preloadHint.URI == seg.URI {
+		pm.preloadHint = nil
+	}
+
 	// Initialize pruned URIs slice
 	prunedURIs := []string{}
 
@@ -180,14 +276,36 @@ func (pm *playlistManager) AddSegment(seg SegmentMeta) ([]string, error) {
 		// If we have windowSize segments, we need to prune 1 to add the new one
 		segmentsToPrune := len(pm.segments) - int(pm.windowSize) + 1
 
-		// Collect URIs of segments to be pruned (from front of slice)
-		for i := 0; i < segmentsToPrune; i++ {
-			prunedURIs = append(prunedURIs, pm.segments[i].URI)
-		}
-
 		// Prune segments from front
+		pruned := pm.segments[:segmentsToPrune]
 		pm.segments = pm.segments[segmentsToPrune:]
 
+		// Segments within the retention window stay on disk for catch-up; only expired ones are deleted
+		if pm.retention > 0 {
+			pm.retained = append(pm.retained, pruned...)
+			pruned = pm.expireRetainedLocked(seg)
+		}
+
+		// Collect URIs of segments to be deleted
+		for _, old := range pruned {
+			prunedURIs = append(prunedURIs, old.URI)
+		}
+
+		// Init segments are shared by consecutive segments; prune one once nothing refers to it
+		stillUsed := seg.Map
+		switch {
+		case len(pm.retained) > 0:
+			stillUsed = pm.retained[0].Map
+		case len(pm.segments) > 0:
+			stillUsed = pm.segments[0].Map
+		}
+		for i, old := range pruned {
+			lastUse := i+1 == len(pruned) || pruned[i+1].Map != old.Map
+			if old.Map != "" && old.Map != stillUsed && lastUse {
+				prunedURIs = append(prunedURIs, old.Map)
+			}
+		}
+
 		// Increment mediaSequence by number of segments pruned
 		pm.mediaSequence += uint64(segmentsToPrune)
 	}
@@ -209,6 +327,27 @@ func (pm *playlistManager) AddSegment(seg SegmentMeta) ([]string, error) {
 	return prunedURIs, nil
 }
 
+// expireRetainedLocked removes and returns the retained segments that are more than the retention
+// older than newest. The caller holds pm.mu.
+func (pm *playlistManager) expireRetainedLocked(newest SegmentMeta) []SegmentMeta {
+	now := time.Now()
+	if newest.ProgramDateTime != nil {
+		now = *newest.ProgramDateTime
+	}
+
+	n := 0
+	for n < len(pm.retained) {
+		programDateTime := pm.retained[n].ProgramDateTime
+		if programDateTime != nil && now.Sub(*programDateTime) <= pm.retention {
+			break
+		}
+		n++
+	}
+	expired := pm.retained[:n]
+	pm.retained = pm.retained[n:]
+	return expired
+}
+
 // SetDiscontinuityNext flags the next segment to have a discontinuity tag
 func (pm *playlistManager) SetDiscontinuityNext() {
 	pm.mu.Lock()
@@ -233,6 +372,14 @@ func (pm *playlistManager) Write() error {
 	mediaSequence := pm.mediaSequence
 	maxDuration := pm.maxDuration
 	windowSize := pm.windowSize
+	partTarget := math.Max(pm.partTarget, pm.maxPartDuration)
+	lowLatency := pm.partTarget > 0
+	pendingParts := append([]PartMeta(nil), pm.pendingParts...)
+	var preloadHint *PreloadHint
+	if pm.preloadHint != nil {
+		hint := *pm.preloadHint
+		preloadHint = &hint
+	}
 	pm.mu.RUnlock()
 
 	// Generate playlist content using strings.Builder
@@ -240,7 +387,7 @@ func (pm *playlistManager) Write() error {
 
 	// Write header
 	builder.WriteString("#EXTM3U\n")
-	builder.WriteString("#EXT-X-VERSION:3\n")
+	builder.WriteString(fmt.Sprintf("#EXT-X-VERSION:%d\n", playlistVersion(segments)))
 
 	// Write media sequence
 	builder.WriteString(fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", mediaSequence))
@@ -249,24 +396,25 @@ func (pm *playlistManager) Write() error {
 	targetDuration := uint(math.Ceil(maxDuration))
 	builder.WriteString(fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", targetDuration))
 
-	// Write each segment
-	for _, seg := range segments {
-		// Write discontinuity tag if set
-		if seg.Discontinuity {
-			builder.WriteString("#EXT-X-DISCONTINUITY\n")
-		}
+	// Low-Latency HLS: players may block on reloads and start PART-HOLD-BACK from the live edge
+	// (three part targets, rather than three full segments)
+	partsFrom := len(segments)
+	if lowLatency {
+		builder.WriteString(fmt.Sprintf("#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", 3*partTarget))
+		builder.WriteString(fmt.Sprintf("#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget))
+		partsFrom = recentPartsStart(segments, 3*float64(targetDuration))
+	}
 
-		// Write program date-time if present
-		// Format as ISO-8601: YYYY-MM-DDTHH:MM:SSZ (e.g., 2025-11-14T01:05:12Z)
-		if seg.ProgramDateTime != nil {
-			builder.WriteString(fmt.Sprintf("#EXT-X-PROGRAM-DATE-TIME:%s\n", seg.ProgramDateTime.UTC().Format("2006-01-02T15:04:05Z")))
+	// Write each segment
+	writeSegments(&builder, segments, partsFrom)
+
+	// The segment being written: its completed parts and a hint for the next one.
+	// They share the last segment's init segment, since only an encoder's first segment starts a new one.
+	if lowLatency {
+		writeParts(&builder, pendingParts)
+		if preloadHint != nil {
+			builder.WriteString(fmt.Sprintf("#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\",BYTERANGE-START=%d\n", preloadHint.URI, preloadHint.ByteStart))
 		}
-
-		// Write EXTINF tag with duration (3 decimal places)
-		builder.WriteString(fmt.Sprintf("#EXTINF:%.3f,\n", seg.Duration))
-
-		// Write segment URI
-		builder.WriteString(fmt.Sprintf("%s\n", seg.URI))
 	}
 
 	// Write ENDLIST tag only in VOD/EVENT mode (windowSize == 0)
@@ -324,10 +472,16 @@ func (pm *playlistManager) Write() error {
 	// Calculate latency
 	latency := time.Since(startTime)
 
-	// Update last successful write timestamp (acquire write lock)
+	// Update last successful write timestamp and wake blocking reloads (acquire write lock)
 	pm.mu.Lock()
 	now := time.Now()
 	pm.lastSuccessfulWrite = &now
+	end := mediaSequence + uint64(len(segments))
+	if end > pm.writtenEnd || (end == pm.writtenEnd && len(pendingParts) > pm.writtenParts) {
+		pm.writtenEnd = end
+		pm.writtenParts = len(pendingParts)
+	}
+	pm.notifyLocked()
 	pm.mu.Unlock()
 
 	// Log write operation with observability metrics
@@ -343,6 +497,116 @@ func (pm *playlistManager) Write() error {
 	return nil
 }
 
+// playlistVersion returns the HLS version segments need: 7 for fMP4 segments (EXT-X-MAP), 3 otherwise
+func playlistVersion(segments []SegmentMeta) int {
+	for _, seg := range segments {
+		if seg.Map != "" {
+			return 7
+		}
+	}
+	return 3
+}
+
+// writeSegments writes the tags and URIs of segments, listing the parts of those from index partsFrom on
+func writeSegments(builder *strings.Builder, segments []SegmentMeta, partsFrom int) {
+	currentMap := ""
+	currentKey := ""
+	currentIV := ""
+	for i, seg := range segments {
+		// Write discontinuity tag if set
+		if seg.Discontinuity {
+			builder.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+
+		// Write the key whenever it rotates or the segment has its own IV. Without an IV
+		// attribute players use the media sequence number.
+		if seg.KeyURI != currentKey || seg.KeyIV != currentIV {
+			switch {
+			case seg.KeyURI == "":
+				builder.WriteString("#EXT-X-KEY:METHOD=NONE\n")
+			case seg.KeyIV != "":
+				builder.WriteString(fmt.Sprintf("#EXT-X-KEY:METHOD=AES-128,URI=\"%s\",IV=%s\n", seg.KeyURI, seg.KeyIV))
+			default:
+				builder.WriteString(fmt.Sprintf("#EXT-X-KEY:METHOD=AES-128,URI=\"%s\"\n", seg.KeyURI))
+			}
+			currentKey = seg.KeyURI
+			currentIV = seg.KeyIV
+		}
+
+		// Write the init segment whenever it changes (always before the first fMP4 segment)
+		if seg.Map != "" && seg.Map != currentMap {
+			builder.WriteString(fmt.Sprintf("#EXT-X-MAP:URI=\"%s\"\n", seg.Map))
+			currentMap = seg.Map
+		}
+
+		// Write program date-time if present
+		// Format as ISO-8601: YYYY-MM-DDTHH:MM:SSZ (e.g., 2025-11-14T01:05:12Z)
+		if seg.ProgramDateTime != nil {
+			builder.WriteString(fmt.Sprintf("#EXT-X-PROGRAM-DATE-TIME:%s\n", seg.ProgramDateTime.UTC().Format("2006-01-02T15:04:05Z")))
+		}
+
+		// Parts are only listed for segments near the live edge
+		if i >= partsFrom {
+			writeParts(builder, seg.Parts)
+		}
+
+		// Write EXTINF tag with duration (3 decimal places)
+		builder.WriteString(fmt.Sprintf("#EXTINF:%.3f,\n", seg.Duration))
+
+		// Write segment URI
+		builder.WriteString(fmt.Sprintf("%s\n", seg.URI))
+	}
+}
+
+// RenderCatchUp renders segments kept for catch-up, numbered from mediaSequence, as an EVENT playlist
+// that players reload while it grows, or as a VOD playlist once ended (nothing will be added to it)
+func RenderCatchUp(mediaSequence uint64, segments []SegmentMeta, ended bool) string {
+	maxDuration := 0.0
+	for _, seg := range segments {
+		maxDuration = math.Max(maxDuration, seg.Duration)
+	}
+	playlistType := "EVENT"
+	if ended {
+		playlistType = "VOD"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("#EXTM3U\n")
+	builder.WriteString(fmt.Sprintf("#EXT-X-VERSION:%d\n", playlistVersion(segments)))
+	builder.WriteString(fmt.Sprintf("#EXT-X-PLAYLIST-TYPE:%s\n", playlistType))
+	builder.WriteString(fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", mediaSequence))
+	builder.WriteString(fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", uint(math.Ceil(maxDuration))))
+	writeSegments(&builder, segments, len(segments))
+	if ended {
+		builder.WriteString("#EXT-X-ENDLIST\n")
+	}
+	return builder.String()
+}
+
+// recentPartsStart returns the index of the first segment whose parts are listed: parts are
+// dropped once a segment is more than window seconds from the end of the playlist
+func recentPartsStart(segments []SegmentMeta, window float64) int {
+	elapsed := 0.0
+	for i := len(segments) - 1; i >= 0; i-- {
+		elapsed += segments[i].Duration
+		if elapsed > window {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// writeParts writes an EXT-X-PART tag for each part
+func writeParts(builder *strings.Builder, parts []PartMeta) {
+	for _, part := range parts {
+		builder.WriteString(fmt.Sprintf("#EXT-X-PART:DURATION=%.3f,URI=\"%s\",BYTERANGE=\"%d@%d\"", part.Duration, part.URI, part.ByteLength, part.ByteStart))
+		if part.Independent {
+			builder.WriteString(",INDEPENDENT=YES")
+		}
+		builder.WriteString("\n")
+	}
+}
+
 // Close performs final write and cleanup
 func (pm *playlistManager) Close() error {
 	// Final write before closing
@@ -378,7 +642,69 @@ func (pm *playlistManager) GetCurrentSegments() []string {
 	return segments
 }
 
+// GetSegments returns the segments currently in the playlist window.
+// Returns a copy to avoid race conditions.
+func (pm *playlistManager) GetSegments() []SegmentMeta {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	segments := make([]SegmentMeta, len(pm.segments))
+	copy(segments, pm.segments)
+	return segments
+}
+
+// GetWindow returns the media sequence number and a copy of the segments in the playlist window
+func (pm *playlistManager) GetWindow() (uint64, []SegmentMeta) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	segments := make([]SegmentMeta, len(pm.segments))
+	copy(segments, pm.segments)
+	return pm.mediaSequence, segments
+}
+
 // GetLastSuccessfulWrite returns the timestamp of the last successful playlist write
+func (pm *playlistManager) SetRetention(retention time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.retention = retention
+}
+
+func (pm *playlistManager) GetRetained() (uint64, []SegmentMeta) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	segments := make([]SegmentMeta, 0, len(pm.retained)+len(pm.segments))
+	segments = append(segments, pm.retained...)
+	segments = append(segments, pm.segments...)
+	return pm.mediaSequence - uint64(len(pm.retained)), segments
+}
+
+func (pm *playlistManager) HasSegment(uri string) bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	for _, segments := range [][]SegmentMeta{pm.retained, pm.segments} {
+		for _, seg := range segments {
+			if seg.URI == uri || seg.Map == uri {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (pm *playlistManager) GetRange(from, to time.Time) (uint64, []SegmentMeta) {
+	first, segments := pm.GetRetained()
+	start := 0
+	for start < len(segments) && (segments[start].ProgramDateTime == nil || segments[start].ProgramDateTime.Before(from)) {
+		start++
+	}
+	end := start
+	for end < len(segments) && segments[end].ProgramDateTime != nil && segments[end].ProgramDateTime.Before(to) {
+		end++
+	}
+	return first + uint64(start), segments[start:end]
+}
+
 func (pm *playlistManager) GetLastSuccessfulWrite() *time.Time {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
@@ -435,6 +761,18 @@ func (pm *playlistManager) GetMediaSequence() uint64 {
 	return pm.mediaSequence
 }
 
+// SetMediaSequence sets the media sequence number of the first segment.
+// It is ignored once segments have been added, since the sequence then tracks pruning.
+func (pm *playlistManager) SetMediaSequence(seq uint64) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.totalSegments > 0 {
+		return
+	}
+	pm.mediaSequence = seq
+}
+
 // GetSegmentCount returns the total number of segments currently in the playlist.
 // This is the length of the segments slice, which may be less than totalSegments
 // if segments have been pruned in sliding window mode.
@@ -443,3 +781,134 @@ func (pm *playlistManager) GetSegmentCount() uint {
 	defer pm.mu.RUnlock()
 	return uint(len(pm.segments))
 }
+
+// SetPartTarget enables Low-Latency HLS with the given part target duration in seconds (0 disables it)
+func (pm *playlistManager) SetPartTarget(seconds float64) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.partTarget = math.Max(seconds, 0)
+}
+
+// GetPartTarget returns the part target duration in seconds (0 when Low-Latency HLS is disabled)
+func (pm *playlistManager) GetPartTarget() float64 {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.partTarget
+}
+
+// AddPart adds a completed part of the segment being written.
+// A part of a different segment than the pending ones replaces them (that segment was never completed).
+func (pm *playlistManager) AddPart(part PartMeta) error {
+	if part.URI == "" {
+		return fmt.Errorf("part URI cannot be empty")
+	}
+	if part.Duration <= 0 {
+		return fmt.Errorf("part duration must be greater than 0")
+	}
+	if part.ByteStart < 0 || part.ByteLength <= 0 {
+		return fmt.Errorf("part byte range must be non-empty")
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if len(pm.pendingParts) > 0 && pm.pendingParts[0].URI != part.URI {
+		pm.pendingParts = nil
+	}
+	pm.pendingParts = append(pm.pendingParts, part)
+	if part.Duration > pm.maxPartDuration {
+		pm.maxPartDuration = part.Duration
+	}
+	pm.notifyLocked()
+
+	logger.Log.Debug().
+		Str("segment_uri", part.URI).
+		Int64("byte_start", part.ByteStart).
+		Int64("byte_length", part.ByteLength).
+		Float64("duration", part.Duration).
+		Int("pending_parts", len(pm.pendingParts)).
+		Msg("Part added to playlist")
+
+	return nil
+}
+
+// SetPreloadHint sets the part players may request next
+func (pm *playlistManager) SetPreloadHint(hint PreloadHint) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.preloadHint = &hint
+}
+
+// WaitForPlaylist blocks until the playlist written to disk contains segment msn, or part `part`
+// of it when part >= 0
+func (pm *playlistManager) WaitForPlaylist(ctx context.Context, msn uint64, part int) error {
+	for {
+		pm.mu.RLock()
+		written, writtenParts, changed := pm.writtenEnd, pm.writtenParts, pm.changed
+		end := max(written, pm.mediaSequence+uint64(len(pm.segments)))
+		pm.mu.RUnlock()
+
+		// end is the segment being written; requests more than two segments past the last one are rejected
+		if msn > end+1 {
+			return fmt.Errorf("%w: requested %d, playlist ends before %d", ErrSequenceTooFar, msn, end)
+		}
+		if msn < written || (part >= 0 && msn == written && part < writtenParts) {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WaitForPart blocks until the part of uri starting at byteStart is complete and returns it.
+// Parts are looked up in the in-memory state: their bytes are on disk once they are added.
+func (pm *playlistManager) WaitForPart(ctx context.Context, uri string, byteStart int64) (PartMeta, error) {
+	for {
+		pm.mu.RLock()
+		part, found := pm.findPartLocked(uri, byteStart)
+		changed := pm.changed
+		pm.mu.RUnlock()
+
+		if found {
+			return part, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return PartMeta{}, ctx.Err()
+		}
+	}
+}
+
+// findPartLocked looks up a part among the pending parts and those of recent segments.
+// Caller must hold pm.mu.
+func (pm *playlistManager) findPartLocked(uri string, byteStart int64) (PartMeta, bool) {
+	for _, part := range pm.pendingParts {
+		if part.URI == uri && part.ByteStart == byteStart {
+			return part, true
+		}
+	}
+	for i := len(pm.segments) - 1; i >= 0; i-- {
+		if pm.segments[i].URI != uri {
+			continue
+		}
+		for _, part := range pm.segments[i].Parts {
+			if part.ByteStart == byteStart {
+				return part, true
+			}
+		}
+		break
+	}
+	return PartMeta{}, false
+}
+
+// notifyLocked wakes WaitForPlaylist and WaitForPart callers. Caller must hold pm.mu for writing.
+func (pm *playlistManager) notifyLocked() {
+	close(pm.changed)
+	pm.changed = make(chan struct{})
+}
@@ -1,6 +1,8 @@
 package playlist
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -601,6 +603,170 @@ func TestPlaylistManager_GetCurrentSegments(t *testing.T) {
 	}
 }
 
+func TestPlaylistManager_GetSegments(t *testing.T) {
+	pm, _ := createTestManager(t, 3)
+
+	for i := 0; i < 5; i++ {
+		_, err := pm.AddSegment(SegmentMeta{
+			URI:      segmentName(i),
+			Duration: 4.0,
+			Map:      "init-0.mp4",
+		})
+		require.NoError(t, err)
+	}
+
+	segments := pm.GetSegments()
+	require.Len(t, segments, 3)
+	assert.Equal(t, uint64(2), pm.GetMediaSequence())
+	assert.Equal(t, segmentName(2), segments[0].URI)
+	assert.Equal(t, "init-0.mp4", segments[0].Map)
+
+	// The result is a copy
+	segments[0].URI = "changed.ts"
+	assert.Equal(t, segmentName(2), pm.GetSegments()[0].URI)
+}
+
+// addTestPart adds a one-second part of uri starting at byteStart
+func addTestPart(t *testing.T, pm Manager, uri string, byteStart int64, independent bool) {
+	t.Helper()
+	require.NoError(t, pm.AddPart(PartMeta{
+		URI:         uri,
+		Duration:    1.0,
+		ByteStart:   byteStart,
+		ByteLength:  100,
+		Independent: independent,
+	}))
+}
+
+func TestPlaylistManager_LowLatencyParts(t *testing.T) {
+	pm, outputPath := createTestManager(t, 10)
+	pm.SetPartTarget(1.0)
+
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 4; j++ {
+			addTestPart(t, pm, fmt.Sprintf("seg-%06d.m4s", i), int64(j*100), j == 0)
+		}
+		_, err := pm.AddSegment(SegmentMeta{URI: fmt.Sprintf("seg-%06d.m4s", i), Duration: 4.0, Map: "init-0.mp4"})
+		require.NoError(t, err)
+	}
+	addTestPart(t, pm, "seg-000005.m4s", 0, true)
+	pm.SetPreloadHint(PreloadHint{URI: "seg-000005.m4s", ByteStart: 100})
+	require.NoError(t, pm.Write())
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	content := string(data)
+
+	assert.Contains(t, content, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=3.000\n")
+	assert.Contains(t, content, "#EXT-X-PART-INF:PART-TARGET=1.000\n")
+	assert.Contains(t, content, `#EXT-X-PART:DURATION=1.000,URI="seg-000004.m4s",BYTERANGE="100@0",INDEPENDENT=YES`+"\n")
+	assert.Contains(t, content, `#EXT-X-PART:DURATION=1.000,URI="seg-000004.m4s",BYTERANGE="100@300"`+"\n")
+	assert.True(t, strings.HasSuffix(content, `#EXT-X-PART:DURATION=1.000,URI="seg-000005.m4s",BYTERANGE="100@0",INDEPENDENT=YES`+"\n"+
+		`#EXT-X-PRELOAD-HINT:TYPE=PART,URI="seg-000005.m4s",BYTERANGE-START=100`+"\n"), content)
+
+	// Parts are only listed for segments within three target durations of the end
+	assert.NotContains(t, content, `URI="seg-000001.m4s",BYTERANGE`)
+	assert.Contains(t, content, `URI="seg-000002.m4s",BYTERANGE`)
+
+	// Parts come before the segment they make up
+	assert.Less(t, strings.Index(content, `URI="seg-000004.m4s",BYTERANGE="100@300"`), strings.Index(content, "\nseg-000004.m4s\n"))
+	assert.Len(t, pm.GetSegments()[4].Parts, 4)
+}
+
+func TestPlaylistManager_NoLowLatencyTagsByDefault(t *testing.T) {
+	pm, outputPath := createTestManager(t, 10)
+
+	addTestPart(t, pm, "seg-000000.m4s", 0, true)
+	_, err := pm.AddSegment(SegmentMeta{URI: "seg-000000.m4s", Duration: 4.0})
+	require.NoError(t, err)
+	require.NoError(t, pm.Write())
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "#EXT-X-SERVER-CONTROL")
+	assert.NotContains(t, string(data), "#EXT-X-PART")
+	assert.Equal(t, 0.0, pm.GetPartTarget())
+}
+
+func TestPlaylistManager_AddPart_Validation(t *testing.T) {
+	pm, _ := createTestManager(t, 10)
+
+	assert.Error(t, pm.AddPart(PartMeta{Duration: 1, ByteLength: 1}))
+	assert.Error(t, pm.AddPart(PartMeta{URI: "seg-000000.m4s", ByteLength: 1}))
+	assert.Error(t, pm.AddPart(PartMeta{URI: "seg-000000.m4s", Duration: 1}))
+}
+
+func TestPlaylistManager_WaitForPlaylist(t *testing.T) {
+	pm, _ := createTestManager(t, 10)
+	pm.SetPartTarget(1.0)
+
+	_, err := pm.AddSegment(SegmentMeta{URI: "seg-000000.m4s", Duration: 4.0})
+	require.NoError(t, err)
+	require.NoError(t, pm.Write())
+
+	// Already in the written playlist
+	require.NoError(t, pm.WaitForPlaylist(context.Background(), 0, -1))
+
+	// More than two segments past the last one
+	err = pm.WaitForPlaylist(context.Background(), 3, -1)
+	assert.True(t, errors.Is(err, ErrSequenceTooFar), "err = %v", err)
+
+	// Part 0 of segment 1 only counts once a playlist listing it is written
+	done := make(chan error, 1)
+	go func() { done <- pm.WaitForPlaylist(context.Background(), 1, 0) }()
+
+	addTestPart(t, pm, "seg-000001.m4s", 0, true)
+	select {
+	case err := <-done:
+		t.Fatalf("returned before the playlist was written: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, pm.Write())
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("WaitForPlaylist did not return after the write")
+	}
+
+	// Times out with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pm.WaitForPlaylist(ctx, 2, -1), context.DeadlineExceeded)
+}
+
+func TestPlaylistManager_WaitForPart(t *testing.T) {
+	pm, _ := createTestManager(t, 10)
+	pm.SetPartTarget(1.0)
+
+	done := make(chan PartMeta, 1)
+	go func() {
+		part, err := pm.WaitForPart(context.Background(), "seg-000000.m4s", 100)
+		if err == nil {
+			done <- part
+		}
+	}()
+
+	addTestPart(t, pm, "seg-000000.m4s", 0, true)
+	addTestPart(t, pm, "seg-000000.m4s", 100, false)
+
+	select {
+	case part := <-done:
+		assert.Equal(t, int64(100), part.ByteLength)
+		assert.False(t, part.Independent)
+	case <-time.After(time.Second):
+		t.Fatal("WaitForPart did not return the part")
+	}
+
+	// Parts of completed segments are still found
+	_, err := pm.AddSegment(SegmentMeta{URI: "seg-000000.m4s", Duration: 2.0})
+	require.NoError(t, err)
+	part, err := pm.WaitForPart(context.Background(), "seg-000000.m4s", 0)
+	require.NoError(t, err)
+	assert.True(t, part.Independent)
+}
+
 func TestPlaylistManager_PrunedSegmentURIs(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
@@ -646,6 +812,120 @@ func TestPlaylistManager_PrunedSegmentURIs(t *testing.T) {
 	assert.Equal(t, segmentName(1), prunedURIs[0], "should prune second segment")
 }
 
+func TestPlaylistManager_InitSegmentMap(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
+
+	pm, err := NewManager(3, outputPath, 4.0)
+	require.NoError(t, err)
+
+	// Two encoders: segments 0-1 share init-0.mp4, segments 2-4 share init-2.mp4
+	maps := []string{"init-0.mp4", "init-0.mp4", "init-2.mp4", "init-2.mp4", "init-2.mp4"}
+	var pruned []string
+	for i, initURI := range maps {
+		prunedURIs, err := pm.AddSegment(SegmentMeta{
+			URI:           fmt.Sprintf("seg-%06d.m4s", i),
+			Duration:      4.0,
+			Map:           initURI,
+			Discontinuity: i == 2,
+		})
+		require.NoError(t, err)
+		pruned = append(pruned, prunedURIs...)
+		if i == 2 {
+			require.NoError(t, pm.Write())
+			content, err := os.ReadFile(outputPath)
+			require.NoError(t, err)
+			playlist := string(content)
+			assert.Contains(t, playlist, "#EXT-X-VERSION:7\n")
+			assert.Equal(t, 1, strings.Count(playlist, `#EXT-X-MAP:URI="init-0.mp4"`), "map written once per init segment")
+			assert.Contains(t, playlist, "#EXT-X-DISCONTINUITY\n#EXT-X-MAP:URI=\"init-2.mp4\"\n")
+		}
+	}
+
+	// init-0.mp4 is pruned together with the last segment that used it
+	assert.Equal(t, []string{"seg-000000.m4s", "seg-000001.m4s", "init-0.mp4"}, pruned)
+
+	// The first remaining segment carries the map again
+	require.NoError(t, pm.Write())
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "#EXT-X-TARGETDURATION:4\n#EXT-X-DISCONTINUITY\n#EXT-X-MAP:URI=\"init-2.mp4\"\n")
+}
+
+func TestPlaylistManager_KeyRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
+
+	pm, err := NewManager(4, outputPath, 4.0)
+	require.NoError(t, err)
+
+	// Segments 0-1 use key 0, segments 2-4 use key 1
+	keys := []string{"keys/0.key", "keys/0.key", "keys/1.key", "keys/1.key", "keys/1.key"}
+	for i, keyURI := range keys {
+		_, err := pm.AddSegment(SegmentMeta{URI: fmt.Sprintf("seg-%06d.ts", i), Duration: 4.0, KeyURI: keyURI})
+		require.NoError(t, err)
+	}
+	require.NoError(t, pm.Write())
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	playlist := string(content)
+
+	// The first remaining segment carries its key, and each rotation writes the next one once
+	assert.Contains(t, playlist, "#EXT-X-TARGETDURATION:4\n#EXT-X-KEY:METHOD=AES-128,URI=\"keys/0.key\"\n#EXTINF:4.000,\nseg-000001.ts\n")
+	assert.Contains(t, playlist, "seg-000001.ts\n#EXT-X-KEY:METHOD=AES-128,URI=\"keys/1.key\"\n#EXTINF:4.000,\nseg-000002.ts\n")
+	assert.Equal(t, 2, strings.Count(playlist, "#EXT-X-KEY:"))
+	assert.NotContains(t, playlist, "IV=", "IV defaults to the media sequence number")
+
+	// Unencrypted segments after encrypted ones switch encryption off
+	_, err = pm.AddSegment(SegmentMeta{URI: "seg-000005.ts", Duration: 4.0})
+	require.NoError(t, err)
+	require.NoError(t, pm.Write())
+	content, err = os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "#EXT-X-KEY:METHOD=NONE\n#EXTINF:4.000,\nseg-000005.ts\n")
+}
+
+func TestPlaylistManager_KeyIV(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
+
+	pm, err := NewManager(4, outputPath, 4.0)
+	require.NoError(t, err)
+
+	// Segments with their own IV each carry a key tag, even when the key does not rotate
+	ivs := []string{"0x000102030405060708090a0b0c0d0e0f", "0x101112131415161718191a1b1c1d1e1f"}
+	for i, iv := range ivs {
+		_, err := pm.AddSegment(SegmentMeta{URI: fmt.Sprintf("seg-%06d.ts", i), Duration: 4.0, KeyURI: "keys/0.key", KeyIV: iv})
+		require.NoError(t, err)
+	}
+	require.NoError(t, pm.Write())
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	playlist := string(content)
+
+	assert.Contains(t, playlist, "#EXT-X-KEY:METHOD=AES-128,URI=\"keys/0.key\",IV="+ivs[0]+"\n#EXTINF:4.000,\nseg-000000.ts\n")
+	assert.Contains(t, playlist, "#EXT-X-KEY:METHOD=AES-128,URI=\"keys/0.key\",IV="+ivs[1]+"\n#EXTINF:4.000,\nseg-000001.ts\n")
+	assert.Equal(t, 2, strings.Count(playlist, "#EXT-X-KEY:"))
+}
+
+func TestPlaylistManager_TSPlaylistVersion3(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
+
+	pm, err := NewManager(3, outputPath, 4.0)
+	require.NoError(t, err)
+	_, err = pm.AddSegment(SegmentMeta{URI: "seg-000000.ts", Duration: 4.0})
+	require.NoError(t, err)
+	require.NoError(t, pm.Write())
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "#EXT-X-VERSION:3\n")
+	assert.NotContains(t, string(content), "#EXT-X-MAP")
+}
+
 func TestPlaylistManager_MediaSequenceIncrements(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
@@ -692,6 +972,36 @@ func TestPlaylistManager_MediaSequenceIncrements(t *testing.T) {
 	assert.Equal(t, uint64(2), pm.GetMediaSequence(), "media sequence should increment by 1 after pruning another segment")
 }
 
+func TestPlaylistManager_SetMediaSequence(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
+
+	pm, err := NewManager(3, outputPath, 4.0)
+	require.NoError(t, err)
+
+	// A rendition joining at segment 40 starts its playlist there
+	pm.SetMediaSequence(40)
+	assert.Equal(t, uint64(40), pm.GetMediaSequence())
+
+	for i := 0; i < 4; i++ {
+		_, err := pm.AddSegment(SegmentMeta{
+			URI:      segmentName(i),
+			Duration: 4.0,
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, uint64(41), pm.GetMediaSequence(), "pruning should continue from the initial sequence")
+
+	// Ignored once segments exist
+	pm.SetMediaSequence(0)
+	assert.Equal(t, uint64(41), pm.GetMediaSequence())
+
+	require.NoError(t, pm.Write())
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "#EXT-X-MEDIA-SEQUENCE:41")
+}
+
 func TestPlaylistManager_VODModeNoPruning(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "playlist.m3u8")
@@ -1527,6 +1837,72 @@ func TestPlaylistManager_MultiplePruningScenarios(t *testing.T) {
 	assert.Equal(t, uint64(3), pm.GetMediaSequence())
 }
 
+func TestPlaylistManager_Retention(t *testing.T) {
+	pm, _ := createTestManager(t, 3)
+	pm.SetRetention(20 * time.Second)
+
+	start := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+	var pruned []string
+	for i := 0; i < 12; i++ {
+		programDateTime := start.Add(time.Duration(i) * 4 * time.Second)
+		prunedURIs, err := pm.AddSegment(SegmentMeta{
+			URI:             segmentName(i),
+			Duration:        4.0,
+			ProgramDateTime: &programDateTime,
+		})
+		require.NoError(t, err)
+		pruned = append(pruned, prunedURIs...)
+	}
+
+	// Segment 11 plays at 44s: segments from 24s on are kept, earlier ones are deleted
+	assert.Equal(t, []string{segmentName(0), segmentName(1), segmentName(2), segmentName(3), segmentName(4), segmentName(5)}, pruned)
+	mediaSequence, window := pm.GetWindow()
+	assert.Equal(t, uint64(9), mediaSequence)
+	assert.Len(t, window, 3)
+
+	first, retained := pm.GetRetained()
+	assert.Equal(t, uint64(6), first)
+	require.Len(t, retained, 6)
+	assert.Equal(t, segmentName(6), retained[0].URI)
+
+	// Ranges cover retained and window segments by program date-time
+	first, segments := pm.GetRange(start.Add(28*time.Second), start.Add(40*time.Second))
+	assert.Equal(t, uint64(7), first)
+	require.Len(t, segments, 3)
+	assert.Equal(t, segmentName(7), segments[0].URI)
+	assert.Equal(t, segmentName(9), segments[2].URI)
+
+	first, segments = pm.GetRange(start, start.Add(time.Hour))
+	assert.Equal(t, uint64(6), first)
+	assert.Len(t, segments, 6)
+
+	// Retained and window segments are published; deleted and future ones are not
+	assert.True(t, pm.HasSegment(segmentName(6)))
+	assert.True(t, pm.HasSegment(segmentName(11)))
+	assert.False(t, pm.HasSegment(segmentName(5)))
+	assert.False(t, pm.HasSegment(segmentName(12)))
+}
+
+func TestRenderCatchUp(t *testing.T) {
+	programDateTime := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+	segments := []SegmentMeta{
+		{URI: segmentName(7), Duration: 4.0, ProgramDateTime: &programDateTime},
+		{URI: segmentName(8), Duration: 3.5, KeyURI: "keys/0.key"},
+	}
+
+	event := RenderCatchUp(7, segments, false)
+	assert.Contains(t, event, "#EXT-X-PLAYLIST-TYPE:EVENT\n")
+	assert.Contains(t, event, "#EXT-X-MEDIA-SEQUENCE:7\n")
+	assert.Contains(t, event, "#EXT-X-TARGETDURATION:4\n")
+	assert.Contains(t, event, "#EXT-X-PROGRAM-DATE-TIME:2025-01-01T20:00:00Z\n")
+	assert.Contains(t, event, "#EXT-X-KEY:METHOD=AES-128,URI=\"keys/0.key\"\n")
+	assert.NotContains(t, event, "#EXT-X-ENDLIST")
+
+	vod := RenderCatchUp(7, segments, true)
+	assert.Contains(t, vod, "#EXT-X-PLAYLIST-TYPE:VOD\n")
+	assert.True(t, strings.HasSuffix(vod, "#EXT-X-ENDLIST\n"))
+}
+
 func TestPlaylistManager_BoundaryAtWindowSizeExactly(t *testing.T) {
 	pm, _ := createTestManager(t, 5)
 
@@ -46,6 +46,22 @@ func TestGenerateMasterPlaylist(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "variant with codecs",
+			variants: []PlaylistVariant{
+				{Bandwidth: 9792000, Resolution: "3840x2160", Codecs: "hvc1.1.6.L150.90,mp4a.40.2", Path: "2160p.m3u8"},
+				{Bandwidth: 5192000, Resolution: "1920x1080", Path: "1080p.m3u8"},
+			},
+			wantErr: false,
+			validate: func(t *testing.T, content string) {
+				if !strings.Contains(content, "#EXT-X-STREAM-INF:BANDWIDTH=9792000,RESOLUTION=3840x2160,CODECS=\"hvc1.1.6.L150.90,mp4a.40.2\"\n2160p.m3u8") {
+					t.Errorf("Missing or incorrect CODECS attribute:\n%s", content)
+				}
+				if !strings.Contains(content, "#EXT-X-STREAM-INF:BANDWIDTH=5192000,RESOLUTION=1920x1080\n") {
+					t.Errorf("Variant without codecs should have no CODECS attribute:\n%s", content)
+				}
+			},
+		},
 		{
 			name: "valid three variants",
 			variants: []PlaylistVariant{
@@ -806,18 +822,24 @@ func TestGetBandwidthForQuality(t *testing.T) {
 	tests := []struct {
 		name    string
 		quality string
+		codec   VideoCodec
 		want    int
 		wantErr bool
 	}{
-		{"1080p", Quality1080p, 5192000, false},
-		{"720p", Quality720p, 3192000, false},
-		{"480p", Quality480p, 1692000, false},
-		{"invalid", "4k", 0, true},
+		{"2160p", Quality2160p, VideoCodecH264, 16192000, false},
+		{"1080p", Quality1080p, VideoCodecH264, 5192000, false},
+		{"720p", Quality720p, VideoCodecH264, 3192000, false},
+		{"480p", Quality480p, VideoCodecH264, 1692000, false},
+		{"default codec", Quality1080p, "", 5192000, false},
+		{"2160p hevc", Quality2160p, VideoCodecHEVC, 9792000, false},
+		{"2160p av1", Quality2160p, VideoCodecAV1, 8192000, false},
+		{"invalid", "4k", VideoCodecH264, 0, true},
+		{"invalid codec", Quality1080p, "vp9", 0, true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetBandwidthForQuality(tt.quality)
+			got, err := GetBandwidthForQuality(tt.quality, tt.codec)
 			if tt.wantErr {
 				if err == nil {
 					t.Error("Expected error, got nil")
@@ -842,6 +864,7 @@ func TestGetResolutionForQuality(t *testing.T) {
 		want    string
 		wantErr bool
 	}{
+		{"2160p", Quality2160p, "3840x2160", false},
 		{"1080p", Quality1080p, "1920x1080", false},
 		{"720p", Quality720p, "1280x720", false},
 		{"480p", Quality480p, "854x480", false},
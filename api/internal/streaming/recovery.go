@@ -2,6 +2,7 @@ package streaming
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -207,14 +208,27 @@ func (m *StreamManager) handleFileError(ctx context.Context, channelID uuid.UUID
 		return fmt.Errorf("no valid items found in playlist")
 	}
 
+	// Record the failing file if it is gone, rather than leaving the media "available" forever
+	if filePath != "" {
+		if err := validateFilePath(filePath); errors.Is(err, ErrFileNotFound) {
+			markMediaMissing(ctx, m.repos, filePath)
+		}
+	}
+
 	// Find first valid (accessible) file
 	var validItem *models.PlaylistItem
 	for _, item := range nextItems {
-		if item.Media != nil {
-			// TODO: Add file existence check here
-			validItem = item
-			break
+		if item.Media == nil || item.Media.IsMissing() {
+			continue
+		}
+		if err := validateFilePath(item.Media.FilePath); err != nil {
+			if errors.Is(err, ErrFileNotFound) {
+				markMediaMissing(ctx, m.repos, item.Media.FilePath)
+			}
+			continue
 		}
+		validItem = item
+		break
 	}
 
 	if validItem == nil {
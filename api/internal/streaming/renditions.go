@@ -0,0 +1,857 @@
+package streaming
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+)
+
+// renditionIdleTimeout stops generating a rendition once no client has requested it for this long.
+// Players refresh live media playlists every segment, so active renditions are requested far more often.
+const renditionIdleTimeout = 30 * time.Second
+
+// qualityLadderOrder lists every rendition from highest to lowest
+var qualityLadderOrder = []string{Quality2160p, Quality1080p, Quality720p, Quality480p}
+
+// qualityLadder returns the renditions offered for a top quality: the top quality and every lower one
+func qualityLadder(top string) []string {
+	for i, quality := range qualityLadderOrder {
+		if quality == top {
+			return append([]string(nil), qualityLadderOrder[i:]...)
+		}
+	}
+	return []string{top}
+}
+
+// segmentSource describes the input of one segment.
+// Every rendition encodes the same sources, so segment numbers line up across renditions.
+type segmentSource struct {
+	number          int
+	videoPath       string
+	offsetSeconds   float64 // Position in the source video, in exact seconds (see mediaDuration)
+	discontinuity   bool
+	programDateTime time.Time
+}
+
+// videoFilters are the filters an encoder applies to its sources
+type videoFilters struct {
+	toneMap     bool
+	deinterlace bool
+}
+
+// mediaVideoFilters returns the filters a media item needs: tone mapping for HDR sources and
+// deinterlacing for interlaced ones
+func mediaVideoFilters(media *models.Media) videoFilters {
+	if media == nil {
+		return videoFilters{}
+	}
+	return videoFilters{toneMap: media.HDR, deinterlace: media.Interlaced}
+}
+
+// periodStart is the first segment of a DASH period: the start of a program, or a segment
+// where an encoder took over mid-playlist and the renditions' init segments may change
+type periodStart struct {
+	number          int
+	programDateTime time.Time
+}
+
+// rendition is the generation state of one quality of a stream
+type rendition struct {
+	live    bool            // Encoded by the batch loop; false while catching up
+	first   int             // First segment of the rendition's playlist
+	next    int             // Next segment number to encode
+	encoder *segmentEncoder // Running encoder; nil until needed or after it exits
+}
+
+// renditionSet tracks which renditions of a stream are generated and the recent segment sources
+// that a late-joining rendition or a restarted encoder starts from
+type renditionSet struct {
+	mu         sync.Mutex
+	renditions map[string]*rendition
+	sources    []segmentSource // Recent segments, oldest first
+	maxSources int
+	periods    []periodStart // DASH period starts, oldest first; the first one may precede sources
+
+	manifestMu sync.Mutex // Serializes DASH manifest writes
+	stateMu    sync.Mutex // Serializes stream state saves and the removal of the stream's directory
+}
+
+// newRenditionSet creates a rendition set that remembers up to maxSources segments
+func newRenditionSet(maxSources int) *renditionSet {
+	return &renditionSet{
+		renditions: make(map[string]*rendition),
+		maxSources: maxSources,
+	}
+}
+
+// record remembers a segment source and returns the renditions the batch loop encodes
+func (rs *renditionSet) record(src segmentSource) []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	// A new video (or a stream starting mid-video) begins a new program
+	if len(rs.sources) == 0 || src.discontinuity || rs.sources[len(rs.sources)-1].videoPath != src.videoPath {
+		rs.addPeriodLocked(periodStart{number: src.number, programDateTime: src.programDateTime})
+	}
+
+	rs.sources = append(rs.sources, src)
+	if len(rs.sources) > rs.maxSources {
+		rs.sources = rs.sources[len(rs.sources)-rs.maxSources:]
+	}
+
+	// Keep the period the oldest remembered segment belongs to, drop the ones before it
+	oldest := rs.sources[0].number
+	for len(rs.periods) > 1 && rs.periods[1].number <= oldest {
+		rs.periods = rs.periods[1:]
+	}
+	return rs.liveLocked()
+}
+
+// addPeriod starts a DASH period at a segment where an encoder took over mid-playlist
+func (rs *renditionSet) addPeriod(number int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for _, src := range rs.sources {
+		if src.number == number {
+			rs.addPeriodLocked(periodStart{number: number, programDateTime: src.programDateTime})
+			return
+		}
+	}
+}
+
+// addPeriodLocked inserts a period start in segment order, ignoring duplicates
+func (rs *renditionSet) addPeriodLocked(start periodStart) {
+	i := len(rs.periods)
+	for i > 0 && rs.periods[i-1].number >= start.number {
+		if rs.periods[i-1].number == start.number {
+			return
+		}
+		i--
+	}
+	rs.periods = append(rs.periods, periodStart{})
+	copy(rs.periods[i+1:], rs.periods[i:])
+	rs.periods[i] = start
+}
+
+// periodStarts returns the DASH period starts, oldest first
+func (rs *renditionSet) periodStarts() []periodStart {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]periodStart(nil), rs.periods...)
+}
+
+// liveLocked returns the live renditions in ladder order
+func (rs *renditionSet) liveLocked() []string {
+	live := make([]string, 0, len(rs.renditions))
+	for _, quality := range qualityLadderOrder {
+		if r, ok := rs.renditions[quality]; ok && r.live {
+			live = append(live, quality)
+		}
+	}
+	return live
+}
+
+// live returns the live renditions in ladder order
+func (rs *renditionSet) live() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.liveLocked()
+}
+
+// isLive reports whether the batch loop encodes a rendition
+func (rs *renditionSet) isLive(quality string) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.renditions[quality]
+	return ok && r.live
+}
+
+// source returns the recorded source of a segment
+func (rs *renditionSet) source(number int) (segmentSource, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for _, src := range rs.sources {
+		if src.number == number {
+			return src, true
+		}
+	}
+	return segmentSource{}, false
+}
+
+// newest returns the most recently recorded segment source
+func (rs *renditionSet) newest() (segmentSource, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if len(rs.sources) == 0 {
+		return segmentSource{}, false
+	}
+	return rs.sources[len(rs.sources)-1], true
+}
+
+// join starts tracking a rendition and returns the first segment it will produce.
+// catchUp is false when there is nothing to catch up on and the rendition is live immediately.
+func (rs *renditionSet) join(quality string, fromSegment int) (first int, catchUp bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	switch {
+	case len(rs.sources) == 0:
+		first = 0
+	case fromSegment > rs.sources[len(rs.sources)-1].number:
+		first = rs.sources[len(rs.sources)-1].number + 1
+	default:
+		first, catchUp = max(fromSegment, rs.sources[0].number), true
+	}
+	rs.renditions[quality] = &rendition{live: !catchUp, first: first, next: first}
+	return first, catchUp
+}
+
+// nextToCatchUp returns the newest recorded segment a joining rendition still has to encode.
+// Once the rendition has caught up it becomes live and ok is false; the check and the switch
+// happen under one lock so the batch loop cannot record a segment in between.
+func (rs *renditionSet) nextToCatchUp(quality string) (target int, ok bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r, tracked := rs.renditions[quality]
+	if !tracked || r.live {
+		return 0, false // Stopped while catching up
+	}
+	if len(rs.sources) > 0 {
+		if newest := rs.sources[len(rs.sources)-1].number; newest >= r.next {
+			return newest, true
+		}
+	}
+	r.live = true
+	return 0, false
+}
+
+// advance records that a rendition has encoded every segment before next
+func (rs *renditionSet) advance(quality string, next int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if r, ok := rs.renditions[quality]; ok && next > r.next {
+		r.next = next
+	}
+}
+
+// leave stops tracking a rendition and returns its encoder, if any, for the caller to stop
+func (rs *renditionSet) leave(quality string) *segmentEncoder {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.renditions[quality]
+	if !ok {
+		return nil
+	}
+	delete(rs.renditions, quality)
+	return r.encoder
+}
+
+// tracked returns the live and joining renditions
+func (rs *renditionSet) tracked() (live, joining map[string]bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	live = make(map[string]bool)
+	joining = make(map[string]bool)
+	for quality, r := range rs.renditions {
+		if r.live {
+			live[quality] = true
+		} else {
+			joining[quality] = true
+		}
+	}
+	return live, joining
+}
+
+// renditionsFor returns the rendition set of a stream, creating it on first use
+func (m *StreamManager) renditionsFor(session *models.StreamSession) *renditionSet {
+	channelIDStr := session.ChannelID.String()
+
+	m.renditionsMu.Lock()
+	defer m.renditionsMu.Unlock()
+	rs, ok := m.renditions[channelIDStr]
+	if !ok {
+		rs = newRenditionSet(m.config.BatchSize * 3) // Same window as the media playlists
+		m.renditions[channelIDStr] = rs
+	}
+	return rs
+}
+
+// deleteRenditions forgets the rendition set of a stopped stream, stops its encoders
+// and ends any catch-up still running
+func (m *StreamManager) deleteRenditions(channelIDStr string) {
+	m.renditionsMu.Lock()
+	rs, ok := m.renditions[channelIDStr]
+	delete(m.renditions, channelIDStr)
+	m.renditionsMu.Unlock()
+
+	if !ok {
+		return
+	}
+	for _, quality := range qualityLadderOrder {
+		if encoder := rs.leave(quality); encoder != nil {
+			encoder.stop()
+		}
+	}
+}
+
+// syncRenditions starts renditions that clients have requested and stops the ones nobody uses.
+// A rendition is in use while its media playlist or segments are requested, or clients report
+// positions in it. The last live rendition is never stopped; idle streams are cleaned up as a whole.
+// Returns the number of renditions being generated.
+func (m *StreamManager) syncRenditions(session *models.StreamSession) int {
+	rs := m.renditionsFor(session)
+	live, joining := rs.tracked()
+	now := time.Now().UTC()
+
+	// Only explicit requests start a rendition; position reports keep a running one alive
+	inUse := make(map[string]bool)
+	for quality, requested := range session.GetRenditionRequests() {
+		if now.Sub(requested) <= renditionIdleTimeout {
+			inUse[quality] = true
+		}
+	}
+	slowest := -1
+	for _, pos := range session.GetClientPositions() {
+		if now.Sub(pos.LastUpdated) > renditionIdleTimeout {
+			continue
+		}
+		if live[pos.Quality] {
+			inUse[pos.Quality] = true
+		}
+		if slowest < 0 || pos.SegmentNumber < slowest {
+			slowest = pos.SegmentNumber
+		}
+	}
+	if slowest < 0 {
+		slowest = session.GetFurthestPosition()
+	}
+
+	for _, quality := range qualityLadderOrder {
+		if inUse[quality] && !live[quality] && !joining[quality] {
+			m.startRendition(session, rs, quality, slowest)
+		}
+	}
+
+	live, joining = rs.tracked()
+	for _, quality := range qualityLadderOrder {
+		if live[quality] && !inUse[quality] && len(live) > 1 {
+			m.stopRendition(session, rs, quality)
+			delete(live, quality)
+		}
+	}
+
+	return len(live) + len(joining)
+}
+
+// startRendition begins generating a rendition from fromSegment (the slowest client) onwards.
+// Segments the batch loop has already produced are caught up in the background before the
+// rendition joins the batch loop.
+func (m *StreamManager) startRendition(session *models.StreamSession, rs *renditionSet, quality string, fromSegment int) {
+	channelIDStr := session.ChannelID.String()
+	qualityDir := filepath.Join(session.GetOutputDir(), quality)
+
+	if err := os.MkdirAll(qualityDir, 0755); err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Str("quality", quality).
+			Msg("Failed to create rendition directory")
+		return
+	}
+	if err := m.ensurePlaylistManager(session, quality, qualityDir); err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Str("quality", quality).
+			Msg("Failed to initialize rendition playlist manager")
+		return
+	}
+
+	first, catchUp := rs.join(quality, fromSegment)
+	if pm, err := m.getPlaylistManager(session, quality); err == nil {
+		pm.SetMediaSequence(uint64(first)) // nolint:gosec // segment numbers are never negative
+	}
+
+	logger.Log.Info().
+		Str("channel_id", channelIDStr).
+		Str("quality", quality).
+		Int("first_segment", first).
+		Bool("catching_up", catchUp).
+		Msg("Starting rendition")
+
+	if catchUp {
+		go m.catchUpRendition(session, rs, quality)
+	} else {
+		m.writeMasterPlaylist(session)
+	}
+}
+
+// catchUpRendition encodes the recorded segments a joining rendition has not produced yet,
+// until it has caught up with the batch loop and becomes live
+func (m *StreamManager) catchUpRendition(session *models.StreamSession, rs *renditionSet, quality string) {
+	for {
+		target, ok := rs.nextToCatchUp(quality)
+		if !ok {
+			// Players tuning in from now on may start on it
+			if rs.isLive(quality) {
+				m.writeMasterPlaylist(session)
+			}
+			return
+		}
+		if err := m.runRendition(context.Background(), session, rs, quality, target); err != nil {
+			if _, joining := rs.tracked(); !joining[quality] {
+				return // Stopped while catching up
+			}
+			logger.Log.Error().
+				Err(err).
+				Str("channel_id", session.ChannelID.String()).
+				Str("quality", quality).
+				Int("segment_number", target).
+				Msg("Failed to catch up rendition, stopping it")
+			m.stopRendition(session, rs, quality)
+			return
+		}
+	}
+}
+
+// stopRendition stops generating a rendition and removes its playlist and segments.
+// The next request for it starts it again.
+func (m *StreamManager) stopRendition(session *models.StreamSession, rs *renditionSet, quality string) {
+	channelIDStr := session.ChannelID.String()
+	if encoder := rs.leave(quality); encoder != nil {
+		encoder.stop()
+	}
+	session.ForgetRendition(quality)
+
+	managerKey := fmt.Sprintf("%s_%s", channelIDStr, quality)
+	m.playlistManagersMu.Lock()
+	delete(m.playlistManagers, managerKey)
+	m.playlistManagersMu.Unlock()
+
+	qualityDir := filepath.Join(session.GetOutputDir(), quality)
+	if err := os.RemoveAll(qualityDir); err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Str("quality", quality).
+			Msg("Failed to remove rendition segments")
+	}
+
+	logger.Log.Info().
+		Str("channel_id", channelIDStr).
+		Str("quality", quality).
+		Msg("Stopped unused rendition")
+}
+
+// recordSegment plans a segment: it fixes the segment's program time and remembers its source
+// so renditions starting later (or restarted encoders) begin at the same point
+func (m *StreamManager) recordSegment(ctx context.Context, session *models.StreamSession, src segmentSource) {
+	src.programDateTime = m.segmentProgramTime(ctx, session, src.number)
+	m.renditionsFor(session).record(src)
+}
+
+// encodeBatch runs the encoder of every live rendition in parallel until segment target is complete.
+// Failures of renditions stopped during encoding are ignored.
+func (m *StreamManager) encodeBatch(ctx context.Context, session *models.StreamSession, target int) error {
+	rs := m.renditionsFor(session)
+	live := rs.live()
+
+	errs := make([]error, len(live))
+	var wg sync.WaitGroup
+	for i, quality := range live {
+		wg.Add(1)
+		go func(i int, quality string) {
+			defer wg.Done()
+			if err := m.runRendition(ctx, session, rs, quality, target); err != nil && rs.isLive(quality) {
+				errs[i] = fmt.Errorf("%s: %w", quality, err)
+			}
+		}(i, quality)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// runRendition lets a rendition's encoder run until segment target is complete, starting a new
+// encoder whenever the previous one has consumed its concat list
+func (m *StreamManager) runRendition(ctx context.Context, session *models.StreamSession, rs *renditionSet, quality string, target int) error {
+	// Batches for streams viewers are already watching go first
+	priority := PriorityStartup
+	if batch := session.GetCurrentBatch(); batch != nil && batch.BatchNumber > 0 {
+		priority = PriorityViewer
+	}
+
+	for {
+		encoder, err := m.renditionEncoder(ctx, session, rs, quality, priority)
+		if err == nil {
+			err = encoder.runUntil(ctx, target)
+		}
+
+		if !errors.Is(err, errEncoderFinished) {
+			return err
+		}
+		if encoder.produced() == 0 {
+			return fmt.Errorf("encoder starting at segment %d exited without producing segments", encoder.start)
+		}
+		// The concat list is used up; the next encoder continues from the next recorded source
+	}
+}
+
+// renditionEncoder returns the running encoder of a rendition, starting one at the rendition's
+// next segment if it has none or the previous one has exited. A new encoder waits for transcode
+// budget and holds it until its process exits, including while it is suspended between batches.
+// The stream's first encoder takes over the budget reserved when the stream was admitted instead.
+func (m *StreamManager) renditionEncoder(ctx context.Context, session *models.StreamSession, rs *renditionSet, quality string, priority TranscodePriority) (*segmentEncoder, error) {
+	rs.mu.Lock()
+	r, ok := rs.renditions[quality]
+	if !ok {
+		rs.mu.Unlock()
+		return nil, fmt.Errorf("rendition %s is not being generated", quality)
+	}
+	if r.encoder != nil && !r.encoder.done() {
+		encoder := r.encoder
+		rs.mu.Unlock()
+		return encoder, nil
+	}
+	first, next := r.first, r.next
+	rs.mu.Unlock()
+
+	release, ok := m.takeAdmission(session.ChannelID.String())
+	if !ok {
+		var err error
+		release, err = m.budget.Acquire(ctx, TranscodeCost(quality, m.currentHardwareAccel(), m.config.WeightedTranscodes), priority)
+		if err != nil {
+			return nil, fmt.Errorf("failed waiting for transcode budget: %w", err)
+		}
+	}
+	encoder, err := m.startEncoder(ctx, session, rs, quality, first, next, release)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if current, ok := rs.renditions[quality]; !ok || current != r {
+		go encoder.stop() // Stopped while starting
+		return nil, fmt.Errorf("rendition %s stopped", quality)
+	}
+	r.encoder = encoder
+	return encoder, nil
+}
+
+// sessionTag returns the part of a stream session's segment filenames that tells it apart from
+// earlier sessions of the channel: numbering restarts with every session, yet segments are cached
+// as immutable. The session ID survives suspend and resume, so resumed segments keep their names.
+func sessionTag(session *models.StreamSession) string {
+	return hex.EncodeToString(session.ID[:4])
+}
+
+// SegmentPrefix returns the filename prefix of a stream session's numbered segments (seg-1a2b3c4d)
+func SegmentPrefix(session *models.StreamSession) string {
+	return defaultSegmentPrefix + "-" + sessionTag(session)
+}
+
+// startEncoder launches a continuous encoder for a rendition at segment number start,
+// reading the playlist from the recorded source of that segment onwards.
+// release is called once the encoder's process exits.
+func (m *StreamManager) startEncoder(ctx context.Context, session *models.StreamSession, rs *renditionSet, quality string, first, start int, release func()) (*segmentEncoder, error) {
+	src, ok := rs.source(start)
+	if !ok {
+		return nil, fmt.Errorf("source of segment %d is no longer available", start)
+	}
+
+	qualityDir := filepath.Join(session.GetOutputDir(), quality)
+	inputPath := filepath.Join(qualityDir, fmt.Sprintf("input-%d.txt", start))
+	listPath := filepath.Join(qualityDir, fmt.Sprintf("segments-%d.csv", start))
+	segmentFormat := session.GetSegmentFormat()
+	partDuration := 0
+	if segmentFormat == SegmentFormatFMP4 {
+		listPath = filepath.Join(qualityDir, fmt.Sprintf("segments-%d.m3u8", start))
+		partDuration = m.config.PartDuration
+	}
+	initFilename := fmt.Sprintf("init-%s-%d.mp4", sessionTag(session), start)
+	filters, err := m.buildEncoderInput(ctx, session, src, inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build input for segment %d: %w", start, err)
+	}
+
+	codec := sessionCodec(session, quality)
+	hwAccel := m.codecHardwareAccel(codec)
+	toneMap := ToneMapNone
+	if filters.toneMap {
+		toneMap = m.currentToneMapper()
+		if toneMap == ToneMapNone {
+			logger.Log.Warn().
+				Str("channel_id", session.ChannelID.String()).
+				Str("video_path", src.videoPath).
+				Msg("No tone mapping filter available (FFmpeg lacks zscale/libzimg), encoding HDR source without tone mapping")
+		}
+	}
+	ffmpegCmd, err := BuildHLSCommand(StreamParams{
+		InputFile:              inputPath,
+		ConcatInput:            true,
+		Quality:                quality,
+		VideoCodec:             codec,
+		HardwareAccel:          hwAccel,
+		StreamPositionSeconds:  int64(start) * int64(m.config.StreamSegmentDuration),
+		EncodingPreset:         m.config.EncodingPreset,
+		StreamSegmentMode:      true,
+		ContinuousSegmentMode:  true,
+		SegmentStartNumber:     start,
+		SegmentListPath:        listPath,
+		SegmentFormat:          segmentFormat,
+		InitFilename:           initFilename,
+		SegmentPrefix:          SegmentPrefix(session),
+		SegmentOutputDir:       qualityDir,
+		SegmentFilenamePattern: m.config.StreamSegmentFilenamePattern,
+		SegmentDuration:        m.config.StreamSegmentDuration,
+		FPS:                    m.config.FPS,
+		PartDurationMs:         partDuration,
+		ToneMap:                toneMap,
+		Deinterlace:            filters.deinterlace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build FFmpeg command for segment %d: %w", start, err)
+	}
+
+	// Within one encoder the output is a single continuous timeline, so only the point where an
+	// encoder takes over mid-playlist (or a stream starting mid-video) is a discontinuity
+	discontinuityAt := -1
+	if start != first || src.discontinuity {
+		discontinuityAt = start
+	}
+	if start != first {
+		rs.addPeriod(start) // The rendition's init segment changes here
+	}
+
+	var encoder *segmentEncoder
+	encoder = newSegmentEncoder(quality, hwAccel, qualityDir, listPath, inputPath, start, func(seg encodedSegment) {
+		m.addEncodedSegment(session, rs, encoder, seg, seg.number == discontinuityAt)
+	})
+	encoder.onExit = release
+	if partDuration > 0 {
+		encoder.initFile = initFilename
+		encoder.onPart = func(part encodedPart) {
+			m.addEncodedPart(session, encoder, part)
+		}
+	}
+	if err := encoder.launch(ffmpegCmd); err != nil {
+		recordFFmpegFailure(err)
+		return nil, fmt.Errorf("failed to launch encoder for segment %d: %w", start, err)
+	}
+
+	logger.Log.Info().
+		Str("channel_id", session.ChannelID.String()).
+		Str("quality", quality).
+		Str("codec", codec.String()).
+		Str("tone_map", string(toneMap)).
+		Bool("deinterlace", filters.deinterlace).
+		Int("start_segment", start).
+		Str("video_path", src.videoPath).
+		Float64("offset_seconds", src.offsetSeconds).
+		Msg("Started continuous encoder")
+
+	return encoder, nil
+}
+
+// buildEncoderInput writes the concat list an encoder reads: the source video from the segment's
+// offset followed by the next playlist items, and returns the filters the sources need. The list
+// stops before the first missing file, so the encoder finishes there and the restart fails on that
+// file instead of FFmpeg. It also stops before the first item that needs different filters, so the
+// restart picks up that item with its own filters.
+func (m *StreamManager) buildEncoderInput(ctx context.Context, session *models.StreamSession, src segmentSource, path string) (videoFilters, error) {
+	if err := validateFilePath(src.videoPath); err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			markMediaMissing(ctx, m.repos, src.videoPath)
+		}
+		return videoFilters{}, err
+	}
+
+	playlistItems, err := m.repos.PlaylistItems.GetWithMedia(ctx, session.ChannelID)
+	if err != nil {
+		return videoFilters{}, fmt.Errorf("failed to get playlist items: %w", err)
+	}
+	loop := false
+	if channel, err := m.repos.Channels.GetByID(ctx, session.ChannelID); err == nil {
+		loop = channel.Loop
+	}
+
+	items := []ConcatItem{{FilePath: src.videoPath, InPoint: src.offsetSeconds}}
+	var filters videoFilters
+	for i, item := range playlistItems {
+		if item.Media == nil || item.Media.FilePath != src.videoPath {
+			continue
+		}
+		filters = mediaVideoFilters(item.Media)
+		for _, next := range GetNextPlaylistItems(playlistItems, i, MaxConcatFiles-1, loop) {
+			if next.Media == nil {
+				continue
+			}
+			if mediaVideoFilters(next.Media) != filters {
+				break
+			}
+			if err := validateFilePath(next.Media.FilePath); err != nil {
+				if errors.Is(err, ErrFileNotFound) {
+					markMediaMissing(ctx, m.repos, next.Media.FilePath)
+				}
+				break
+			}
+			items = append(items, ConcatItem{FilePath: next.Media.FilePath})
+		}
+		break
+	}
+
+	return filters, BuildConcatFile(items, path)
+}
+
+// addEncodedSegment adds a segment an encoder has completed to the rendition's playlist,
+// deletes segments pruned from the playlist window, saves the stream state and records generation metrics
+func (m *StreamManager) addEncodedSegment(session *models.StreamSession, rs *renditionSet, encoder *segmentEncoder, seg encodedSegment, discontinuity bool) {
+	channelIDStr := session.ChannelID.String()
+	quality := encoder.quality
+	rs.advance(quality, seg.number+1)
+
+	pm, err := m.getPlaylistManager(session, quality)
+	if err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Str("quality", quality).
+			Str("segment_filename", seg.filename).
+			Msg("Failed to get playlist manager, segment generated but not added to playlist")
+		return
+	}
+
+	// ProgramDateTime comes from the recorded source so every rendition carries the same value.
+	// Encoders that cannot be suspended run past the recorded sources; extrapolate for those.
+	programDateTime := session.StartedAt.UTC().Add(time.Duration(seg.number*m.config.StreamSegmentDuration) * time.Second)
+	if src, ok := rs.source(seg.number); ok {
+		programDateTime = src.programDateTime
+	}
+
+	// Encrypted streams never publish a segment in the clear
+	keyURI, keyIV := "", ""
+	if session.GetEncryption() == models.EncryptionAES128 {
+		index := m.keyIndex(seg.number)
+		iv := m.segmentIV(session, seg.number)
+		segmentPath := filepath.Join(encoder.dir, seg.filename)
+		if err := encryptSegmentFile(segmentPath, m.segmentKey(session, index), iv); err != nil {
+			logger.Log.Error().
+				Err(err).
+				Str("channel_id", channelIDStr).
+				Str("quality", quality).
+				Str("segment_filename", seg.filename).
+				Msg("Failed to encrypt segment, not adding it to playlist")
+			_ = os.Remove(segmentPath)
+			return
+		}
+		keyURI = fmt.Sprintf(segmentKeyURIPattern, index)
+		keyIV = formatIV(iv)
+	}
+
+	prunedURIs, err := pm.AddSegment(playlist.SegmentMeta{
+		URI:             seg.filename,
+		Duration:        seg.duration,
+		ProgramDateTime: &programDateTime,
+		Discontinuity:   discontinuity,
+		Map:             seg.initFile,
+		KeyURI:          keyURI,
+		KeyIV:           keyIV,
+	})
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Str("segment_filename", seg.filename).
+			Msg("Failed to add segment to playlist")
+		return
+	}
+
+	// Low-Latency HLS players may request the next segment's first part before it exists
+	if pm.GetPartTarget() > 0 {
+		pm.SetPreloadHint(playlist.PreloadHint{URI: fmt.Sprintf(continuousSegmentPattern(SegmentPrefix(session), SegmentFormatFMP4), seg.number+1)})
+	}
+
+	// Delete pruned segment files from disk
+	for _, prunedURI := range prunedURIs {
+		segmentPath := filepath.Join(encoder.dir, prunedURI)
+		if err := os.Remove(segmentPath); err != nil {
+			// Log warning but don't fail - file may already be deleted or not exist
+			logger.Log.Warn().
+				Err(err).
+				Str("channel_id", channelIDStr).
+				Str("segment_path", segmentPath).
+				Msg("Failed to delete pruned segment file")
+		}
+	}
+
+	if err := pm.Write(); err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", channelIDStr).
+			Str("segment_filename", seg.filename).
+			Msg("Failed to write playlist")
+		return
+	}
+	m.writeDASHManifest(session, rs)
+	m.saveStreamState(session, rs)
+
+	// Speed ratio > 1.0 means the encoder is slower than real-time
+	generationSpeedRatio := seg.encodeTime.Seconds() / seg.duration
+	observeSegmentGeneration(quality, encoder.hwAccel, seg.encodeTime, generationSpeedRatio)
+
+	logger.Log.Debug().
+		Str("channel_id", channelIDStr).
+		Str("quality", quality).
+		Int("segment_number", seg.number).
+		Str("segment_filename", seg.filename).
+		Float64("duration", seg.duration).
+		Dur("generation_time", seg.encodeTime).
+		Msg("Encoded segment added to playlist")
+}
+
+// addEncodedPart publishes a Low-Latency HLS part of a segment an encoder is still writing
+// and hints the part after it
+func (m *StreamManager) addEncodedPart(session *models.StreamSession, encoder *segmentEncoder, part encodedPart) {
+	pm, err := m.getPlaylistManager(session, encoder.quality)
+	if err != nil {
+		return
+	}
+
+	if err := pm.AddPart(playlist.PartMeta{
+		URI:         part.filename,
+		Duration:    part.duration,
+		ByteStart:   part.start,
+		ByteLength:  part.length,
+		Independent: part.independent,
+	}); err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("channel_id", session.ChannelID.String()).
+			Str("quality", encoder.quality).
+			Str("segment_filename", part.filename).
+			Msg("Failed to add part to playlist")
+		return
+	}
+	pm.SetPreloadHint(playlist.PreloadHint{URI: part.filename, ByteStart: part.start + part.length})
+
+	if err := pm.Write(); err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("channel_id", session.ChannelID.String()).
+			Str("quality", encoder.quality).
+			Str("segment_filename", part.filename).
+			Msg("Failed to write playlist")
+	}
+}
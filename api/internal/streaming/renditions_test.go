@@ -0,0 +1,306 @@
+package streaming
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestQualityLadder(t *testing.T) {
+	tests := []struct {
+		top  string
+		want []string
+	}{
+		{Quality2160p, []string{Quality2160p, Quality1080p, Quality720p, Quality480p}},
+		{Quality1080p, []string{Quality1080p, Quality720p, Quality480p}},
+		{Quality720p, []string{Quality720p, Quality480p}},
+		{Quality480p, []string{Quality480p}},
+	}
+	for _, tt := range tests {
+		if got := qualityLadder(tt.top); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("qualityLadder(%s) = %v, want %v", tt.top, got, tt.want)
+		}
+	}
+}
+
+func TestMediaVideoFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		media *models.Media
+		want  videoFilters
+	}{
+		{name: "no media", media: nil, want: videoFilters{}},
+		{name: "SDR progressive", media: &models.Media{}, want: videoFilters{}},
+		{name: "HDR", media: &models.Media{HDR: true}, want: videoFilters{toneMap: true}},
+		{name: "interlaced", media: &models.Media{Interlaced: true}, want: videoFilters{deinterlace: true}},
+		{name: "HDR interlaced", media: &models.Media{HDR: true, Interlaced: true}, want: videoFilters{toneMap: true, deinterlace: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mediaVideoFilters(tt.media); got != tt.want {
+				t.Errorf("mediaVideoFilters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenditionSet_JoinAndCatchUp(t *testing.T) {
+	rs := newRenditionSet(3)
+
+	if first, catchUp := rs.join(Quality1080p, 0); first != 0 || catchUp {
+		t.Fatalf("join before any segment = (%d, %v), want (0, false)", first, catchUp)
+	}
+	for i := 0; i < 4; i++ {
+		if live := rs.record(segmentSource{number: i}); !reflect.DeepEqual(live, []string{Quality1080p}) {
+			t.Fatalf("record(%d) live = %v, want [1080p]", i, live)
+		}
+	}
+
+	// Segment 0 has left the window, so a client at segment 0 is caught up from segment 1
+	first, catchUp := rs.join(Quality480p, 0)
+	if first != 1 || !catchUp {
+		t.Fatalf("join(480p, 0) = (%d, %v), want (1, true)", first, catchUp)
+	}
+
+	target, ok := rs.nextToCatchUp(Quality480p)
+	if !ok || target != 3 {
+		t.Fatalf("nextToCatchUp = (%d, %v), want (3, true)", target, ok)
+	}
+
+	// Segments recorded while catching up are caught up on too instead of encoded by the batch loop
+	if live := rs.record(segmentSource{number: 4}); !reflect.DeepEqual(live, []string{Quality1080p}) {
+		t.Fatalf("record while joining live = %v, want [1080p]", live)
+	}
+	rs.advance(Quality480p, 4)
+	if target, ok = rs.nextToCatchUp(Quality480p); !ok || target != 4 {
+		t.Fatalf("nextToCatchUp after segment 3 = (%d, %v), want (4, true)", target, ok)
+	}
+	rs.advance(Quality480p, 5)
+	if _, ok = rs.nextToCatchUp(Quality480p); ok {
+		t.Fatal("nextToCatchUp after the newest segment should switch the rendition to live")
+	}
+
+	if live := rs.record(segmentSource{number: 5}); !reflect.DeepEqual(live, []string{Quality1080p, Quality480p}) {
+		t.Errorf("record after catch up live = %v, want [1080p 480p]", live)
+	}
+
+	// A client ahead of everything recorded joins live at the next segment
+	if first, catchUp := rs.join(Quality720p, 10); first != 6 || catchUp {
+		t.Errorf("join(720p, 10) = (%d, %v), want (6, false)", first, catchUp)
+	}
+}
+
+func TestRenditionSet_LeaveWhileCatchingUp(t *testing.T) {
+	rs := newRenditionSet(5)
+	rs.join(Quality1080p, 0)
+	rs.record(segmentSource{number: 0})
+
+	rs.join(Quality720p, 0)
+	rs.leave(Quality720p)
+	if _, ok := rs.nextToCatchUp(Quality720p); ok {
+		t.Error("a stopped rendition should not keep catching up")
+	}
+	if rs.isLive(Quality720p) {
+		t.Error("a stopped rendition should not become live")
+	}
+}
+
+func TestRenditionSet_Periods(t *testing.T) {
+	rs := newRenditionSet(4)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	source := func(number int, video string) segmentSource {
+		return segmentSource{number: number, videoPath: video, programDateTime: start.Add(time.Duration(number) * 4 * time.Second)}
+	}
+	periodNumbers := func() []int {
+		var numbers []int
+		for _, p := range rs.periodStarts() {
+			numbers = append(numbers, p.number)
+		}
+		return numbers
+	}
+
+	// Programs start at the first segment and wherever the video changes
+	rs.record(source(0, "a.mp4"))
+	rs.record(source(1, "a.mp4"))
+	rs.record(source(2, "b.mp4"))
+	rs.record(source(3, "b.mp4"))
+	if got := periodNumbers(); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Fatalf("periods = %v, want [0 2]", got)
+	}
+
+	// Encoder restarts split a program; duplicates are ignored
+	rs.addPeriod(3)
+	rs.addPeriod(2)
+	if got := periodNumbers(); !reflect.DeepEqual(got, []int{0, 2, 3}) {
+		t.Fatalf("periods after restart = %v, want [0 2 3]", got)
+	}
+	if p := rs.periodStarts()[2]; !p.programDateTime.Equal(start.Add(12 * time.Second)) {
+		t.Errorf("restart period program time = %v, want %v", p.programDateTime, start.Add(12*time.Second))
+	}
+
+	// Segments 0-2 leave the window; the period segment 3 belongs to is kept
+	rs.record(source(4, "b.mp4"))
+	rs.record(source(5, "b.mp4"))
+	rs.record(source(6, "b.mp4"))
+	if got := periodNumbers(); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("periods after pruning = %v, want [3]", got)
+	}
+}
+
+func TestSegmentPrefix_UniquePerSession(t *testing.T) {
+	channelID := uuid.New()
+	first, second := models.NewStreamSession(channelID), models.NewStreamSession(channelID)
+
+	if SegmentPrefix(first) == SegmentPrefix(second) {
+		t.Errorf("sessions of one channel share segment prefix %s", SegmentPrefix(first))
+	}
+	filename := fmt.Sprintf(continuousSegmentPattern(SegmentPrefix(first), SegmentFormatTS), 12)
+	if number, ok := SegmentNumber(filename); !ok || number != 12 {
+		t.Errorf("SegmentNumber(%q) = %d, %v, want 12", filename, number, ok)
+	}
+}
+
+func TestSyncRenditions(t *testing.T) {
+	outputDir := t.TempDir()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		BatchSize:             5,
+		StreamSegmentDuration: 4,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(outputDir)
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}, {Level: Quality480p}})
+	session.IncrementClients()
+
+	if n := m.syncRenditions(session); n != 0 {
+		t.Fatalf("syncRenditions with no requests = %d, want 0", n)
+	}
+
+	session.RequestRendition(Quality720p)
+	if n := m.syncRenditions(session); n != 1 {
+		t.Fatalf("syncRenditions after requesting 720p = %d, want 1", n)
+	}
+	if _, err := m.getPlaylistManager(session, Quality720p); err != nil {
+		t.Fatalf("720p playlist manager not created: %v", err)
+	}
+
+	// 720p stops being requested while 480p is requested
+	session.RequestRendition(Quality480p)
+	session.RenditionRequests[Quality720p] = time.Now().Add(-2 * renditionIdleTimeout)
+	if err := os.WriteFile(filepath.Join(outputDir, Quality720p, "seg-1.ts"), nil, 0o644); err != nil {
+		t.Fatalf("failed to write segment: %v", err)
+	}
+
+	if n := m.syncRenditions(session); n != 1 {
+		t.Fatalf("syncRenditions after switching to 480p = %d, want 1", n)
+	}
+	rs := m.renditionsFor(session)
+	if rs.isLive(Quality720p) || !rs.isLive(Quality480p) {
+		t.Errorf("live renditions = 720p %v, 480p %v, want only 480p", rs.isLive(Quality720p), rs.isLive(Quality480p))
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, Quality720p)); !os.IsNotExist(err) {
+		t.Error("stopped rendition segments should be removed")
+	}
+	if _, ok := session.GetRenditionRequests()[Quality720p]; ok {
+		t.Error("stopped rendition request should be forgotten")
+	}
+
+	// The last rendition keeps running even when idle
+	session.RenditionRequests[Quality480p] = time.Now().Add(-2 * renditionIdleTimeout)
+	if n := m.syncRenditions(session); n != 1 {
+		t.Errorf("syncRenditions with every rendition idle = %d, want 1", n)
+	}
+}
+
+func TestSyncRenditions_PositionsKeepRenditionAlive(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		BatchSize:             5,
+		StreamSegmentDuration: 4,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(t.TempDir())
+	session.SetQualities([]models.StreamQuality{{Level: Quality1080p}, {Level: Quality720p}})
+
+	session.RequestRendition(Quality1080p)
+	session.RequestRendition(Quality720p)
+	m.syncRenditions(session)
+
+	// 1080p is only reported through client positions now
+	session.RenditionRequests[Quality1080p] = time.Now().Add(-2 * renditionIdleTimeout)
+	session.UpdateClientPosition("player", 3, Quality1080p)
+
+	if n := m.syncRenditions(session); n != 2 {
+		t.Errorf("syncRenditions = %d, want 2 (1080p kept alive by the client position)", n)
+	}
+}
+
+func TestAddEncodedPart_LowLatencyPlaylist(t *testing.T) {
+	outputDir := t.TempDir()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		BatchSize:             5,
+		StreamSegmentDuration: 4,
+		PartDuration:          1000,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(outputDir)
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}})
+	session.SetSegmentFormat(SegmentFormatFMP4)
+	session.RequestRendition(Quality720p)
+	m.syncRenditions(session)
+
+	pm, ok := m.PlaylistManager(session.ChannelID, Quality720p)
+	if !ok {
+		t.Fatal("playlist manager not created")
+	}
+	if pm.GetPartTarget() != 1.0 {
+		t.Fatalf("part target = %v, want 1.0 for an fMP4 stream", pm.GetPartTarget())
+	}
+
+	encoder := newSegmentEncoder(Quality720p, HardwareAccelNone, filepath.Join(outputDir, Quality720p), "", "", 0, nil)
+	m.addEncodedPart(session, encoder, encodedPart{number: 1, filename: "seg-000001.m4s", length: 500, duration: 1.0, independent: true})
+
+	data, err := os.ReadFile(filepath.Join(outputDir, Quality720p, Quality720p+".m3u8"))
+	if err != nil {
+		t.Fatalf("playlist not written: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, `#EXT-X-PART:DURATION=1.000,URI="seg-000001.m4s",BYTERANGE="500@0",INDEPENDENT=YES`) {
+		t.Errorf("playlist is missing the part:\n%s", content)
+	}
+	if !strings.Contains(content, `#EXT-X-PRELOAD-HINT:TYPE=PART,URI="seg-000001.m4s",BYTERANGE-START=500`) {
+		t.Errorf("playlist is missing the preload hint for the next part:\n%s", content)
+	}
+}
+
+func TestSyncRenditions_NoPartsForTSStreams(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		BatchSize:             5,
+		StreamSegmentDuration: 4,
+		PartDuration:          1000,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(t.TempDir())
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}})
+	session.RequestRendition(Quality720p)
+	m.syncRenditions(session)
+
+	pm, ok := m.PlaylistManager(session.ChannelID, Quality720p)
+	if !ok {
+		t.Fatal("playlist manager not created")
+	}
+	if pm.GetPartTarget() != 0 {
+		t.Errorf("part target = %v, want 0 for an MPEG-TS stream", pm.GetPartTarget())
+	}
+}
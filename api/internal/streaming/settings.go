@@ -0,0 +1,218 @@
+package streaming
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// transcodeQualityLevels maps the transcode_quality setting to the stream quality level it produces
+var transcodeQualityLevels = map[string]string{
+	models.QualityUltra:  Quality2160p,
+	models.QualityHigh:   Quality1080p,
+	models.QualityMedium: Quality720p,
+	models.QualityLow:    Quality480p,
+}
+
+// QualityForSetting returns the stream quality level for a transcode_quality setting
+func QualityForSetting(transcodeQuality string) (string, error) {
+	quality, ok := transcodeQualityLevels[transcodeQuality]
+	if !ok {
+		return "", fmt.Errorf("%w: transcode quality %q", ErrInvalidQuality, transcodeQuality)
+	}
+	return quality, nil
+}
+
+// ApplySettings applies runtime settings to the stream manager.
+// Hardware acceleration takes effect from the next generated segment; a quality change
+// reloads every active stream so viewers switch to the new rendition.
+func (m *StreamManager) ApplySettings(settings *models.Settings) {
+	quality, err := QualityForSetting(settings.TranscodeQuality)
+	if err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Msg("Ignoring invalid transcode quality setting")
+	}
+
+	hwAccel := HardwareAccel(settings.HardwareAccel)
+	if !hwAccel.IsValid() {
+		logger.Log.Warn().
+			Str("hardware_accel", settings.HardwareAccel).
+			Msg("Ignoring invalid hardware acceleration setting")
+		hwAccel = ""
+	}
+
+	m.mu.Lock()
+	previousQuality := m.quality
+	if quality != "" {
+		m.quality = quality
+	}
+	if hwAccel != "" {
+		m.config.HardwareAccel = hwAccel.String()
+	}
+	currentQuality := m.quality
+	currentHwAccel := m.config.HardwareAccel
+	encoders := m.encoders
+	m.mu.Unlock()
+
+	if caps := encodersCapabilities(encoders); caps != nil {
+		method := HardwareAccel(currentHwAccel)
+		if method != HardwareAccelAuto && !caps.IsVerified(method) {
+			logger.Log.Warn().
+				Str("hardware_accel", currentHwAccel).
+				Msg("Configured hardware encoder was not verified on this machine")
+		}
+	}
+
+	logger.Log.Info().
+		Str("quality", currentQuality).
+		Str("hardware_accel", currentHwAccel).
+		Msg("Applied streaming settings")
+
+	if currentQuality == previousQuality {
+		return
+	}
+
+	for _, session := range m.sessionManager.List() {
+		if sessionQuality(session) == currentQuality {
+			continue
+		}
+
+		channelID := session.ChannelID
+		go func(channelID uuid.UUID) {
+			if err := m.reloadStream(context.Background(), channelID, "transcode quality changed"); err != nil {
+				logger.Log.Error().
+					Err(err).
+					Str("channel_id", channelID.String()).
+					Msg("Failed to reload stream after quality change")
+			}
+		}(channelID)
+	}
+}
+
+// currentQuality returns the quality level used for newly started streams
+func (m *StreamManager) currentQuality() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.quality
+}
+
+// SetEncoderDetector sets the detector used to resolve "auto" hardware acceleration
+// Must be called before the manager is started
+func (m *StreamManager) SetEncoderDetector(detector *EncoderDetector) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.encoders = detector
+}
+
+// currentHardwareAccel returns the hardware acceleration method used for new segments,
+// with "auto" resolved to the detected encoder (software if detection is unavailable)
+func (m *StreamManager) currentHardwareAccel() HardwareAccel {
+	m.mu.RLock()
+	method := HardwareAccel(m.config.HardwareAccel)
+	encoders := m.encoders
+	m.mu.RUnlock()
+
+	if encoders == nil {
+		if method == HardwareAccelAuto {
+			return HardwareAccelNone
+		}
+		return method
+	}
+	return encoders.Resolve(method)
+}
+
+// renditionCodec returns the video codec a rendition of a new stream is encoded with: the codec
+// configured for its quality, or H.264 when this machine cannot encode that codec or the stream's
+// segment format cannot carry it (AV1 and HEVC need fMP4)
+func (m *StreamManager) renditionCodec(quality, segmentFormat string) VideoCodec {
+	m.mu.RLock()
+	codec := VideoCodec(m.config.Codecs[quality]).orDefault()
+	encoders := m.encoders
+	m.mu.RUnlock()
+
+	if codec == VideoCodecH264 {
+		return codec
+	}
+
+	reason := ""
+	switch {
+	case !codec.IsValid():
+		reason = "unknown codec"
+	case codec == VideoCodecAV1 && segmentFormat != SegmentFormatFMP4:
+		reason = "AV1 requires fmp4 segments"
+	case codec == VideoCodecHEVC && segmentFormat != SegmentFormatFMP4:
+		reason = "HEVC requires fmp4 segments" // Safari only plays HEVC from fMP4
+	default:
+		if caps := encodersCapabilities(encoders); caps != nil && !caps.CanEncode(codec, HardwareAccelAuto) {
+			reason = "no verified encoder"
+		}
+	}
+	if reason != "" {
+		logger.Log.Warn().
+			Str("quality", quality).
+			Str("codec", codec.String()).
+			Str("reason", reason).
+			Msg("Falling back to H.264 for rendition")
+		return VideoCodecH264
+	}
+	return codec
+}
+
+// codecHardwareAccel returns the hardware acceleration method to encode a codec with: the current
+// method when it has a verified encoder for the codec, otherwise the best verified one.
+// H.264 always uses the current method.
+func (m *StreamManager) codecHardwareAccel(codec VideoCodec) HardwareAccel {
+	method := m.currentHardwareAccel()
+	if codec.orDefault() == VideoCodecH264 {
+		return method
+	}
+
+	m.mu.RLock()
+	encoders := m.encoders
+	m.mu.RUnlock()
+
+	caps := encodersCapabilities(encoders)
+	if caps == nil {
+		if !hasEncoder(codec, method) {
+			return HardwareAccelNone
+		}
+		return method
+	}
+	if caps.CanEncode(codec, method) {
+		return method
+	}
+	if verified := caps.Codecs[codec]; len(verified) > 0 {
+		return verified[0] // Sorted by priority
+	}
+	return HardwareAccelNone
+}
+
+// encodersCapabilities returns the cached capabilities of a possibly nil detector
+func encodersCapabilities(detector *EncoderDetector) *EncoderCapabilities {
+	if detector == nil {
+		return nil
+	}
+	return detector.Capabilities()
+}
+
+// sessionCodec returns the video codec of a session's rendition (H.264 if it has none)
+func sessionCodec(session *models.StreamSession, quality string) VideoCodec {
+	for _, q := range session.GetQualities() {
+		if q.Level == quality {
+			return VideoCodec(q.Codec).orDefault()
+		}
+	}
+	return VideoCodecH264
+}
+
+// sessionQuality returns the quality level a session was started with
+func sessionQuality(session *models.StreamSession) string {
+	if qualities := session.GetQualities(); len(qualities) > 0 {
+		return qualities[0].Level
+	}
+	return Quality1080p
+}
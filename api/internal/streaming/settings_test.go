@@ -0,0 +1,148 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestQualityForSetting(t *testing.T) {
+	tests := []struct {
+		setting string
+		want    string
+		wantErr bool
+	}{
+		{models.QualityUltra, Quality2160p, false},
+		{models.QualityHigh, Quality1080p, false},
+		{models.QualityMedium, Quality720p, false},
+		{models.QualityLow, Quality480p, false},
+		{"extreme", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.setting, func(t *testing.T) {
+			got, err := QualityForSetting(tt.setting)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuality) {
+					t.Errorf("QualityForSetting(%q) error = %v, want ErrInvalidQuality", tt.setting, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("QualityForSetting(%q) unexpected error: %v", tt.setting, err)
+			}
+			if got != tt.want {
+				t.Errorf("QualityForSetting(%q) = %q, want %q", tt.setting, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamManager_ApplySettings(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{HardwareAccel: string(HardwareAccelNone)})
+
+	m.ApplySettings(&models.Settings{
+		TranscodeQuality: models.QualityLow,
+		HardwareAccel:    models.HardwareAccelVAAPI,
+	})
+	if got := m.currentQuality(); got != Quality480p {
+		t.Errorf("currentQuality() = %q, want %q", got, Quality480p)
+	}
+	if got := m.currentHardwareAccel(); got != HardwareAccelVAAPI {
+		t.Errorf("currentHardwareAccel() = %q, want %q", got, HardwareAccelVAAPI)
+	}
+
+	// Invalid values leave the current settings untouched
+	m.ApplySettings(&models.Settings{TranscodeQuality: "extreme", HardwareAccel: "cuda"})
+	if got := m.currentQuality(); got != Quality480p {
+		t.Errorf("currentQuality() after invalid settings = %q, want %q", got, Quality480p)
+	}
+	if got := m.currentHardwareAccel(); got != HardwareAccelVAAPI {
+		t.Errorf("currentHardwareAccel() after invalid settings = %q, want %q", got, HardwareAccelVAAPI)
+	}
+}
+
+func TestStreamManager_RenditionCodec(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		HardwareAccel: string(HardwareAccelAuto),
+		Codecs:        map[string]string{Quality2160p: "hevc", Quality1080p: "av1"},
+	})
+
+	// Without detection the configured codec is trusted
+	if got := m.renditionCodec(Quality2160p, SegmentFormatFMP4); got != VideoCodecHEVC {
+		t.Errorf("renditionCodec(2160p) = %v, want hevc", got)
+	}
+	if got := m.renditionCodec(Quality720p, SegmentFormatTS); got != VideoCodecH264 {
+		t.Errorf("renditionCodec(720p) = %v, want h264 for unlisted rungs", got)
+	}
+
+	// HEVC and AV1 need fMP4 segments
+	if got := m.renditionCodec(Quality2160p, SegmentFormatTS); got != VideoCodecH264 {
+		t.Errorf("renditionCodec(2160p, ts) = %v, want h264", got)
+	}
+	if got := m.renditionCodec(Quality1080p, SegmentFormatTS); got != VideoCodecH264 {
+		t.Errorf("renditionCodec(1080p, ts) = %v, want h264", got)
+	}
+	if got := m.renditionCodec(Quality1080p, SegmentFormatFMP4); got != VideoCodecAV1 {
+		t.Errorf("renditionCodec(1080p, fmp4) = %v, want av1", got)
+	}
+
+	// A codec this machine cannot encode falls back to H.264
+	detector := &EncoderDetector{
+		detect: func(ctx context.Context) (map[VideoCodec][]HardwareAccel, error) {
+			return map[VideoCodec][]HardwareAccel{
+				VideoCodecH264: {HardwareAccelNone, HardwareAccelNVENC},
+				VideoCodecHEVC: {HardwareAccelNVENC},
+			}, nil
+		},
+		verify: func(ctx context.Context, codec VideoCodec, method HardwareAccel) error { return nil },
+	}
+	detector.Detect(context.Background())
+	m.SetEncoderDetector(detector)
+
+	if got := m.renditionCodec(Quality2160p, SegmentFormatFMP4); got != VideoCodecHEVC {
+		t.Errorf("renditionCodec(2160p) with hevc_nvenc = %v, want hevc", got)
+	}
+	if got := m.renditionCodec(Quality1080p, SegmentFormatFMP4); got != VideoCodecH264 {
+		t.Errorf("renditionCodec(1080p) without an AV1 encoder = %v, want h264", got)
+	}
+}
+
+func TestStreamManager_CodecHardwareAccel(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{HardwareAccel: string(HardwareAccelVideoToolbox)})
+
+	// Without detection only methods lacking an encoder for the codec change
+	if got := m.codecHardwareAccel(VideoCodecHEVC); got != HardwareAccelVideoToolbox {
+		t.Errorf("codecHardwareAccel(hevc) = %v, want videotoolbox", got)
+	}
+	if got := m.codecHardwareAccel(VideoCodecAV1); got != HardwareAccelNone {
+		t.Errorf("codecHardwareAccel(av1) = %v, want none", got)
+	}
+
+	detector := &EncoderDetector{
+		detect: func(ctx context.Context) (map[VideoCodec][]HardwareAccel, error) {
+			return map[VideoCodec][]HardwareAccel{
+				VideoCodecH264: {HardwareAccelNone, HardwareAccelVideoToolbox},
+				VideoCodecHEVC: {HardwareAccelNone},
+				VideoCodecAV1:  {HardwareAccelNone, HardwareAccelQSV},
+			}, nil
+		},
+		verify: func(ctx context.Context, codec VideoCodec, method HardwareAccel) error { return nil },
+	}
+	detector.Detect(context.Background())
+	m.SetEncoderDetector(detector)
+
+	// H.264 keeps the configured method; other codecs use their best verified one
+	if got := m.codecHardwareAccel(VideoCodecH264); got != HardwareAccelVideoToolbox {
+		t.Errorf("codecHardwareAccel(h264) = %v, want videotoolbox", got)
+	}
+	if got := m.codecHardwareAccel(VideoCodecHEVC); got != HardwareAccelNone {
+		t.Errorf("codecHardwareAccel(hevc) = %v, want none", got)
+	}
+	if got := m.codecHardwareAccel(VideoCodecAV1); got != HardwareAccelQSV {
+		t.Errorf("codecHardwareAccel(av1) = %v, want qsv", got)
+	}
+}
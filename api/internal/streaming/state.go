@@ -0,0 +1,405 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+)
+
+// streamStateFilename is the file in a stream's output directory its state is saved to
+const streamStateFilename = "session.json"
+
+// Stream state errors
+var (
+	ErrStreamStateExpired = errors.New("saved stream state is too old to resume")
+	ErrStreamStateInvalid = errors.New("saved stream state cannot be resumed")
+)
+
+// streamState is what a stream needs to resume after a restart: the session (batch, clients,
+// positions), the recent segment sources encoders restart from and the playlist window of
+// every live rendition
+type streamState struct {
+	SavedAt    time.Time                 `json:"saved_at"`
+	Session    *models.StreamSession     `json:"session"`
+	Sources    []sourceState             `json:"sources"`
+	Periods    []periodState             `json:"periods"`
+	Renditions map[string]renditionState `json:"renditions"`
+}
+
+// sourceState is a saved segmentSource
+type sourceState struct {
+	Number          int       `json:"number"`
+	VideoPath       string    `json:"video_path"`
+	OffsetSeconds   float64   `json:"offset_seconds"`
+	Discontinuity   bool      `json:"discontinuity,omitempty"`
+	ProgramDateTime time.Time `json:"program_date_time"`
+}
+
+// periodState is a saved periodStart
+type periodState struct {
+	Number          int       `json:"number"`
+	ProgramDateTime time.Time `json:"program_date_time"`
+}
+
+// renditionState is the saved playlist window of a live rendition. Its segments are numbered
+// consecutively from MediaSequence; the segments retained for catch-up precede them.
+type renditionState struct {
+	First         int                    `json:"first"`
+	MediaSequence uint64                 `json:"media_sequence"`
+	Segments      []playlist.SegmentMeta `json:"segments"`
+	Retained      []playlist.SegmentMeta `json:"retained,omitempty"`
+}
+
+// next returns the number of the segment after the rendition's playlist window
+func (r renditionState) next() int {
+	return int(r.MediaSequence) + len(r.Segments) // nolint:gosec // segment numbers fit in an int
+}
+
+// streamResumeWindow is how long after it was saved a stream can still be resumed: the length of
+// its playlist window. Players away for longer have nothing left in common with the saved window.
+func (m *StreamManager) streamResumeWindow() time.Duration {
+	return time.Duration(m.config.BatchSize*3*m.config.StreamSegmentDuration) * time.Second
+}
+
+// saveStreamState writes a stream's state next to its segments. Streams being stopped are not
+// saved, so a stopped stream's directory is never recreated.
+func (m *StreamManager) saveStreamState(session *models.StreamSession, rs *renditionSet) {
+	rs.stateMu.Lock()
+	defer rs.stateMu.Unlock()
+	if StreamState(session.GetState()) == StateStopping {
+		return
+	}
+	m.writeStreamStateLocked(session, rs, false)
+}
+
+// writeStreamStateLocked writes a stream's state file. The segments retained for catch-up (hours
+// of them) are only written with retained, when the stream is suspended: saves after every segment
+// write the playlist windows alone. The caller holds rs.stateMu.
+func (m *StreamManager) writeStreamStateLocked(session *models.StreamSession, rs *renditionSet, retained bool) {
+	outputDir := session.GetOutputDir()
+	if outputDir == "" {
+		return
+	}
+
+	state := streamState{
+		SavedAt:    time.Now().UTC(),
+		Session:    session,
+		Renditions: make(map[string]renditionState),
+	}
+	firsts := make(map[string]int)
+	rs.mu.Lock()
+	for _, src := range rs.sources {
+		state.Sources = append(state.Sources, sourceState{
+			Number:          src.number,
+			VideoPath:       src.videoPath,
+			OffsetSeconds:   src.offsetSeconds,
+			Discontinuity:   src.discontinuity,
+			ProgramDateTime: src.programDateTime,
+		})
+	}
+	for _, period := range rs.periods {
+		state.Periods = append(state.Periods, periodState{Number: period.number, ProgramDateTime: period.programDateTime})
+	}
+	for quality, r := range rs.renditions {
+		if r.live {
+			firsts[quality] = r.first
+		}
+	}
+	rs.mu.Unlock()
+
+	// Renditions still catching up start over after a restart
+	for quality, first := range firsts {
+		pm, err := m.getPlaylistManager(session, quality)
+		if err != nil {
+			continue
+		}
+		mediaSequence, segments := pm.GetWindow()
+		r := renditionState{First: first, MediaSequence: mediaSequence, Segments: segments}
+		// Retained segments are those before the window: even if a segment was added since, they
+		// end right before its first segment
+		if retained {
+			if retainedFrom, all := pm.GetRetained(); retainedFrom <= mediaSequence {
+				r.Retained = all[:mediaSequence-retainedFrom]
+			}
+		}
+		state.Renditions[quality] = r
+	}
+
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = WritePlaylistAtomic(filepath.Join(outputDir, streamStateFilename), string(data))
+	}
+	if err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("channel_id", session.ChannelID.String()).
+			Msg("Failed to save stream state")
+	}
+}
+
+// suspendStream stops a stream on shutdown without removing its segments. Its state is saved
+// first, so the stream resumes from the same segment numbers when the manager starts again.
+func (m *StreamManager) suspendStream(session *models.StreamSession) {
+	channelIDStr := session.ChannelID.String()
+	rs := m.renditionsFor(session)
+
+	rs.stateMu.Lock()
+	session.SetState(StateStopping.String())
+	m.writeStreamStateLocked(session, rs, true)
+	rs.stateMu.Unlock()
+
+	if pid := session.GetFFmpegPID(); pid > 0 {
+		if err := terminateProcess(pid); err != nil {
+			logger.Log.Warn().
+				Err(err).
+				Str("channel_id", channelIDStr).
+				Int("pid", pid).
+				Msg("Failed to terminate FFmpeg process")
+		}
+	}
+
+	m.releaseAdmission(channelIDStr)
+	m.deleteRenditions(channelIDStr)
+	m.closePlaylistManagersForChannel(channelIDStr)
+	m.sessionManager.Delete(channelIDStr)
+	m.sessionManager.DeleteCircuitBreaker(channelIDStr)
+
+	logger.Log.Info().
+		Str("channel_id", channelIDStr).
+		Msg("Stream suspended, segments kept for resuming")
+}
+
+// restoreStreams resumes the streams saved in the segment directory, e.g. before a restart.
+// Directories of streams that cannot be resumed are removed.
+func (m *StreamManager) restoreStreams(ctx context.Context) {
+	entries, err := os.ReadDir(m.config.SegmentPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Log.Warn().
+				Err(err).
+				Str("segment_path", m.config.SegmentPath).
+				Msg("Failed to read segment directory, not resuming streams")
+		}
+		return
+	}
+
+	restored := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || !isLikelyChannelID(entry.Name()) {
+			continue
+		}
+		outputDir := filepath.Join(m.config.SegmentPath, entry.Name())
+		if err := m.restoreStream(ctx, outputDir); err != nil {
+			logger.Log.Info().
+				Err(err).
+				Str("output_dir", outputDir).
+				Msg("Not resuming saved stream, removing its segments")
+			if err := cleanupSegments(outputDir); err != nil {
+				logger.Log.Warn().
+					Err(err).
+					Str("output_dir", outputDir).
+					Msg("Failed to cleanup segments")
+			}
+			continue
+		}
+		restored++
+	}
+
+	if restored > 0 {
+		logger.Log.Info().
+			Int("restored_count", restored).
+			Msg("Resumed saved streams")
+	}
+}
+
+// restoreStream resumes the stream saved in outputDir. Every live rendition continues after the
+// last segment of its saved playlist window, with the same segment numbers and media sequence.
+// The current batch is cut back to the segments all renditions have, so the next batch plans the
+// rest again.
+func (m *StreamManager) restoreStream(ctx context.Context, outputDir string) error {
+	data, err := os.ReadFile(filepath.Join(outputDir, streamStateFilename))
+	if err != nil {
+		return fmt.Errorf("failed to read stream state: %w", err)
+	}
+	state := streamState{Session: models.NewStreamSession(uuid.Nil)}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse stream state: %w", err)
+	}
+
+	session := state.Session
+	channelIDStr := session.ChannelID.String()
+	if age := time.Since(state.SavedAt); age > m.streamResumeWindow() {
+		return fmt.Errorf("%w: saved %s ago", ErrStreamStateExpired, age.Round(time.Second))
+	}
+	if channelIDStr != filepath.Base(outputDir) || filepath.Clean(session.GetOutputDir()) != filepath.Clean(outputDir) {
+		return fmt.Errorf("%w: saved for another segment directory", ErrStreamStateInvalid)
+	}
+	// Without a configured key secret the keys of the saved segments are gone
+	if session.GetEncryption() == models.EncryptionAES128 && m.config.KeySecret == "" {
+		return fmt.Errorf("%w: encrypted with a key secret that changed on restart", ErrStreamStateInvalid)
+	}
+	if m.repos != nil {
+		if _, err := m.repos.Channels.GetByID(ctx, session.ChannelID); err != nil {
+			return fmt.Errorf("failed to get channel: %w", err)
+		}
+	}
+
+	// The batch loop resumes after the last segment every rendition has
+	resume := -1
+	for quality, r := range state.Renditions {
+		if len(r.Segments) == 0 {
+			delete(state.Renditions, quality)
+			continue
+		}
+		if resume < 0 || r.next() < resume {
+			resume = r.next()
+		}
+	}
+	batch := session.GetCurrentBatch()
+	if resume < 0 || batch == nil || len(state.Sources) == 0 {
+		return fmt.Errorf("%w: no segments", ErrStreamStateInvalid)
+	}
+
+	// The next batch starts where the resume segment was planned, or after the last planned segment
+	last := state.Sources[len(state.Sources)-1]
+	found := resume == last.Number+1
+	if found {
+		batch.VideoSourcePath = last.VideoPath
+		batch.VideoStartOffset = last.OffsetSeconds + float64(m.config.StreamSegmentDuration)
+	}
+	for _, src := range state.Sources {
+		if src.Number == resume {
+			batch.VideoSourcePath = src.VideoPath
+			batch.VideoStartOffset = src.OffsetSeconds
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: source of segment %d is missing", ErrStreamStateInvalid, resume)
+	}
+	batch.EndSegment = resume - 1
+	batch.IsComplete = true
+	if batch.GenerationEnded.IsZero() {
+		batch.GenerationEnded = state.SavedAt
+	}
+
+	rs := newRenditionSet(m.config.BatchSize * 3)
+	for _, src := range state.Sources {
+		if src.Number < resume {
+			rs.sources = append(rs.sources, segmentSource{
+				number:          src.Number,
+				videoPath:       src.VideoPath,
+				offsetSeconds:   src.OffsetSeconds,
+				discontinuity:   src.Discontinuity,
+				programDateTime: src.ProgramDateTime,
+			})
+		}
+	}
+	for _, period := range state.Periods {
+		if period.Number < resume {
+			rs.periods = append(rs.periods, periodStart{number: period.Number, programDateTime: period.ProgramDateTime})
+		}
+	}
+	for quality, r := range state.Renditions {
+		rs.renditions[quality] = &rendition{live: true, first: r.First, next: r.next()}
+	}
+
+	// Saved clients may never come back, so none are counted: players re-register with their
+	// playlist reloads, and if none do within the grace period the stream stops. The renditions'
+	// idle timeouts start over.
+	savedClients := session.GetClientCount()
+	session.ClearSessions()
+	session.SetState(StateIdle.String())
+	session.SetFFmpegPID(0)
+	session.UpdateLastAccess()
+	for quality := range state.Renditions {
+		session.RequestRendition(quality)
+	}
+
+	m.renditionsMu.Lock()
+	m.renditions[channelIDStr] = rs
+	m.renditionsMu.Unlock()
+
+	for quality, r := range state.Renditions {
+		if err := m.restorePlaylist(session, quality, r); err != nil {
+			m.closePlaylistManagersForChannel(channelIDStr)
+			m.renditionsMu.Lock()
+			delete(m.renditions, channelIDStr)
+			m.renditionsMu.Unlock()
+			return err
+		}
+	}
+	m.writeDASHManifest(session, rs)
+	m.sessionManager.Set(channelIDStr, session)
+
+	logger.Log.Info().
+		Str("channel_id", channelIDStr).
+		Int("saved_clients", savedClients).
+		Int("resume_segment", resume).
+		Int("renditions", len(state.Renditions)).
+		Msg("Resumed saved stream")
+
+	return nil
+}
+
+// restorePlaylist recreates a rendition's playlist manager with its saved window and the segments
+// retained for catch-up, deleting those that expired while the server was down. Segment files the
+// state does not list (retained segments of a stream that was not suspended cleanly) are deleted.
+func (m *StreamManager) restorePlaylist(session *models.StreamSession, quality string, r renditionState) error {
+	if err := m.ensurePlaylistManager(session, quality, filepath.Join(session.GetOutputDir(), quality)); err != nil {
+		return err
+	}
+	pm, err := m.getPlaylistManager(session, quality)
+	if err != nil {
+		return err
+	}
+
+	qualityDir := filepath.Join(session.GetOutputDir(), quality)
+	pm.SetMediaSequence(r.MediaSequence - uint64(len(r.Retained)))
+	for _, seg := range append(r.Retained, r.Segments...) {
+		prunedURIs, err := pm.AddSegment(seg)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s playlist: %w", quality, err)
+		}
+		for _, prunedURI := range prunedURIs {
+			_ = os.Remove(filepath.Join(qualityDir, prunedURI))
+		}
+	}
+	if err := pm.Write(); err != nil {
+		return fmt.Errorf("failed to write %s playlist: %w", quality, err)
+	}
+	removeUnlistedSegments(qualityDir, pm)
+	return nil
+}
+
+// removeUnlistedSegments deletes the segment and init segment files of a rendition directory that
+// its playlist manager no longer lists, in its window or retained for catch-up
+func removeUnlistedSegments(qualityDir string, pm playlist.Manager) {
+	entries, err := os.ReadDir(qualityDir)
+	if err != nil {
+		return
+	}
+	listed := make(map[string]bool)
+	_, segments := pm.GetRetained()
+	for _, seg := range segments {
+		listed[seg.URI] = true
+		listed[seg.Map] = true
+	}
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".ts", ".m4s", ".mp4":
+			if !entry.IsDir() && !listed[entry.Name()] {
+				_ = os.Remove(filepath.Join(qualityDir, entry.Name()))
+			}
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package streaming
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+	"github.com/stwalsh4118/hermes/internal/streaming/playlist"
+)
+
+// newSavedStreamManager returns a manager with a running stream in segmentPath whose 720p rendition
+// has segments 0-4 and whose 480p rendition has segments 0-2 of a batch planned up to segment 9
+func newSavedStreamManager(t *testing.T, segmentPath string) (*StreamManager, *models.StreamSession) {
+	t.Helper()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		SegmentPath:           segmentPath,
+		BatchSize:             10,
+		StreamSegmentDuration: 4,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(filepath.Join(segmentPath, session.ChannelID.String()))
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}, {Level: Quality480p}})
+	session.RequestRendition(Quality720p)
+	session.RequestRendition(Quality480p)
+	session.RegisterSession("player-1")
+	session.IncrementClients()
+	session.UpdateClientPosition("player-1", 3, Quality720p)
+	session.SetCurrentBatch(&models.BatchState{BatchNumber: 0, StartSegment: 0, EndSegment: 9, VideoSourcePath: "a.mp4"})
+	m.sessionManager.Set(session.ChannelID.String(), session)
+	m.syncRenditions(session)
+
+	// The whole batch is planned; only some of its segments are encoded
+	rs := m.renditionsFor(session)
+	videos := make([]string, 10)
+	for number := range videos {
+		videos[number] = "a.mp4"
+		rs.record(segmentSource{number: number, videoPath: "a.mp4", offsetSeconds: float64(number) * 4})
+	}
+	addTestSegments(t, m, session, Quality720p, 0, videos[:5], "")
+	addTestSegments(t, m, session, Quality480p, 0, videos[:3], "")
+	return m, session
+}
+
+func TestStreamState_RestoreResumesWhereRenditionsStopped(t *testing.T) {
+	segmentPath := t.TempDir()
+	m, session := newSavedStreamManager(t, segmentPath)
+	m.Stop()
+
+	if _, err := os.Stat(filepath.Join(session.GetOutputDir(), streamStateFilename)); err != nil {
+		t.Fatalf("stream state not saved on stop: %v", err)
+	}
+
+	restarted := NewStreamManager(nil, nil, m.config)
+	restarted.restoreStreams(t.Context())
+
+	restored, ok := restarted.GetStream(session.ChannelID)
+	if !ok {
+		t.Fatal("stream was not resumed")
+	}
+	if restored.ID != session.ID || restored.GetFurthestPosition() != 3 {
+		t.Errorf("session = id %s, furthest %d; want the saved session", restored.ID, restored.GetFurthestPosition())
+	}
+	// Clients are only counted again once their players re-register
+	if restored.GetClientCount() != 0 || restored.RegisterSession("player-1") != true {
+		t.Errorf("restored session has %d clients and keeps its registrations; want none", restored.GetClientCount())
+	}
+	if restored.GetState() != StateIdle.String() {
+		t.Errorf("state = %s, want idle", restored.GetState())
+	}
+
+	// The batch is cut back to the segments every rendition has
+	batch := restored.GetCurrentBatch()
+	if batch == nil || batch.EndSegment != 2 || !batch.IsComplete || batch.VideoStartOffset != 12 {
+		t.Fatalf("batch = %+v, want complete up to segment 2, continuing at offset 12", batch)
+	}
+
+	rs := restarted.renditionsFor(restored)
+	if newest, _ := rs.newest(); newest.number != 2 {
+		t.Errorf("newest source = %d, want 2 (later sources are planned again)", newest.number)
+	}
+	for quality, want := range map[string]int{Quality720p: 5, Quality480p: 3} {
+		if r := rs.renditions[quality]; r == nil || !r.live || r.next != want {
+			t.Errorf("%s rendition = %+v, want live continuing at %d", quality, r, want)
+		}
+	}
+
+	pm, ok := restarted.PlaylistManager(session.ChannelID, Quality720p)
+	if !ok {
+		t.Fatal("720p playlist manager not restored")
+	}
+	mediaSequence, segments := pm.GetWindow()
+	if mediaSequence != 0 || len(segments) != 5 {
+		t.Errorf("720p window = sequence %d with %d segments, want 0 with 5", mediaSequence, len(segments))
+	}
+}
+
+// newCatchUpStreamManager returns a manager with a running stream in segmentPath whose 720p
+// rendition has segments 0-9, of which 0-3 left its six segment window and are retained for catch-up
+func newCatchUpStreamManager(t *testing.T, segmentPath string) (*StreamManager, *models.StreamSession) {
+	t.Helper()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		SegmentPath:           segmentPath,
+		BatchSize:             2,
+		StreamSegmentDuration: 4,
+		CatchUpSeconds:        3600,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(filepath.Join(segmentPath, session.ChannelID.String()))
+	session.SetQualities([]models.StreamQuality{{Level: Quality720p}})
+	session.RequestRendition(Quality720p)
+	session.SetCurrentBatch(&models.BatchState{BatchNumber: 4, StartSegment: 8, EndSegment: 9, VideoSourcePath: "a.mp4"})
+	m.sessionManager.Set(session.ChannelID.String(), session)
+	m.syncRenditions(session)
+
+	pm, err := m.getPlaylistManager(session, Quality720p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := m.renditionsFor(session)
+	for number := range 10 {
+		programDateTime := session.StartedAt.Add(time.Duration(number) * 4 * time.Second)
+		rs.record(segmentSource{number: number, videoPath: "a.mp4", offsetSeconds: float64(number) * 4, programDateTime: programDateTime})
+		uri := fmt.Sprintf("seg-%06d.ts", number)
+		if err := os.WriteFile(filepath.Join(session.GetOutputDir(), Quality720p, uri), []byte("segment"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pm.AddSegment(playlist.SegmentMeta{URI: uri, Duration: 4, ProgramDateTime: &programDateTime}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m, session
+}
+
+func TestStreamState_RetainedSegmentsOnlySavedOnSuspend(t *testing.T) {
+	segmentPath := t.TempDir()
+	m, session := newCatchUpStreamManager(t, segmentPath)
+	qualityDir := filepath.Join(session.GetOutputDir(), Quality720p)
+
+	readState := func() renditionState {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(session.GetOutputDir(), streamStateFilename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var state streamState
+		if err := json.Unmarshal(data, &state); err != nil {
+			t.Fatal(err)
+		}
+		return state.Renditions[Quality720p]
+	}
+
+	// Saves after every segment write the window alone
+	m.saveStreamState(session, m.renditionsFor(session))
+	if r := readState(); r.MediaSequence != 4 || len(r.Segments) != 6 || len(r.Retained) != 0 {
+		t.Errorf("saved rendition = sequence %d, %d segments, %d retained; want 4, 6, 0", r.MediaSequence, len(r.Segments), len(r.Retained))
+	}
+
+	// Suspending saves the retained segments before the window
+	m.Stop()
+	r := readState()
+	if r.MediaSequence != 4 || len(r.Segments) != 6 || len(r.Retained) != 4 || r.Retained[0].URI != "seg-000000.ts" {
+		t.Fatalf("saved rendition = sequence %d, %d segments, %d retained; want 4, 6, 4 from segment 0", r.MediaSequence, len(r.Segments), len(r.Retained))
+	}
+
+	restarted := NewStreamManager(nil, nil, m.config)
+	if err := restarted.restoreStream(t.Context(), session.GetOutputDir()); err != nil {
+		t.Fatalf("restoreStream failed: %v", err)
+	}
+	pm, err := restarted.getPlaylistManager(session, Quality720p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, segments := pm.GetRetained(); first != 0 || len(segments) != 10 || pm.GetMediaSequence() != 4 {
+		t.Errorf("restored playlist = first %d, %d segments, sequence %d; want 0, 10, 4", first, len(segments), pm.GetMediaSequence())
+	}
+	if _, err := os.Stat(filepath.Join(qualityDir, "seg-000000.ts")); err != nil {
+		t.Errorf("retained segment was removed: %v", err)
+	}
+}
+
+func TestStreamState_UnsavedRetainedSegmentsAreRemoved(t *testing.T) {
+	segmentPath := t.TempDir()
+	m, session := newCatchUpStreamManager(t, segmentPath)
+	qualityDir := filepath.Join(session.GetOutputDir(), Quality720p)
+
+	// The server stops without suspending the stream: only the window was saved
+	m.saveStreamState(session, m.renditionsFor(session))
+
+	restarted := NewStreamManager(nil, nil, m.config)
+	if err := restarted.restoreStream(t.Context(), session.GetOutputDir()); err != nil {
+		t.Fatalf("restoreStream failed: %v", err)
+	}
+	pm, err := restarted.getPlaylistManager(session, Quality720p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, segments := pm.GetRetained(); first != 4 || len(segments) != 6 {
+		t.Errorf("restored playlist = first %d, %d segments; want the window from 4, 6 segments", first, len(segments))
+	}
+	for number := range 10 {
+		_, err := os.Stat(filepath.Join(qualityDir, fmt.Sprintf("seg-%06d.ts", number)))
+		if kept := err == nil; kept != (number >= 4) {
+			t.Errorf("segment %d kept = %v, want %v", number, kept, number >= 4)
+		}
+	}
+}
+
+func TestStreamState_ExpiredStateIsRemoved(t *testing.T) {
+	segmentPath := t.TempDir()
+	m, session := newSavedStreamManager(t, segmentPath)
+	m.Stop()
+
+	// Pretend the server was down for longer than the playlist window
+	statePath := filepath.Join(session.GetOutputDir(), streamStateFilename)
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state map[string]any
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	state["saved_at"] = time.Now().Add(-time.Hour)
+	data, _ = json.Marshal(state)
+	if err := os.WriteFile(statePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := NewStreamManager(nil, nil, m.config)
+	if err := restarted.restoreStream(t.Context(), session.GetOutputDir()); !errors.Is(err, ErrStreamStateExpired) {
+		t.Errorf("restoreStream err = %v, want ErrStreamStateExpired", err)
+	}
+	restarted.restoreStreams(t.Context())
+
+	if _, ok := restarted.GetStream(session.ChannelID); ok {
+		t.Error("expired stream was resumed")
+	}
+	if _, err := os.Stat(session.GetOutputDir()); !os.IsNotExist(err) {
+		t.Error("segments of an expired stream were kept")
+	}
+}
+
+func TestStreamState_EncryptedStreamNeedsKeySecret(t *testing.T) {
+	segmentPath := t.TempDir()
+	m, session := newSavedStreamManager(t, segmentPath)
+	session.SetEncryption(models.EncryptionAES128)
+	m.Stop()
+
+	restarted := NewStreamManager(nil, nil, m.config)
+	if err := restarted.restoreStream(t.Context(), session.GetOutputDir()); !errors.Is(err, ErrStreamStateInvalid) {
+		t.Errorf("restoreStream err = %v, want ErrStreamStateInvalid without a key secret", err)
+	}
+
+	m.config.KeySecret = "secret"
+	if err := restarted.restoreStream(t.Context(), session.GetOutputDir()); err != nil {
+		t.Errorf("restoreStream with a key secret failed: %v", err)
+	}
+}
+
+func TestStopStream_RemovesStreamState(t *testing.T) {
+	segmentPath := t.TempDir()
+	m, session := newSavedStreamManager(t, segmentPath)
+	m.saveStreamState(session, m.renditionsFor(session))
+
+	if err := m.StopStream(t.Context(), session.ChannelID); err != nil {
+		t.Fatalf("StopStream failed: %v", err)
+	}
+	statePath := filepath.Join(session.GetOutputDir(), streamStateFilename)
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Error("stopped stream's state still exists")
+	}
+
+	// A save racing with the stop does not bring the state back
+	m.saveStreamState(session, m.renditionsFor(session))
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Error("state save recreated a stopped stream's state")
+	}
+}
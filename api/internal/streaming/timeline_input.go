@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
@@ -42,9 +43,9 @@ type TimelineInput struct {
 
 // ConcatItem represents a file in FFmpeg concat demuxer format
 type ConcatItem struct {
-	FilePath string // Absolute path to media file
-	InPoint  int64  // Start time within file (seconds, 0 = start)
-	OutPoint int64  // End time within file (0 = use all)
+	FilePath string  // Absolute path to media file
+	InPoint  float64 // Start time within file (seconds, 0 = start)
+	OutPoint int64   // End time within file (0 = use all)
 }
 
 // BuildTimelineInput converts a channel's timeline position into FFmpeg input parameters.
@@ -113,12 +114,19 @@ func BuildTimelineInput(
 	shouldConcat := remainingSeconds < ConcatThreshold
 
 	// Build input based on strategy
+	var input *TimelineInput
 	if shouldConcat {
-		return buildConcatInput(ctx, currentFilePath, position.OffsetSeconds,
+		input, err = buildConcatInput(ctx, currentFilePath, position.OffsetSeconds,
 			remainingSeconds, playlist, currentPosition, channel.Loop)
+	} else {
+		input, err = buildSimpleInput(currentFilePath, position.OffsetSeconds, remainingSeconds)
 	}
 
-	return buildSimpleInput(currentFilePath, position.OffsetSeconds, remainingSeconds)
+	if errors.Is(err, ErrFileNotFound) {
+		markMissingPlaylistMedia(ctx, repos, playlist)
+	}
+
+	return input, err
 }
 
 // buildSimpleInput creates a simple seek-based input for a single file
@@ -167,7 +175,7 @@ func buildConcatInput(
 	concatItems := []ConcatItem{
 		{
 			FilePath: currentFilePath,
-			InPoint:  offsetSeconds,
+			InPoint:  float64(offsetSeconds),
 			OutPoint: 0, // Use all remaining
 		},
 	}
@@ -292,7 +300,7 @@ func BuildConcatFile(items []ConcatItem, outputPath string) error {
 
 		// Write inpoint if non-zero (in seconds)
 		if item.InPoint > 0 {
-			builder.WriteString(fmt.Sprintf("inpoint %d\n", item.InPoint))
+			builder.WriteString("inpoint " + strconv.FormatFloat(item.InPoint, 'f', -1, 64) + "\n")
 		}
 
 		// Write outpoint if specified (0 means use all)
@@ -356,3 +364,35 @@ func validateFilePath(path string) error {
 
 	return nil
 }
+
+// markMissingPlaylistMedia re-checks every file in a playlist and marks the ones that no
+// longer exist as missing, so they surface in the reconciliation report
+func markMissingPlaylistMedia(ctx context.Context, repos *db.Repositories, playlist []*models.PlaylistItem) {
+	for _, item := range playlist {
+		if item.Media == nil || item.Media.IsMissing() {
+			continue
+		}
+		if err := validateFilePath(item.Media.FilePath); errors.Is(err, ErrFileNotFound) {
+			markMediaMissing(ctx, repos, item.Media.FilePath)
+		}
+	}
+}
+
+// markMediaMissing records that a media file has disappeared from disk.
+// Failures are logged only; streaming recovery continues regardless.
+func markMediaMissing(ctx context.Context, repos *db.Repositories, filePath string) {
+	marked, err := repos.Media.MarkMissingByPath(ctx, filePath)
+	if err != nil {
+		logger.Log.Error().
+			Err(err).
+			Str("file_path", filePath).
+			Msg("Failed to mark media as missing")
+		return
+	}
+
+	if marked {
+		logger.Log.Warn().
+			Str("file_path", filePath).
+			Msg("Media file is missing from disk, marked as missing")
+	}
+}
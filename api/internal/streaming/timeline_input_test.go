@@ -212,6 +212,17 @@ func TestBuildConcatFile(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Fractional inpoint",
+			items: []ConcatItem{
+				{FilePath: "/path/to/video1.mp4", InPoint: 2.35},
+			},
+			expectedLines: []string{
+				"file '/path/to/video1.mp4'",
+				"inpoint 2.35",
+			},
+			expectError: false,
+		},
 		{
 			name: "Items with inpoint and outpoint",
 			items: []ConcatItem{
@@ -519,7 +530,7 @@ func TestBuildConcatInput(t *testing.T) {
 			assert.GreaterOrEqual(t, result.TotalDuration, tt.minDuration)
 
 			// Verify first item has correct inpoint
-			assert.Equal(t, tt.offsetSeconds, result.ConcatItems[0].InPoint)
+			assert.Equal(t, float64(tt.offsetSeconds), result.ConcatItems[0].InPoint)
 
 			// Verify concat file was created
 			assert.NotEmpty(t, result.ConcatFilePath)
@@ -0,0 +1,188 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/stwalsh4118/hermes/internal/logger"
+)
+
+// ToneMapper is an FFmpeg filter implementation that tone maps HDR (PQ/HLG BT.2020) video to SDR BT.709
+type ToneMapper string
+
+// Tone mappers
+const (
+	ToneMapNone   ToneMapper = ""       // No tone mapping; HDR sources are encoded without it
+	ToneMapZscale ToneMapper = "zscale" // Software: zscale (FFmpeg built with libzimg) and tonemap
+	ToneMapCUDA   ToneMapper = "cuda"   // tonemap_cuda (Jellyfin FFmpeg builds)
+	ToneMapOpenCL ToneMapper = "opencl" // tonemap_opencl
+	ToneMapVAAPI  ToneMapper = "vaapi"  // tonemap_vaapi
+)
+
+// toneMapperPriority lists tone mappers in order of preference: hardware ones take the heaviest
+// filtering of HDR sources off the CPU
+var toneMapperPriority = []ToneMapper{ToneMapCUDA, ToneMapOpenCL, ToneMapVAAPI, ToneMapZscale}
+
+// toneMapperFilters are the FFmpeg filters each tone mapper needs
+var toneMapperFilters = map[ToneMapper][]string{
+	ToneMapZscale: {"zscale", "tonemap"},
+	ToneMapCUDA:   {"tonemap_cuda", "hwupload", "hwdownload"},
+	ToneMapOpenCL: {"tonemap_opencl", "hwupload", "hwdownload"},
+	ToneMapVAAPI:  {"tonemap_vaapi", "hwupload", "hwdownload"},
+}
+
+// toneMapDevice is the name of the hardware device hardware tone mappers filter on
+const toneMapDevice = "tm"
+
+// deviceArgs returns the global FFmpeg options that create the hardware device a tone mapper
+// filters on (none for software tone mapping)
+func (t ToneMapper) deviceArgs() []string {
+	var device string
+	switch t {
+	case ToneMapCUDA:
+		device = "cuda=" + toneMapDevice
+	case ToneMapOpenCL:
+		device = "opencl=" + toneMapDevice
+	case ToneMapVAAPI:
+		device = "vaapi=" + toneMapDevice + ":" + vaapiDevice
+	default:
+		return nil
+	}
+	return []string{"-init_hw_device", device, "-filter_hw_device", toneMapDevice}
+}
+
+// filter returns the tone mapper's filter chain. Hardware tone mappers upload the decoded frames
+// and download the result, so every encoder receives the same software yuv420p frames.
+func (t ToneMapper) filter() string {
+	switch t {
+	case ToneMapZscale:
+		return toneMapFilter
+	case ToneMapCUDA:
+		return "format=p010,hwupload,tonemap_cuda=tonemap=hable:desat=0:t=bt709:m=bt709:p=bt709:format=yuv420p," +
+			"hwdownload,format=yuv420p"
+	case ToneMapOpenCL:
+		return "format=p010,hwupload,tonemap_opencl=tonemap=hable:desat=0:t=bt709:m=bt709:p=bt709:format=nv12," +
+			"hwdownload,format=nv12,format=yuv420p"
+	case ToneMapVAAPI:
+		return "format=p010,hwupload,tonemap_vaapi=format=nv12:t=bt709:m=bt709:p=bt709," +
+			"hwdownload,format=nv12,format=yuv420p"
+	default:
+		return ""
+	}
+}
+
+// detectFilters lists the filters the local ffmpeg binary was built with
+func detectFilters(ctx context.Context) (map[string]bool, error) {
+	if err := CheckFFmpegInstalled(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ffmpegTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "ffmpeg", "-filters", "-hide_banner").Output()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrTimeout
+		}
+		return nil, fmt.Errorf("failed to list filters: %w", err)
+	}
+	return parseFilters(string(output)), nil
+}
+
+// parseFilters extracts filter names from `ffmpeg -filters` output,
+// where each filter line reads " T.C zscale            V->V       description"
+func parseFilters(output string) map[string]bool {
+	filters := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.Contains(fields[2], "->") {
+			filters[fields[1]] = true
+		}
+	}
+	return filters
+}
+
+// verifyToneMapper runs a hardware tone mapper over a few frames of a generated HDR10 source
+func verifyToneMapper(ctx context.Context, mapper ToneMapper) error {
+	ctx, cancel := context.WithTimeout(ctx, encoderVerifyTimeout)
+	defer cancel()
+
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	args = append(args, mapper.deviceArgs()...)
+	args = append(args,
+		"-f", "lavfi", "-i", "color=c=black:s=256x144:r=25:d=0.2,format=yuv420p10le,"+
+			"setparams=color_primaries=bt2020:color_trc=smpte2084:colorspace=bt2020nc",
+		"-vf", mapper.filter(),
+		"-frames:v", "5", "-f", "null", "-")
+
+	output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("test tone map timed out: %w", ctx.Err())
+		}
+		return fmt.Errorf("test tone map failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// detectToneMappers returns the tone mappers this machine can use, in priority order.
+// Hardware tone mappers must pass a test run; software tone mapping only needs its filters.
+func (d *EncoderDetector) detectToneMappers(ctx context.Context, failures map[string]string) []ToneMapper {
+	if d.filters == nil {
+		return nil
+	}
+	filters, err := d.filters(ctx)
+	if err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Msg("Filter detection failed, HDR sources will not be tone mapped")
+		return nil
+	}
+
+	mappers := make([]ToneMapper, 0, len(toneMapperPriority))
+	for _, mapper := range toneMapperPriority {
+		if !hasFilters(filters, toneMapperFilters[mapper]) {
+			continue
+		}
+		if mapper != ToneMapZscale {
+			if err := d.verifyToneMap(ctx, mapper); err != nil {
+				failures["tonemap_"+string(mapper)] = err.Error()
+				logger.Log.Warn().
+					Err(err).
+					Str("tone_mapper", string(mapper)).
+					Msg("Hardware tone mapper failed test run")
+				continue
+			}
+		}
+		mappers = append(mappers, mapper)
+	}
+	return mappers
+}
+
+// hasFilters reports whether every named filter is available
+func hasFilters(available map[string]bool, names []string) bool {
+	for _, name := range names {
+		if !available[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// currentToneMapper returns the tone mapper used for HDR sources: the best one detected, or
+// software tone mapping when detection has not run
+func (m *StreamManager) currentToneMapper() ToneMapper {
+	m.mu.RLock()
+	encoders := m.encoders
+	m.mu.RUnlock()
+
+	caps := encodersCapabilities(encoders)
+	if caps == nil {
+		return ToneMapZscale
+	}
+	return caps.ToneMap
+}
@@ -0,0 +1,109 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stwalsh4118/hermes/internal/config"
+)
+
+func TestParseFilters(t *testing.T) {
+	output := `Filters:
+  T.. = Timeline support
+  .S. = Slice threading
+  ..C = Command support
+  A = Audio input/output
+  V = Video input/output
+ ... tonemap           V->V       Conversion to/from different dynamic ranges.
+ ... tonemap_opencl    V->V       Perform HDR to SDR conversion with tonemapping.
+ .SC zscale            V->V       Apply resizing, colorspace and bit depth conversion.
+`
+	filters := parseFilters(output)
+	for _, name := range []string{"tonemap", "tonemap_opencl", "zscale"} {
+		if !filters[name] {
+			t.Errorf("filter %q not parsed", name)
+		}
+	}
+	if len(filters) != 3 {
+		t.Errorf("parsed %v, want 3 filters", filters)
+	}
+}
+
+// newToneMapDetector creates a detector with stubbed filters where hardware tone mappers in failing fail their test run
+func newToneMapDetector(filters []string, failing ...ToneMapper) *EncoderDetector {
+	detector := newFakeEncoderDetector([]HardwareAccel{HardwareAccelNone}, nil)
+	detector.filters = func(ctx context.Context) (map[string]bool, error) {
+		available := make(map[string]bool, len(filters))
+		for _, name := range filters {
+			available[name] = true
+		}
+		return available, nil
+	}
+	detector.verifyToneMap = func(ctx context.Context, mapper ToneMapper) error {
+		for _, f := range failing {
+			if f == mapper {
+				return errors.New("no OpenCL device")
+			}
+		}
+		return nil
+	}
+	return detector
+}
+
+func TestEncoderDetector_DetectToneMappers(t *testing.T) {
+	hardware := []string{"hwupload", "hwdownload", "tonemap_opencl", "tonemap_vaapi"}
+	software := []string{"zscale", "tonemap"}
+
+	tests := []struct {
+		name        string
+		filters     []string
+		failing     []ToneMapper
+		wantMappers []ToneMapper
+		wantFailure string
+	}{
+		{name: "software only", filters: software, wantMappers: []ToneMapper{ToneMapZscale}},
+		{name: "without zimg", filters: []string{"tonemap"}, wantMappers: []ToneMapper{}},
+		{name: "hardware first", filters: append(hardware, software...), wantMappers: []ToneMapper{ToneMapOpenCL, ToneMapVAAPI, ToneMapZscale}},
+		{
+			name: "failed hardware", filters: append(hardware, software...), failing: []ToneMapper{ToneMapOpenCL},
+			wantMappers: []ToneMapper{ToneMapVAAPI, ToneMapZscale}, wantFailure: "tonemap_opencl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps := newToneMapDetector(tt.filters, tt.failing...).Detect(context.Background())
+			if !reflect.DeepEqual(caps.ToneMappers, tt.wantMappers) {
+				t.Errorf("ToneMappers = %v, want %v", caps.ToneMappers, tt.wantMappers)
+			}
+			want := ToneMapNone
+			if len(tt.wantMappers) > 0 {
+				want = tt.wantMappers[0]
+			}
+			if caps.ToneMap != want {
+				t.Errorf("ToneMap = %q, want %q", caps.ToneMap, want)
+			}
+			if _, ok := caps.Failures[tt.wantFailure]; tt.wantFailure != "" && !ok {
+				t.Errorf("expected a failure entry for %s, got %v", tt.wantFailure, caps.Failures)
+			}
+		})
+	}
+}
+
+func TestStreamManager_CurrentToneMapper(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{})
+
+	// Before detection software tone mapping is assumed
+	if got := m.currentToneMapper(); got != ToneMapZscale {
+		t.Errorf("currentToneMapper() = %q before detection, want zscale", got)
+	}
+
+	// Without zscale HDR sources are not tone mapped
+	detector := newToneMapDetector([]string{"tonemap"})
+	detector.Detect(context.Background())
+	m.SetEncoderDetector(detector)
+	if got := m.currentToneMapper(); got != ToneMapNone {
+		t.Errorf("currentToneMapper() = %q without zscale, want none", got)
+	}
+}
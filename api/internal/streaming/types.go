@@ -66,7 +66,8 @@ func (s StreamState) CanTransitionTo(newState StreamState) bool {
 
 // StreamQuality contains information about a quality variant for adaptive streaming
 type StreamQuality struct {
-	Level        string `json:"level"`         // Quality level: "1080p", "720p", "480p"
+	Level        string `json:"level"`         // Quality level: "2160p", "1080p", "720p", "480p"
+	Codec        string `json:"codec"`         // Video codec: "h264", "hevc", "av1"
 	Bitrate      int    `json:"bitrate"`       // Video bitrate in kbps
 	Resolution   string `json:"resolution"`    // Resolution: "1920x1080"
 	SegmentPath  string `json:"segment_path"`  // Path to segments for this quality
@@ -164,3 +165,15 @@ func (m *SessionManager) DeleteCircuitBreaker(channelID string) {
 	defer m.mu.Unlock()
 	delete(m.circuitBreakers, channelID)
 }
+
+// CircuitBreakers returns a snapshot of the circuit breakers keyed by channel ID (thread-safe)
+func (m *SessionManager) CircuitBreakers() map[string]*CircuitBreaker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	breakers := make(map[string]*CircuitBreaker, len(m.circuitBreakers))
+	for channelID, cb := range m.circuitBreakers {
+		breakers[channelID] = cb
+	}
+	return breakers
+}
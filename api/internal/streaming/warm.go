@@ -0,0 +1,107 @@
+package streaming
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/logger"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+// warmReleaseWait is how long a new stream waits for the transcode budget of stopped warm
+// streams; their FFmpeg processes release it once they have exited
+const warmReleaseWait = 5 * time.Second
+
+// warmSessions returns the idle streams past their grace period that stay warm: the
+// WarmChannels most recently watched ones whose last client left less than WarmSeconds ago
+func (m *StreamManager) warmSessions(sessions []*models.StreamSession, gracePeriod time.Duration) map[uuid.UUID]bool {
+	warmFor := time.Duration(m.config.WarmSeconds) * time.Second
+
+	candidates := make([]*models.StreamSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.ShouldCleanup(gracePeriod) && session.IdleDuration() <= warmFor {
+			candidates = append(candidates, session)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b *models.StreamSession) int {
+		return b.GetLastAccessTime().Compare(a.GetLastAccessTime())
+	})
+
+	warm := make(map[uuid.UUID]bool)
+	for _, session := range candidates[:min(len(candidates), m.config.WarmChannels)] {
+		warm[session.ID] = true
+	}
+	return warm
+}
+
+// keepWarmStreamLive keeps a warm stream generating its lowest rendition only: requesting it
+// keeps it running while the higher renditions stop once nobody requests them
+func (m *StreamManager) keepWarmStreamLive(session *models.StreamSession) {
+	if qualities := session.GetQualities(); len(qualities) > 0 {
+		session.RequestRendition(qualities[len(qualities)-1].Level)
+	}
+	m.followLiveEdge(session)
+}
+
+// wakeWarmStream turns a warm stream a client tunes back to into a regular stream. Players
+// start on the warm rendition, whose segments exist already, while the top rendition starts
+// from the live edge; they switch up the ladder once it is generated.
+func (m *StreamManager) wakeWarmStream(session *models.StreamSession) {
+	session.SetWarm(false)
+	session.UpdateLastAccess()
+	if qualities := session.GetQualities(); len(qualities) > 0 {
+		session.RequestRendition(qualities[0].Level) // Started by the batch coordinator's next check
+	}
+	m.writeMasterPlaylist(session)
+
+	logger.Log.Info().
+		Str("channel_id", session.ChannelID.String()).
+		Strs("live_renditions", m.renditionsFor(session).live()).
+		Msg("Client tuned to warm stream, starting full quality ladder")
+}
+
+// stopWarmStreams stops every warm stream so its transcode budget goes to a stream a client
+// asked for, and returns how many were stopped
+func (m *StreamManager) stopWarmStreams(ctx context.Context) int {
+	stopped := 0
+	for _, session := range m.sessionManager.List() {
+		if !session.IsWarm() || session.GetClientCount() > 0 {
+			continue
+		}
+		if err := m.StopStream(ctx, session.ChannelID); err != nil {
+			logger.Log.Warn().
+				Err(err).
+				Str("channel_id", session.ChannelID.String()).
+				Msg("Failed to stop warm stream")
+			continue
+		}
+		stopped++
+	}
+	return stopped
+}
+
+// writeMasterPlaylist rewrites a stream's master playlist so its first variant, where players
+// start, is generated: the top rendition once it is live, otherwise the best live one
+func (m *StreamManager) writeMasterPlaylist(session *models.StreamSession) {
+	qualities := session.GetQualities()
+	live := m.renditionsFor(session).live()
+	if len(qualities) == 0 || len(live) == 0 {
+		return
+	}
+
+	// live is in ladder order, so its first entry is the best live rendition
+	start := slices.IndexFunc(qualities, func(q models.StreamQuality) bool { return q.Level == live[0] })
+	if start > 0 {
+		ordered := append([]models.StreamQuality{qualities[start]}, qualities[:start]...)
+		qualities = append(ordered, qualities[start+1:]...)
+	}
+
+	if err := m.generateMasterPlaylist(session.GetOutputDir(), qualities); err != nil {
+		logger.Log.Warn().
+			Err(err).
+			Str("channel_id", session.ChannelID.String()).
+			Msg("Failed to rewrite master playlist")
+	}
+}
@@ -0,0 +1,87 @@
+package streaming
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stwalsh4118/hermes/internal/config"
+	"github.com/stwalsh4118/hermes/internal/models"
+)
+
+func TestWarmSessions_KeepsMostRecentlyWatched(t *testing.T) {
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{WarmChannels: 2, WarmSeconds: 600})
+
+	idleFor := func(idle time.Duration) *models.StreamSession {
+		session := models.NewStreamSession(uuid.New())
+		session.LastAccessTime = time.Now().UTC().Add(-idle)
+		return session
+	}
+	watching := idleFor(time.Hour)
+	watching.IncrementClients()
+	recent, older, oldest, expired := idleFor(time.Minute), idleFor(2*time.Minute), idleFor(3*time.Minute), idleFor(time.Hour)
+
+	warm := m.warmSessions([]*models.StreamSession{oldest, expired, watching, older, recent}, 30*time.Second)
+	if len(warm) != 2 || !warm[recent.ID] || !warm[older.ID] {
+		t.Errorf("warm = %v, want the two most recently watched idle streams", warm)
+	}
+
+	m.config.WarmChannels = 0
+	if warm := m.warmSessions([]*models.StreamSession{recent}, 30*time.Second); len(warm) != 0 {
+		t.Errorf("warm = %v with warm channels disabled", warm)
+	}
+}
+
+func TestWakeWarmStream_StartsOnWarmRendition(t *testing.T) {
+	segmentPath := t.TempDir()
+	m := NewStreamManager(nil, nil, &config.StreamingConfig{
+		SegmentPath:           segmentPath,
+		BatchSize:             10,
+		StreamSegmentDuration: 4,
+		WarmChannels:          3,
+		WarmSeconds:           600,
+	})
+
+	session := models.NewStreamSession(uuid.New())
+	session.SetOutputDir(filepath.Join(segmentPath, session.ChannelID.String()))
+	session.SetQualities([]models.StreamQuality{
+		{Level: Quality720p, Codec: VideoCodecH264.String()},
+		{Level: Quality480p, Codec: VideoCodecH264.String()},
+	})
+	session.SetWarm(true)
+	m.sessionManager.Set(session.ChannelID.String(), session)
+	if err := os.MkdirAll(session.GetOutputDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the lowest rendition is generated while the stream is warm
+	m.keepWarmStreamLive(session)
+	m.syncRenditions(session)
+	if live := m.renditionsFor(session).live(); len(live) != 1 || live[0] != Quality480p {
+		t.Fatalf("live renditions = %v, want 480p", live)
+	}
+
+	// Tuning back in wakes the stream instead of starting a new one
+	joined, err := m.StartStream(t.Context(), session.ChannelID)
+	if err != nil {
+		t.Fatalf("StartStream failed: %v", err)
+	}
+	if joined != session || session.IsWarm() {
+		t.Fatal("warm stream not reused and woken")
+	}
+	if _, requested := session.GetRenditionRequests()[Quality720p]; !requested {
+		t.Error("top rendition not requested for the full ladder")
+	}
+
+	// Players start on the warm rendition until the top rendition is generated
+	content, err := os.ReadFile(filepath.Join(session.GetOutputDir(), "master.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, second := strings.Index(string(content), "480p.m3u8"), strings.Index(string(content), "720p.m3u8"); first < 0 || second < first {
+		t.Errorf("master playlist does not start on the warm rendition:\n%s", content)
+	}
+}
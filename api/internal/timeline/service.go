@@ -35,8 +35,15 @@ func NewTimelineService(repos *db.Repositories) *TimelineService {
 //   - error: channel.ErrChannelNotFound, ErrChannelNotStarted, ErrEmptyPlaylist,
 //     ErrPlaylistFinished, or wrapped database errors
 func (s *TimelineService) GetCurrentPosition(ctx context.Context, channelID uuid.UUID) (*TimelinePosition, error) {
+	return s.GetPositionAt(ctx, channelID, time.Now().UTC())
+}
+
+// GetPositionAt calculates the timeline position of a channel at the given time, e.g. to find
+// the program that was playing at a past moment. It returns the same errors as GetCurrentPosition.
+func (s *TimelineService) GetPositionAt(ctx context.Context, channelID uuid.UUID, at time.Time) (*TimelinePosition, error) {
 	logger.Log.Debug().
 		Str("channel_id", channelID.String()).
+		Time("at", at).
 		Msg("Starting timeline calculation")
 
 	// Fetch channel from database
@@ -73,9 +80,8 @@ func (s *TimelineService) GetCurrentPosition(ctx context.Context, channelID uuid
 		return nil, ErrEmptyPlaylist
 	}
 
-	// Calculate current position using the calculator
-	currentTime := time.Now().UTC()
-	position, err := CalculatePosition(ch.StartTime, currentTime, playlist, ch.Loop)
+	// Calculate the position using the calculator
+	position, err := CalculatePosition(ch.StartTime, at.UTC(), playlist, ch.Loop)
 	if err != nil {
 		// Calculator errors are already well-defined, pass them through
 		logger.Log.Warn().
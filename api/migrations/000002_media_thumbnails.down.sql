@@ -0,0 +1,3 @@
+-- Remove generated artwork paths from media
+ALTER TABLE media DROP COLUMN sprite_path;
+ALTER TABLE media DROP COLUMN thumbnail_path;
@@ -0,0 +1,3 @@
+-- Add generated artwork paths to media
+ALTER TABLE media ADD COLUMN thumbnail_path TEXT;
+ALTER TABLE media ADD COLUMN sprite_path TEXT;
@@ -0,0 +1,9 @@
+-- Drop the full-text index first since its triggers reference media.description
+DROP TRIGGER IF EXISTS media_fts_ai;
+DROP TRIGGER IF EXISTS media_fts_ad;
+DROP TRIGGER IF EXISTS media_fts_au;
+DROP TABLE IF EXISTS media_fts;
+
+DROP INDEX IF EXISTS idx_playlist_items_media;
+DROP INDEX IF EXISTS idx_media_duration;
+ALTER TABLE media DROP COLUMN description;
@@ -0,0 +1,8 @@
+-- Add free-text description for search and EPG display
+-- The media_fts full-text index itself is created by db.EnsureMediaSearchIndex after
+-- migrations, since FTS5 availability depends on how the SQLite driver was built
+ALTER TABLE media ADD COLUMN description TEXT;
+
+-- Speed up common library filters
+CREATE INDEX IF NOT EXISTS idx_media_duration ON media(duration);
+CREATE INDEX IF NOT EXISTS idx_playlist_items_media ON playlist_items(media_id);
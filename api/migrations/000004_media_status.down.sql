@@ -0,0 +1,5 @@
+-- Remove media availability tracking
+DROP INDEX IF EXISTS idx_media_status;
+
+ALTER TABLE media DROP COLUMN missing_since;
+ALTER TABLE media DROP COLUMN status;
@@ -0,0 +1,5 @@
+-- Track whether each media file still exists on disk
+ALTER TABLE media ADD COLUMN status TEXT NOT NULL DEFAULT 'available' CHECK (status IN ('available', 'missing'));
+ALTER TABLE media ADD COLUMN missing_since DATETIME;
+
+CREATE INDEX idx_media_status ON media(status);
@@ -0,0 +1,3 @@
+-- Restore the placeholder settings row if none exists
+INSERT OR IGNORE INTO settings (id, media_library_path)
+VALUES (1, './media');
@@ -0,0 +1,9 @@
+-- Remove the untouched placeholder settings row from the initial schema so the
+-- server can seed settings from its configuration on next startup.
+-- Rows that were changed are kept as-is.
+DELETE FROM settings
+WHERE id = 1
+  AND media_library_path = './media'
+  AND transcode_quality = 'medium'
+  AND hardware_accel = 'none'
+  AND server_port = 8080;
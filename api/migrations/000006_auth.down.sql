@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_user_sessions_expires;
+DROP INDEX IF EXISTS idx_user_sessions_user;
+DROP TABLE IF EXISTS user_sessions;
+
+DROP INDEX IF EXISTS idx_api_keys_user;
+DROP TABLE IF EXISTS api_keys;
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_channel_access_group;
+DROP INDEX IF EXISTS idx_channel_access_user;
+DROP TABLE IF EXISTS channel_access;
+
+DROP INDEX IF EXISTS idx_user_group_members_user;
+DROP TABLE IF EXISTS user_group_members;
+DROP TABLE IF EXISTS user_groups;
+
+ALTER TABLE users DROP COLUMN role;
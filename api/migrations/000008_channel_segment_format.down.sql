@@ -0,0 +1,2 @@
+-- Remove per-channel segment format
+ALTER TABLE channels DROP COLUMN segment_format;
@@ -0,0 +1,2 @@
+-- Segment container per channel; existing channels keep MPEG-TS
+ALTER TABLE channels ADD COLUMN segment_format TEXT NOT NULL DEFAULT 'ts' CHECK (segment_format IN ('ts', 'fmp4'));
@@ -0,0 +1,3 @@
+-- Remove source video characteristics
+ALTER TABLE media DROP COLUMN interlaced;
+ALTER TABLE media DROP COLUMN hdr;
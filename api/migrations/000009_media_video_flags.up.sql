@@ -0,0 +1,3 @@
+-- Source video characteristics detected at scan time; they select tone mapping and deinterlacing filters
+ALTER TABLE media ADD COLUMN hdr INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE media ADD COLUMN interlaced INTEGER NOT NULL DEFAULT 0;
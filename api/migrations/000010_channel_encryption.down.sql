@@ -0,0 +1,2 @@
+-- Remove per-channel segment encryption
+ALTER TABLE channels DROP COLUMN encryption;
@@ -0,0 +1,2 @@
+-- Segment encryption per channel; existing channels stay unencrypted
+ALTER TABLE channels ADD COLUMN encryption TEXT NOT NULL DEFAULT 'none' CHECK (encryption IN ('none', 'aes-128'));
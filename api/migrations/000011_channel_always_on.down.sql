@@ -0,0 +1,4 @@
+-- Remove always-on channels
+ALTER TABLE channels DROP COLUMN always_on_end;
+ALTER TABLE channels DROP COLUMN always_on_start;
+ALTER TABLE channels DROP COLUMN always_on;
@@ -0,0 +1,4 @@
+-- Always-on channels keep streaming without clients, optionally only within a daily window
+ALTER TABLE channels ADD COLUMN always_on INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE channels ADD COLUMN always_on_start TEXT;
+ALTER TABLE channels ADD COLUMN always_on_end TEXT;
@@ -203,11 +203,11 @@ func waitForSegmentsInPlaylist(t *testing.T, segmentPath string, expectedCount i
 }
 
 // waitForSegmentsToExist waits for specific segment files to exist on disk
-func waitForSegmentsToExist(t *testing.T, segmentPath string, quality string, startSegment, endSegment int, timeout time.Duration) bool {
+func waitForSegmentsToExist(t *testing.T, segmentPath, prefix string, startSegment, endSegment int, timeout time.Duration) bool {
 	t.Helper()
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
-		if verifySegmentsExist(t, segmentPath, quality, startSegment, endSegment) {
+		if verifySegmentsExist(t, segmentPath, prefix, startSegment, endSegment) {
 			return true
 		}
 		time.Sleep(200 * time.Millisecond)
@@ -217,15 +217,14 @@ func waitForSegmentsToExist(t *testing.T, segmentPath string, quality string, st
 
 // verifySegmentsExist checks if segment files exist for a given range
 // segmentDir should be the quality-specific directory (e.g., /tmp/.../channel_id/1080p)
-func verifySegmentsExist(t *testing.T, segmentDir string, quality string, startSegment, endSegment int) bool {
+// and prefix the session's segment prefix (streaming.SegmentPrefix)
+func verifySegmentsExist(t *testing.T, segmentDir, prefix string, startSegment, endSegment int) bool {
 	t.Helper()
 
 	// segmentDir is already the quality directory (from GetSegmentPath())
 	// So we use it directly without joining with quality again
 	for i := startSegment; i <= endSegment; i++ {
-		// Handle segment filename wrapping (%03d pattern wraps at 1000)
-		filenameSegment := i % 1000
-		segmentFilename := fmt.Sprintf("%s_segment_%03d.ts", quality, filenameSegment)
+		segmentFilename := fmt.Sprintf("%s-%06d.ts", prefix, i)
 		segmentPath := filepath.Join(segmentDir, segmentFilename)
 
 		if _, err := os.Stat(segmentPath); os.IsNotExist(err) {
@@ -237,15 +236,14 @@ func verifySegmentsExist(t *testing.T, segmentDir string, quality string, startS
 
 // verifySegmentsDeleted checks if segment files are deleted for a given range
 // segmentDir should be the quality-specific directory (e.g., /tmp/.../channel_id/1080p)
-func verifySegmentsDeleted(t *testing.T, segmentDir string, quality string, startSegment, endSegment int) bool {
+// and prefix the session's segment prefix (streaming.SegmentPrefix)
+func verifySegmentsDeleted(t *testing.T, segmentDir, prefix string, startSegment, endSegment int) bool {
 	t.Helper()
 
 	// segmentDir is already the quality directory (from GetSegmentPath())
 	// So we use it directly without joining with quality again
 	for i := startSegment; i <= endSegment; i++ {
-		// Handle segment filename wrapping (%03d pattern wraps at 1000)
-		filenameSegment := i % 1000
-		segmentFilename := fmt.Sprintf("%s_segment_%03d.ts", quality, filenameSegment)
+		segmentFilename := fmt.Sprintf("%s-%06d.ts", prefix, i)
 		segmentPath := filepath.Join(segmentDir, segmentFilename)
 
 		if _, err := os.Stat(segmentPath); err == nil {
@@ -340,7 +338,7 @@ func TestBatchGeneration(t *testing.T) {
 	assert.False(t, batch.GenerationEnded.IsZero(), "GenerationEnded should be set")
 
 	// Verify segments created on disk
-	assert.True(t, verifySegmentsExist(t, segmentPath, "1080p", 0, testBatchSize-1),
+	assert.True(t, verifySegmentsExist(t, segmentPath, streaming.SegmentPrefix(session), 0, testBatchSize-1),
 		"Segment files should exist on disk")
 
 	// Verify FFmpeg process exited (no hanging processes)
@@ -400,7 +398,7 @@ func TestPositionTracking(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	api.SetupStreamRoutes(apiGroup, manager)
+	api.SetupStreamRoutes(apiGroup, manager, nil, nil)
 
 	sessionID := uuid.New().String()
 
@@ -478,7 +476,7 @@ func TestAutomaticTriggering(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	api.SetupStreamRoutes(apiGroup, manager)
+	api.SetupStreamRoutes(apiGroup, manager, nil, nil)
 
 	sessionID := uuid.New().String()
 
@@ -557,7 +555,7 @@ func TestBatchContinuation(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	api.SetupStreamRoutes(apiGroup, manager)
+	api.SetupStreamRoutes(apiGroup, manager, nil, nil)
 
 	sessionID := uuid.New().String()
 
@@ -576,7 +574,7 @@ func TestBatchContinuation(t *testing.T) {
 	segmentPath := session.GetSegmentPath()
 	// Wait for second batch segments to actually exist on disk
 	// The batch FFmpeg process may exit before all segments are flushed
-	require.True(t, waitForSegmentsToExist(t, segmentPath, "1080p", testBatchSize, testBatchSize*2-1, 10*time.Second),
+	require.True(t, waitForSegmentsToExist(t, segmentPath, streaming.SegmentPrefix(session), testBatchSize, testBatchSize*2-1, 10*time.Second),
 		"Second batch segments should exist on disk")
 
 	// Verify batch continuation
@@ -588,14 +586,14 @@ func TestBatchContinuation(t *testing.T) {
 		"Second batch should start where first batch ended")
 
 	// Verify video position calculated correctly
-	expectedOffset := firstVideoOffset + int64(testBatchSize*testSegmentDuration)
+	expectedOffset := firstVideoOffset + float64(testBatchSize*testSegmentDuration)
 	assert.Equal(t, expectedOffset, secondBatch.VideoStartOffset,
 		"Video offset should continue from previous batch")
 
 	// Verify no gaps in segments
-	assert.True(t, verifySegmentsExist(t, segmentPath, "1080p", 0, testBatchSize-1),
+	assert.True(t, verifySegmentsExist(t, segmentPath, streaming.SegmentPrefix(session), 0, testBatchSize-1),
 		"First batch segments should still exist")
-	assert.True(t, verifySegmentsExist(t, segmentPath, "1080p", testBatchSize, testBatchSize*2-1),
+	assert.True(t, verifySegmentsExist(t, segmentPath, streaming.SegmentPrefix(session), testBatchSize, testBatchSize*2-1),
 		"Second batch segments should exist")
 }
 
@@ -643,7 +641,7 @@ func TestMultiClientSynchronization(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	api.SetupStreamRoutes(apiGroup, manager)
+	api.SetupStreamRoutes(apiGroup, manager, nil, nil)
 
 	// Each client reports different positions
 	sessionID1 := uuid.New().String()
@@ -716,7 +714,7 @@ func TestBatchCleanup(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	api.SetupStreamRoutes(apiGroup, manager)
+	api.SetupStreamRoutes(apiGroup, manager, nil, nil)
 
 	sessionID := uuid.New().String()
 	segmentPath := session.GetSegmentPath()
@@ -739,11 +737,11 @@ func TestBatchCleanup(t *testing.T) {
 	completed = waitForBatchCompletion(t, session, 30*time.Second)
 	require.True(t, completed, "Batch 1 did not complete")
 	// Wait for batch 1 segments to be written
-	require.True(t, waitForSegmentsToExist(t, segmentPath, "1080p", testBatchSize, testBatchSize*2-1, 10*time.Second),
+	require.True(t, waitForSegmentsToExist(t, segmentPath, streaming.SegmentPrefix(session), testBatchSize, testBatchSize*2-1, 10*time.Second),
 		"Batch 1 segments should exist on disk")
 
 	// Verify batch 0 segments still exist (N-1 batch kept during N generation)
-	assert.True(t, verifySegmentsExist(t, segmentPath, "1080p", 0, testBatchSize-1),
+	assert.True(t, verifySegmentsExist(t, segmentPath, streaming.SegmentPrefix(session), 0, testBatchSize-1),
 		"Batch 0 segments should still exist (N-1 kept during N generation)")
 
 	// Trigger batch 2
@@ -756,7 +754,7 @@ func TestBatchCleanup(t *testing.T) {
 	completed = waitForBatchCompletion(t, session, 30*time.Second)
 	require.True(t, completed, "Batch 2 did not complete")
 	// Wait for batch 2 segments to be written
-	require.True(t, waitForSegmentsToExist(t, segmentPath, "1080p", testBatchSize*2, testBatchSize*3-1, 10*time.Second),
+	require.True(t, waitForSegmentsToExist(t, segmentPath, streaming.SegmentPrefix(session), testBatchSize*2, testBatchSize*3-1, 10*time.Second),
 		"Batch 2 segments should exist on disk")
 
 	// Give cleanup a moment to run (cleanup happens after batch completion)
@@ -764,15 +762,15 @@ func TestBatchCleanup(t *testing.T) {
 
 	// Verify batch 0 deleted (N-2 batch deleted after N completes)
 	// Batch 2 completed, so batch 0 (N-2) should be deleted
-	assert.True(t, verifySegmentsDeleted(t, segmentPath, "1080p", 0, testBatchSize-1),
+	assert.True(t, verifySegmentsDeleted(t, segmentPath, streaming.SegmentPrefix(session), 0, testBatchSize-1),
 		"Batch 0 segments should be deleted (N-2 cleanup)")
 
 	// Verify batch 1 still exists (N-1 batch kept during N generation)
-	assert.True(t, verifySegmentsExist(t, segmentPath, "1080p", testBatchSize, testBatchSize*2-1),
+	assert.True(t, verifySegmentsExist(t, segmentPath, streaming.SegmentPrefix(session), testBatchSize, testBatchSize*2-1),
 		"Batch 1 segments should still exist (N-1 kept during N generation)")
 
 	// Verify batch 2 exists
-	assert.True(t, verifySegmentsExist(t, segmentPath, "1080p", testBatchSize*2, testBatchSize*3-1),
+	assert.True(t, verifySegmentsExist(t, segmentPath, streaming.SegmentPrefix(session), testBatchSize*2, testBatchSize*3-1),
 		"Batch 2 segments should exist")
 }
 
@@ -36,7 +36,7 @@ func TestChannelAPI(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	api.SetupChannelRoutes(apiGroup, channelService, playlistService, timelineService)
+	api.SetupChannelRoutes(apiGroup, channelService, playlistService, timelineService, nil)
 
 	t.Run("CreateChannel_Success", func(t *testing.T) {
 		startTime := time.Now().Add(-24 * time.Hour)
@@ -395,7 +395,7 @@ func TestPlaylistAPI(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	apiGroup := router.Group("/api")
-	api.SetupChannelRoutes(apiGroup, channelService, playlistService, timelineService)
+	api.SetupChannelRoutes(apiGroup, channelService, playlistService, timelineService, nil)
 
 	// Create test channel and media for use across tests
 	ctx := context.Background()
@@ -65,7 +65,7 @@ func setupTestRouter(scanner *media.Scanner, repos *db.Repositories) *gin.Engine
 	router.Use(gin.Recovery())
 
 	apiGroup := router.Group("/api")
-	api.SetupMediaRoutes(apiGroup, scanner, repos)
+	api.SetupMediaRoutes(apiGroup, scanner, repos, nil, nil)
 
 	return router
 }
@@ -23,6 +23,8 @@ export interface Media {
   resolution: string | null;
   file_size: number | null;
   created_at: string;
+  hdr: boolean;
+  interlaced: boolean;
 }
 
 export interface PlaylistItem {
@@ -37,7 +39,7 @@ export interface PlaylistItem {
 export interface Settings {
   id: number;
   media_library_path: string;
-  transcode_quality: "high" | "medium" | "low";
+  transcode_quality: "ultra" | "high" | "medium" | "low";
   hardware_accel: "none" | "nvenc" | "qsv" | "vaapi" | "videotoolbox";
   server_port: number;
   updated_at: string;